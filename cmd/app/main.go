@@ -1,14 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"io"
 	"os"
 	"os/exec"
 	"os/signal"
 	"runtime"
 	"syscall"
 	"test-go/config"
+	"test-go/internal/logstore"
 	"test-go/internal/monitoring"
 	"test-go/internal/server"
 	"test-go/pkg/logger"
@@ -52,8 +53,15 @@ func main() {
 		}
 	}
 
-	// 3. Init Broadcaster for monitoring
-	broadcaster := monitoring.NewLogBroadcaster()
+	// 3. Init Broadcaster for monitoring, backed by the log store
+	var logStore *logstore.Store
+	if cfg.Monitoring.Enabled && cfg.Monitoring.LogStore.Enabled {
+		logStore, err = logstore.Open(cfg.Monitoring.LogStore)
+		if err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+		}
+	}
+	broadcaster := monitoring.NewLogBroadcaster(logStore)
 
 	// Check if TUI mode is enabled
 	if cfg.App.EnableTUI {
@@ -108,8 +116,14 @@ func runWithTUI(cfg *config.Config, bannerText string, broadcaster *monitoring.L
 
 	// Init Logger (quiet mode so logs go to TUI only)
 	// We also broadcast to the monitoring system so the Web UI Live Logs work
-	multiWriter := io.MultiWriter(liveTUI, broadcaster)
-	l := logger.NewQuiet(cfg.App.Debug, multiWriter)
+	l := logger.NewWithConfig(logger.LoggerConfig{
+		Debug:       cfg.App.Debug,
+		Quiet:       true,
+		Broadcaster: broadcaster,
+		TUI:         liveTUI,
+		Pipeline:    cfg.Log,
+	})
+	defer l.Close()
 
 	// Start Live TUI in background
 	liveTUI.Start()
@@ -145,6 +159,9 @@ func runWithTUI(cfg *config.Config, bannerText string, broadcaster *monitoring.L
 	<-sigChan
 
 	liveTUI.AddLog("warn", "Shutting down...")
+	if err := shutdown(srv); err != nil {
+		liveTUI.AddLog("error", "Failed to flush telemetry: "+err.Error())
+	}
 	liveTUI.Stop()
 }
 
@@ -158,7 +175,12 @@ func runWithConsole(cfg *config.Config, bannerText string, broadcaster *monitori
 	}
 
 	// Init Logger (normal mode with console output)
-	l := logger.New(cfg.App.Debug, broadcaster)
+	l := logger.NewWithConfig(logger.LoggerConfig{
+		Debug:       cfg.App.Debug,
+		Broadcaster: broadcaster,
+		Pipeline:    cfg.Log,
+	})
+	defer l.Close()
 
 	// Log startup info
 	l.Info("Starting Application", "name", cfg.App.Name, "env", cfg.App.Env)
@@ -202,6 +224,16 @@ func runWithConsole(cfg *config.Config, bannerText string, broadcaster *monitori
 	<-sigChan
 
 	l.Warn("Shutting down...")
+	if err := shutdown(srv); err != nil {
+		l.Error("Failed to flush telemetry", err)
+	}
+}
+
+// shutdown gives the server a few seconds to flush buffered telemetry
+func shutdown(srv *server.Server) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return srv.Shutdown(ctx)
 }
 
 // logServiceStatus logs whether a service is enabled or skipped
@@ -0,0 +1,248 @@
+// Command mockidp is a minimal OpenID Connect provider for trying dashboard
+// single sign-on locally. It signs in whoever submits its form, with the groups
+// they type, so it must never be exposed outside a development machine.
+//
+//	go run ./cmd/mockidp -addr :9999
+//
+// and in config.yaml:
+//
+//	monitoring:
+//	  oidc:
+//	    enabled: true
+//	    issuer: "http://localhost:9999"
+//	    client_id: "dashboard"
+//	    redirect_url: "http://localhost:9090/auth/oidc/callback"
+//	    roles:
+//	      admin: ["ops"]
+//	      viewer: ["dev"]
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+type authCode struct {
+	clientID  string
+	redirect  string
+	nonce     string
+	challenge string
+	username  string
+	groups    []string
+	expires   time.Time
+}
+
+type idp struct {
+	issuer string
+	key    *rsa.PrivateKey
+	signer jose.Signer
+
+	mu    sync.Mutex
+	codes map[string]authCode
+}
+
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html><body style="font-family:sans-serif;max-width:360px;margin:60px auto">
+<h2>Mock identity provider</h2>
+<form method="post">
+{{range $k, $v := .}}<input type="hidden" name="{{$k}}" value="{{index $v 0}}">{{end}}
+<p><label>Username<br><input name="username" value="alice"></label></p>
+<p><label>Groups (comma separated)<br><input name="groups" value="ops"></label></p>
+<button>Sign in</button>
+</form></body></html>`))
+
+func main() {
+	addr := flag.String("addr", ":9999", "listen address")
+	issuer := flag.String("issuer", "http://localhost:9999", "issuer URL as seen by the dashboard and the browser")
+	flag.Parse()
+
+	p, err := newIDP(*issuer)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("mock IdP listening on %s (issuer %s)", *addr, p.issuer)
+	log.Fatal(http.ListenAndServe(*addr, p.handler()))
+}
+
+// newIDP creates a provider with a fresh signing key.
+func newIDP(issuer string) (*idp, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "mock"))
+	if err != nil {
+		return nil, err
+	}
+	return &idp{issuer: strings.TrimSuffix(issuer, "/"), key: key, signer: signer, codes: make(map[string]authCode)}, nil
+}
+
+func (p *idp) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/jwks", p.jwks)
+	mux.HandleFunc("/authorize", p.authorize)
+	mux.HandleFunc("/token", p.token)
+	mux.HandleFunc("/logout", p.logout)
+	return mux
+}
+
+func (p *idp) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.issuer,
+		"authorization_endpoint":                p.issuer + "/authorize",
+		"token_endpoint":                        p.issuer + "/token",
+		"jwks_uri":                              p.issuer + "/jwks",
+		"end_session_endpoint":                  p.issuer + "/logout",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (p *idp) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{
+		Key: &p.key.PublicKey, KeyID: "mock", Algorithm: string(jose.RS256), Use: "sig",
+	}}})
+}
+
+// authorize shows the sign-in form on GET and issues a code on POST.
+func (p *idp) authorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.Method == http.MethodGet {
+		if r.Form.Get("code_challenge_method") != "S256" {
+			http.Error(w, "PKCE with S256 is required", http.StatusBadRequest)
+			return
+		}
+		_ = loginPage.Execute(w, r.URL.Query())
+		return
+	}
+
+	redirect, err := url.Parse(r.Form.Get("redirect_uri"))
+	if err != nil || redirect.Scheme == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	var groups []string
+	for _, g := range strings.Split(r.Form.Get("groups"), ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			groups = append(groups, g)
+		}
+	}
+
+	code := randomString()
+	p.mu.Lock()
+	p.codes[code] = authCode{
+		clientID:  r.Form.Get("client_id"),
+		redirect:  redirect.String(),
+		nonce:     r.Form.Get("nonce"),
+		challenge: r.Form.Get("code_challenge"),
+		username:  r.Form.Get("username"),
+		groups:    groups,
+		expires:   time.Now().Add(time.Minute),
+	}
+	p.mu.Unlock()
+
+	q := redirect.Query()
+	q.Set("code", code)
+	q.Set("state", r.Form.Get("state"))
+	redirect.RawQuery = q.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (p *idp) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p.mu.Lock()
+	code, ok := p.codes[r.Form.Get("code")]
+	delete(p.codes, r.Form.Get("code"))
+	p.mu.Unlock()
+
+	clientID := r.Form.Get("client_id")
+	if user, _, hasBasic := r.BasicAuth(); hasBasic {
+		clientID = user
+	}
+
+	sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	switch {
+	case !ok || time.Now().After(code.expires):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	case clientID != code.clientID || r.Form.Get("redirect_uri") != code.redirect:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_client"})
+		return
+	case base64.RawURLEncoding.EncodeToString(sum[:]) != code.challenge:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	now := time.Now()
+	idToken, err := jwt.Signed(p.signer).Claims(jwt.Claims{
+		Issuer:   p.issuer,
+		Subject:  "mock|" + code.username,
+		Audience: jwt.Audience{code.clientID},
+		IssuedAt: jwt.NewNumericDate(now),
+		Expiry:   jwt.NewNumericDate(now.Add(time.Hour)),
+	}).Claims(map[string]interface{}{
+		"nonce":              code.nonce,
+		"preferred_username": code.username,
+		"email":              code.username + "@example.test",
+		"groups":             code.groups,
+	}).Serialize()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (p *idp) logout(w http.ResponseWriter, r *http.Request) {
+	if target := r.URL.Query().Get("post_logout_redirect_uri"); target != "" {
+		http.Redirect(w, r, target, http.StatusFound)
+		return
+	}
+	fmt.Fprintln(w, "Signed out of the mock identity provider.")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
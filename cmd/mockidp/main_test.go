@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"test-go/config"
+	"test-go/internal/monitoring/sso"
+)
+
+func TestSignIn(t *testing.T) {
+	srv := httptest.NewUnstartedServer(nil)
+	p, err := newIDP("http://" + srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Config.Handler = p.handler()
+	srv.Start()
+	defer srv.Close()
+
+	provider, err := sso.New(config.OIDCConfig{
+		Enabled:       true,
+		Issuer:        srv.URL,
+		ClientID:      "dashboard",
+		RedirectURL:   "http://localhost:9090/auth/oidc/callback",
+		Scopes:        []string{"openid", "profile", "email"},
+		UsernameClaim: "preferred_username",
+		GroupsClaim:   "groups",
+		Roles:         map[string][]string{"admin": {"ops"}, "viewer": {"dev"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		groups   string
+		wantRole string
+		wantErr  error
+	}{
+		{"admin group", "ops", "admin", nil},
+		{"viewer group", "dev", "viewer", nil},
+		{"admin wins over viewer", "dev, ops", "admin", nil},
+		{"unmapped group", "sales", "", sso.ErrNoRole},
+	}
+
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, code := signIn(t, ctx, provider, "alice", tt.groups)
+
+			id, err := provider.Exchange(ctx, state, code)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Exchange error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if id.Role != tt.wantRole || id.Username != "alice" || id.Subject != "mock|alice" {
+				t.Errorf("identity = %+v, want alice as %s", id, tt.wantRole)
+			}
+		})
+	}
+
+	t.Run("codes are single use", func(t *testing.T) {
+		state, code := signIn(t, ctx, provider, "alice", "ops")
+		if _, err := provider.Exchange(ctx, state, code); err != nil {
+			t.Fatalf("exchange: %v", err)
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if _, ok := p.codes[code]; ok {
+			t.Error("code is still redeemable after the exchange")
+		}
+	})
+}
+
+// signIn walks the browser side of the flow: load the form, submit it and read
+// the code off the redirect back to the dashboard.
+func signIn(t *testing.T, ctx context.Context, provider *sso.Provider, username, groups string) (state, code string) {
+	t.Helper()
+	authURL, _, err := provider.AuthURL(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("login form: status %d", resp.StatusCode)
+	}
+
+	form := u.Query()
+	form.Set("username", username)
+	form.Set("groups", groups)
+	u.RawQuery = ""
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err = client.PostForm(u.String(), form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("sign in: status %d, want a redirect", resp.StatusCode)
+	}
+	back, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return back.Query().Get("state"), back.Query().Get("code")
+}
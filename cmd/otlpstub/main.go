@@ -0,0 +1,152 @@
+// Command otlpstub is a minimal OTLP/HTTP trace receiver for trying tracing
+// locally without a collector. It accepts every export and prints one line per
+// span to stdout, so it must never be exposed outside a development machine.
+//
+//	go run ./cmd/otlpstub -addr :4318
+//
+// and in config.yaml:
+//
+//	tracing:
+//	  enabled: true
+//	  endpoint: "localhost:4318"
+//	  insecure: true
+package main
+
+import (
+	"compress/gzip"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxBody caps a single export request.
+const maxBody = 16 << 20
+
+func main() {
+	addr := flag.String("addr", ":4318", "listen address")
+	flag.Parse()
+
+	log.Printf("otlpstub listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, newReceiver(os.Stdout)))
+}
+
+type receiver struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func newReceiver(out io.Writer) http.Handler {
+	r := &receiver{out: out}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/traces", r.traces)
+	return mux
+}
+
+// traces accepts protobuf-encoded exports, optionally gzip-compressed.
+func (rc *receiver) traces(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if ct := r.Header.Get("Content-Type"); ct != "application/x-protobuf" {
+		http.Error(w, "only application/x-protobuf is supported", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var body io.Reader = http.MaxBytesReader(w, r.Body, maxBody)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req collectortrace.ExportTraceServiceRequest
+	if err := proto.Unmarshal(data, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rc.print(&req)
+
+	resp, _ := proto.Marshal(&collectortrace.ExportTraceServiceResponse{})
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	_, _ = w.Write(resp)
+}
+
+func (rc *receiver) print(req *collectortrace.ExportTraceServiceRequest) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	for _, rs := range req.GetResourceSpans() {
+		service := attribute(rs.GetResource().GetAttributes(), "service.name")
+		for _, ss := range rs.GetScopeSpans() {
+			for _, s := range ss.GetSpans() {
+				duration := time.Duration(s.GetEndTimeUnixNano() - s.GetStartTimeUnixNano())
+				fmt.Fprintf(rc.out, "%s trace=%s span=%s parent=%s %s %q %s %s\n",
+					service,
+					hex.EncodeToString(s.GetTraceId()),
+					hex.EncodeToString(s.GetSpanId()),
+					hex.EncodeToString(s.GetParentSpanId()),
+					kind(s.GetKind()),
+					s.GetName(),
+					duration.Round(time.Microsecond),
+					status(s.GetStatus()),
+				)
+			}
+		}
+	}
+}
+
+// attribute returns the string value of the named attribute, or "".
+func attribute(attrs []*commonpb.KeyValue, name string) string {
+	for _, kv := range attrs {
+		if kv.GetKey() == name {
+			return kv.GetValue().GetStringValue()
+		}
+	}
+	return ""
+}
+
+func kind(k tracepb.Span_SpanKind) string {
+	switch k {
+	case tracepb.Span_SPAN_KIND_SERVER:
+		return "server"
+	case tracepb.Span_SPAN_KIND_CLIENT:
+		return "client"
+	case tracepb.Span_SPAN_KIND_PRODUCER:
+		return "producer"
+	case tracepb.Span_SPAN_KIND_CONSUMER:
+		return "consumer"
+	default:
+		return "internal"
+	}
+}
+
+func status(s *tracepb.Status) string {
+	if s.GetCode() != tracepb.Status_STATUS_CODE_ERROR {
+		return "ok"
+	}
+	if s.GetMessage() != "" {
+		return "error: " + s.GetMessage()
+	}
+	return "error"
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"test-go/config"
+	"test-go/pkg/tracing"
+)
+
+// syncBuffer collects the output of concurrent exports.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestExport(t *testing.T) {
+	var out syncBuffer
+	srv := httptest.NewServer(newReceiver(&out))
+	defer srv.Close()
+
+	shutdown, err := tracing.Init(context.Background(), config.TracingConfig{
+		Enabled:     true,
+		Endpoint:    strings.TrimPrefix(srv.URL, "http://"),
+		Insecure:    true,
+		ServiceName: "orders",
+		SampleRatio: 1,
+	}, "test-go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, parent := tracing.Tracer().Start(context.Background(), "GET /orders/:id", trace.WithSpanKind(trace.SpanKindServer))
+	_, child := tracing.Tracer().Start(ctx, "gorm.query orders", trace.WithSpanKind(trace.SpanKindClient))
+	tracing.End(child, errors.New("record locked"))
+	tracing.End(parent, nil)
+
+	// Shutdown flushes the batch to the receiver
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	traceID := parent.SpanContext().TraceID().String()
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"server span", `orders trace=` + traceID + ` span=` + parent.SpanContext().SpanID().String() + ` parent= server "GET /orders/:id"`},
+		{"child span", `parent=` + parent.SpanContext().SpanID().String() + ` client "gorm.query orders"`},
+		{"error status", "error: record locked"},
+	}
+
+	got := out.String()
+	for _, tt := range tests {
+		if !strings.Contains(got, tt.want) {
+			t.Errorf("%s: %q not in output:\n%s", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Command smtpstub is a minimal SMTP server for trying alert email notifications
+// locally. It accepts every message without authentication or TLS and prints it
+// to stdout, so it must never be exposed outside a development machine.
+//
+//	go run ./cmd/smtpstub -addr :2525
+//
+// and in config.yaml:
+//
+//	alerting:
+//	  email:
+//	    enabled: true
+//	    host: "localhost"
+//	    port: 2525
+//	    tls: "none"
+//	    from: "alerts@example.com"
+//	    to: ["oncall@example.com"]
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", ":2525", "listen address")
+	flag.Parse()
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("smtpstub listening on %s", ln.Addr())
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			log.Fatal(err)
+		}
+		go serve(conn, os.Stdout)
+	}
+}
+
+// serve speaks SMTP on conn and writes each message it accepts to out.
+func serve(conn net.Conn, out io.Writer) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Minute))
+
+	r := bufio.NewReader(conn)
+	reply := func(s string) { fmt.Fprintf(conn, "%s\r\n", s) }
+
+	reply("220 smtpstub ready")
+
+	var from string
+	var to []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch verb {
+		case "EHLO", "HELO":
+			reply("250 smtpstub")
+		case "MAIL":
+			from, to = argument(line), nil
+			reply("250 OK")
+		case "RCPT":
+			to = append(to, argument(line))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var body strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" || l == ".\n" {
+					break
+				}
+				body.WriteString(strings.TrimPrefix(l, "."))
+			}
+			fmt.Fprintf(out, "--- message from %s to %s at %s ---\n%s\n", from, strings.Join(to, ", "), time.Now().Format(time.RFC3339), body.String())
+			reply("250 OK: queued")
+		case "RSET":
+			from, to = "", nil
+			reply("250 OK")
+		case "NOOP":
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// argument returns the address in "MAIL FROM:<a@b>" or "RCPT TO:<a@b>".
+func argument(line string) string {
+	if i := strings.Index(line, ":"); i >= 0 {
+		return strings.Trim(strings.TrimSpace(line[i+1:]), "<>")
+	}
+	return ""
+}
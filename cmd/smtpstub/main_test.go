@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"test-go/config"
+	"test-go/internal/alerting"
+	"test-go/pkg/logger"
+)
+
+// syncBuffer collects the output of concurrent connections.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+type fakeMetrics map[string]float64
+
+func (m fakeMetrics) Latest(name string) (float64, bool) {
+	v, ok := m[name]
+	return v, ok
+}
+
+func TestAlertEmails(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	var out syncBuffer
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn, &out)
+		}
+	}()
+
+	cfg := config.AlertingConfig{
+		Rules: []config.AlertRule{{Name: "queue_backlog", Metric: "queue.depth", Threshold: 100, Severity: "critical"}},
+		Email: config.AlertEmailConfig{
+			Enabled: true,
+			Host:    "127.0.0.1",
+			Port:    ln.Addr().(*net.TCPAddr).Port,
+			TLS:     "none",
+			From:    "alerts@example.com",
+			To:      []string{"oncall@example.com", "team@example.com"},
+		},
+	}
+	engine, err := alerting.New(cfg, "test-go", fakeMetrics{"queue.depth": 250}, nil, nil, logger.NewQuiet(false, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		send        func(ctx context.Context)
+		wantSubject string
+	}{
+		{
+			name: "test notification",
+			send: func(ctx context.Context) {
+				if failed := engine.SendTest(ctx, "admin"); len(failed) > 0 {
+					t.Fatalf("SendTest failed: %v", failed)
+				}
+			},
+			wantSubject: "Subject: [TEST] test-go: test (info)",
+		},
+		{
+			name:        "firing rule",
+			send:        engine.Evaluate,
+			wantSubject: "Subject: [FIRING] test-go: queue_backlog (critical)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.send(context.Background())
+
+			// Rule notifications are sent in the background
+			deadline := time.Now().Add(5 * time.Second)
+			for !strings.Contains(out.String(), tt.wantSubject) {
+				if time.Now().After(deadline) {
+					t.Fatalf("no message with %q in:\n%s", tt.wantSubject, out.String())
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		})
+	}
+
+	if want := "--- message from alerts@example.com to oncall@example.com, team@example.com"; !strings.Contains(out.String(), want) {
+		t.Errorf("envelope missing %q in:\n%s", want, out.String())
+	}
+}
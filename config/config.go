@@ -8,29 +8,116 @@ import (
 )
 
 type Config struct {
-	App        AppConfig        `mapstructure:"app"`
-	Server     ServerConfig     `mapstructure:"server"`
-	Services   ServicesConfig   `mapstructure:"services"`
-	Auth       AuthConfig       `mapstructure:"auth"`
-	Redis      RedisConfig      `mapstructure:"redis"`
-	Kafka      KafkaConfig      `mapstructure:"kafka"`
-	Postgres   PostgresConfig   `mapstructure:"postgres"`
-	Monitoring MonitoringConfig `mapstructure:"monitoring"`
-	Cron       CronConfig       `mapstructure:"cron"`
+	App         AppConfig         `mapstructure:"app"`
+	Server      ServerConfig      `mapstructure:"server"`
+	Services    ServicesConfig    `mapstructure:"services"`
+	Auth        AuthConfig        `mapstructure:"auth"`
+	Redis       RedisConfig       `mapstructure:"redis"`
+	Kafka       KafkaConfig       `mapstructure:"kafka"`
+	Postgres    PostgresConfig    `mapstructure:"postgres"`
+	Monitoring  MonitoringConfig  `mapstructure:"monitoring"`
+	Cron        CronConfig        `mapstructure:"cron"`
+	Idempotency IdempotencyConfig `mapstructure:"idempotency"`
+	HTTPCache   HTTPCacheConfig   `mapstructure:"http_cache"`
+	Compression CompressionConfig `mapstructure:"compression"`
+	Log         LogConfig         `mapstructure:"log"`
+	AccessLog   AccessLogConfig   `mapstructure:"access_log"`
+	Audit       AuditConfig       `mapstructure:"audit"`
+	IPFilter    IPFilterConfig    `mapstructure:"ip_filter"`
+	ProbeGuard  ProbeGuardConfig  `mapstructure:"probe_guard"`
+	Capture     CaptureConfig     `mapstructure:"capture"`
+	Metrics     MetricsConfig     `mapstructure:"metrics"`
+	Tracing     TracingConfig     `mapstructure:"tracing"`
+	Alerting    AlertingConfig    `mapstructure:"alerting"`
 }
 
 type MonitoringConfig struct {
-	Port           string         `mapstructure:"port"`
-	UpdatePeriod   time.Duration  `mapstructure:"update_period"`
-	Enabled        bool           `mapstructure:"enabled"`
-	UploadDir      string         `mapstructure:"upload_dir"`
-	Password       string         `mapstructure:"password"`
-	Title          string         `mapstructure:"title"`
-	Subtitle       string         `mapstructure:"subtitle"`
-	MaxPhotoSizeMB int            `mapstructure:"max_photo_size_mb"`
-	MinIO          MinIOConfig    `mapstructure:"minio"`
-	External       ExternalConfig `mapstructure:"external"`
-	ObfuscateAPI   bool           `mapstructure:"obfuscate_api"`
+	Port           string           `mapstructure:"port"`
+	UpdatePeriod   time.Duration    `mapstructure:"update_period"`
+	Enabled        bool             `mapstructure:"enabled"`
+	UploadDir      string           `mapstructure:"upload_dir"`
+	Password       string           `mapstructure:"password"`
+	Title          string           `mapstructure:"title"`
+	Subtitle       string           `mapstructure:"subtitle"`
+	MaxPhotoSizeMB int              `mapstructure:"max_photo_size_mb"`
+	MinIO          MinIOConfig      `mapstructure:"minio"`
+	External       ExternalConfig   `mapstructure:"external"`
+	ObfuscateAPI   bool             `mapstructure:"obfuscate_api"`
+	OIDC           OIDCConfig       `mapstructure:"oidc"`
+	History        HistoryConfig    `mapstructure:"history"`
+	LogStore       LogStoreConfig   `mapstructure:"log_store"`
+	Profiling      ProfilingConfig  `mapstructure:"profiling"`
+	Synthetics     SyntheticsConfig `mapstructure:"synthetics"`
+	StatusPage     StatusPageConfig `mapstructure:"status_page"`
+	Status         StatusConfig     `mapstructure:"status"`
+	Errors         ErrorsConfig     `mapstructure:"errors"`
+}
+
+// LogStoreConfig keeps the log lines behind the dashboard live stream so they can be
+// searched, exported and replayed to clients that reconnect. The newest lines stay in
+// memory; with Dir set every line is also appended to size-rotated NDJSON segments.
+type LogStoreConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	BufferSize    int           `mapstructure:"buffer_size"`     // lines kept in memory
+	Dir           string        `mapstructure:"dir"`             // empty keeps logs in memory only
+	SegmentSizeMB int           `mapstructure:"segment_size_mb"` // rotate segments at this size
+	MaxSegments   int           `mapstructure:"max_segments"`    // oldest segments beyond this are deleted
+	Retention     time.Duration `mapstructure:"retention"`       // segments older than this are deleted; 0 keeps them
+}
+
+// ProfilingConfig exposes pprof on the monitoring server and lets admins capture
+// profiles from the dashboard. The last MaxCaptures captures are kept in memory.
+type ProfilingConfig struct {
+	Enabled     bool                `mapstructure:"enabled"`
+	Pprof       bool                `mapstructure:"pprof"`        // mount net/http/pprof at /debug/pprof (admins only)
+	MaxCaptures int                 `mapstructure:"max_captures"` // captures kept for download
+	MaxSeconds  int                 `mapstructure:"max_seconds"`  // longest CPU, mutex or block capture
+	Auto        ProfilingAutoConfig `mapstructure:"auto"`
+}
+
+// ProfilingAutoConfig captures profiles when the process CPU usage crosses a
+// threshold, to catch intermittent spikes.
+type ProfilingAutoConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	CPUThreshold  float64       `mapstructure:"cpu_threshold"`  // percent of all cores used by this process
+	CheckInterval time.Duration `mapstructure:"check_interval"` // how often CPU usage is sampled
+	Cooldown      time.Duration `mapstructure:"cooldown"`       // minimum time between automatic captures
+	Seconds       int           `mapstructure:"seconds"`        // length of CPU, mutex and block captures
+	Kinds         []string      `mapstructure:"kinds"`          // profiles captured on each trigger
+}
+
+// HistoryConfig samples system, runtime, HTTP and infrastructure metrics for the
+// dashboard charts. Recent points stay in memory; all points are written to the
+// monitoring SQLite database and rolled up into 1m and 1h averages.
+type HistoryConfig struct {
+	Enabled    bool             `mapstructure:"enabled"`
+	Interval   time.Duration    `mapstructure:"interval"`    // sampling interval
+	BufferSize int              `mapstructure:"buffer_size"` // points kept in memory per metric
+	Retention  HistoryRetention `mapstructure:"retention"`
+}
+
+// HistoryRetention is how long each resolution is kept in the database.
+type HistoryRetention struct {
+	Raw    time.Duration `mapstructure:"raw"`
+	Minute time.Duration `mapstructure:"minute"`
+	Hour   time.Duration `mapstructure:"hour"`
+}
+
+// OIDCConfig enables OpenID Connect single sign-on for the monitoring dashboard.
+// Password login stays available as a break-glass fallback.
+type OIDCConfig struct {
+	Enabled               bool                `mapstructure:"enabled"`
+	Issuer                string              `mapstructure:"issuer"`
+	ClientID              string              `mapstructure:"client_id"`
+	ClientSecret          string              `mapstructure:"client_secret"` // empty for public clients (PKCE only)
+	RedirectURL           string              `mapstructure:"redirect_url"`  // e.g. http://localhost:9090/auth/oidc/callback
+	Scopes                []string            `mapstructure:"scopes"`
+	UsernameClaim         string              `mapstructure:"username_claim"`
+	GroupsClaim           string              `mapstructure:"groups_claim"`
+	Roles                 map[string][]string `mapstructure:"roles"`        // role -> groups granting it, e.g. admin: ["ops"]
+	DefaultRole           string              `mapstructure:"default_role"` // role for users in no mapped group; empty denies them
+	ButtonLabel           string              `mapstructure:"button_label"`
+	PostLogoutRedirectURL string              `mapstructure:"post_logout_redirect_url"`
 }
 
 type MinIOConfig struct {
@@ -41,13 +128,120 @@ type MinIOConfig struct {
 	BucketName      string `mapstructure:"bucket_name"`
 }
 
+// ExternalConfig lists the services probed in the background. Interval and Timeout
+// are defaults for services that do not set their own.
 type ExternalConfig struct {
-	Services []ExternalService `mapstructure:"services"`
+	Interval    time.Duration     `mapstructure:"interval"`
+	Timeout     time.Duration     `mapstructure:"timeout"`
+	HistorySize int               `mapstructure:"history_size"` // recent results kept per service
+	Retention   time.Duration     `mapstructure:"retention"`    // hourly uptime and state changes in the database
+	Services    []ExternalService `mapstructure:"services"`
 }
 
+// ExternalService is one probe target. HTTP checks request URL; TCP checks connect to
+// Address (host:port); DNS checks resolve Host, through Resolver when set.
 type ExternalService struct {
-	Name string `mapstructure:"name"`
-	URL  string `mapstructure:"url"`
+	Name     string        `mapstructure:"name"`
+	Type     string        `mapstructure:"type"` // http (default), tcp or dns
+	URL      string        `mapstructure:"url"`
+	Address  string        `mapstructure:"address"`
+	Host     string        `mapstructure:"host"`
+	Resolver string        `mapstructure:"resolver"` // host:port of a DNS server
+	Interval time.Duration `mapstructure:"interval"`
+	Timeout  time.Duration `mapstructure:"timeout"`
+
+	// HTTP only
+	Method         string              `mapstructure:"method"`
+	Headers        map[string]string   `mapstructure:"headers"`
+	ExpectedStatus string              `mapstructure:"expected_status"` // e.g. "200-299" or "200,204"
+	BodyContains   string              `mapstructure:"body_contains"`
+	JSON           []ExternalAssertion `mapstructure:"json"`
+	TLSExpiryDays  int                 `mapstructure:"tls_expiry_days"` // degraded when the certificate expires sooner
+}
+
+// StatusConfig controls how the dashboard's /api/status data is collected. Each source
+// (redis, postgres, kafka, cron, storage, system, system_info, external) refreshes in
+// the background on its own interval, and requests are answered from the last result.
+type StatusConfig struct {
+	Interval time.Duration                 `mapstructure:"interval"` // default refresh interval
+	Timeout  time.Duration                 `mapstructure:"timeout"`  // default per-refresh timeout
+	Sources  map[string]StatusSourceConfig `mapstructure:"sources"`  // overrides by source name
+}
+
+// StatusSourceConfig overrides the refresh interval and timeout of one source.
+type StatusSourceConfig struct {
+	Interval time.Duration `mapstructure:"interval"`
+	Timeout  time.Duration `mapstructure:"timeout"`
+}
+
+// ErrorsConfig groups the errors API handlers return, by type, message template and
+// origin, so each distinct failure shows up once with its occurrence history.
+type ErrorsConfig struct {
+	Enabled   bool          `mapstructure:"enabled"`
+	MinStatus int           `mapstructure:"min_status"` // lower response statuses are not tracked
+	MaxGroups int           `mapstructure:"max_groups"` // the least recently seen groups are dropped beyond this
+	Samples   int           `mapstructure:"samples"`    // recent occurrences kept per group
+	Flush     time.Duration `mapstructure:"flush"`      // how often changed groups are saved
+}
+
+// StatusPageConfig serves a public, read-only status page at /status, with its data
+// at /status.json, on the monitoring port. Only the configured component names and
+// descriptions, their states, daily uptime and incident notes are shown.
+type StatusPageConfig struct {
+	Enabled    bool              `mapstructure:"enabled"`
+	Title      string            `mapstructure:"title"`
+	Interval   time.Duration     `mapstructure:"interval"` // how often component states are sampled
+	Components []StatusComponent `mapstructure:"components"`
+}
+
+// StatusComponent is one row of the status page.
+type StatusComponent struct {
+	Name        string `mapstructure:"name"` // shown publicly
+	Description string `mapstructure:"description"`
+	// postgres, redis, kafka, storage, service:<name>, external:<service name> or
+	// synthetic:<check name>
+	Source string `mapstructure:"source"`
+}
+
+// SyntheticsConfig defines scripted checks: ordered HTTP steps run on a cron schedule,
+// where values extracted from one response can be used in later requests.
+type SyntheticsConfig struct {
+	History int              `mapstructure:"history"` // runs kept per check
+	Checks  []SyntheticCheck `mapstructure:"checks"`
+}
+
+// SyntheticCheck is one scripted flow. Step URLs, header values, bodies and expected
+// values are Go templates over the variables, e.g. {{.task_id}}; {{json .title}}
+// quotes a value for a JSON body, and {{.run_id}} is unique per run. Variable names
+// are lower case, as viper lowercases map keys.
+type SyntheticCheck struct {
+	Name      string            `mapstructure:"name"`
+	Schedule  string            `mapstructure:"schedule"` // cron spec with seconds
+	Budget    time.Duration     `mapstructure:"budget"`   // the run fails when it takes longer
+	Variables map[string]string `mapstructure:"variables"`
+	Steps     []SyntheticStep   `mapstructure:"steps"`
+}
+
+// SyntheticStep is one request of a check. It fails the run when the response does
+// not match the expected status, body or JSON assertions.
+type SyntheticStep struct {
+	Name           string              `mapstructure:"name"`
+	Method         string              `mapstructure:"method"`
+	URL            string              `mapstructure:"url"`
+	Headers        map[string]string   `mapstructure:"headers"`
+	Body           string              `mapstructure:"body"`
+	Timeout        time.Duration       `mapstructure:"timeout"`
+	ExpectedStatus string              `mapstructure:"expected_status"` // e.g. "200-299" or "200,204"
+	BodyContains   string              `mapstructure:"body_contains"`
+	JSON           []ExternalAssertion `mapstructure:"json"`
+	Extract        map[string]string   `mapstructure:"extract"` // variable -> JSON path in the response
+}
+
+// ExternalAssertion checks a value in a JSON response body. Path is dotted, with
+// numbers indexing arrays (data.items.0.id). An empty Equals only requires the path.
+type ExternalAssertion struct {
+	Path   string `mapstructure:"path"`
+	Equals string `mapstructure:"equals"`
 }
 
 type CronConfig struct {
@@ -85,9 +279,269 @@ func (s ServicesConfig) IsEnabled(serviceName string) bool {
 	return true // Default to enabled if not specified
 }
 
+// IdempotencyConfig controls replay of mutating requests carrying an Idempotency-Key header.
+type IdempotencyConfig struct {
+	Enabled   bool          `mapstructure:"enabled"`
+	Header    string        `mapstructure:"header"`      // header name, default "Idempotency-Key"
+	TTL       time.Duration `mapstructure:"ttl"`         // how long a stored response is replayed
+	LockTTL   time.Duration `mapstructure:"lock_ttl"`    // reservation lifetime; renewed while the first request runs
+	Store     string        `mapstructure:"store"`       // "memory" or "redis" (falls back to memory if Redis is down)
+	Methods   []string      `mapstructure:"methods"`     // methods the key is honoured on
+	MaxBodyKB int           `mapstructure:"max_body_kb"` // larger bodies are rejected with 413
+}
+
+// HTTPCacheConfig controls ETags, Cache-Control headers and the server-side response cache for GET routes.
+type HTTPCacheConfig struct {
+	Enabled             bool            `mapstructure:"enabled"`
+	ETag                bool            `mapstructure:"etag"`                  // compute ETags and answer If-None-Match with 304
+	Store               string          `mapstructure:"store"`                 // "memory" or "redis"
+	DefaultCacheControl string          `mapstructure:"default_cache_control"` // used when no rule matches
+	MaxBodyKB           int             `mapstructure:"max_body_kb"`           // larger responses are not cached server-side
+	Rules               []HTTPCacheRule `mapstructure:"rules"`
+}
+
+// HTTPCacheRule applies to every route under Path. A TTL > 0 enables server-side caching;
+// any successful write under Path invalidates the cached entries.
+type HTTPCacheRule struct {
+	Path         string        `mapstructure:"path"`
+	CacheControl string        `mapstructure:"cache_control"`
+	TTL          time.Duration `mapstructure:"ttl"`
+}
+
+// CompressionConfig controls response compression on both the API and monitoring servers.
+type CompressionConfig struct {
+	Enabled      bool     `mapstructure:"enabled"`
+	Encodings    []string `mapstructure:"encodings"`     // server preference order: br, zstd, gzip
+	MinSize      int      `mapstructure:"min_size"`      // bytes; smaller responses are sent as-is
+	ContentTypes []string `mapstructure:"content_types"` // allowlist, "text/*" style wildcards accepted
+	ExcludePaths []string `mapstructure:"exclude_paths"` // paths never compressed (e.g. SSE streams); a trailing "/" covers everything under it
+}
+
+// LogConfig shapes the application log pipeline. Every entry is one JSON event
+// (time, level, logger, message and fields) that each sink filters by its own level
+// and renders in its own format.
+type LogConfig struct {
+	Level     string            `mapstructure:"level"`   // default minimum level; app.debug lowers it to debug
+	Modules   map[string]string `mapstructure:"modules"` // levels for named loggers, e.g. service_d: debug
+	Sampling  LogSamplingConfig `mapstructure:"sampling"`
+	Console   LogSinkConfig     `mapstructure:"console"`   // stdout, unless the TUI is running
+	Broadcast LogSinkConfig     `mapstructure:"broadcast"` // dashboard live stream and log store
+	TUI       LogSinkConfig     `mapstructure:"tui"`
+	File      LogFileSinkConfig `mapstructure:"file"`
+
+	DebugOverride LogDebugOverrideConfig `mapstructure:"debug_override"`
+}
+
+// LogDebugOverrideConfig lets a request carrying a signed token, minted from the
+// dashboard, log at debug level regardless of the configured levels.
+type LogDebugOverrideConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Header  string        `mapstructure:"header"`
+	Secret  string        `mapstructure:"secret"`  // HMAC key; empty = random, tokens die with the process
+	MaxTTL  time.Duration `mapstructure:"max_ttl"` // longest validity of a token
+}
+
+// LogSinkConfig filters and renders one log sink.
+type LogSinkConfig struct {
+	Level  string `mapstructure:"level"`  // empty = every entry the logger emits
+	Format string `mapstructure:"format"` // "pretty" (coloured text), "text" or "json"
+}
+
+// LogFileSinkConfig writes the application log to a rotating file.
+type LogFileSinkConfig struct {
+	Enabled       bool `mapstructure:"enabled"`
+	LogSinkConfig `mapstructure:",squash"`
+	LogFileConfig `mapstructure:",squash"`
+}
+
+// LogSamplingConfig thins debug and info entries from hot paths such as the request
+// log: after Burst entries per Period only every Every-th one is kept. Warnings and
+// errors are never sampled.
+type LogSamplingConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Burst   uint32        `mapstructure:"burst"`
+	Period  time.Duration `mapstructure:"period"`
+	Every   uint32        `mapstructure:"every"`
+}
+
+// AccessLogConfig controls the structured per-request access log.
+// It is independent of the application log: its own sink, fields and sampling.
+type AccessLogConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	Output       string        `mapstructure:"output"`        // "stdout" (JSON lines) or "file"
+	Fields       []string      `mapstructure:"fields"`        // empty = default set; unknown names are read from request headers
+	SampleRate   float64       `mapstructure:"sample_rate"`   // 0-1, applies to non-error responses only
+	ExcludePaths []string      `mapstructure:"exclude_paths"` // path prefixes never logged (health checks, static assets)
+	File         LogFileConfig `mapstructure:"file"`
+}
+
+// LogFileConfig configures a rotating log file.
+type LogFileConfig struct {
+	Path           string        `mapstructure:"path"`
+	MaxSizeMB      int           `mapstructure:"max_size_mb"`
+	RotateInterval time.Duration `mapstructure:"rotate_interval"`
+	MaxBackups     int           `mapstructure:"max_backups"`
+	MaxAge         time.Duration `mapstructure:"max_age"`
+	Compress       bool          `mapstructure:"compress"`
+}
+
+// AuditConfig controls the persistent audit trail of API writes and dashboard actions.
+type AuditConfig struct {
+	Enabled       bool     `mapstructure:"enabled"`
+	Store         string   `mapstructure:"store"`          // "sqlite" (monitoring DB) or "postgres" (falls back to sqlite if Postgres is down)
+	RetentionDays int      `mapstructure:"retention_days"` // 0 = keep forever
+	APIWrites     bool     `mapstructure:"api_writes"`     // audit POST/PUT/PATCH/DELETE on the public API
+	MaxBodyBytes  int      `mapstructure:"max_body_bytes"` // cap for before/after summaries
+	RedactFields  []string `mapstructure:"redact_fields"`  // JSON keys masked in summaries
+}
+
+// IPFilterConfig restricts which client networks may reach the API and monitoring servers.
+// Rules listed here are read-only at runtime; the dashboard can add more on top of them.
+type IPFilterConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	TrustedProxies []string      `mapstructure:"trusted_proxies"` // CIDRs whose X-Forwarded-For is honoured; empty = use the socket address
+	BlockedHistory int           `mapstructure:"blocked_history"` // blocked attempts kept for the dashboard
+	API            IPFilterRules `mapstructure:"api"`
+	Monitoring     IPFilterRules `mapstructure:"monitoring"`
+}
+
+// IPFilterRules are the server-wide lists plus per-route-group overrides.
+// Deny always wins; an allowlist, when present, admits only the listed networks.
+type IPFilterRules struct {
+	Allow  []string        `mapstructure:"allow"`
+	Deny   []string        `mapstructure:"deny"`
+	Groups []IPFilterGroup `mapstructure:"groups"`
+}
+
+// IPFilterGroup applies extra lists to requests under Path.
+// A group allowlist replaces the server-wide one for that group.
+type IPFilterGroup struct {
+	Path  string   `mapstructure:"path"`
+	Allow []string `mapstructure:"allow"`
+	Deny  []string `mapstructure:"deny"`
+}
+
+// ProbeGuardConfig detects scanners from 404s and suspicious paths and bans them temporarily.
+type ProbeGuardConfig struct {
+	Enabled             bool          `mapstructure:"enabled"`
+	Store               string        `mapstructure:"store"`                // "memory" or "redis" (falls back to memory if Redis is disabled)
+	Window              time.Duration `mapstructure:"window"`               // sliding window for counting
+	NotFoundThreshold   int           `mapstructure:"not_found_threshold"`  // 404s per IP within the window before a ban
+	SuspiciousThreshold int           `mapstructure:"suspicious_threshold"` // suspicious-path hits per IP within the window before a ban
+	SuspiciousPatterns  []string      `mapstructure:"suspicious_patterns"`  // case-insensitive substrings of the request path
+	BanDuration         time.Duration `mapstructure:"ban_duration"`
+	IgnoreCIDRs         []string      `mapstructure:"ignore_cidrs"` // never counted or banned, e.g. internal health checkers
+}
+
+// CaptureConfig controls opt-in recording of full request/response pairs for debugging.
+// A request is captured when it matches Paths, carries a valid capture token in Header,
+// or is picked by SampleRate.
+type CaptureConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	SampleRate    float64       `mapstructure:"sample_rate"`    // 0-1 fraction of all other traffic
+	Paths         []string      `mapstructure:"paths"`          // route prefixes always captured
+	Header        string        `mapstructure:"header"`         // request header carrying a capture token minted on the dashboard
+	Secret        string        `mapstructure:"secret"`         // HMAC key for capture tokens; empty = random, tokens die with the process
+	MaxTTL        time.Duration `mapstructure:"max_ttl"`        // longest validity of a capture token
+	ExcludePaths  []string      `mapstructure:"exclude_paths"`  // path prefixes never captured
+	MaxBodyBytes  int           `mapstructure:"max_body_bytes"` // per body; the rest is dropped
+	MaxEntries    int           `mapstructure:"max_entries"`    // oldest captures are evicted
+	RedactHeaders []string      `mapstructure:"redact_headers"`
+	RedactFields  []string      `mapstructure:"redact_fields"` // JSON keys, form fields and query params
+}
+
+// MetricsConfig controls the Prometheus /metrics endpoint.
+type MetricsConfig struct {
+	Enabled bool      `mapstructure:"enabled"`
+	Path    string    `mapstructure:"path"`
+	Server  string    `mapstructure:"server"`  // "api" or "monitoring": which port serves Path
+	Token   string    `mapstructure:"token"`   // optional bearer token required to scrape
+	Buckets []float64 `mapstructure:"buckets"` // request latency histogram buckets, in seconds
+}
+
+// TracingConfig controls OpenTelemetry span export over OTLP/HTTP.
+type TracingConfig struct {
+	Enabled     bool              `mapstructure:"enabled"`
+	Endpoint    string            `mapstructure:"endpoint"` // collector host:port, e.g. localhost:4318
+	Insecure    bool              `mapstructure:"insecure"` // plain HTTP instead of HTTPS
+	Headers     map[string]string `mapstructure:"headers"`  // e.g. an API key for a hosted backend
+	ServiceName string            `mapstructure:"service_name"`
+	SampleRatio float64           `mapstructure:"sample_ratio"` // 0-1 for new traces; incoming sampled traces are kept
+}
+
+// AlertingConfig evaluates alert rules over the metric history and dependency health
+// checks, and notifies webhooks and email when alerts fire and resolve.
+type AlertingConfig struct {
+	Enabled            bool                `mapstructure:"enabled"`
+	EvaluationInterval time.Duration       `mapstructure:"evaluation_interval"`
+	RepeatInterval     time.Duration       `mapstructure:"repeat_interval"` // re-notify alerts still firing; 0 notifies once
+	Rules              []AlertRule         `mapstructure:"rules"`
+	Webhooks           []AlertWebhook      `mapstructure:"webhooks"`
+	Email              AlertEmailConfig    `mapstructure:"email"`
+	Maintenance        []MaintenanceWindow `mapstructure:"maintenance"`
+}
+
+// AlertRule fires when Metric compares true against Threshold, or when the Health
+// check fails, continuously for at least For. Set exactly one of Metric and Health.
+type AlertRule struct {
+	Name        string        `mapstructure:"name"`
+	Metric      string        `mapstructure:"metric"`   // metric history name, e.g. system.cpu.percent
+	Operator    string        `mapstructure:"operator"` // >, >=, <, <=, ==, != (default >)
+	Threshold   float64       `mapstructure:"threshold"`
+	Health      string        `mapstructure:"health"` // postgres, redis, kafka, external:<service name> or synthetic:<check name>
+	For         time.Duration `mapstructure:"for"`
+	Severity    string        `mapstructure:"severity"` // info, warning (default) or critical
+	Description string        `mapstructure:"description"`
+}
+
+// AlertWebhook posts notifications as JSON. Format "slack" and "teams" send payloads
+// their incoming webhooks accept; "generic" (default) sends the alert itself.
+type AlertWebhook struct {
+	Name        string            `mapstructure:"name"`
+	URL         string            `mapstructure:"url"`
+	Format      string            `mapstructure:"format"`
+	Headers     map[string]string `mapstructure:"headers"`
+	MinSeverity string            `mapstructure:"min_severity"`
+}
+
+// AlertEmailConfig sends notifications over SMTP.
+type AlertEmailConfig struct {
+	Enabled     bool     `mapstructure:"enabled"`
+	Host        string   `mapstructure:"host"`
+	Port        int      `mapstructure:"port"`
+	Username    string   `mapstructure:"username"` // empty skips authentication
+	Password    string   `mapstructure:"password"`
+	From        string   `mapstructure:"from"`
+	To          []string `mapstructure:"to"`
+	TLS         string   `mapstructure:"tls"` // "starttls" (when offered, default), "tls" (implicit) or "none"
+	MinSeverity string   `mapstructure:"min_severity"`
+}
+
+// MaintenanceWindow silences notifications once, between Start and End (RFC3339),
+// or each time Schedule (cron syntax) fires, for Duration. Empty Rules covers all rules.
+type MaintenanceWindow struct {
+	Name     string        `mapstructure:"name"`
+	Start    string        `mapstructure:"start"`
+	End      string        `mapstructure:"end"`
+	Schedule string        `mapstructure:"schedule"`
+	Duration time.Duration `mapstructure:"duration"`
+	Rules    []string      `mapstructure:"rules"`
+}
+
 type AuthConfig struct {
-	Type   string `mapstructure:"type"` // e.g., "jwt", "apikey", "none"
-	Secret string `mapstructure:"secret"`
+	Type    string        `mapstructure:"type"` // e.g., "jwt", "apikey", "none"
+	Secret  string        `mapstructure:"secret"`
+	APIKeys APIKeysConfig `mapstructure:"api_keys"`
+}
+
+// APIKeysConfig controls database-backed API keys, used when auth.type is "apikey".
+type APIKeysConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	Store          string        `mapstructure:"store"`           // "sqlite" (monitoring DB) or "postgres"
+	Prefix         string        `mapstructure:"prefix"`          // prepended to generated keys, e.g. "gbp"
+	Header         string        `mapstructure:"header"`          // also accepted: Authorization: Bearer <key>
+	CacheTTL       time.Duration `mapstructure:"cache_ttl"`       // how long validated keys are cached in memory
+	ProtectedPaths []string      `mapstructure:"protected_paths"` // route prefixes that require a key
 }
 
 type RedisConfig struct {
@@ -132,6 +586,49 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("app.enable_tui", true)    // TUI enabled by default
 	viper.SetDefault("server.port", "8080")
 	viper.SetDefault("auth.type", "none")
+
+	viper.SetDefault("monitoring.oidc.enabled", false)
+	viper.SetDefault("monitoring.oidc.scopes", []string{"openid", "profile", "email"})
+	viper.SetDefault("monitoring.oidc.username_claim", "preferred_username")
+	viper.SetDefault("monitoring.oidc.groups_claim", "groups")
+	viper.SetDefault("monitoring.oidc.button_label", "Sign in with SSO")
+	viper.SetDefault("monitoring.history.enabled", true)
+	viper.SetDefault("monitoring.history.interval", "10s")
+	viper.SetDefault("monitoring.history.buffer_size", 360)
+	viper.SetDefault("monitoring.history.retention.raw", "24h")
+	viper.SetDefault("monitoring.history.retention.minute", "168h")
+	viper.SetDefault("monitoring.history.retention.hour", "2160h")
+	viper.SetDefault("monitoring.log_store.enabled", true)
+	viper.SetDefault("monitoring.log_store.buffer_size", 10000)
+	viper.SetDefault("monitoring.log_store.dir", "")
+	viper.SetDefault("monitoring.log_store.segment_size_mb", 16)
+	viper.SetDefault("monitoring.log_store.max_segments", 10)
+	viper.SetDefault("monitoring.log_store.retention", "168h")
+	viper.SetDefault("monitoring.external.interval", "30s")
+	viper.SetDefault("monitoring.external.timeout", "5s")
+	viper.SetDefault("monitoring.external.history_size", 120)
+	viper.SetDefault("monitoring.external.retention", "2160h")
+	viper.SetDefault("monitoring.synthetics.history", 50)
+	viper.SetDefault("monitoring.status_page.enabled", false)
+	viper.SetDefault("monitoring.status_page.title", "Service Status")
+	viper.SetDefault("monitoring.status_page.interval", "1m")
+	viper.SetDefault("monitoring.errors.enabled", true)
+	viper.SetDefault("monitoring.errors.min_status", 500)
+	viper.SetDefault("monitoring.errors.max_groups", 500)
+	viper.SetDefault("monitoring.errors.samples", 10)
+	viper.SetDefault("monitoring.errors.flush", "10s")
+	viper.SetDefault("monitoring.status.interval", "5s")
+	viper.SetDefault("monitoring.status.timeout", "3s")
+	viper.SetDefault("monitoring.profiling.enabled", false)
+	viper.SetDefault("monitoring.profiling.pprof", false)
+	viper.SetDefault("monitoring.profiling.max_captures", 10)
+	viper.SetDefault("monitoring.profiling.max_seconds", 60)
+	viper.SetDefault("monitoring.profiling.auto.enabled", false)
+	viper.SetDefault("monitoring.profiling.auto.cpu_threshold", 80.0)
+	viper.SetDefault("monitoring.profiling.auto.check_interval", "10s")
+	viper.SetDefault("monitoring.profiling.auto.cooldown", "10m")
+	viper.SetDefault("monitoring.profiling.auto.seconds", 10)
+	viper.SetDefault("monitoring.profiling.auto.kinds", []string{"cpu", "goroutine"})
 	// Services config uses a dynamic map - no hardcoded defaults needed
 	// Services default to enabled if not specified (see ServicesConfig.IsEnabled)
 
@@ -139,6 +636,107 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("kafka.enabled", false)
 	viper.SetDefault("postgres.enabled", false)
 
+	viper.SetDefault("idempotency.enabled", false)
+	viper.SetDefault("idempotency.header", "Idempotency-Key")
+	viper.SetDefault("idempotency.ttl", "24h")
+	viper.SetDefault("idempotency.lock_ttl", "1m")
+	viper.SetDefault("idempotency.store", "memory")
+	viper.SetDefault("idempotency.methods", []string{"POST", "PATCH"})
+	viper.SetDefault("idempotency.max_body_kb", 1024)
+
+	viper.SetDefault("http_cache.enabled", false)
+	viper.SetDefault("http_cache.etag", true)
+	viper.SetDefault("http_cache.store", "memory")
+	viper.SetDefault("http_cache.default_cache_control", "no-cache")
+	viper.SetDefault("http_cache.max_body_kb", 1024)
+
+	viper.SetDefault("compression.enabled", false)
+	viper.SetDefault("compression.encodings", []string{"br", "zstd", "gzip"})
+	viper.SetDefault("compression.min_size", 1024)
+	viper.SetDefault("compression.content_types", []string{
+		"application/json", "application/javascript", "text/*", "image/svg+xml",
+	})
+	viper.SetDefault("compression.exclude_paths", []string{"/api/logs", "/api/cpu"})
+
+	viper.SetDefault("log.level", "info")
+	viper.SetDefault("log.sampling.enabled", false)
+	viper.SetDefault("log.sampling.burst", 100)
+	viper.SetDefault("log.sampling.period", "1s")
+	viper.SetDefault("log.sampling.every", 10)
+	viper.SetDefault("log.console.format", "pretty")
+	viper.SetDefault("log.broadcast.format", "json")
+	viper.SetDefault("log.tui.format", "json")
+	viper.SetDefault("log.file.enabled", false)
+	viper.SetDefault("log.file.format", "json")
+	viper.SetDefault("log.file.path", "logs/app.log")
+	viper.SetDefault("log.file.max_size_mb", 100)
+	viper.SetDefault("log.file.rotate_interval", "24h")
+	viper.SetDefault("log.file.max_backups", 7)
+	viper.SetDefault("log.file.compress", true)
+	viper.SetDefault("log.debug_override.enabled", true)
+	viper.SetDefault("log.debug_override.header", "X-Debug-Log")
+	viper.SetDefault("log.debug_override.max_ttl", "1h")
+
+	viper.SetDefault("access_log.enabled", false)
+	viper.SetDefault("access_log.output", "stdout")
+	viper.SetDefault("access_log.sample_rate", 1.0)
+	viper.SetDefault("access_log.exclude_paths", []string{"/health"})
+	viper.SetDefault("access_log.file.path", "logs/access.log")
+	viper.SetDefault("access_log.file.max_size_mb", 100)
+	viper.SetDefault("access_log.file.rotate_interval", "24h")
+	viper.SetDefault("access_log.file.max_backups", 7)
+	viper.SetDefault("access_log.file.compress", true)
+
+	viper.SetDefault("audit.enabled", false)
+	viper.SetDefault("audit.store", "sqlite")
+	viper.SetDefault("audit.retention_days", 90)
+	viper.SetDefault("audit.api_writes", true)
+	viper.SetDefault("audit.max_body_bytes", 2048)
+	viper.SetDefault("audit.redact_fields", []string{"password", "secret", "token", "authorization"})
+
+	viper.SetDefault("ip_filter.enabled", false)
+	viper.SetDefault("ip_filter.blocked_history", 500)
+
+	viper.SetDefault("probe_guard.enabled", false)
+	viper.SetDefault("probe_guard.store", "memory")
+	viper.SetDefault("probe_guard.window", "1m")
+	viper.SetDefault("probe_guard.not_found_threshold", 30)
+	viper.SetDefault("probe_guard.suspicious_threshold", 3)
+	viper.SetDefault("probe_guard.ban_duration", "1h")
+	viper.SetDefault("probe_guard.ignore_cidrs", []string{"127.0.0.1/32", "::1/128"})
+
+	viper.SetDefault("auth.api_keys.enabled", false)
+	viper.SetDefault("auth.api_keys.store", "sqlite")
+	viper.SetDefault("auth.api_keys.prefix", "gbp")
+	viper.SetDefault("auth.api_keys.header", "X-API-Key")
+	viper.SetDefault("auth.api_keys.cache_ttl", "1m")
+	viper.SetDefault("auth.api_keys.protected_paths", []string{"/api/v1"})
+
+	viper.SetDefault("capture.enabled", false)
+	viper.SetDefault("capture.sample_rate", 0.0)
+	viper.SetDefault("capture.header", "X-Debug-Capture")
+	viper.SetDefault("capture.max_ttl", "1h")
+	viper.SetDefault("capture.exclude_paths", []string{"/health"})
+	viper.SetDefault("capture.max_body_bytes", 16384)
+	viper.SetDefault("capture.max_entries", 500)
+	viper.SetDefault("capture.redact_headers", []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-API-Key"})
+	viper.SetDefault("capture.redact_fields", []string{"password", "secret", "token", "api_key"})
+
+	viper.SetDefault("metrics.enabled", false)
+	viper.SetDefault("metrics.path", "/metrics")
+	viper.SetDefault("metrics.server", "api")
+
+	viper.SetDefault("tracing.enabled", false)
+	viper.SetDefault("tracing.endpoint", "localhost:4318")
+	viper.SetDefault("tracing.insecure", true)
+	viper.SetDefault("tracing.sample_ratio", 1.0)
+
+	viper.SetDefault("alerting.enabled", false)
+	viper.SetDefault("alerting.evaluation_interval", "15s")
+	viper.SetDefault("alerting.repeat_interval", "4h")
+	viper.SetDefault("alerting.email.port", 587)
+	viper.SetDefault("alerting.email.tls", "starttls")
+
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
 			return nil, err
@@ -0,0 +1,377 @@
+// Package alerting evaluates alert rules over sampled metrics and dependency health
+// checks. Each rule has at most one active alert: it is pending while the condition
+// holds for less than the rule's For duration, then firing until the condition clears.
+// Notifications go out when an alert fires, again every repeat interval while it
+// keeps firing, and when it resolves, unless a silence or maintenance window covers it.
+package alerting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"test-go/config"
+	"test-go/pkg/logger"
+)
+
+// Severities, lowest first.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Alert states.
+const (
+	StatePending  = "pending"
+	StateFiring   = "firing"
+	StateResolved = "resolved"
+)
+
+const historySize = 200 // resolved alerts kept for the dashboard
+
+var (
+	ErrSilenceNotFound = errors.New("silence not found")
+	ErrUnknownRule     = errors.New("no alert rule with that name")
+)
+
+// MetricReader returns the latest sampled value of a metric.
+type MetricReader interface {
+	Latest(name string) (float64, bool)
+}
+
+// HealthCheck reports whether a dependency is healthy and, when it is not, why.
+type HealthCheck func(ctx context.Context) (healthy bool, detail string)
+
+// Alert is the current or last episode of a rule's condition holding.
+type Alert struct {
+	Rule         string    `json:"rule"`
+	Severity     string    `json:"severity"`
+	State        string    `json:"state"`
+	Description  string    `json:"description,omitempty"`
+	Summary      string    `json:"summary"` // what was observed at the last evaluation
+	ActiveAt     time.Time `json:"active_at"`
+	FiredAt      time.Time `json:"fired_at,omitzero"`
+	ResolvedAt   time.Time `json:"resolved_at,omitzero"`
+	SilencedBy   string    `json:"silenced_by,omitempty"` // silence ID or maintenance window name
+	LastNotified time.Time `json:"last_notified,omitzero"`
+}
+
+// RuleStatus describes a configured rule and its last evaluation.
+type RuleStatus struct {
+	Name        string  `json:"name"`
+	Kind        string  `json:"kind"` // metric or health
+	Metric      string  `json:"metric,omitempty"`
+	Operator    string  `json:"operator,omitempty"`
+	Threshold   float64 `json:"threshold"`
+	Health      string  `json:"health,omitempty"`
+	For         string  `json:"for"`
+	Severity    string  `json:"severity"`
+	Description string  `json:"description,omitempty"`
+	State       string  `json:"state"` // ok, pending or firing
+	LastValue   string  `json:"last_value"`
+}
+
+// Engine evaluates the configured rules on an interval.
+type Engine struct {
+	rules       []rule
+	metrics     MetricReader
+	checks      map[string]HealthCheck
+	notifiers   []Notifier
+	maintenance []*window
+	store       Store
+	interval    time.Duration
+	repeat      time.Duration
+	source      string
+	logger      *logger.Logger
+
+	mu       sync.RWMutex
+	active   map[string]*Alert
+	lastSeen map[string]string // rule -> observed value at the last evaluation
+	history  []Alert
+	silences []Silence
+
+	statusMu sync.Mutex
+	status   map[string]NotifierStatus
+}
+
+// New validates cfg and builds an engine. source names this application in
+// notifications. metrics may be nil when the metric history is disabled; store may
+// be nil to keep silences in memory only. Call Start to begin evaluating.
+func New(cfg config.AlertingConfig, source string, metrics MetricReader, checks map[string]HealthCheck, store Store, l *logger.Logger) (*Engine, error) {
+	rules, err := compileRules(cfg.Rules)
+	if err != nil {
+		return nil, err
+	}
+	windows, err := compileWindows(cfg.Maintenance)
+	if err != nil {
+		return nil, err
+	}
+	notifiers, err := buildNotifiers(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := cfg.EvaluationInterval
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+
+	e := &Engine{
+		rules:       rules,
+		metrics:     metrics,
+		checks:      checks,
+		notifiers:   notifiers,
+		maintenance: windows,
+		store:       store,
+		interval:    interval,
+		repeat:      cfg.RepeatInterval,
+		source:      source,
+		logger:      l,
+		active:      make(map[string]*Alert),
+		lastSeen:    make(map[string]string),
+		status:      make(map[string]NotifierStatus),
+	}
+
+	for _, r := range rules {
+		switch {
+		case r.Metric != "" && metrics == nil:
+			l.Warn("Alert rule needs the metric history, which is disabled", "rule", r.Name, "metric", r.Metric)
+		case r.Health != "" && checks[r.Health] == nil:
+			l.Warn("Alert rule checks a dependency that is not enabled", "rule", r.Name, "health", r.Health)
+		}
+	}
+
+	if store != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		saved, err := store.Load(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("alerting: failed to load silences: %w", err)
+		}
+		e.silences = saved
+	}
+
+	return e, nil
+}
+
+// Start evaluates the rules every evaluation interval until ctx is cancelled.
+func (e *Engine) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+
+		for {
+			e.Evaluate(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Evaluate checks every rule once and sends the resulting notifications.
+func (e *Engine) Evaluate(ctx context.Context) {
+	now := time.Now()
+	e.pruneSilences(now)
+
+	for _, r := range e.rules {
+		holds, summary, ok := e.observe(ctx, r)
+		if !ok {
+			e.mu.Lock()
+			e.lastSeen[r.Name] = "no data"
+			e.mu.Unlock()
+			continue
+		}
+		if n := e.transition(r, holds, summary, now); n != nil {
+			e.dispatch(*n)
+		}
+	}
+}
+
+// observe returns whether r's condition holds and a summary of what was seen.
+// ok is false when there is nothing to evaluate (no sample or no check).
+func (e *Engine) observe(ctx context.Context, r rule) (holds bool, summary string, ok bool) {
+	if r.Metric != "" {
+		if e.metrics == nil {
+			return false, "", false
+		}
+		v, ok := e.metrics.Latest(r.Metric)
+		if !ok {
+			return false, "", false
+		}
+		return r.compare(v, r.Threshold), fmt.Sprintf("%s = %.2f (threshold %s %.2f)", r.Metric, v, r.Operator, r.Threshold), true
+	}
+
+	check := e.checks[r.Health]
+	if check == nil {
+		return false, "", false
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	healthy, detail := check(ctx)
+	if healthy {
+		return false, r.Health + " is healthy", true
+	}
+	if detail == "" {
+		detail = "unhealthy"
+	}
+	return true, r.Health + " is down: " + detail, true
+}
+
+// transition updates r's alert and returns the notification to send, if any.
+func (e *Engine) transition(r rule, holds bool, summary string, now time.Time) *Notification {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.lastSeen[r.Name] = summary
+	a := e.active[r.Name]
+
+	if !holds {
+		if a == nil {
+			return nil
+		}
+		delete(e.active, r.Name)
+		if a.State != StateFiring {
+			return nil // cleared while still pending
+		}
+		a.State = StateResolved
+		a.ResolvedAt = now
+		a.Summary = summary
+		e.history = append(e.history, *a)
+		if over := len(e.history) - historySize; over > 0 {
+			e.history = e.history[over:]
+		}
+		// Only announce the resolution of alerts whose firing was announced
+		if a.LastNotified.IsZero() || e.silencedBy(r.Name, now) != "" {
+			return nil
+		}
+		return &Notification{Status: StateResolved, Alert: *a, Source: e.source}
+	}
+
+	if a == nil {
+		a = &Alert{Rule: r.Name, Severity: r.Severity, Description: r.Description, State: StatePending, ActiveAt: now}
+		e.active[r.Name] = a
+	}
+	a.Summary = summary
+	a.SilencedBy = e.silencedBy(r.Name, now)
+
+	if a.State == StatePending && now.Sub(a.ActiveAt) >= r.For {
+		a.State = StateFiring
+		a.FiredAt = now
+	}
+	if a.State != StateFiring || a.SilencedBy != "" {
+		return nil
+	}
+
+	// First notification, including one held back by a silence that has since ended,
+	// then reminders every repeat interval
+	if a.LastNotified.IsZero() || (e.repeat > 0 && now.Sub(a.LastNotified) >= e.repeat) {
+		a.LastNotified = now
+		return &Notification{Status: StateFiring, Alert: *a, Source: e.source}
+	}
+	return nil
+}
+
+// Alerts returns the pending and firing alerts, most severe first.
+func (e *Engine) Alerts() []Alert {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	out := make([]Alert, 0, len(e.active))
+	for _, a := range e.active {
+		out = append(out, *a)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if si, sj := severityRank(out[i].Severity), severityRank(out[j].Severity); si != sj {
+			return si > sj
+		}
+		return out[i].ActiveAt.Before(out[j].ActiveAt)
+	})
+	return out
+}
+
+// History returns recently resolved alerts, newest first.
+func (e *Engine) History() []Alert {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	out := make([]Alert, len(e.history))
+	for i, a := range e.history {
+		out[len(e.history)-1-i] = a
+	}
+	return out
+}
+
+// Rules returns the configured rules with their current state.
+func (e *Engine) Rules() []RuleStatus {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	out := make([]RuleStatus, 0, len(e.rules))
+	for _, r := range e.rules {
+		s := RuleStatus{
+			Name:        r.Name,
+			Kind:        "metric",
+			Metric:      r.Metric,
+			Operator:    r.Operator,
+			Threshold:   r.Threshold,
+			Health:      r.Health,
+			For:         r.For.String(),
+			Severity:    r.Severity,
+			Description: r.Description,
+			State:       "ok",
+			LastValue:   e.lastSeen[r.Name],
+		}
+		if r.Health != "" {
+			s.Kind, s.Operator = "health", ""
+		}
+		if a := e.active[r.Name]; a != nil {
+			s.State = a.State
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func (e *Engine) hasRule(name string) bool {
+	for _, r := range e.rules {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// silencedBy returns the silence ID or maintenance window covering rule, or "".
+// The caller holds e.mu.
+func (e *Engine) silencedBy(ruleName string, now time.Time) string {
+	for _, s := range e.silences {
+		if s.covers(ruleName, now) {
+			return s.ID
+		}
+	}
+	for _, w := range e.maintenance {
+		if w.covers(ruleName, now) {
+			return "maintenance:" + w.name
+		}
+	}
+	return ""
+}
+
+func severityRank(s string) int {
+	switch strings.ToLower(s) {
+	case SeverityCritical:
+		return 2
+	case SeverityWarning:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,206 @@
+package alerting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"test-go/config"
+	"test-go/pkg/logger"
+)
+
+type fakeMetrics map[string]float64
+
+func (m fakeMetrics) Latest(name string) (float64, bool) {
+	v, ok := m[name]
+	return v, ok
+}
+
+func TestObserve(t *testing.T) {
+	metrics := fakeMetrics{"system.cpu.percent": 90, "queue.depth": 0}
+	checks := map[string]HealthCheck{
+		"redis":    func(context.Context) (bool, string) { return true, "" },
+		"postgres": func(context.Context) (bool, string) { return false, "connection refused" },
+	}
+
+	tests := []struct {
+		name      string
+		rule      config.AlertRule
+		wantHolds bool
+		wantOK    bool
+	}{
+		{"above threshold", config.AlertRule{Metric: "system.cpu.percent", Threshold: 80}, true, true},
+		{"at threshold with >", config.AlertRule{Metric: "system.cpu.percent", Threshold: 90}, false, true},
+		{"at threshold with >=", config.AlertRule{Metric: "system.cpu.percent", Operator: ">=", Threshold: 90}, true, true},
+		{"below threshold with <", config.AlertRule{Metric: "queue.depth", Operator: "<", Threshold: 1}, true, true},
+		{"equality", config.AlertRule{Metric: "queue.depth", Operator: "==", Threshold: 0}, true, true},
+		{"inequality", config.AlertRule{Metric: "queue.depth", Operator: "!=", Threshold: 0}, false, true},
+		{"no sample yet", config.AlertRule{Metric: "memory.percent", Threshold: 80}, false, false},
+		{"healthy dependency", config.AlertRule{Health: "redis"}, false, true},
+		{"unhealthy dependency", config.AlertRule{Health: "postgres"}, true, true},
+		{"dependency not enabled", config.AlertRule{Health: "kafka"}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.Name = "rule"
+			e := newTestEngine(t, config.AlertingConfig{Rules: []config.AlertRule{tt.rule}}, metrics, checks)
+			holds, summary, ok := e.observe(context.Background(), e.rules[0])
+			if holds != tt.wantHolds || ok != tt.wantOK {
+				t.Errorf("observe = (%v, %q, %v), want holds %v ok %v", holds, summary, ok, tt.wantHolds, tt.wantOK)
+			}
+		})
+	}
+}
+
+type evalStep struct {
+	at         time.Duration // since the first step
+	holds      bool
+	wantState  string // of the active alert; "" when there is none
+	wantNotify string // status of the notification sent; "" when none
+}
+
+func TestTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    config.AlertRule
+		repeat  time.Duration
+		silence bool // silence the rule before the first step
+		window  bool // a maintenance window covers the rule
+		steps   []evalStep
+	}{
+		{
+			name: "pending until the for duration, then firing and resolved",
+			rule: config.AlertRule{For: time.Minute},
+			steps: []evalStep{
+				{at: 0, holds: true, wantState: StatePending},
+				{at: 30 * time.Second, holds: true, wantState: StatePending},
+				{at: time.Minute, holds: true, wantState: StateFiring, wantNotify: StateFiring},
+				{at: 90 * time.Second, holds: true, wantState: StateFiring},
+				{at: 2 * time.Minute, holds: false, wantNotify: StateResolved},
+			},
+		},
+		{
+			name: "no for duration fires at once",
+			steps: []evalStep{
+				{at: 0, holds: true, wantState: StateFiring, wantNotify: StateFiring},
+			},
+		},
+		{
+			name: "clearing while pending sends nothing",
+			rule: config.AlertRule{For: time.Minute},
+			steps: []evalStep{
+				{at: 0, holds: true, wantState: StatePending},
+				{at: 30 * time.Second, holds: false},
+				{at: 45 * time.Second, holds: true, wantState: StatePending},
+			},
+		},
+		{
+			name:   "reminders every repeat interval",
+			repeat: 5 * time.Minute,
+			steps: []evalStep{
+				{at: 0, holds: true, wantState: StateFiring, wantNotify: StateFiring},
+				{at: 4 * time.Minute, holds: true, wantState: StateFiring},
+				{at: 5 * time.Minute, holds: true, wantState: StateFiring, wantNotify: StateFiring},
+			},
+		},
+		{
+			name:    "silenced alerts fire and resolve quietly",
+			silence: true,
+			steps: []evalStep{
+				{at: 0, holds: true, wantState: StateFiring},
+				{at: time.Minute, holds: false},
+			},
+		},
+		{
+			name:   "maintenance windows silence like silences",
+			window: true,
+			steps: []evalStep{
+				{at: 0, holds: true, wantState: StateFiring},
+				{at: time.Minute, holds: false},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.Name = "cpu"
+			tt.rule.Metric = "system.cpu.percent"
+			cfg := config.AlertingConfig{Rules: []config.AlertRule{tt.rule}, RepeatInterval: tt.repeat}
+			start := time.Now()
+			if tt.window {
+				cfg.Maintenance = []config.MaintenanceWindow{{
+					Name:  "upgrade",
+					Start: start.Add(-time.Hour).Format(time.RFC3339),
+					End:   start.Add(time.Hour).Format(time.RFC3339),
+					Rules: []string{"cpu"},
+				}}
+			}
+			e := newTestEngine(t, cfg, fakeMetrics{}, nil)
+			if tt.silence {
+				if _, err := e.AddSilence(context.Background(), "cpu", time.Hour, "deploy", "test"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for i, step := range tt.steps {
+				n := e.transition(e.rules[0], step.holds, "", start.Add(step.at))
+
+				var notified string
+				if n != nil {
+					notified = n.Status
+				}
+				if notified != step.wantNotify {
+					t.Errorf("step %d: notification %q, want %q", i+1, notified, step.wantNotify)
+				}
+				var state string
+				if a := e.active["cpu"]; a != nil {
+					state = a.State
+				}
+				if state != step.wantState {
+					t.Errorf("step %d: state %q, want %q", i+1, state, step.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestCompileRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    config.AlertRule
+		wantErr bool
+	}{
+		{"metric rule", config.AlertRule{Name: "cpu", Metric: "system.cpu.percent", Threshold: 90}, false},
+		{"health rule", config.AlertRule{Name: "db", Health: "postgres"}, false},
+		{"external service", config.AlertRule{Name: "api", Health: "external:payments"}, false},
+		{"no name", config.AlertRule{Metric: "system.cpu.percent"}, true},
+		{"neither metric nor health", config.AlertRule{Name: "x"}, true},
+		{"both metric and health", config.AlertRule{Name: "x", Metric: "m", Health: "redis"}, true},
+		{"unknown health check", config.AlertRule{Name: "x", Health: "mysql"}, true},
+		{"unknown operator", config.AlertRule{Name: "x", Metric: "m", Operator: "=>"}, true},
+		{"unknown severity", config.AlertRule{Name: "x", Metric: "m", Severity: "fatal"}, true},
+		{"negative for", config.AlertRule{Name: "x", Metric: "m", For: -time.Second}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := compileRules([]config.AlertRule{tt.rule})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error: %v", err, tt.wantErr)
+			}
+			if err == nil && (rules[0].Operator != ">" && tt.rule.Operator == "" || rules[0].Severity == "") {
+				t.Errorf("defaults not applied: %+v", rules[0].AlertRule)
+			}
+		})
+	}
+}
+
+func newTestEngine(t *testing.T, cfg config.AlertingConfig, metrics MetricReader, checks map[string]HealthCheck) *Engine {
+	t.Helper()
+	e, err := New(cfg, "test", metrics, checks, nil, logger.NewQuiet(false, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
@@ -0,0 +1,389 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"test-go/config"
+)
+
+const notifyTimeout = 30 * time.Second
+
+// Notification is sent to every notifier whose minimum severity the alert meets.
+type Notification struct {
+	Status string `json:"status"` // firing, resolved or test
+	Alert  Alert  `json:"alert"`
+	Source string `json:"source"`
+}
+
+func (n Notification) title() string {
+	status := strings.ToUpper(n.Status)
+	return fmt.Sprintf("[%s] %s: %s (%s)", status, n.Source, n.Alert.Rule, n.Alert.Severity)
+}
+
+func (n Notification) text() string {
+	var b strings.Builder
+	if n.Alert.Description != "" {
+		b.WriteString(n.Alert.Description + "\n")
+	}
+	b.WriteString(n.Alert.Summary + "\n")
+	switch n.Status {
+	case StateResolved:
+		fmt.Fprintf(&b, "Resolved at %s after %s", n.Alert.ResolvedAt.Format(time.RFC3339), n.Alert.ResolvedAt.Sub(n.Alert.FiredAt).Round(time.Second))
+	default:
+		fmt.Fprintf(&b, "Active since %s", n.Alert.ActiveAt.Format(time.RFC3339))
+	}
+	return b.String()
+}
+
+// Notifier delivers notifications to one destination.
+type Notifier interface {
+	Name() string
+	Kind() string
+	MinSeverity() string
+	Notify(ctx context.Context, n Notification) error
+}
+
+// NotifierStatus is the delivery record of one notifier.
+type NotifierStatus struct {
+	Name        string    `json:"name"`
+	Kind        string    `json:"kind"`
+	MinSeverity string    `json:"min_severity"`
+	Sent        int64     `json:"sent"`
+	Failed      int64     `json:"failed"`
+	LastSent    time.Time `json:"last_sent,omitzero"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at,omitzero"`
+}
+
+func buildNotifiers(cfg config.AlertingConfig) ([]Notifier, error) {
+	var out []Notifier
+	for i, w := range cfg.Webhooks {
+		n, err := newWebhookNotifier(w, i)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	if cfg.Email.Enabled {
+		n, err := newEmailNotifier(cfg.Email)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+func normalizeMinSeverity(s string) (string, error) {
+	if s == "" {
+		return SeverityInfo, nil
+	}
+	s = strings.ToLower(s)
+	if !validSeverity(s) {
+		return "", fmt.Errorf("unknown min_severity %q", s)
+	}
+	return s, nil
+}
+
+// dispatch sends n to every notifier that accepts its severity, in the background.
+func (e *Engine) dispatch(n Notification) {
+	for _, nt := range e.notifiers {
+		if severityRank(n.Alert.Severity) < severityRank(nt.MinSeverity()) {
+			continue
+		}
+		go func(nt Notifier) {
+			ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+			defer cancel()
+			if err := e.deliver(ctx, nt, n); err != nil {
+				e.logger.Error("Failed to send alert notification", err, "notifier", nt.Name(), "rule", n.Alert.Rule, "status", n.Status)
+			}
+		}(nt)
+	}
+}
+
+func (e *Engine) deliver(ctx context.Context, nt Notifier, n Notification) error {
+	err := nt.Notify(ctx, n)
+
+	e.statusMu.Lock()
+	defer e.statusMu.Unlock()
+	s := e.status[nt.Name()]
+	if err != nil {
+		s.Failed++
+		s.LastError = err.Error()
+		s.LastErrorAt = time.Now()
+	} else {
+		s.Sent++
+		s.LastSent = time.Now()
+	}
+	e.status[nt.Name()] = s
+	return err
+}
+
+// Notifiers returns the delivery record of each configured notifier.
+func (e *Engine) Notifiers() []NotifierStatus {
+	e.statusMu.Lock()
+	defer e.statusMu.Unlock()
+
+	out := make([]NotifierStatus, 0, len(e.notifiers))
+	for _, nt := range e.notifiers {
+		s := e.status[nt.Name()]
+		s.Name, s.Kind, s.MinSeverity = nt.Name(), nt.Kind(), nt.MinSeverity()
+		out = append(out, s)
+	}
+	return out
+}
+
+// SendTest sends a test notification to every notifier regardless of severity
+// filters and returns the error of each one that failed, by notifier name.
+func (e *Engine) SendTest(ctx context.Context, requestedBy string) map[string]string {
+	now := time.Now()
+	n := Notification{
+		Status: "test",
+		Source: e.source,
+		Alert: Alert{
+			Rule:        "test",
+			Severity:    SeverityInfo,
+			State:       StateFiring,
+			Description: "Test notification requested by " + requestedBy,
+			Summary:     "If you can read this, alert notifications reach this destination.",
+			ActiveAt:    now,
+			FiredAt:     now,
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+
+	type result struct {
+		name string
+		err  error
+	}
+	results := make(chan result, len(e.notifiers))
+	for _, nt := range e.notifiers {
+		go func(nt Notifier) {
+			results <- result{nt.Name(), e.deliver(ctx, nt, n)}
+		}(nt)
+	}
+
+	failed := make(map[string]string)
+	for range e.notifiers {
+		if r := <-results; r.err != nil {
+			failed[r.name] = r.err.Error()
+		}
+	}
+	return failed
+}
+
+// WebhookNotifier posts notifications as JSON.
+type WebhookNotifier struct {
+	cfg    config.AlertWebhook
+	client *http.Client
+}
+
+func newWebhookNotifier(cfg config.AlertWebhook, i int) (*WebhookNotifier, error) {
+	if cfg.Name == "" {
+		cfg.Name = fmt.Sprintf("webhook-%d", i+1)
+	}
+	if !strings.HasPrefix(cfg.URL, "http://") && !strings.HasPrefix(cfg.URL, "https://") {
+		return nil, fmt.Errorf("alerting: webhook %q: url must be http or https", cfg.Name)
+	}
+	switch cfg.Format {
+	case "":
+		cfg.Format = "generic"
+	case "generic", "slack", "teams":
+	default:
+		return nil, fmt.Errorf("alerting: webhook %q: unknown format %q", cfg.Name, cfg.Format)
+	}
+	var err error
+	if cfg.MinSeverity, err = normalizeMinSeverity(cfg.MinSeverity); err != nil {
+		return nil, fmt.Errorf("alerting: webhook %q: %w", cfg.Name, err)
+	}
+	return &WebhookNotifier{cfg: cfg, client: &http.Client{Timeout: notifyTimeout}}, nil
+}
+
+func (w *WebhookNotifier) Name() string        { return w.cfg.Name }
+func (w *WebhookNotifier) Kind() string        { return "webhook:" + w.cfg.Format }
+func (w *WebhookNotifier) MinSeverity() string { return w.cfg.MinSeverity }
+
+func (w *WebhookNotifier) Notify(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(w.payload(n))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (w *WebhookNotifier) payload(n Notification) interface{} {
+	color := severityColor(n)
+	switch w.cfg.Format {
+	case "slack":
+		return map[string]interface{}{
+			"text": n.title(),
+			"attachments": []map[string]interface{}{{
+				"color":  "#" + color,
+				"text":   n.text(),
+				"footer": n.Source,
+				"ts":     time.Now().Unix(),
+			}},
+		}
+	case "teams":
+		return map[string]interface{}{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    n.title(),
+			"themeColor": color,
+			"title":      n.title(),
+			"text":       strings.ReplaceAll(n.text(), "\n", "<br>"),
+		}
+	default:
+		return n
+	}
+}
+
+func severityColor(n Notification) string {
+	if n.Status == StateResolved {
+		return "2EB67D"
+	}
+	switch n.Alert.Severity {
+	case SeverityCritical:
+		return "E01E5A"
+	case SeverityWarning:
+		return "ECB22E"
+	default:
+		return "36C5F0"
+	}
+}
+
+// EmailNotifier sends notifications over SMTP.
+type EmailNotifier struct {
+	cfg config.AlertEmailConfig
+}
+
+func newEmailNotifier(cfg config.AlertEmailConfig) (*EmailNotifier, error) {
+	if cfg.Host == "" || cfg.From == "" || len(cfg.To) == 0 {
+		return nil, fmt.Errorf("alerting: email needs host, from and to")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	switch cfg.TLS {
+	case "":
+		cfg.TLS = "starttls"
+	case "starttls", "tls", "none":
+	default:
+		return nil, fmt.Errorf("alerting: email: unknown tls mode %q", cfg.TLS)
+	}
+	var err error
+	if cfg.MinSeverity, err = normalizeMinSeverity(cfg.MinSeverity); err != nil {
+		return nil, fmt.Errorf("alerting: email: %w", err)
+	}
+	return &EmailNotifier{cfg: cfg}, nil
+}
+
+func (m *EmailNotifier) Name() string        { return "email" }
+func (m *EmailNotifier) Kind() string        { return "email" }
+func (m *EmailNotifier) MinSeverity() string { return m.cfg.MinSeverity }
+
+func (m *EmailNotifier) Notify(ctx context.Context, n Notification) error {
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: m.cfg.Host}
+
+	dialer := &net.Dialer{}
+	var conn net.Conn
+	var err error
+	if m.cfg.TLS == "tls" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if m.cfg.TLS == "starttls" {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		}
+	}
+	if m.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(m.cfg.From); err != nil {
+		return err
+	}
+	for _, to := range m.cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	wc, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := wc.Write(m.message(n)); err != nil {
+		return err
+	}
+	if err := wc.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (m *EmailNotifier) message(n Notification) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", m.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(m.cfg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", n.title())
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(n.text(), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
@@ -0,0 +1,182 @@
+package alerting
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"test-go/config"
+
+	"github.com/robfig/cron/v3"
+)
+
+// rule is a validated config.AlertRule.
+type rule struct {
+	config.AlertRule
+	compare func(v, threshold float64) bool
+}
+
+var operators = map[string]func(v, threshold float64) bool{
+	">":  func(v, t float64) bool { return v > t },
+	">=": func(v, t float64) bool { return v >= t },
+	"<":  func(v, t float64) bool { return v < t },
+	"<=": func(v, t float64) bool { return v <= t },
+	"==": func(v, t float64) bool { return v == t },
+	"!=": func(v, t float64) bool { return v != t },
+}
+
+func compileRules(in []config.AlertRule) ([]rule, error) {
+	seen := make(map[string]bool)
+	out := make([]rule, 0, len(in))
+
+	for i, r := range in {
+		r.Name = strings.TrimSpace(r.Name)
+		if r.Name == "" {
+			return nil, fmt.Errorf("alerting: rule %d has no name", i+1)
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf("alerting: duplicate rule name %q", r.Name)
+		}
+		seen[r.Name] = true
+
+		if (r.Metric == "") == (r.Health == "") {
+			return nil, fmt.Errorf("alerting: rule %q must set exactly one of metric and health", r.Name)
+		}
+		if r.Health != "" {
+			switch {
+			case r.Health == "postgres", r.Health == "redis", r.Health == "kafka":
+			case strings.HasPrefix(r.Health, "external:") && len(r.Health) > len("external:"):
+			case strings.HasPrefix(r.Health, "synthetic:") && len(r.Health) > len("synthetic:"):
+			default:
+				return nil, fmt.Errorf("alerting: rule %q: unknown health check %q", r.Name, r.Health)
+			}
+		}
+
+		if r.Operator == "" {
+			r.Operator = ">"
+		}
+		cmp, ok := operators[r.Operator]
+		if !ok {
+			return nil, fmt.Errorf("alerting: rule %q: unknown operator %q", r.Name, r.Operator)
+		}
+
+		if r.Severity == "" {
+			r.Severity = SeverityWarning
+		}
+		r.Severity = strings.ToLower(r.Severity)
+		if !validSeverity(r.Severity) {
+			return nil, fmt.Errorf("alerting: rule %q: unknown severity %q", r.Name, r.Severity)
+		}
+		if r.For < 0 {
+			return nil, fmt.Errorf("alerting: rule %q: negative for duration", r.Name)
+		}
+
+		out = append(out, rule{AlertRule: r, compare: cmp})
+	}
+	return out, nil
+}
+
+func validSeverity(s string) bool {
+	return s == SeverityInfo || s == SeverityWarning || s == SeverityCritical
+}
+
+// window is a validated config.MaintenanceWindow.
+type window struct {
+	cfg      config.MaintenanceWindow
+	name     string
+	start    time.Time
+	end      time.Time
+	schedule cron.Schedule
+	duration time.Duration
+	rules    map[string]bool
+}
+
+// WindowStatus describes a maintenance window for the dashboard.
+type WindowStatus struct {
+	Name     string    `json:"name"`
+	Start    string    `json:"start,omitempty"`
+	End      string    `json:"end,omitempty"`
+	Schedule string    `json:"schedule,omitempty"`
+	Duration string    `json:"duration,omitempty"`
+	Rules    []string  `json:"rules"`
+	Active   bool      `json:"active"`
+	Next     time.Time `json:"next,omitzero"` // next scheduled start
+}
+
+func compileWindows(in []config.MaintenanceWindow) ([]*window, error) {
+	out := make([]*window, 0, len(in))
+
+	for i, mw := range in {
+		w := &window{cfg: mw, name: mw.Name, duration: mw.Duration}
+		if w.name == "" {
+			w.name = fmt.Sprintf("window %d", i+1)
+		}
+		if len(mw.Rules) > 0 {
+			w.rules = make(map[string]bool, len(mw.Rules))
+			for _, r := range mw.Rules {
+				w.rules[r] = true
+			}
+		}
+
+		switch {
+		case mw.Schedule != "":
+			sched, err := cron.ParseStandard(mw.Schedule)
+			if err != nil {
+				return nil, fmt.Errorf("alerting: maintenance %q: invalid schedule: %w", w.name, err)
+			}
+			if mw.Duration <= 0 {
+				return nil, fmt.Errorf("alerting: maintenance %q: a schedule needs a duration", w.name)
+			}
+			w.schedule = sched
+		case mw.Start != "" && mw.End != "":
+			var err error
+			if w.start, err = time.Parse(time.RFC3339, mw.Start); err != nil {
+				return nil, fmt.Errorf("alerting: maintenance %q: invalid start: %w", w.name, err)
+			}
+			if w.end, err = time.Parse(time.RFC3339, mw.End); err != nil {
+				return nil, fmt.Errorf("alerting: maintenance %q: invalid end: %w", w.name, err)
+			}
+			if !w.end.After(w.start) {
+				return nil, fmt.Errorf("alerting: maintenance %q: end is not after start", w.name)
+			}
+		default:
+			return nil, fmt.Errorf("alerting: maintenance %q needs start and end, or schedule and duration", w.name)
+		}
+
+		out = append(out, w)
+	}
+	return out, nil
+}
+
+func (w *window) covers(ruleName string, now time.Time) bool {
+	if w.rules != nil && !w.rules[ruleName] {
+		return false
+	}
+	return w.active(now)
+}
+
+func (w *window) active(now time.Time) bool {
+	if w.schedule == nil {
+		return !now.Before(w.start) && now.Before(w.end)
+	}
+	// The latest start within the last duration, if any, is still running
+	return !w.schedule.Next(now.Add(-w.duration)).After(now)
+}
+
+// Maintenance returns the configured maintenance windows and whether each is active.
+func (e *Engine) Maintenance() []WindowStatus {
+	now := time.Now()
+	out := make([]WindowStatus, 0, len(e.maintenance))
+	for _, w := range e.maintenance {
+		s := WindowStatus{Name: w.name, Start: w.cfg.Start, End: w.cfg.End, Schedule: w.cfg.Schedule, Rules: w.cfg.Rules, Active: w.active(now)}
+		if w.schedule != nil {
+			s.Duration = w.duration.String()
+			s.Next = w.schedule.Next(now)
+		}
+		if s.Rules == nil {
+			s.Rules = []string{}
+		}
+		out = append(out, s)
+	}
+	return out
+}
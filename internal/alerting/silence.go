@@ -0,0 +1,209 @@
+package alerting
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// Silence suppresses notifications for one rule, or all rules when Rule is empty,
+// until EndsAt. Alerts keep being evaluated and shown while silenced.
+type Silence struct {
+	ID        string    `json:"id"`
+	Rule      string    `json:"rule"`
+	Comment   string    `json:"comment"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	EndsAt    time.Time `json:"ends_at"`
+}
+
+func (s Silence) covers(ruleName string, now time.Time) bool {
+	return (s.Rule == "" || s.Rule == ruleName) && now.Before(s.EndsAt)
+}
+
+// Store persists silences across restarts.
+type Store interface {
+	Load(ctx context.Context) ([]Silence, error)
+	Save(ctx context.Context, s Silence) error
+	Delete(ctx context.Context, id string) error
+}
+
+// Silences returns the silences that have not yet ended.
+func (e *Engine) Silences() []Silence {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	now := time.Now()
+	out := []Silence{}
+	for _, s := range e.silences {
+		if now.Before(s.EndsAt) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// AddSilence silences rule (all rules when empty) for d.
+func (e *Engine) AddSilence(ctx context.Context, rule string, d time.Duration, comment, createdBy string) (Silence, error) {
+	if rule != "" && !e.hasRule(rule) {
+		return Silence{}, ErrUnknownRule
+	}
+
+	now := time.Now()
+	s := Silence{
+		ID:        newID(),
+		Rule:      rule,
+		Comment:   comment,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		EndsAt:    now.Add(d),
+	}
+	if e.store != nil {
+		if err := e.store.Save(ctx, s); err != nil {
+			return Silence{}, err
+		}
+	}
+
+	e.mu.Lock()
+	e.silences = append(e.silences, s)
+	e.mu.Unlock()
+	return s, nil
+}
+
+// RemoveSilence ends a silence early and returns it.
+func (e *Engine) RemoveSilence(ctx context.Context, id string) (Silence, error) {
+	e.mu.RLock()
+	var found *Silence
+	for _, s := range e.silences {
+		if s.ID == id {
+			found = &s
+			break
+		}
+	}
+	e.mu.RUnlock()
+
+	if found == nil {
+		return Silence{}, ErrSilenceNotFound
+	}
+	if e.store != nil {
+		if err := e.store.Delete(ctx, id); err != nil {
+			return Silence{}, err
+		}
+	}
+
+	e.mu.Lock()
+	for i, s := range e.silences {
+		if s.ID == id {
+			e.silences = append(e.silences[:i], e.silences[i+1:]...)
+			break
+		}
+	}
+	e.mu.Unlock()
+	return *found, nil
+}
+
+// pruneSilences drops silences that have ended.
+func (e *Engine) pruneSilences(now time.Time) {
+	e.mu.Lock()
+	var expired []string
+	kept := e.silences[:0]
+	for _, s := range e.silences {
+		if now.Before(s.EndsAt) {
+			kept = append(kept, s)
+		} else {
+			expired = append(expired, s.ID)
+		}
+	}
+	e.silences = kept
+	e.mu.Unlock()
+
+	if e.store == nil {
+		return
+	}
+	for _, id := range expired {
+		if err := e.store.Delete(context.Background(), id); err != nil {
+			e.logger.Error("Failed to delete expired silence", err, "id", id)
+		}
+	}
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// SQLStore keeps silences in the alert_silences table of the monitoring SQLite database.
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore creates the alert_silences table if needed.
+func NewSQLStore(db *sql.DB) (*SQLStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("alert silence store: database is not available")
+	}
+
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS alert_silences (
+			id TEXT PRIMARY KEY,
+			rule TEXT NOT NULL DEFAULT '',
+			comment TEXT,
+			created_by TEXT,
+			created_at BIGINT NOT NULL,
+			ends_at BIGINT NOT NULL
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("alert silence store: failed to create schema: %w", err)
+	}
+
+	return &SQLStore{db: db}, nil
+}
+
+func (s *SQLStore) Load(ctx context.Context) ([]Silence, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, rule, COALESCE(comment, ''), COALESCE(created_by, ''), created_at, ends_at
+		FROM alert_silences
+		WHERE ends_at > ?
+		ORDER BY created_at
+	`, time.Now().UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var silences []Silence
+	for rows.Next() {
+		var sl Silence
+		var created, ends int64
+		if err := rows.Scan(&sl.ID, &sl.Rule, &sl.Comment, &sl.CreatedBy, &created, &ends); err != nil {
+			return nil, err
+		}
+		sl.CreatedAt = time.UnixMilli(created)
+		sl.EndsAt = time.UnixMilli(ends)
+		silences = append(silences, sl)
+	}
+	return silences, rows.Err()
+}
+
+func (s *SQLStore) Save(ctx context.Context, sl Silence) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO alert_silences (id, rule, comment, created_by, created_at, ends_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, sl.ID, sl.Rule, sl.Comment, sl.CreatedBy, sl.CreatedAt.UnixMilli(), sl.EndsAt.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to save silence: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) Delete(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM alert_silences WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete silence: %w", err)
+	}
+	return nil
+}
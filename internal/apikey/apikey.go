@@ -0,0 +1,273 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"test-go/pkg/cache"
+	"test-go/pkg/logger"
+)
+
+// Well-known scopes. Other scope names may be used by services for finer checks.
+const (
+	ScopeRead  = "read"  // GET and HEAD requests
+	ScopeWrite = "write" // every other method
+	ScopeAll   = "*"
+)
+
+var (
+	ErrInvalidKey = errors.New("invalid API key")
+	ErrRevoked    = errors.New("API key has been revoked")
+	ErrExpired    = errors.New("API key has expired")
+	ErrNotFound   = errors.New("API key not found")
+)
+
+// lastUsedInterval throttles last-used writes for busy keys.
+const lastUsedInterval = time.Minute
+
+// Key is a stored API key. The secret itself is never stored, only its SHA-256 hash.
+type Key struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // first characters of the key, for recognising it in lists
+	Hash       string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP string     `json:"last_used_ip,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	CreatedBy  string     `json:"created_by,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// HasScope reports whether the key grants scope.
+func (k *Key) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == ScopeAll || s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Active reports whether the key is neither revoked nor expired at t.
+func (k *Key) Active(t time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || t.Before(*k.ExpiresAt))
+}
+
+// Store persists keys.
+type Store interface {
+	Insert(ctx context.Context, k Key) error
+	Get(ctx context.Context, id string) (*Key, error)
+	List(ctx context.Context) ([]Key, error)
+	Revoke(ctx context.Context, id string, at time.Time) error
+	TouchLastUsed(ctx context.Context, id string, at time.Time, ip string) error
+}
+
+// Manager creates and validates keys. Validated keys are cached in memory for cacheTTL;
+// revoking through the Manager drops the cache entry immediately.
+type Manager struct {
+	store    Store
+	prefix   string
+	cacheTTL time.Duration
+	cache    *cache.Cache[Key]
+	logger   *logger.Logger
+
+	touchMu   sync.Mutex
+	lastTouch map[string]time.Time
+}
+
+// NewManager creates a Manager. prefix is prepended to generated keys.
+func NewManager(store Store, prefix string, cacheTTL time.Duration, l *logger.Logger) *Manager {
+	if prefix == "" {
+		prefix = "key"
+	}
+	if cacheTTL <= 0 {
+		cacheTTL = time.Minute
+	}
+
+	m := &Manager{
+		store:     store,
+		prefix:    prefix,
+		cacheTTL:  cacheTTL,
+		cache:     cache.New[Key](),
+		logger:    l,
+		lastTouch: make(map[string]time.Time),
+	}
+
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			m.cache.Cleanup()
+		}
+	}()
+
+	return m
+}
+
+// Create generates a key and returns it in plain text together with its record.
+// The plain-text key cannot be recovered later.
+func (m *Manager) Create(ctx context.Context, name string, scopes []string, expiresAt *time.Time, createdBy string) (string, Key, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", Key{}, fmt.Errorf("name is required")
+	}
+	if len(scopes) == 0 {
+		return "", Key{}, fmt.Errorf("at least one scope is required")
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return "", Key{}, fmt.Errorf("expiry must be in the future")
+	}
+
+	id, err := randomHex(6)
+	if err != nil {
+		return "", Key{}, err
+	}
+	secret, err := randomHex(24)
+	if err != nil {
+		return "", Key{}, err
+	}
+
+	// Format: <prefix>_<id>_<secret>; the id makes lookups an indexed read
+	plain := m.prefix + "_" + id + "_" + secret
+	k := Key{
+		ID:        id,
+		Name:      strings.TrimSpace(name),
+		Prefix:    plain[:len(m.prefix)+1+len(id)+4],
+		Hash:      hashKey(plain),
+		Scopes:    normalizeScopes(scopes),
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+		CreatedBy: createdBy,
+	}
+	if err := m.store.Insert(ctx, k); err != nil {
+		return "", Key{}, err
+	}
+	return plain, k, nil
+}
+
+// Authenticate validates a plain-text key and records its use from ip.
+func (m *Manager) Authenticate(ctx context.Context, plain, ip string) (*Key, error) {
+	id, ok := m.parseID(plain)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+
+	k, cached := m.cache.Get(id)
+	if !cached {
+		stored, err := m.store.Get(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrInvalidKey
+		}
+		if err != nil {
+			return nil, err
+		}
+		k = *stored
+		m.cache.Set(id, k, m.cacheTTL)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(k.Hash), []byte(hashKey(plain))) != 1 {
+		return nil, ErrInvalidKey
+	}
+	if k.RevokedAt != nil {
+		return nil, ErrRevoked
+	}
+	if !k.Active(time.Now()) {
+		return nil, ErrExpired
+	}
+
+	m.touch(k.ID, ip)
+	return &k, nil
+}
+
+// touch records last use in the background, at most once per lastUsedInterval per key.
+func (m *Manager) touch(id, ip string) {
+	now := time.Now()
+
+	m.touchMu.Lock()
+	if last, ok := m.lastTouch[id]; ok && now.Sub(last) < lastUsedInterval {
+		m.touchMu.Unlock()
+		return
+	}
+	m.lastTouch[id] = now
+	m.touchMu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := m.store.TouchLastUsed(ctx, id, now, ip); err != nil {
+			m.logger.Error("Failed to record API key use", err, "key_id", id)
+		}
+	}()
+}
+
+func (m *Manager) parseID(plain string) (string, bool) {
+	rest, ok := strings.CutPrefix(plain, m.prefix+"_")
+	if !ok {
+		return "", false
+	}
+	id, secret, ok := strings.Cut(rest, "_")
+	if !ok || id == "" || secret == "" {
+		return "", false
+	}
+	return id, true
+}
+
+// List returns every key, newest first.
+func (m *Manager) List(ctx context.Context) ([]Key, error) {
+	return m.store.List(ctx)
+}
+
+// Revoke disables a key immediately on this instance; other instances notice within the cache TTL.
+func (m *Manager) Revoke(ctx context.Context, id string) (*Key, error) {
+	k, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if k.RevokedAt != nil {
+		return k, nil
+	}
+
+	now := time.Now()
+	if err := m.store.Revoke(ctx, id, now); err != nil {
+		return nil, err
+	}
+	m.cache.Delete(id)
+	k.RevokedAt = &now
+	return k, nil
+}
+
+func normalizeScopes(scopes []string) []string {
+	seen := make(map[string]bool, len(scopes))
+	out := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	return out
+}
+
+func hashKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,170 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"test-go/pkg/logger"
+)
+
+// memStore keeps keys in memory; failing makes every call return an error.
+type memStore struct {
+	mu      sync.Mutex
+	keys    map[string]Key
+	failing bool
+}
+
+func newMemStore() *memStore {
+	return &memStore{keys: make(map[string]Key)}
+}
+
+var errStoreDown = errors.New("store down")
+
+func (s *memStore) Insert(_ context.Context, k Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[k.ID] = k
+	return nil
+}
+
+func (s *memStore) Get(_ context.Context, id string) (*Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failing {
+		return nil, errStoreDown
+	}
+	k, ok := s.keys[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &k, nil
+}
+
+func (s *memStore) List(context.Context) ([]Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Key, 0, len(s.keys))
+	for _, k := range s.keys {
+		out = append(out, k)
+	}
+	return out, nil
+}
+
+func (s *memStore) Revoke(_ context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := s.keys[id]
+	k.RevokedAt = &at
+	s.keys[id] = k
+	return nil
+}
+
+func (s *memStore) TouchLastUsed(_ context.Context, id string, at time.Time, ip string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := s.keys[id]
+	k.LastUsedAt, k.LastUsedIP = &at, ip
+	s.keys[id] = k
+	return nil
+}
+
+// testLogger is shared because last-used writes log from background goroutines.
+var testLogger = logger.NewQuiet(false, nil)
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	m := NewManager(store, "sk", time.Minute, testLogger)
+
+	valid, _, err := m.Create(ctx, "ci", []string{"read"}, nil, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, revokedKey, _ := m.Create(ctx, "old", []string{"read"}, nil, "admin")
+	if _, err := m.Revoke(ctx, revokedKey.ID); err != nil {
+		t.Fatal(err)
+	}
+	expiring := time.Now().Add(time.Hour)
+	expired, expiredKey, _ := m.Create(ctx, "temp", []string{"read"}, &expiring, "admin")
+	past := time.Now().Add(-time.Minute)
+	expiredKey.ExpiresAt = &past
+	store.keys[expiredKey.ID] = expiredKey
+
+	id := strings.Split(valid, "_")[1]
+
+	tests := []struct {
+		name    string
+		plain   string
+		wantErr error
+	}{
+		{"valid key", valid, nil},
+		{"empty", "", ErrInvalidKey},
+		{"other prefix", strings.Replace(valid, "sk_", "pk_", 1), ErrInvalidKey},
+		{"no secret", "sk_" + id, ErrInvalidKey},
+		{"empty secret", "sk_" + id + "_", ErrInvalidKey},
+		{"empty id", "sk__" + strings.Split(valid, "_")[2], ErrInvalidKey},
+		{"unknown id", "sk_000000000000_" + strings.Split(valid, "_")[2], ErrInvalidKey},
+		{"known id with a wrong secret", "sk_" + id + "_" + strings.Repeat("0", 48), ErrInvalidKey},
+		{"secret with a trailing character", valid + "0", ErrInvalidKey},
+		{"revoked", revoked, ErrRevoked},
+		{"expired", expired, ErrExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := m.Authenticate(ctx, tt.plain, "203.0.113.1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && k.Name != "ci" {
+				t.Errorf("authenticated as %q", k.Name)
+			}
+			if err != nil && k != nil {
+				t.Errorf("key returned with error %v", err)
+			}
+		})
+	}
+
+	t.Run("store failure is not reported as an invalid key", func(t *testing.T) {
+		store.mu.Lock()
+		store.failing = true
+		store.mu.Unlock()
+		defer func() {
+			store.mu.Lock()
+			store.failing = false
+			store.mu.Unlock()
+		}()
+
+		fresh := NewManager(store, "sk", time.Minute, testLogger)
+		_, err := fresh.Authenticate(ctx, valid, "203.0.113.1")
+		if err == nil || errors.Is(err, ErrInvalidKey) {
+			t.Errorf("err = %v, want the store error", err)
+		}
+	})
+}
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		scopes []string
+		scope  string
+		want   bool
+	}{
+		{[]string{"read"}, ScopeRead, true},
+		{[]string{"read"}, ScopeWrite, false},
+		{[]string{"read", "write"}, ScopeWrite, true},
+		{[]string{"*"}, ScopeWrite, true},
+		{[]string{"reports"}, ScopeRead, false},
+		{nil, ScopeRead, false},
+	}
+
+	for _, tt := range tests {
+		k := Key{Scopes: normalizeScopes(tt.scopes)}
+		if got := k.HasScope(tt.scope); got != tt.want {
+			t.Errorf("%v HasScope(%s) = %v, want %v", tt.scopes, tt.scope, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialects supported by SQLStore.
+const (
+	DialectSQLite   = "sqlite"
+	DialectPostgres = "postgres"
+)
+
+// SQLStore keeps keys in the api_keys table of a SQLite or Postgres database.
+// Timestamps are Unix milliseconds; scopes are stored comma-separated.
+type SQLStore struct {
+	db      *sql.DB
+	dialect string
+}
+
+// NewSQLStore creates the api_keys table if needed.
+func NewSQLStore(db *sql.DB, dialect string) (*SQLStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("api key store: database is not available")
+	}
+
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS api_keys (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			prefix TEXT NOT NULL,
+			key_hash TEXT NOT NULL,
+			scopes TEXT NOT NULL,
+			expires_at BIGINT,
+			last_used_at BIGINT,
+			last_used_ip TEXT,
+			created_at BIGINT NOT NULL,
+			created_by TEXT,
+			revoked_at BIGINT
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("api key store: failed to create schema: %w", err)
+	}
+
+	return &SQLStore{db: db, dialect: dialect}, nil
+}
+
+// rebind converts ? placeholders to $n for Postgres.
+func (s *SQLStore) rebind(query string) string {
+	if s.dialect != DialectPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+const keyColumns = `id, name, prefix, key_hash, scopes, expires_at, last_used_at, COALESCE(last_used_ip, ''),
+	created_at, COALESCE(created_by, ''), revoked_at`
+
+func (s *SQLStore) Insert(ctx context.Context, k Key) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(`
+		INSERT INTO api_keys (id, name, prefix, key_hash, scopes, expires_at, created_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`), k.ID, k.Name, k.Prefix, k.Hash, strings.Join(k.Scopes, ","), toMillis(k.ExpiresAt), k.CreatedAt.UnixMilli(), k.CreatedBy)
+	if err != nil {
+		return fmt.Errorf("failed to insert api key: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) Get(ctx context.Context, id string) (*Key, error) {
+	row := s.db.QueryRowContext(ctx, s.rebind(`SELECT `+keyColumns+` FROM api_keys WHERE id = ?`), id)
+	k, err := scanKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return k, nil
+}
+
+func (s *SQLStore) List(ctx context.Context) ([]Key, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+keyColumns+` FROM api_keys ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]Key, 0)
+	for rows.Next() {
+		k, err := scanKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, *k)
+	}
+	return keys, rows.Err()
+}
+
+func (s *SQLStore) Revoke(ctx context.Context, id string, at time.Time) error {
+	res, err := s.db.ExecContext(ctx, s.rebind(`UPDATE api_keys SET revoked_at = ? WHERE id = ?`), at.UnixMilli(), id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) TouchLastUsed(ctx context.Context, id string, at time.Time, ip string) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(`UPDATE api_keys SET last_used_at = ?, last_used_ip = ? WHERE id = ?`), at.UnixMilli(), ip, id)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanKey(row rowScanner) (*Key, error) {
+	var k Key
+	var scopes string
+	var expiresAt, lastUsedAt, revokedAt sql.NullInt64
+	var createdAt int64
+	if err := row.Scan(&k.ID, &k.Name, &k.Prefix, &k.Hash, &scopes, &expiresAt, &lastUsedAt, &k.LastUsedIP,
+		&createdAt, &k.CreatedBy, &revokedAt); err != nil {
+		return nil, err
+	}
+
+	if scopes != "" {
+		k.Scopes = strings.Split(scopes, ",")
+	}
+	k.CreatedAt = time.UnixMilli(createdAt)
+	k.ExpiresAt = fromMillis(expiresAt)
+	k.LastUsedAt = fromMillis(lastUsedAt)
+	k.RevokedAt = fromMillis(revokedAt)
+	return &k, nil
+}
+
+func toMillis(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UnixMilli()
+}
+
+func fromMillis(v sql.NullInt64) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := time.UnixMilli(v.Int64)
+	return &t
+}
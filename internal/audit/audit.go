@@ -0,0 +1,173 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"test-go/internal/redact"
+	"test-go/pkg/logger"
+)
+
+// Outcomes recorded on an Entry.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Actor types recorded on an Entry.
+const (
+	ActorUser   = "user"   // monitoring dashboard user
+	ActorAPI    = "api"    // caller of the public API
+	ActorSystem = "system" // the application itself
+)
+
+// Entry is one audited action.
+type Entry struct {
+	ID        int64     `json:"id"`
+	Time      time.Time `json:"time"`
+	Actor     string    `json:"actor"`
+	ActorType string    `json:"actor_type"`
+	Action    string    `json:"action"` // e.g. "config.save", "POST /api/v1/users"
+	Target    string    `json:"target"` // what was acted on, e.g. "config.yaml", "/api/v1/users/42"
+	RequestID string    `json:"request_id"`
+	IP        string    `json:"ip"`
+	Before    string    `json:"before,omitempty"` // summary of the state before the action
+	After     string    `json:"after,omitempty"`  // summary of the state after / the submitted change
+	Outcome   string    `json:"outcome"`
+	Status    int       `json:"status,omitempty"` // HTTP status, when the action was a request
+	Error     string    `json:"error,omitempty"`
+}
+
+// Filter narrows an audit search. Zero values are ignored.
+type Filter struct {
+	Actor   string
+	Action  string // prefix match
+	Target  string // substring match
+	Outcome string
+	Query   string // free text over action, target, before and after
+	From    time.Time
+	To      time.Time
+	Limit   int
+	Offset  int
+}
+
+// Store persists audit entries.
+type Store interface {
+	Insert(ctx context.Context, e Entry) error
+	Search(ctx context.Context, f Filter) ([]Entry, int64, error)
+	Prune(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Recorder writes entries to a Store in the background so auditing never
+// slows down the request being audited.
+type Recorder struct {
+	store     Store
+	logger    *logger.Logger
+	queue     chan Entry
+	retention time.Duration
+}
+
+// NewRecorder starts the writer and, if retention > 0, a daily pruning loop.
+func NewRecorder(store Store, retention time.Duration, l *logger.Logger) *Recorder {
+	r := &Recorder{
+		store:     store,
+		logger:    l,
+		queue:     make(chan Entry, 1024),
+		retention: retention,
+	}
+
+	go r.run()
+	if retention > 0 {
+		go r.pruneLoop()
+	}
+
+	return r
+}
+
+// Record queues an entry. Time and Outcome are filled in when empty.
+// A nil Recorder is valid and records nothing, so callers need no enabled checks.
+func (r *Recorder) Record(e Entry) {
+	if r == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Outcome == "" {
+		e.Outcome = OutcomeSuccess
+	}
+
+	select {
+	case r.queue <- e:
+	default:
+		// Queue full: write synchronously rather than lose an audit record
+		r.write(e)
+	}
+}
+
+// Search queries the underlying store.
+func (r *Recorder) Search(ctx context.Context, f Filter) ([]Entry, int64, error) {
+	return r.store.Search(ctx, f)
+}
+
+func (r *Recorder) run() {
+	for e := range r.queue {
+		r.write(e)
+	}
+}
+
+func (r *Recorder) write(e Entry) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := r.store.Insert(ctx, e); err != nil {
+		r.logger.Error("Failed to write audit entry", err, "action", e.Action, "actor", e.Actor)
+	}
+}
+
+func (r *Recorder) pruneLoop() {
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		n, err := r.store.Prune(ctx, time.Now().Add(-r.retention))
+		cancel()
+		if err != nil {
+			r.logger.Error("Failed to prune audit log", err)
+		} else if n > 0 {
+			r.logger.Info("Pruned audit log", "deleted", n)
+		}
+		<-ticker.C
+	}
+}
+
+// Summarize renders v as compact JSON capped at max bytes, with sensitive fields
+// masked by r. Strings that are not JSON are only truncated; r may be nil.
+func Summarize(v interface{}, max int, r *redact.Redactor) string {
+	var raw []byte
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		raw = []byte(t)
+	case []byte:
+		raw = t
+	default:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return ""
+		}
+		raw = b
+	}
+
+	if r != nil {
+		raw = r.Body("application/json", raw)
+	}
+
+	s := string(raw)
+	if max > 0 && len(s) > max {
+		s = s[:max] + "…"
+	}
+	return s
+}
@@ -0,0 +1,175 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialects supported by SQLStore.
+const (
+	DialectSQLite   = "sqlite"
+	DialectPostgres = "postgres"
+)
+
+// SQLStore keeps audit entries in the audit_log table of a SQLite or Postgres database.
+// Timestamps are stored as Unix milliseconds so range queries behave the same on both.
+type SQLStore struct {
+	db      *sql.DB
+	dialect string
+}
+
+// NewSQLStore creates the audit_log table if needed.
+func NewSQLStore(db *sql.DB, dialect string) (*SQLStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("audit store: database is not available")
+	}
+
+	idColumn := "id INTEGER PRIMARY KEY AUTOINCREMENT"
+	if dialect == DialectPostgres {
+		idColumn = "id BIGSERIAL PRIMARY KEY"
+	}
+
+	schema := []string{
+		`CREATE TABLE IF NOT EXISTS audit_log (
+			` + idColumn + `,
+			created_at BIGINT NOT NULL,
+			actor TEXT NOT NULL,
+			actor_type TEXT NOT NULL,
+			action TEXT NOT NULL,
+			target TEXT,
+			request_id TEXT,
+			ip TEXT,
+			before_state TEXT,
+			after_state TEXT,
+			outcome TEXT NOT NULL,
+			status INTEGER,
+			error TEXT
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log (created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log (actor)`,
+	}
+	for _, stmt := range schema {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, fmt.Errorf("audit store: failed to create schema: %w", err)
+		}
+	}
+
+	return &SQLStore{db: db, dialect: dialect}, nil
+}
+
+// rebind converts ? placeholders to $n for Postgres.
+func (s *SQLStore) rebind(query string) string {
+	if s.dialect != DialectPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (s *SQLStore) Insert(ctx context.Context, e Entry) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(`
+		INSERT INTO audit_log (created_at, actor, actor_type, action, target, request_id, ip, before_state, after_state, outcome, status, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`), e.Time.UnixMilli(), e.Actor, e.ActorType, e.Action, e.Target, e.RequestID, e.IP, e.Before, e.After, e.Outcome, e.Status, e.Error)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) Search(ctx context.Context, f Filter) ([]Entry, int64, error) {
+	var where []string
+	var args []interface{}
+
+	if f.Actor != "" {
+		where = append(where, "actor = ?")
+		args = append(args, f.Actor)
+	}
+	if f.Action != "" {
+		where = append(where, "action LIKE ?")
+		args = append(args, f.Action+"%")
+	}
+	if f.Target != "" {
+		where = append(where, "target LIKE ?")
+		args = append(args, "%"+f.Target+"%")
+	}
+	if f.Outcome != "" {
+		where = append(where, "outcome = ?")
+		args = append(args, f.Outcome)
+	}
+	if f.Query != "" {
+		where = append(where, "(action LIKE ? OR target LIKE ? OR before_state LIKE ? OR after_state LIKE ?)")
+		q := "%" + f.Query + "%"
+		args = append(args, q, q, q, q)
+	}
+	if !f.From.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, f.From.UnixMilli())
+	}
+	if !f.To.IsZero() {
+		where = append(where, "created_at <= ?")
+		args = append(args, f.To.UnixMilli())
+	}
+
+	clause := ""
+	if len(where) > 0 {
+		clause = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int64
+	if err := s.db.QueryRowContext(ctx, s.rebind("SELECT COUNT(*) FROM audit_log"+clause), args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	pageArgs := append(append([]interface{}{}, args...), limit, f.Offset)
+	rows, err := s.db.QueryContext(ctx, s.rebind(`
+		SELECT id, created_at, actor, actor_type, action, COALESCE(target, ''), COALESCE(request_id, ''), COALESCE(ip, ''),
+			COALESCE(before_state, ''), COALESCE(after_state, ''), outcome, COALESCE(status, 0), COALESCE(error, '')
+		FROM audit_log`+clause+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`), pageArgs...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]Entry, 0)
+	for rows.Next() {
+		var e Entry
+		var ts int64
+		if err := rows.Scan(&e.ID, &ts, &e.Actor, &e.ActorType, &e.Action, &e.Target, &e.RequestID, &e.IP,
+			&e.Before, &e.After, &e.Outcome, &e.Status, &e.Error); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		e.Time = time.UnixMilli(ts)
+		entries = append(entries, e)
+	}
+
+	return entries, total, rows.Err()
+}
+
+func (s *SQLStore) Prune(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, s.rebind("DELETE FROM audit_log WHERE created_at < ?"), before.UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune audit log: %w", err)
+	}
+	return res.RowsAffected()
+}
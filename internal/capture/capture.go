@@ -0,0 +1,235 @@
+package capture
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Triggers record why a request was captured.
+const (
+	TriggerHeader = "header"
+	TriggerPath   = "path"
+	TriggerSample = "sample"
+)
+
+// Body is a captured request or response body.
+// Non-UTF-8 content is stored base64-encoded, as HAR expects.
+type Body struct {
+	Text      string `json:"text"`
+	Encoding  string `json:"encoding,omitempty"` // "base64" for binary content
+	Size      int64  `json:"size"`               // full size, before truncation
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// NewBody builds a Body from the first bytes of content. size is the full length.
+func NewBody(b []byte, size int64, truncated bool) Body {
+	body := Body{Size: size, Truncated: truncated}
+	if utf8.Valid(b) {
+		body.Text = string(b)
+	} else {
+		body.Text = base64.StdEncoding.EncodeToString(b)
+		body.Encoding = "base64"
+	}
+	return body
+}
+
+// Capture is one recorded request/response pair. Sensitive values are redacted before storage.
+type Capture struct {
+	ID              int64       `json:"id"`
+	Time            time.Time   `json:"time"`
+	DurationMs      float64     `json:"duration_ms"`
+	Trigger         string      `json:"trigger"`
+	RequestID       string      `json:"request_id"`
+	ClientIP        string      `json:"client_ip"`
+	Method          string      `json:"method"`
+	URL             string      `json:"url"`
+	Path            string      `json:"path"`
+	Route           string      `json:"route"`
+	Proto           string      `json:"proto"`
+	RequestHeaders  http.Header `json:"request_headers"`
+	RequestBody     Body        `json:"request_body"`
+	Status          int         `json:"status"`
+	ResponseHeaders http.Header `json:"response_headers"`
+	ResponseBody    Body        `json:"response_body"`
+}
+
+// Summary is the list view of a Capture, without headers and bodies.
+type Summary struct {
+	ID           int64     `json:"id"`
+	Time         time.Time `json:"time"`
+	DurationMs   float64   `json:"duration_ms"`
+	Trigger      string    `json:"trigger"`
+	RequestID    string    `json:"request_id"`
+	ClientIP     string    `json:"client_ip"`
+	Method       string    `json:"method"`
+	Path         string    `json:"path"`
+	Route        string    `json:"route"`
+	Status       int       `json:"status"`
+	RequestSize  int64     `json:"request_size"`
+	ResponseSize int64     `json:"response_size"`
+}
+
+func (c *Capture) summary() Summary {
+	return Summary{
+		ID:           c.ID,
+		Time:         c.Time,
+		DurationMs:   c.DurationMs,
+		Trigger:      c.Trigger,
+		RequestID:    c.RequestID,
+		ClientIP:     c.ClientIP,
+		Method:       c.Method,
+		Path:         c.Path,
+		Route:        c.Route,
+		Status:       c.Status,
+		RequestSize:  c.RequestBody.Size,
+		ResponseSize: c.ResponseBody.Size,
+	}
+}
+
+// Filter narrows a listing. Zero values are ignored.
+type Filter struct {
+	Method    string
+	MinStatus int
+	MaxStatus int
+	Query     string // substring of path, route or request ID
+	Limit     int
+	Offset    int
+}
+
+func (f Filter) match(c *Capture) bool {
+	if f.Method != "" && !strings.EqualFold(f.Method, c.Method) {
+		return false
+	}
+	if f.MinStatus > 0 && c.Status < f.MinStatus {
+		return false
+	}
+	if f.MaxStatus > 0 && c.Status > f.MaxStatus {
+		return false
+	}
+	if f.Query != "" &&
+		!strings.Contains(c.Path, f.Query) &&
+		!strings.Contains(c.Route, f.Query) &&
+		c.RequestID != f.Query {
+		return false
+	}
+	return true
+}
+
+// Store keeps the most recent captures in memory; the oldest is evicted when full.
+type Store struct {
+	mu      sync.RWMutex
+	entries []*Capture
+	next    int
+	seq     int64
+}
+
+// NewStore creates a store holding at most max captures.
+func NewStore(max int) *Store {
+	if max <= 0 {
+		max = 500
+	}
+	return &Store{entries: make([]*Capture, 0, max)}
+}
+
+// Add assigns an ID and stores c.
+func (s *Store) Add(c *Capture) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	c.ID = s.seq
+	if len(s.entries) < cap(s.entries) {
+		s.entries = append(s.entries, c)
+		return
+	}
+	s.entries[s.next] = c
+	s.next = (s.next + 1) % len(s.entries)
+}
+
+// newestFirst walks the ring from the latest capture back.
+func (s *Store) newestFirst(fn func(c *Capture) bool) {
+	n := len(s.entries)
+	for i := 0; i < n; i++ {
+		if !fn(s.entries[(s.next-1-i+n)%n]) {
+			return
+		}
+	}
+}
+
+// List returns matching summaries, newest first, and the number of matches.
+func (s *Store) List(f Filter) ([]Summary, int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	out := make([]Summary, 0, limit)
+	total := 0
+	s.newestFirst(func(c *Capture) bool {
+		if !f.match(c) {
+			return true
+		}
+		if total >= f.Offset && len(out) < limit {
+			out = append(out, c.summary())
+		}
+		total++
+		return true
+	})
+	return out, total
+}
+
+// Get returns the capture with the given ID, or nil if it was evicted.
+func (s *Store) Get(id int64) *Capture {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found *Capture
+	s.newestFirst(func(c *Capture) bool {
+		if c.ID == id {
+			found = c
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// Select returns full captures for ids, or every capture matching f when ids is empty.
+// Results are oldest first, the order HAR viewers expect.
+func (s *Store) Select(ids []int64, f Filter) []*Capture {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	want := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+
+	var out []*Capture
+	s.newestFirst(func(c *Capture) bool {
+		if (len(want) > 0 && want[c.ID]) || (len(want) == 0 && f.match(c)) {
+			out = append(out, c)
+		}
+		return true
+	})
+
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
+
+// Clear drops every capture.
+func (s *Store) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = s.entries[:0]
+	s.next = 0
+}
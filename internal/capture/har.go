@@ -0,0 +1,190 @@
+package capture
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HAR 1.2 document types (http://www.softwareishard.com/blog/har-12-spec/).
+// Only the fields we can fill from a Capture are included.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// ToHAR converts captures to a HAR document. creator names the exporting application.
+func ToHAR(captures []*Capture, creator, version string) HAR {
+	entries := make([]HAREntry, 0, len(captures))
+	for _, c := range captures {
+		entries = append(entries, toHAREntry(c))
+	}
+	return HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: creator, Version: version},
+		Entries: entries,
+	}}
+}
+
+func toHAREntry(c *Capture) HAREntry {
+	req := HARRequest{
+		Method:      c.Method,
+		URL:         c.URL,
+		HTTPVersion: c.Proto,
+		Cookies:     []HARNameValue{},
+		Headers:     harHeaders(c.RequestHeaders),
+		QueryString: harQuery(c.URL),
+		HeadersSize: -1,
+		BodySize:    c.RequestBody.Size,
+	}
+	if c.RequestBody.Size > 0 {
+		req.PostData = &HARPostData{
+			MimeType: c.RequestHeaders.Get("Content-Type"),
+			Text:     c.RequestBody.Text,
+			Comment:  truncatedComment(c.RequestBody),
+		}
+		if c.RequestBody.Encoding != "" {
+			// postData has no encoding field; say so rather than silently emitting base64
+			req.PostData.Comment = strings.TrimSpace(req.PostData.Comment + " body is base64-encoded")
+		}
+	}
+
+	return HAREntry{
+		StartedDateTime: c.Time.Format(time.RFC3339Nano),
+		Time:            c.DurationMs,
+		Request:         req,
+		Response: HARResponse{
+			Status:      c.Status,
+			StatusText:  http.StatusText(c.Status),
+			HTTPVersion: c.Proto,
+			Cookies:     []HARNameValue{},
+			Headers:     harHeaders(c.ResponseHeaders),
+			Content: HARContent{
+				Size:     c.ResponseBody.Size,
+				MimeType: c.ResponseHeaders.Get("Content-Type"),
+				Text:     c.ResponseBody.Text,
+				Encoding: c.ResponseBody.Encoding,
+				Comment:  truncatedComment(c.ResponseBody),
+			},
+			RedirectURL: c.ResponseHeaders.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    c.ResponseBody.Size,
+		},
+		Timings: HARTimings{Send: 0, Wait: c.DurationMs, Receive: 0},
+		Comment: "request_id=" + c.RequestID + " trigger=" + c.Trigger,
+	}
+}
+
+func harHeaders(h http.Header) []HARNameValue {
+	out := []HARNameValue{}
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range h[k] {
+			out = append(out, HARNameValue{Name: k, Value: v})
+		}
+	}
+	return out
+}
+
+func harQuery(rawURL string) []HARNameValue {
+	out := []HARNameValue{}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return out
+	}
+	q := u.Query()
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range q[k] {
+			out = append(out, HARNameValue{Name: k, Value: v})
+		}
+	}
+	return out
+}
+
+func truncatedComment(b Body) string {
+	if b.Truncated {
+		return "body truncated by capture limit"
+	}
+	return ""
+}
@@ -0,0 +1,237 @@
+// Package collector refreshes status sources in the background so readers get the
+// last snapshot of each without waiting. Every source runs in its own goroutine on
+// its own interval and under its own timeout; a slow or failing source keeps its
+// previous data and reports the error without holding up the others.
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"test-go/config"
+	"test-go/pkg/logger"
+)
+
+// Func collects the current data of a source. It should honour ctx; one that does not
+// is abandoned when the timeout passes and not started again until it returns.
+type Func func(ctx context.Context) (interface{}, error)
+
+// Snapshot is the last collected data of a source.
+type Snapshot struct {
+	Data       interface{} `json:"-"`
+	UpdatedAt  time.Time   `json:"updated_at,omitzero"` // when Data was collected
+	CheckedAt  time.Time   `json:"checked_at,omitzero"` // when the last refresh ended
+	DurationMs int64       `json:"duration_ms"`
+	Interval   string      `json:"interval"`
+	Error      string      `json:"error,omitempty"` // of the last refresh; Data is then older
+	Stale      bool        `json:"stale"`           // no fresh data for three intervals
+}
+
+type source struct {
+	name     string
+	fn       Func
+	interval time.Duration
+	timeout  time.Duration
+
+	snap    Snapshot
+	running bool // a refresh that outlived its timeout has not returned yet
+}
+
+// Collector runs the registered sources.
+type Collector struct {
+	cfg    config.StatusConfig
+	logger *logger.Logger
+
+	mu      sync.RWMutex
+	sources []*source
+	started bool
+}
+
+// New returns a collector with the default interval and timeout of cfg. Register the
+// sources, then call Start.
+func New(cfg config.StatusConfig, l *logger.Logger) *Collector {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 3 * time.Second
+	}
+	return &Collector{cfg: cfg, logger: l}
+}
+
+// Register adds a source. interval and timeout replace the configured defaults for
+// it when set, and monitoring.status.sources.<name> replaces both. Register panics
+// once the collector is started.
+func (c *Collector) Register(name string, interval, timeout time.Duration, fn Func) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.started {
+		panic("collector: Register called after Start")
+	}
+
+	s := &source{name: name, fn: fn, interval: c.cfg.Interval, timeout: c.cfg.Timeout}
+	if interval > 0 {
+		s.interval = interval
+	}
+	if timeout > 0 {
+		s.timeout = timeout
+	}
+	if o, ok := c.cfg.Sources[name]; ok {
+		if o.Interval > 0 {
+			s.interval = o.Interval
+		}
+		if o.Timeout > 0 {
+			s.timeout = o.Timeout
+		}
+	}
+	s.snap.Interval = s.interval.String()
+	c.sources = append(c.sources, s)
+}
+
+// Start refreshes every source right away and then on its interval until ctx is done.
+func (c *Collector) Start(ctx context.Context) {
+	c.mu.Lock()
+	c.started = true
+	sources := c.sources
+	c.mu.Unlock()
+
+	for _, s := range sources {
+		go func() {
+			ticker := time.NewTicker(s.interval)
+			defer ticker.Stop()
+			for {
+				c.refresh(ctx, s)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+}
+
+type result struct {
+	data interface{}
+	err  error
+}
+
+// refresh runs s once. A run that outlives the timeout keeps going in the background
+// and its result is still recorded; until then later ticks are skipped so a hung
+// backend does not pile up goroutines.
+func (c *Collector) refresh(ctx context.Context, s *source) {
+	c.mu.Lock()
+	if s.running {
+		c.mu.Unlock()
+		return
+	}
+	s.running = true
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	start := time.Now()
+	done := make(chan result, 1)
+	go func() {
+		defer cancel()
+		var r result
+		defer func() {
+			if p := recover(); p != nil {
+				r = result{err: fmt.Errorf("panic: %v", p)}
+			}
+			done <- r
+			c.record(s, start, r)
+		}()
+		r.data, r.err = s.fn(ctx)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		// The run may have finished just as the context was cancelled
+		c.mu.Lock()
+		timedOut := s.running
+		if timedOut {
+			s.snap.CheckedAt = time.Now()
+			s.snap.DurationMs = time.Since(start).Milliseconds()
+			s.snap.Error = fmt.Sprintf("timed out after %s", s.timeout)
+		}
+		c.mu.Unlock()
+		if timedOut {
+			c.logger.Warn("Status source timed out", "source", s.name, "timeout", s.timeout.String())
+		}
+	}
+}
+
+// record stores the outcome of a run, however late it returned.
+func (c *Collector) record(s *source, start time.Time, r result) {
+	now := time.Now()
+
+	c.mu.Lock()
+	prev := s.snap.Error
+	s.running = false
+	s.snap.CheckedAt = now
+	s.snap.DurationMs = now.Sub(start).Milliseconds()
+	if r.err != nil {
+		s.snap.Error = r.err.Error()
+	} else {
+		s.snap.Data = r.data
+		s.snap.UpdatedAt = now
+		s.snap.Error = ""
+	}
+	errMsg := s.snap.Error
+	c.mu.Unlock()
+
+	if errMsg != "" && errMsg != prev {
+		c.logger.Warn("Status source failed", "source", s.name, "error", errMsg)
+	}
+}
+
+// Get returns the last snapshot of the named source.
+func (c *Collector) Get(name string) (Snapshot, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	for _, s := range c.sources {
+		if s.name == name {
+			return s.snapshot(now), true
+		}
+	}
+	return Snapshot{}, false
+}
+
+// Snapshots returns the last snapshot of every source by name.
+func (c *Collector) Snapshots() map[string]Snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	out := make(map[string]Snapshot, len(c.sources))
+	for _, s := range c.sources {
+		out[s.name] = s.snapshot(now)
+	}
+	return out
+}
+
+// Names lists the registered sources.
+func (c *Collector) Names() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	names := make([]string, 0, len(c.sources))
+	for _, s := range c.sources {
+		names = append(names, s.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// snapshot copies s.snap with Stale set. The caller holds c.mu.
+func (s *source) snapshot(now time.Time) Snapshot {
+	snap := s.snap
+	snap.Stale = snap.UpdatedAt.IsZero() || now.Sub(snap.UpdatedAt) > 3*s.interval
+	return snap
+}
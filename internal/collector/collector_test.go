@@ -0,0 +1,157 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"test-go/config"
+	"test-go/pkg/logger"
+)
+
+// testLogger is shared because abandoned refreshes keep logging after their test ends.
+var testLogger = logger.NewQuiet(false, nil)
+
+func TestRefreshTimeouts(t *testing.T) {
+	cfg := config.StatusConfig{Interval: time.Hour, Timeout: 50 * time.Millisecond}
+
+	tests := []struct {
+		name      string
+		fn        func(ctx context.Context, run int) (interface{}, error)
+		runs      int           // refreshes to start
+		wait      time.Duration // after the last refresh, before reading the snapshot
+		wantData  interface{}
+		wantError string // substring; empty means no error
+		wantCalls int32
+	}{
+		{
+			name:      "fast source",
+			fn:        func(context.Context, int) (interface{}, error) { return "fresh", nil },
+			runs:      1,
+			wantData:  "fresh",
+			wantCalls: 1,
+		},
+		{
+			name: "source that honours the timeout",
+			fn: func(ctx context.Context, _ int) (interface{}, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+			runs:      1,
+			wait:      20 * time.Millisecond, // for the cancelled run to return its error
+			wantError: "deadline exceeded",
+			wantCalls: 1,
+		},
+		{
+			name: "hung source is abandoned and not started again",
+			fn: func(context.Context, int) (interface{}, error) {
+				time.Sleep(200 * time.Millisecond)
+				return "late", nil
+			},
+			runs:      3,
+			wantError: "timed out after 50ms",
+			wantCalls: 1,
+		},
+		{
+			name: "late result is still recorded",
+			fn: func(context.Context, int) (interface{}, error) {
+				time.Sleep(100 * time.Millisecond)
+				return "late", nil
+			},
+			runs:      1,
+			wait:      150 * time.Millisecond,
+			wantData:  "late",
+			wantCalls: 1,
+		},
+		{
+			name: "failure keeps the previous data",
+			fn: func(_ context.Context, run int) (interface{}, error) {
+				if run == 1 {
+					return "first", nil
+				}
+				return nil, errors.New("backend down")
+			},
+			runs:      2,
+			wantData:  "first",
+			wantError: "backend down",
+			wantCalls: 2,
+		},
+		{
+			name: "panic is reported as an error",
+			fn: func(context.Context, int) (interface{}, error) {
+				panic("nil manager")
+			},
+			runs:      1,
+			wantError: "panic: nil manager",
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(cfg, testLogger)
+			var calls atomic.Int32
+			c.Register("src", 0, 0, func(ctx context.Context) (interface{}, error) {
+				return tt.fn(ctx, int(calls.Add(1)))
+			})
+			s := c.sources[0]
+
+			for range tt.runs {
+				c.refresh(context.Background(), s)
+			}
+			time.Sleep(tt.wait)
+
+			snap, ok := c.Get("src")
+			if !ok {
+				t.Fatal("source not registered")
+			}
+			if snap.Data != tt.wantData {
+				t.Errorf("data = %v, want %v", snap.Data, tt.wantData)
+			}
+			if tt.wantError == "" && snap.Error != "" {
+				t.Errorf("error = %q, want none", snap.Error)
+			}
+			if tt.wantError != "" && !strings.Contains(snap.Error, tt.wantError) {
+				t.Errorf("error = %q, want %q", snap.Error, tt.wantError)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("source ran %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRegisterOverrides(t *testing.T) {
+	cfg := config.StatusConfig{
+		Interval: 5 * time.Second,
+		Timeout:  3 * time.Second,
+		Sources: map[string]config.StatusSourceConfig{
+			"storage": {Timeout: 20 * time.Second},
+		},
+	}
+	c := New(cfg, testLogger)
+	noop := func(context.Context) (interface{}, error) { return nil, nil }
+	c.Register("redis", 0, 0, noop)
+	c.Register("system_info", 10*time.Minute, 0, noop)
+	c.Register("storage", time.Minute, 10*time.Second, noop)
+
+	tests := []struct {
+		name         string
+		wantInterval time.Duration
+		wantTimeout  time.Duration
+	}{
+		{"redis", 5 * time.Second, 3 * time.Second},
+		{"system_info", 10 * time.Minute, 3 * time.Second},
+		{"storage", time.Minute, 20 * time.Second},
+	}
+
+	for i, tt := range tests {
+		s := c.sources[i]
+		if s.name != tt.name || s.interval != tt.wantInterval || s.timeout != tt.wantTimeout {
+			t.Errorf("%s: interval %s timeout %s, want %s and %s", s.name, s.interval, s.timeout, tt.wantInterval, tt.wantTimeout)
+		}
+	}
+}
@@ -0,0 +1,403 @@
+// Package errtrack groups the errors API handlers return. Each error is fingerprinted
+// by its type, its message with the variable parts (ids, numbers, quoted values)
+// replaced, and its origin: the panicking function, or the handler that returned it.
+// A group keeps counts, first and last sight, the routes it occurred on and its most
+// recent occurrences with request IDs and stacks. Groups can be resolved or ignored;
+// a resolved group that occurs again is re-opened as a regression.
+package errtrack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"test-go/config"
+	"test-go/pkg/logger"
+)
+
+// Group states.
+const (
+	StatusOpen     = "open"
+	StatusResolved = "resolved"
+	StatusIgnored  = "ignored" // still counted, but never re-opened
+)
+
+// maxRoutes bounds the routes counted per group; later ones are counted as otherRoute.
+const (
+	maxRoutes  = 20
+	otherRoute = "other"
+)
+
+var (
+	ErrGroupNotFound = errors.New("error group not found")
+	ErrInvalidStatus = errors.New("status must be open, resolved or ignored")
+)
+
+// Event is one error returned from a request.
+type Event struct {
+	Err       error
+	Status    int // response status
+	Method    string
+	Route     string // the route template, such as /api/v1/tasks/:id
+	Path      string
+	RequestID string
+	Handler   string // the function that handled the request
+	Time      time.Time
+}
+
+// Occurrence is a recent event of a group.
+type Occurrence struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id,omitempty"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Status    int       `json:"status"`
+	Message   string    `json:"message"`         // as returned, before templating
+	Stack     string    `json:"stack,omitempty"` // panics only
+}
+
+// Group is every occurrence of one error.
+type Group struct {
+	ID          string           `json:"id"` // the fingerprint
+	Type        string           `json:"type"`
+	Message     string           `json:"message"` // template
+	Origin      string           `json:"origin"`
+	Panic       bool             `json:"panic"`
+	Status      string           `json:"status"`
+	LastStatus  int              `json:"last_status"` // response status of the latest occurrence
+	Count       int64            `json:"count"`
+	FirstSeen   time.Time        `json:"first_seen"`
+	LastSeen    time.Time        `json:"last_seen"`
+	Routes      map[string]int64 `json:"routes"`
+	ResolvedAt  time.Time        `json:"resolved_at,omitzero"`
+	ResolvedBy  string           `json:"resolved_by,omitempty"`
+	Regressions int              `json:"regressions"`
+	RegressedAt time.Time        `json:"regressed_at,omitzero"`
+	Samples     []Occurrence     `json:"samples,omitempty"` // newest first
+}
+
+// Store persists groups across restarts.
+type Store interface {
+	LoadGroups(ctx context.Context) ([]Group, error)
+	SaveGroup(ctx context.Context, g Group) error
+	DeleteGroup(ctx context.Context, id string) error
+}
+
+// Tracker aggregates events into groups.
+type Tracker struct {
+	minStatus int
+	maxGroups int
+	samples   int
+	flush     time.Duration
+	store     Store
+	logger    *logger.Logger
+
+	mu      sync.Mutex
+	groups  map[string]*Group
+	dirty   map[string]bool
+	deleted map[string]bool
+}
+
+// New returns a tracker; store may be nil to keep groups in memory only. Call Start
+// to load stored groups and begin saving changes.
+func New(cfg config.ErrorsConfig, store Store, l *logger.Logger) *Tracker {
+	t := &Tracker{
+		minStatus: cfg.MinStatus,
+		maxGroups: cfg.MaxGroups,
+		samples:   cfg.Samples,
+		flush:     cfg.Flush,
+		store:     store,
+		logger:    l,
+		groups:    make(map[string]*Group),
+		dirty:     make(map[string]bool),
+		deleted:   make(map[string]bool),
+	}
+	if t.minStatus <= 0 {
+		t.minStatus = 500
+	}
+	if t.maxGroups <= 0 {
+		t.maxGroups = 500
+	}
+	if t.samples <= 0 {
+		t.samples = 10
+	}
+	if t.flush <= 0 {
+		t.flush = 10 * time.Second
+	}
+	return t
+}
+
+// Start loads the stored groups and saves changed ones every flush interval until
+// ctx is done, and once more then.
+func (t *Tracker) Start(ctx context.Context) {
+	if t.store == nil {
+		return
+	}
+	groups, err := t.store.LoadGroups(ctx)
+	if err != nil {
+		t.logger.Warn("Failed to load error groups", "error", err.Error())
+	}
+	t.mu.Lock()
+	for i := range groups {
+		g := groups[i]
+		if _, ok := t.groups[g.ID]; !ok {
+			t.groups[g.ID] = &g
+		}
+	}
+	t.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(t.flush)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				t.save(context.Background())
+				return
+			case <-ticker.C:
+				t.save(ctx)
+			}
+		}
+	}()
+}
+
+// MinStatus is the lowest response status that is tracked.
+func (t *Tracker) MinStatus() int { return t.minStatus }
+
+// Capture counts ev in its group, creating the group on first sight. Events below the
+// minimum status are ignored.
+func (t *Tracker) Capture(ev Event) {
+	if ev.Err == nil || ev.Status < t.minStatus {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	f := fingerprint(ev)
+
+	occ := Occurrence{
+		Time:      ev.Time,
+		RequestID: ev.RequestID,
+		Method:    ev.Method,
+		Path:      ev.Path,
+		Status:    ev.Status,
+		Message:   f.raw,
+		Stack:     f.stack,
+	}
+	route := ev.Method + " " + ev.Route
+	if ev.Route == "" {
+		route = ev.Method + " " + ev.Path
+	}
+
+	t.mu.Lock()
+	g, ok := t.groups[f.id]
+	if !ok {
+		g = &Group{
+			ID:        f.id,
+			Type:      f.typ,
+			Message:   f.message,
+			Origin:    f.origin,
+			Panic:     f.stack != "",
+			Status:    StatusOpen,
+			FirstSeen: ev.Time,
+			LastSeen:  ev.Time,
+			Routes:    make(map[string]int64),
+		}
+		t.groups[f.id] = g
+		delete(t.deleted, f.id)
+		t.evict()
+	}
+	g.Count++
+	g.LastSeen = ev.Time
+	g.LastStatus = ev.Status
+	if _, ok := g.Routes[route]; ok || len(g.Routes) < maxRoutes {
+		g.Routes[route]++
+	} else {
+		g.Routes[otherRoute]++
+	}
+	g.Samples = append([]Occurrence{occ}, g.Samples...)
+	if len(g.Samples) > t.samples {
+		g.Samples = g.Samples[:t.samples]
+	}
+	regressed := g.Status == StatusResolved
+	if regressed {
+		g.Status = StatusOpen
+		g.Regressions++
+		g.RegressedAt = ev.Time
+		g.ResolvedAt = time.Time{}
+		g.ResolvedBy = ""
+	}
+	t.dirty[f.id] = true
+	t.mu.Unlock()
+
+	if regressed {
+		t.logger.Warn("Resolved error occurred again", "group", f.id, "type", f.typ, "message", f.message, "route", route)
+	}
+}
+
+// evict drops the least recently seen groups beyond the maximum. The caller holds t.mu.
+func (t *Tracker) evict() {
+	for len(t.groups) > t.maxGroups {
+		var oldest *Group
+		for _, g := range t.groups {
+			if oldest == nil || g.LastSeen.Before(oldest.LastSeen) {
+				oldest = g
+			}
+		}
+		delete(t.groups, oldest.ID)
+		delete(t.dirty, oldest.ID)
+		t.deleted[oldest.ID] = true
+	}
+}
+
+// Groups returns the groups in status, or all of them when status is empty, most
+// recently seen first and without their samples.
+func (t *Tracker) Groups(status string) []Group {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := make([]Group, 0, len(t.groups))
+	for _, g := range t.groups {
+		if status != "" && g.Status != status {
+			continue
+		}
+		cp := g.copy()
+		cp.Samples = nil
+		out = append(out, cp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].LastSeen.After(out[j].LastSeen) })
+	return out
+}
+
+// Counts returns the number of groups in each state.
+func (t *Tracker) Counts() map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts := map[string]int{StatusOpen: 0, StatusResolved: 0, StatusIgnored: 0}
+	for _, g := range t.groups {
+		counts[g.Status]++
+	}
+	return counts
+}
+
+// Group returns one group with its samples.
+func (t *Tracker) Group(id string) (Group, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	g, ok := t.groups[id]
+	if !ok {
+		return Group{}, false
+	}
+	return g.copy(), true
+}
+
+// SetStatus resolves, ignores or re-opens a group and saves it right away.
+func (t *Tracker) SetStatus(ctx context.Context, id, status, user string) (Group, error) {
+	if status != StatusOpen && status != StatusResolved && status != StatusIgnored {
+		return Group{}, ErrInvalidStatus
+	}
+
+	t.mu.Lock()
+	g, ok := t.groups[id]
+	if !ok {
+		t.mu.Unlock()
+		return Group{}, ErrGroupNotFound
+	}
+	g.Status = status
+	g.ResolvedAt, g.ResolvedBy = time.Time{}, ""
+	if status == StatusResolved {
+		g.ResolvedAt, g.ResolvedBy = time.Now(), user
+	}
+	cp := g.copy()
+	delete(t.dirty, id)
+	t.mu.Unlock()
+
+	if t.store != nil {
+		if err := t.store.SaveGroup(ctx, cp); err != nil {
+			return cp, err
+		}
+	}
+	return cp, nil
+}
+
+// Delete forgets a group; it starts afresh if the error occurs again.
+func (t *Tracker) Delete(ctx context.Context, id string) (Group, error) {
+	t.mu.Lock()
+	g, ok := t.groups[id]
+	if !ok {
+		t.mu.Unlock()
+		return Group{}, ErrGroupNotFound
+	}
+	delete(t.groups, id)
+	delete(t.dirty, id)
+	t.mu.Unlock()
+
+	if t.store != nil {
+		if err := t.store.DeleteGroup(ctx, id); err != nil {
+			return *g, err
+		}
+	}
+	return *g, nil
+}
+
+// save writes the groups changed since the last call and deletes evicted ones.
+func (t *Tracker) save(ctx context.Context) {
+	t.mu.Lock()
+	changed := make([]Group, 0, len(t.dirty))
+	for id := range t.dirty {
+		changed = append(changed, t.groups[id].copy())
+	}
+	deleted := make([]string, 0, len(t.deleted))
+	for id := range t.deleted {
+		deleted = append(deleted, id)
+	}
+	t.dirty = make(map[string]bool)
+	t.deleted = make(map[string]bool)
+	t.mu.Unlock()
+
+	// Failed writes are retried on the next flush
+	var failed []error
+	for _, g := range changed {
+		if err := t.store.SaveGroup(ctx, g); err != nil {
+			failed = append(failed, err)
+			t.mu.Lock()
+			if _, ok := t.groups[g.ID]; ok {
+				t.dirty[g.ID] = true
+			}
+			t.mu.Unlock()
+		}
+	}
+	for _, id := range deleted {
+		if err := t.store.DeleteGroup(ctx, id); err != nil {
+			failed = append(failed, err)
+			t.mu.Lock()
+			if _, ok := t.groups[id]; !ok {
+				t.deleted[id] = true
+			}
+			t.mu.Unlock()
+		}
+	}
+	if len(failed) > 0 {
+		t.logger.Warn("Failed to save error groups", "failed", len(failed), "error", failed[0].Error())
+	}
+}
+
+func (g *Group) copy() Group {
+	cp := *g
+	cp.Routes = make(map[string]int64, len(g.Routes))
+	for k, v := range g.Routes {
+		cp.Routes[k] = v
+	}
+	cp.Samples = append([]Occurrence(nil), g.Samples...)
+	return cp
+}
+
+// String identifies a group in log lines and audit entries.
+func (g Group) String() string {
+	return fmt.Sprintf("%s: %s", g.Type, g.Message)
+}
@@ -0,0 +1,130 @@
+package errtrack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// PanicError is a recovered panic, with the stack of the panicking goroutine.
+type PanicError struct {
+	Value  interface{}
+	Stack  string
+	Origin string // the function that panicked
+}
+
+// Recovered wraps the value of recover(). Call it from the deferred function that
+// recovered, so the panicking frame is still on the stack.
+func Recovered(v interface{}) *PanicError {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	// The panicking function is the first one outside the runtime after gopanic
+	var origin, fallback string
+	afterPanic := false
+	for {
+		f, more := frames.Next()
+		switch {
+		case f.Function == "runtime.gopanic":
+			afterPanic = true
+		case strings.HasPrefix(f.Function, "runtime."):
+		case afterPanic:
+			origin = f.Function
+		case fallback == "":
+			fallback = f.Function
+		}
+		if origin != "" || !more {
+			break
+		}
+	}
+	if origin == "" {
+		origin = fallback
+	}
+	return &PanicError{Value: v, Stack: string(debug.Stack()), Origin: origin}
+}
+
+func (e *PanicError) Error() string { return fmt.Sprintf("panic: %v", e.Value) }
+
+// Unwrap returns the panic value when it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+type signature struct {
+	id      string
+	typ     string
+	message string // template
+	raw     string
+	origin  string
+	stack   string
+}
+
+// fingerprint derives the group of ev from the innermost error type, the templated
+// message and the origin.
+func fingerprint(ev Event) signature {
+	p := signature{raw: ev.Err.Error(), origin: shortFunc(ev.Handler)}
+
+	var pe *PanicError
+	if errors.As(ev.Err, &pe) {
+		p.typ = fmt.Sprintf("panic(%T)", pe.Value)
+		p.raw = fmt.Sprint(pe.Value)
+		p.origin = shortFunc(pe.Origin)
+		p.stack = pe.Stack
+	} else {
+		root := ev.Err
+		for next := errors.Unwrap(root); next != nil; next = errors.Unwrap(root) {
+			root = next
+		}
+		p.typ = fmt.Sprintf("%T", root)
+	}
+	p.message = template(p.raw)
+
+	sum := sha256.Sum256([]byte(p.typ + "\n" + p.message + "\n" + p.origin))
+	p.id = hex.EncodeToString(sum[:6])
+	return p
+}
+
+var (
+	uuidRe   = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	quotedRe = regexp.MustCompile(`"[^"]*"|'[^']*'|` + "`[^`]*`")
+	ipRe     = regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}(:\d+)?\b`)
+	hexRe    = regexp.MustCompile(`\b(0x[0-9a-fA-F]+|[0-9a-fA-F]{6,})\b`)
+	numberRe = regexp.MustCompile(`\b\d+(\.\d+)?`)
+)
+
+// template replaces the parts of an error message that differ between occurrences
+// of the same error, such as ids, numbers and quoted values.
+func template(msg string) string {
+	msg = uuidRe.ReplaceAllString(msg, "<uuid>")
+	msg = quotedRe.ReplaceAllString(msg, "<str>")
+	msg = ipRe.ReplaceAllString(msg, "<ip>")
+	msg = hexRe.ReplaceAllStringFunc(msg, func(s string) string {
+		// hashes and object ids mix digits and letters; plain words and numbers do not
+		if strings.HasPrefix(s, "0x") || (strings.ContainsAny(s, "0123456789") && strings.ContainsAny(s, "abcdefABCDEF")) {
+			return "<hex>"
+		}
+		return s
+	})
+	msg = numberRe.ReplaceAllString(msg, "<n>")
+	if len(msg) > 500 {
+		msg = msg[:500]
+	}
+	return msg
+}
+
+// shortFunc trims the module path and method value suffix off a function name:
+// test-go/internal/modules.(*ServiceD).getTask-fm becomes modules.(*ServiceD).getTask.
+func shortFunc(name string) string {
+	name = strings.TrimSuffix(name, "-fm")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,123 @@
+package errtrack
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"task not found", "task not found"},
+		{"task 42 not found", "task <n> not found"},
+		{"user 3fa85f64-5717-4562-b3fc-2c963f66afa6 missing", "user <uuid> missing"},
+		{`unknown field "colour"`, "unknown field <str>"},
+		{"dial tcp 10.0.0.12:5432: connection refused", "dial tcp <ip>: connection refused"},
+		{"object 5f1d7a3b9c2e not in bucket", "object <hex> not in bucket"},
+		{"pointer 0xc000123456 freed", "pointer <hex> freed"},
+		{"decade facade", "decade facade"}, // hex letters only: plain words
+		{"took 1.5s after 3 retries", "took <n>s after <n> retries"},
+	}
+
+	for _, tt := range tests {
+		if got := template(tt.in); got != tt.want {
+			t.Errorf("template(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+type notFoundError struct{ id int }
+
+func (e *notFoundError) Error() string { return fmt.Sprintf("task %d not found", e.id) }
+
+func TestFingerprint(t *testing.T) {
+	const handler = "test-go/internal/services/modules.(*ServiceD).getTask-fm"
+	base := Event{Err: &notFoundError{id: 1}, Handler: handler}
+
+	tests := []struct {
+		name     string
+		ev       Event
+		wantSame bool
+		wantType string
+	}{
+		{
+			name:     "same error with another id",
+			ev:       Event{Err: &notFoundError{id: 2}, Handler: handler},
+			wantSame: true,
+			wantType: "*errtrack.notFoundError",
+		},
+		{
+			name:     "wrapped error keeps the root type",
+			ev:       Event{Err: fmt.Errorf("loading: %w", &notFoundError{id: 3}), Handler: handler},
+			wantType: "*errtrack.notFoundError",
+		},
+		{
+			name:     "same error from another handler",
+			ev:       Event{Err: &notFoundError{id: 1}, Handler: "test-go/internal/services/modules.(*ServiceD).listTasks-fm"},
+			wantType: "*errtrack.notFoundError",
+		},
+		{
+			name:     "different error type",
+			ev:       Event{Err: fs.ErrNotExist, Handler: handler},
+			wantType: "*errors.errorString",
+		},
+		{
+			name:     "panic is grouped by the panicking function",
+			ev:       Event{Err: &PanicError{Value: "task 1 not found", Origin: "test-go/pkg/store.(*DB).Get"}, Handler: handler},
+			wantType: "panic(string)",
+		},
+	}
+
+	want := fingerprint(base)
+	if want.origin != "modules.(*ServiceD).getTask" {
+		t.Fatalf("origin = %q, want the short handler name", want.origin)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fingerprint(tt.ev)
+			if (got.id == want.id) != tt.wantSame {
+				t.Errorf("id %s vs %s: same = %v, want %v", got.id, want.id, got.id == want.id, tt.wantSame)
+			}
+			if got.typ != tt.wantType {
+				t.Errorf("type = %q, want %q", got.typ, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestRecoveredOrigin(t *testing.T) {
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = Recovered(r)
+			}
+		}()
+		explode()
+		return nil
+	}()
+
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("err = %v, want a *PanicError", err)
+	}
+	if !strings.HasSuffix(pe.Origin, ".explode") {
+		t.Errorf("origin = %q, want the panicking function", pe.Origin)
+	}
+	if !strings.Contains(pe.Stack, "explode") {
+		t.Error("stack does not include the panicking function")
+	}
+	if pe.Error() != "panic: boom" {
+		t.Errorf("Error() = %q", pe.Error())
+	}
+}
+
+//go:noinline
+func explode() {
+	panic("boom")
+}
@@ -0,0 +1,138 @@
+package errtrack
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SQLStore keeps error groups in the monitoring SQLite database.
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore creates the error_groups table if needed.
+func NewSQLStore(db *sql.DB) (*SQLStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("error store: database is not available")
+	}
+
+	schema := []string{
+		`CREATE TABLE IF NOT EXISTS error_groups (
+			id TEXT PRIMARY KEY,
+			type TEXT NOT NULL,
+			message TEXT NOT NULL,
+			origin TEXT NOT NULL,
+			panic INTEGER NOT NULL,
+			status TEXT NOT NULL,
+			last_status INTEGER NOT NULL,
+			count INTEGER NOT NULL,
+			first_seen BIGINT NOT NULL,
+			last_seen BIGINT NOT NULL,
+			resolved_at BIGINT,
+			resolved_by TEXT,
+			regressions INTEGER NOT NULL,
+			regressed_at BIGINT,
+			routes TEXT NOT NULL,
+			samples TEXT NOT NULL
+		)`,
+	}
+	for _, stmt := range schema {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, fmt.Errorf("error store: failed to create schema: %w", err)
+		}
+	}
+
+	return &SQLStore{db: db}, nil
+}
+
+func (s *SQLStore) LoadGroups(ctx context.Context) ([]Group, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, type, message, origin, panic, status, last_status, count, first_seen, last_seen,
+			COALESCE(resolved_at, 0), COALESCE(resolved_by, ''), regressions, COALESCE(regressed_at, 0), routes, samples
+		FROM error_groups
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []Group
+	for rows.Next() {
+		var g Group
+		var panicked int
+		var first, last, resolved, regressed int64
+		var routes, samples string
+		if err := rows.Scan(&g.ID, &g.Type, &g.Message, &g.Origin, &panicked, &g.Status, &g.LastStatus, &g.Count,
+			&first, &last, &resolved, &g.ResolvedBy, &g.Regressions, &regressed, &routes, &samples); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(routes), &g.Routes); err != nil {
+			return nil, fmt.Errorf("error group %s: invalid routes: %w", g.ID, err)
+		}
+		if err := json.Unmarshal([]byte(samples), &g.Samples); err != nil {
+			return nil, fmt.Errorf("error group %s: invalid samples: %w", g.ID, err)
+		}
+		if g.Routes == nil {
+			g.Routes = make(map[string]int64)
+		}
+		g.Panic = panicked != 0
+		g.FirstSeen = time.UnixMilli(first).UTC()
+		g.LastSeen = time.UnixMilli(last).UTC()
+		if resolved > 0 {
+			g.ResolvedAt = time.UnixMilli(resolved).UTC()
+		}
+		if regressed > 0 {
+			g.RegressedAt = time.UnixMilli(regressed).UTC()
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+func (s *SQLStore) SaveGroup(ctx context.Context, g Group) error {
+	routes, err := json.Marshal(g.Routes)
+	if err != nil {
+		return err
+	}
+	samples, err := json.Marshal(g.Samples)
+	if err != nil {
+		return err
+	}
+	panicked := 0
+	if g.Panic {
+		panicked = 1
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO error_groups (id, type, message, origin, panic, status, last_status, count, first_seen, last_seen,
+			resolved_at, resolved_by, regressions, regressed_at, routes, samples)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			status = excluded.status, last_status = excluded.last_status, count = excluded.count,
+			last_seen = excluded.last_seen, resolved_at = excluded.resolved_at, resolved_by = excluded.resolved_by,
+			regressions = excluded.regressions, regressed_at = excluded.regressed_at,
+			routes = excluded.routes, samples = excluded.samples
+	`, g.ID, g.Type, g.Message, g.Origin, panicked, g.Status, g.LastStatus, g.Count, g.FirstSeen.UnixMilli(), g.LastSeen.UnixMilli(),
+		nullMillis(g.ResolvedAt), g.ResolvedBy, g.Regressions, nullMillis(g.RegressedAt), string(routes), string(samples))
+	if err != nil {
+		return fmt.Errorf("failed to save error group: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) DeleteGroup(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM error_groups WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete error group: %w", err)
+	}
+	return nil
+}
+
+func nullMillis(t time.Time) sql.NullInt64 {
+	if t.IsZero() {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.UnixMilli(), Valid: true}
+}
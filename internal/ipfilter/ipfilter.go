@@ -0,0 +1,404 @@
+package ipfilter
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"test-go/config"
+	"test-go/pkg/logger"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Scopes name the server a rule applies to.
+const (
+	ScopeAPI        = "api"
+	ScopeMonitoring = "monitoring"
+)
+
+// Rule actions.
+const (
+	ActionAllow = "allow"
+	ActionDeny  = "deny"
+)
+
+// Rule sources. Config rules are read-only at runtime.
+const (
+	SourceConfig  = "config"
+	SourceRuntime = "runtime"
+)
+
+var (
+	ErrRuleNotFound = errors.New("rule not found")
+	ErrReadOnlyRule = errors.New("rule comes from config.yaml and cannot be removed at runtime")
+	ErrLockout      = errors.New("change would block your own address from the monitoring dashboard")
+)
+
+// Rule allows or denies one network for a server, optionally only under a route prefix.
+type Rule struct {
+	ID        string    `json:"id"`
+	Scope     string    `json:"scope"`
+	Group     string    `json:"group"` // route prefix; empty applies to the whole server
+	Action    string    `json:"action"`
+	CIDR      string    `json:"cidr"`
+	Note      string    `json:"note,omitempty"`
+	Source    string    `json:"source"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+
+	network *net.IPNet
+}
+
+// BlockedAttempt is a request rejected by the filter.
+type BlockedAttempt struct {
+	Time      time.Time `json:"time"`
+	Scope     string    `json:"scope"`
+	IP        string    `json:"ip"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Reason    string    `json:"reason"`
+	RequestID string    `json:"request_id,omitempty"`
+}
+
+// Store persists runtime rules so they survive restarts.
+type Store interface {
+	Load(ctx context.Context) ([]Rule, error)
+	Save(ctx context.Context, r Rule) error
+	Delete(ctx context.Context, id string) error
+}
+
+// Filter evaluates client IPs against allow and deny rules.
+// Rules can be changed while the servers are running; checks never block on the store.
+type Filter struct {
+	store          Store
+	logger         *logger.Logger
+	trustedProxies []string
+
+	mu    sync.RWMutex
+	rules []Rule
+
+	blockedMu    sync.Mutex
+	blocked      []BlockedAttempt
+	blockedNext  int
+	blockedTotal uint64
+}
+
+// New builds a filter from config rules plus any runtime rules in store (which may be nil).
+func New(cfg config.IPFilterConfig, store Store, l *logger.Logger) (*Filter, error) {
+	if _, err := NewIPExtractor(cfg.TrustedProxies); err != nil {
+		return nil, err
+	}
+
+	history := cfg.BlockedHistory
+	if history <= 0 {
+		history = 500
+	}
+
+	f := &Filter{
+		store:          store,
+		logger:         l,
+		trustedProxies: cfg.TrustedProxies,
+		blocked:        make([]BlockedAttempt, 0, history),
+	}
+
+	for scope, rules := range map[string]config.IPFilterRules{ScopeAPI: cfg.API, ScopeMonitoring: cfg.Monitoring} {
+		if err := f.addConfigRules(scope, "", rules.Allow, rules.Deny); err != nil {
+			return nil, err
+		}
+		for _, g := range rules.Groups {
+			if g.Path == "" {
+				return nil, fmt.Errorf("ip filter: %s group without path", scope)
+			}
+			if err := f.addConfigRules(scope, g.Path, g.Allow, g.Deny); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if store != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		saved, err := store.Load(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("ip filter: failed to load rules: %w", err)
+		}
+		for _, r := range saved {
+			if err := r.parse(); err != nil {
+				l.Warn("Skipping invalid stored IP rule", "id", r.ID, "cidr", r.CIDR)
+				continue
+			}
+			f.rules = append(f.rules, r)
+		}
+	}
+
+	return f, nil
+}
+
+func (f *Filter) addConfigRules(scope, group string, allow, deny []string) error {
+	add := func(action string, cidrs []string) error {
+		for _, cidr := range cidrs {
+			r := Rule{
+				ID:     fmt.Sprintf("cfg-%d", len(f.rules)+1),
+				Scope:  scope,
+				Group:  group,
+				Action: action,
+				CIDR:   cidr,
+				Source: SourceConfig,
+			}
+			if err := r.parse(); err != nil {
+				return err
+			}
+			f.rules = append(f.rules, r)
+		}
+		return nil
+	}
+	if err := add(ActionAllow, allow); err != nil {
+		return err
+	}
+	return add(ActionDeny, deny)
+}
+
+// parse validates the rule and normalizes its CIDR; a bare IP becomes a single-host network.
+func (r *Rule) parse() error {
+	switch r.Scope {
+	case ScopeAPI, ScopeMonitoring:
+	default:
+		return fmt.Errorf("ip filter: unknown scope %q", r.Scope)
+	}
+	switch r.Action {
+	case ActionAllow, ActionDeny:
+	default:
+		return fmt.Errorf("ip filter: unknown action %q", r.Action)
+	}
+	if r.Group != "" && !strings.HasPrefix(r.Group, "/") {
+		return fmt.Errorf("ip filter: group %q must start with /", r.Group)
+	}
+
+	cidr := strings.TrimSpace(r.CIDR)
+	if !strings.Contains(cidr, "/") {
+		ip := net.ParseIP(cidr)
+		if ip == nil {
+			return fmt.Errorf("ip filter: invalid address %q", r.CIDR)
+		}
+		if ip.To4() != nil {
+			cidr += "/32"
+		} else {
+			cidr += "/128"
+		}
+	}
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return fmt.Errorf("ip filter: invalid CIDR %q", r.CIDR)
+	}
+
+	r.CIDR = network.String()
+	r.network = network
+	return nil
+}
+
+// Check reports whether ip may reach path on the given server, and why not.
+func (f *Filter) Check(scope, path string, ip net.IP) (bool, string) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return evaluate(f.rules, scope, path, ip)
+}
+
+// evaluate applies deny-wins semantics. Among allowlists, the one on the most
+// specific matching group decides, so a group can open or narrow the server-wide list.
+func evaluate(rules []Rule, scope, path string, ip net.IP) (bool, string) {
+	allowGroup := -1
+	allowed := false
+
+	for _, r := range rules {
+		if r.Scope != scope || !matchGroup(r.Group, path) {
+			continue
+		}
+		switch r.Action {
+		case ActionDeny:
+			if ip != nil && r.network.Contains(ip) {
+				return false, "denied by " + r.CIDR
+			}
+		case ActionAllow:
+			if len(r.Group) > allowGroup {
+				allowGroup = len(r.Group)
+				allowed = false
+			}
+			if len(r.Group) == allowGroup && ip != nil && r.network.Contains(ip) {
+				allowed = true
+			}
+		}
+	}
+
+	if allowGroup >= 0 && !allowed {
+		return false, "not in allowlist"
+	}
+	return true, ""
+}
+
+func matchGroup(group, path string) bool {
+	if group == "" || path == group {
+		return true
+	}
+	return strings.HasPrefix(path, strings.TrimSuffix(group, "/")+"/")
+}
+
+// Rules returns all rules ordered by scope, group and action.
+func (f *Filter) Rules() []Rule {
+	f.mu.RLock()
+	out := append([]Rule(nil), f.rules...)
+	f.mu.RUnlock()
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Scope != out[j].Scope {
+			return out[i].Scope < out[j].Scope
+		}
+		if out[i].Group != out[j].Group {
+			return out[i].Group < out[j].Group
+		}
+		return out[i].Action < out[j].Action
+	})
+	return out
+}
+
+// TrustedProxies returns the configured proxy networks.
+func (f *Filter) TrustedProxies() []string {
+	return f.trustedProxies
+}
+
+// AddRule validates, stores and activates a runtime rule.
+// If protect is set, the change is refused when it would lock that address out of the dashboard.
+func (f *Filter) AddRule(ctx context.Context, r Rule, protect net.IP) (Rule, error) {
+	r.ID = newID()
+	r.Source = SourceRuntime
+	r.CreatedAt = time.Now()
+	if err := r.parse(); err != nil {
+		return Rule{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	next := append(append([]Rule(nil), f.rules...), r)
+	if err := checkLockout(next, protect); err != nil {
+		return Rule{}, err
+	}
+	if f.store != nil {
+		if err := f.store.Save(ctx, r); err != nil {
+			return Rule{}, err
+		}
+	}
+	f.rules = next
+	return r, nil
+}
+
+// RemoveRule deletes a runtime rule, with the same lockout protection as AddRule.
+func (f *Filter) RemoveRule(ctx context.Context, id string, protect net.IP) (Rule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	idx := -1
+	for i, r := range f.rules {
+		if r.ID == id {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return Rule{}, ErrRuleNotFound
+	}
+	removed := f.rules[idx]
+	if removed.Source == SourceConfig {
+		return Rule{}, ErrReadOnlyRule
+	}
+
+	next := append(append([]Rule(nil), f.rules[:idx]...), f.rules[idx+1:]...)
+	if err := checkLockout(next, protect); err != nil {
+		return Rule{}, err
+	}
+	if f.store != nil {
+		if err := f.store.Delete(ctx, id); err != nil {
+			return Rule{}, err
+		}
+	}
+	f.rules = next
+	return removed, nil
+}
+
+func checkLockout(rules []Rule, protect net.IP) error {
+	if protect == nil {
+		return nil
+	}
+	if ok, _ := evaluate(rules, ScopeMonitoring, "/dashboard", protect); !ok {
+		return ErrLockout
+	}
+	return nil
+}
+
+// RecordBlocked keeps a blocked attempt in the bounded history.
+func (f *Filter) RecordBlocked(a BlockedAttempt) {
+	f.blockedMu.Lock()
+	defer f.blockedMu.Unlock()
+
+	f.blockedTotal++
+	if len(f.blocked) < cap(f.blocked) {
+		f.blocked = append(f.blocked, a)
+		return
+	}
+	f.blocked[f.blockedNext] = a
+	f.blockedNext = (f.blockedNext + 1) % len(f.blocked)
+}
+
+// Blocked returns up to limit recent attempts, newest first, and the total since startup.
+func (f *Filter) Blocked(limit int) ([]BlockedAttempt, uint64) {
+	f.blockedMu.Lock()
+	defer f.blockedMu.Unlock()
+
+	n := len(f.blocked)
+	if limit <= 0 || limit > n {
+		limit = n
+	}
+	out := make([]BlockedAttempt, 0, limit)
+	for i := 0; i < limit; i++ {
+		// Walk backwards from the most recent write
+		idx := (f.blockedNext - 1 - i + n) % n
+		out = append(out, f.blocked[idx])
+	}
+	return out, f.blockedTotal
+}
+
+// NewIPExtractor resolves the client address for echo's RealIP, honouring
+// X-Forwarded-For only when the direct peer is one of the trusted proxy networks.
+// With none, the socket address is used and forwarding headers are ignored.
+func NewIPExtractor(trusted []string) (echo.IPExtractor, error) {
+	if len(trusted) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	opts := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, cidr := range trusted {
+		r := Rule{Scope: ScopeAPI, Action: ActionAllow, CIDR: cidr}
+		if err := r.parse(); err != nil {
+			return nil, fmt.Errorf("ip filter: invalid trusted proxy %q", cidr)
+		}
+		opts = append(opts, echo.TrustIPRange(r.network))
+	}
+	return echo.ExtractIPFromXFFHeader(opts...), nil
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,115 @@
+package ipfilter
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"test-go/config"
+	"test-go/pkg/logger"
+)
+
+func TestCheck(t *testing.T) {
+	cfg := config.IPFilterConfig{
+		API: config.IPFilterRules{
+			Allow: []string{"10.0.0.0/8", "2001:db8::/32"},
+			Deny:  []string{"10.1.0.0/16", "10.2.3.4", "2001:db8:bad::/48"},
+			Groups: []config.IPFilterGroup{
+				{Path: "/api/v1/admin", Allow: []string{"10.9.0.0/16"}},
+				{Path: "/api/v1/admin/reports", Allow: []string{"10.9.9.0/24"}},
+				{Path: "/api/v1/public", Allow: []string{"0.0.0.0/0", "::/0"}, Deny: []string{"198.51.100.0/24"}},
+			},
+		},
+		Monitoring: config.IPFilterRules{
+			Allow: []string{"127.0.0.1"},
+		},
+	}
+	f, err := New(cfg, nil, logger.NewQuiet(false, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		scope string
+		path  string
+		ip    string
+		want  bool
+	}{
+		{"server allowlist admits", ScopeAPI, "/api/v1/tasks", "10.5.6.7", true},
+		{"server allowlist rejects others", ScopeAPI, "/api/v1/tasks", "203.0.113.1", false},
+		{"deny inside an allowed network wins", ScopeAPI, "/api/v1/tasks", "10.1.2.3", false},
+		{"single-address deny", ScopeAPI, "/api/v1/tasks", "10.2.3.4", false},
+		{"neighbour of a single-address deny", ScopeAPI, "/api/v1/tasks", "10.2.3.5", true},
+		{"group allowlist replaces the server one", ScopeAPI, "/api/v1/admin/users", "10.5.6.7", false},
+		{"group allowlist admits its network", ScopeAPI, "/api/v1/admin/users", "10.9.1.1", true},
+		{"most specific group decides", ScopeAPI, "/api/v1/admin/reports/daily", "10.9.1.1", false},
+		{"most specific group admits", ScopeAPI, "/api/v1/admin/reports/daily", "10.9.9.9", true},
+		{"group matches whole segments only", ScopeAPI, "/api/v1/administrators", "10.5.6.7", true},
+		{"server deny still applies in an open group", ScopeAPI, "/api/v1/public/feed", "10.1.2.3", false},
+		{"group deny", ScopeAPI, "/api/v1/public/feed", "198.51.100.20", false},
+		{"open group admits anyone else", ScopeAPI, "/api/v1/public/feed", "203.0.113.1", true},
+		{"IPv6 allowlist", ScopeAPI, "/api/v1/tasks", "2001:db8:1::1", true},
+		{"IPv6 deny inside the allowlist", ScopeAPI, "/api/v1/tasks", "2001:db8:bad::1", false},
+		{"IPv6 outside the allowlist", ScopeAPI, "/api/v1/tasks", "2001:db9::1", false},
+		{"IPv4-mapped IPv6 matches the IPv4 rule", ScopeAPI, "/api/v1/tasks", "::ffff:10.5.6.7", true},
+		{"unparseable address fails an allowlist", ScopeAPI, "/api/v1/tasks", "", false},
+		{"scopes are separate", ScopeMonitoring, "/api/status", "10.5.6.7", false},
+		{"monitoring allowlist", ScopeMonitoring, "/api/status", "127.0.0.1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := f.Check(tt.scope, tt.path, net.ParseIP(tt.ip))
+			if got != tt.want {
+				t.Errorf("Check(%s, %s, %s) = %v (%s), want %v", tt.scope, tt.path, tt.ip, got, reason, tt.want)
+			}
+			if !got && reason == "" {
+				t.Error("rejected without a reason")
+			}
+		})
+	}
+}
+
+func TestNewIPExtractor(t *testing.T) {
+	tests := []struct {
+		name    string
+		trusted []string
+		peer    string
+		xff     string
+		want    string
+	}{
+		{"no trusted proxies ignores the header", nil, "203.0.113.9:1234", "198.51.100.7", "203.0.113.9"},
+		{"untrusted peer cannot spoof", []string{"10.0.0.0/8"}, "203.0.113.9:1234", "198.51.100.7", "203.0.113.9"},
+		{"trusted proxy forwards the client", []string{"10.0.0.0/8"}, "10.0.0.5:1234", "198.51.100.7", "198.51.100.7"},
+		{"chain of trusted proxies", []string{"10.0.0.0/8"}, "10.0.0.5:1234", "198.51.100.7, 10.0.0.6", "198.51.100.7"},
+		{"client-supplied entries left of the proxy are ignored", []string{"10.0.0.0/8"}, "10.0.0.5:1234", "1.1.1.1, 198.51.100.7", "198.51.100.7"},
+		{"loopback is not trusted unless listed", []string{"10.0.0.0/8"}, "127.0.0.1:1234", "198.51.100.7", "127.0.0.1"},
+		{"trusted proxy without a header", []string{"10.0.0.0/8"}, "10.0.0.5:1234", "", "10.0.0.5"},
+		{"IPv6 trusted proxy", []string{"fd00::/8"}, "[fd00::1]:443", "2001:db8::7", "2001:db8::7"},
+		{"IPv6 untrusted peer", []string{"fd00::/8"}, "[2001:db8::9]:443", "2001:db8::7", "2001:db8::9"},
+		{"single trusted address", []string{"10.0.0.5"}, "10.0.0.6:1234", "198.51.100.7", "10.0.0.6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extract, err := NewIPExtractor(tt.trusted)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.peer
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if got := extract(req); got != tt.want {
+				t.Errorf("client IP = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewIPExtractor([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("invalid trusted proxy accepted")
+	}
+}
@@ -0,0 +1,81 @@
+package ipfilter
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SQLStore keeps runtime rules in the ip_filter_rules table of the monitoring SQLite database.
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore creates the ip_filter_rules table if needed.
+func NewSQLStore(db *sql.DB) (*SQLStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("ip filter store: database is not available")
+	}
+
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS ip_filter_rules (
+			id TEXT PRIMARY KEY,
+			scope TEXT NOT NULL,
+			grp TEXT NOT NULL DEFAULT '',
+			action TEXT NOT NULL,
+			cidr TEXT NOT NULL,
+			note TEXT,
+			created_by TEXT,
+			created_at BIGINT NOT NULL
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("ip filter store: failed to create schema: %w", err)
+	}
+
+	return &SQLStore{db: db}, nil
+}
+
+func (s *SQLStore) Load(ctx context.Context) ([]Rule, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, scope, grp, action, cidr, COALESCE(note, ''), COALESCE(created_by, ''), created_at
+		FROM ip_filter_rules
+		ORDER BY created_at
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []Rule
+	for rows.Next() {
+		var r Rule
+		var ts int64
+		if err := rows.Scan(&r.ID, &r.Scope, &r.Group, &r.Action, &r.CIDR, &r.Note, &r.CreatedBy, &ts); err != nil {
+			return nil, err
+		}
+		r.Source = SourceRuntime
+		r.CreatedAt = time.UnixMilli(ts)
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
+
+func (s *SQLStore) Save(ctx context.Context, r Rule) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO ip_filter_rules (id, scope, grp, action, cidr, note, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, r.ID, r.Scope, r.Group, r.Action, r.CIDR, r.Note, r.CreatedBy, r.CreatedAt.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to save ip rule: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) Delete(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM ip_filter_rules WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete ip rule: %w", err)
+	}
+	return nil
+}
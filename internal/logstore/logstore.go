@@ -0,0 +1,213 @@
+// Package logstore keeps the log lines written to the dashboard live stream so they
+// can be searched, exported and replayed to clients that reconnect. Every line gets a
+// sequential ID. The newest lines are held in a ring in memory; when a directory is
+// configured all lines are also appended to NDJSON segment files, rotated by size and
+// deleted by count and age.
+package logstore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"test-go/config"
+)
+
+const (
+	flushInterval = time.Second
+	pruneInterval = time.Minute
+	maxLineBytes  = 1 << 20 // longest line read back from a segment
+)
+
+// ErrBadRange is returned for queries whose end is before their start.
+var ErrBadRange = errors.New("logstore: range end is before its start")
+
+// Entry is one stored log line.
+type Entry struct {
+	ID      uint64                 `json:"id"`
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+	Raw     string                 `json:"raw"` // the line as written, without the trailing newline
+}
+
+// Store is an io.Writer that parses and keeps every line written to it.
+type Store struct {
+	mu     sync.RWMutex
+	ring   []Entry
+	next   int // ring index of the next write
+	full   bool
+	lastID uint64
+
+	disk *segments // nil keeps logs in memory only
+	done chan struct{}
+}
+
+// Open creates a store. When cfg.Dir cannot be used the store keeps logs in memory
+// only and the error is returned alongside it.
+func Open(cfg config.LogStoreConfig) (*Store, error) {
+	size := cfg.BufferSize
+	if size <= 0 {
+		size = 10000
+	}
+	s := &Store{ring: make([]Entry, size), done: make(chan struct{})}
+
+	if cfg.Dir == "" {
+		return s, nil
+	}
+	disk, lastID, err := openSegments(cfg)
+	if err != nil {
+		return s, fmt.Errorf("logstore: keeping logs in memory only: %w", err)
+	}
+	s.disk = disk
+	s.lastID = lastID
+	go s.maintain()
+	return s, nil
+}
+
+// Write stores each line of p and returns len(p). It never fails, so a broken
+// segment file cannot stop logging.
+func (s *Store) Write(p []byte) (int, error) {
+	s.Append(p)
+	return len(p), nil
+}
+
+// Append stores each non-empty line of p and returns the stored entries.
+func (s *Store) Append(p []byte) []Entry {
+	now := time.Now()
+	var out []Entry
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, line := range splitLines(p) {
+		s.lastID++
+		e := parse(line, now)
+		e.ID = s.lastID
+
+		s.ring[s.next] = e
+		s.next = (s.next + 1) % len(s.ring)
+		if s.next == 0 {
+			s.full = true
+		}
+		if s.disk != nil {
+			s.disk.append(e)
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// LastID returns the ID of the newest entry, or 0 when nothing was stored.
+func (s *Store) LastID() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastID
+}
+
+// Stats describes what the store holds.
+type Stats struct {
+	Buffered   int    `json:"buffered"`
+	BufferSize int    `json:"buffer_size"`
+	OldestID   uint64 `json:"oldest_id"` // oldest entry in memory
+	LastID     uint64 `json:"last_id"`
+	Persistent bool   `json:"persistent"`
+	Segments   int    `json:"segments"`
+	DiskBytes  int64  `json:"disk_bytes"`
+	DiskError  string `json:"disk_error,omitempty"`
+}
+
+func (s *Store) Stats() Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	st := Stats{Buffered: s.count(), BufferSize: len(s.ring), LastID: s.lastID, Persistent: s.disk != nil}
+	if st.Buffered > 0 {
+		st.OldestID = s.at(0).ID
+	}
+	if s.disk != nil {
+		st.Segments, st.DiskBytes = s.disk.usage()
+		if s.disk.err != nil {
+			st.DiskError = s.disk.err.Error()
+		}
+	}
+	return st
+}
+
+// Close flushes and closes the current segment.
+func (s *Store) Close() error {
+	if s.disk == nil {
+		return nil
+	}
+	close(s.done)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.disk.close()
+}
+
+func (s *Store) maintain() {
+	flush := time.NewTicker(flushInterval)
+	defer flush.Stop()
+	prune := time.NewTicker(pruneInterval)
+	defer prune.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-flush.C:
+			s.mu.Lock()
+			s.disk.flush()
+			s.mu.Unlock()
+		case <-prune.C:
+			s.mu.Lock()
+			s.disk.prune(time.Now())
+			s.mu.Unlock()
+		}
+	}
+}
+
+// count returns the number of entries in the ring. The caller holds s.mu.
+func (s *Store) count() int {
+	if s.full {
+		return len(s.ring)
+	}
+	return s.next
+}
+
+// at returns the i-th oldest entry in the ring. The caller holds s.mu.
+func (s *Store) at(i int) Entry {
+	if !s.full {
+		return s.ring[i]
+	}
+	return s.ring[(s.next+i)%len(s.ring)]
+}
+
+func splitLines(p []byte) []string {
+	var out []string
+	start := 0
+	for i := 0; i <= len(p); i++ {
+		if i == len(p) || p[i] == '\n' {
+			line := p[start:i]
+			if n := len(line); n > 0 && line[n-1] == '\r' {
+				line = line[:n-1]
+			}
+			if len(line) > 0 {
+				out = append(out, string(line))
+			}
+			start = i + 1
+		}
+	}
+	return out
+}
+
+func fileSize(path string) int64 {
+	if fi, err := os.Stat(path); err == nil {
+		return fi.Size()
+	}
+	return 0
+}
@@ -0,0 +1,108 @@
+package logstore
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ansi = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	// key=value or key="quoted value" as printed by zerolog's console writer
+	consoleField = regexp.MustCompile(`(?:^|\s)([A-Za-z_][\w.\-]*)=("(?:[^"\\]|\\.)*"|\S*)`)
+)
+
+// Levels, least severe first.
+var levels = []string{"trace", "debug", "info", "warn", "error", "fatal", "panic"}
+
+// normalizeLevel maps zerolog level names and their console abbreviations to the
+// names in levels. Unknown levels are returned lower-cased.
+func normalizeLevel(l string) string {
+	l = strings.ToLower(strings.Trim(l, "[] "))
+	switch l {
+	case "trc":
+		return "trace"
+	case "dbg":
+		return "debug"
+	case "inf":
+		return "info"
+	case "wrn", "warning":
+		return "warn"
+	case "err":
+		return "error"
+	case "ftl":
+		return "fatal"
+	case "pnc":
+		return "panic"
+	}
+	return l
+}
+
+// levelRank orders levels; unknown levels rank with info.
+func levelRank(l string) int {
+	for i, name := range levels {
+		if name == l {
+			return i
+		}
+	}
+	return 2
+}
+
+// parse turns a zerolog JSON line, or a console line in quiet mode, into an entry.
+func parse(line string, now time.Time) Entry {
+	e := Entry{Time: now, Level: "info", Raw: line}
+
+	if strings.HasPrefix(line, "{") {
+		var m map[string]interface{}
+		if json.Unmarshal([]byte(line), &m) == nil {
+			if v, ok := m["time"].(string); ok {
+				if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+					e.Time = t
+				}
+			}
+			if v, ok := m["level"].(string); ok {
+				e.Level = normalizeLevel(v)
+			}
+			if v, ok := m["message"].(string); ok {
+				e.Message = v
+			}
+			delete(m, "time")
+			delete(m, "level")
+			delete(m, "message")
+			if len(m) > 0 {
+				e.Fields = m
+			}
+			return e
+		}
+	}
+
+	// "15:04:05 INF message key=value ..."
+	rest := strings.TrimSpace(ansi.ReplaceAllString(line, ""))
+	if len(rest) >= 8 && rest[2] == ':' && rest[5] == ':' {
+		rest = strings.TrimSpace(rest[8:])
+	}
+	if i := strings.IndexByte(rest, ' '); i > 0 {
+		if l := normalizeLevel(rest[:i]); levelRank(l) != 2 || l == "info" {
+			e.Level = l
+			rest = strings.TrimSpace(rest[i+1:])
+		}
+	}
+
+	matches := consoleField.FindAllStringSubmatchIndex(rest, -1)
+	if len(matches) == 0 {
+		e.Message = rest
+		return e
+	}
+	e.Message = strings.TrimSpace(rest[:matches[0][0]])
+	e.Fields = make(map[string]interface{}, len(matches))
+	for _, m := range matches {
+		key, val := rest[m[2]:m[3]], rest[m[4]:m[5]]
+		if unq, err := strconv.Unquote(val); err == nil {
+			val = unq
+		}
+		e.Fields[key] = val
+	}
+	return e
+}
@@ -0,0 +1,232 @@
+package logstore
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	defaultLimit = 200
+	maxLimit     = 100000
+)
+
+// Filter selects entries. Zero values match everything.
+type Filter struct {
+	Level  string // minimum level, e.g. "warn"
+	From   time.Time
+	To     time.Time
+	Text   string         // case-insensitive substring of the raw line
+	Regex  *regexp.Regexp // matched against the raw line
+	Fields map[string]string
+
+	// After returns the entries following this ID, oldest first. Otherwise the
+	// newest entries are returned, limited to IDs below Before when it is set.
+	After  uint64
+	Before uint64
+	Limit  int
+}
+
+// Result is a page of entries in ID order.
+type Result struct {
+	Entries []Entry `json:"entries"`
+	More    bool    `json:"more"` // further matches exist beyond the limit
+}
+
+func (f *Filter) match(e Entry) bool {
+	if f.After > 0 && e.ID <= f.After {
+		return false
+	}
+	if f.Before > 0 && e.ID >= f.Before {
+		return false
+	}
+	if f.Level != "" && levelRank(e.Level) < levelRank(f.Level) {
+		return false
+	}
+	if !f.From.IsZero() && e.Time.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && e.Time.After(f.To) {
+		return false
+	}
+	if f.Text != "" && !strings.Contains(strings.ToLower(e.Raw), f.Text) {
+		return false
+	}
+	if f.Regex != nil && !f.Regex.MatchString(e.Raw) {
+		return false
+	}
+	for k, want := range f.Fields {
+		v, ok := e.Fields[k]
+		if !ok || fmt.Sprint(v) != want {
+			return false
+		}
+	}
+	return true
+}
+
+// Query returns the entries matching f from memory and, for older entries, from disk.
+func (s *Store) Query(f Filter) (Result, error) {
+	if !f.From.IsZero() && !f.To.IsZero() && f.To.Before(f.From) {
+		return Result{}, ErrBadRange
+	}
+	if f.Level != "" {
+		f.Level = normalizeLevel(f.Level)
+	}
+	f.Text = strings.ToLower(f.Text)
+	if f.Limit <= 0 {
+		f.Limit = defaultLimit
+	}
+	if f.Limit > maxLimit {
+		f.Limit = maxLimit
+	}
+
+	if f.After > 0 {
+		return s.forward(f)
+	}
+	return s.backward(f)
+}
+
+// Since returns up to limit entries after id, for replaying to a reconnecting client.
+func (s *Store) Since(id uint64, limit int) []Entry {
+	res, _ := s.Query(Filter{After: id, Limit: limit})
+	return res.Entries
+}
+
+// forward collects matches oldest first: disk entries older than the ring, then the ring.
+func (s *Store) forward(f Filter) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var res Result
+	add := func(e Entry) bool {
+		if !f.match(e) {
+			return true
+		}
+		if len(res.Entries) == f.Limit {
+			res.More = true
+			return false
+		}
+		res.Entries = append(res.Entries, e)
+		return true
+	}
+
+	n := s.count()
+	oldest := s.lastID + 1
+	if n > 0 {
+		oldest = s.at(0).ID
+	}
+
+	if s.disk != nil && f.After+1 < oldest {
+		s.disk.flush()
+		for _, seg := range s.disk.list {
+			if next := s.disk.nextFirstID(seg); next > 0 && next <= f.After+1 {
+				continue // entirely before After
+			}
+			stop := false
+			err := readSegment(seg.path, func(e Entry) bool {
+				if e.ID >= oldest {
+					stop = true
+					return false
+				}
+				if !add(e) {
+					stop = true
+					return false
+				}
+				return true
+			})
+			if err != nil {
+				return res, err
+			}
+			if stop {
+				break
+			}
+		}
+	}
+
+	if !res.More {
+		for i := 0; i < n; i++ {
+			if !add(s.at(i)) {
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+// backward collects the newest matches: the ring from newest to oldest, then disk
+// segments from newest to oldest for entries older than the ring.
+func (s *Store) backward(f Filter) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rev []Entry // newest first
+	more := false
+	add := func(e Entry) bool {
+		if !f.match(e) {
+			return true
+		}
+		if len(rev) == f.Limit {
+			more = true
+			return false
+		}
+		rev = append(rev, e)
+		return true
+	}
+
+	n := s.count()
+	oldest := s.lastID + 1
+	for i := n - 1; i >= 0; i-- {
+		e := s.at(i)
+		oldest = e.ID
+		if !f.From.IsZero() && e.Time.Before(f.From) {
+			oldest = 1 // everything older is out of range too
+			break
+		}
+		if !add(e) {
+			break
+		}
+	}
+
+	if s.disk != nil && !more && oldest > 1 {
+		s.disk.flush()
+		for i := len(s.disk.list) - 1; i >= 0 && !more; i-- {
+			seg := s.disk.list[i]
+			if seg.firstID >= oldest {
+				continue
+			}
+			if f.Before > 0 && seg.firstID >= f.Before {
+				continue
+			}
+
+			var page []Entry
+			err := readSegment(seg.path, func(e Entry) bool {
+				if e.ID >= oldest {
+					return false
+				}
+				if f.match(e) {
+					page = append(page, e)
+				}
+				return true
+			})
+			if err != nil {
+				return Result{}, err
+			}
+			for j := len(page) - 1; j >= 0; j-- {
+				if !add(page[j]) {
+					break
+				}
+			}
+			// Segments are in time order, so an older one cannot be in range
+			if len(page) == 0 && !f.From.IsZero() && s.disk.modTime(seg).Before(f.From) {
+				break
+			}
+		}
+	}
+
+	entries := make([]Entry, len(rev))
+	for i, e := range rev {
+		entries[len(rev)-1-i] = e
+	}
+	return Result{Entries: entries, More: more}, nil
+}
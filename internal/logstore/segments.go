@@ -0,0 +1,209 @@
+package logstore
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"test-go/config"
+)
+
+const segmentPrefix, segmentExt = "segment-", ".ndjson"
+
+// segment is one NDJSON file holding entries from firstID on.
+type segment struct {
+	path    string
+	firstID uint64
+}
+
+// segments appends entries to the newest file and rotates it by size. The Store
+// holds its lock around every call.
+type segments struct {
+	dir       string
+	maxBytes  int64
+	maxCount  int
+	retention time.Duration
+
+	list []segment // oldest first; the last one is open for writing
+	file *os.File
+	w    *bufio.Writer
+	size int64
+	err  error // last write error, reported in Stats
+}
+
+func segmentName(firstID uint64) string {
+	return fmt.Sprintf("%s%020d%s", segmentPrefix, firstID, segmentExt)
+}
+
+// openSegments lists existing segments in cfg.Dir and returns the last ID written.
+func openSegments(cfg config.LogStoreConfig) (*segments, uint64, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, 0, err
+	}
+
+	d := &segments{
+		dir:       cfg.Dir,
+		maxBytes:  int64(cfg.SegmentSizeMB) << 20,
+		maxCount:  cfg.MaxSegments,
+		retention: cfg.Retention,
+	}
+	if d.maxBytes <= 0 {
+		d.maxBytes = 16 << 20
+	}
+
+	names, err := filepath.Glob(filepath.Join(cfg.Dir, segmentPrefix+"*"+segmentExt))
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, name := range names {
+		id, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(name), segmentPrefix), segmentExt), 10, 64)
+		if err == nil {
+			d.list = append(d.list, segment{path: name, firstID: id})
+		}
+	}
+	sort.Slice(d.list, func(i, j int) bool { return d.list[i].firstID < d.list[j].firstID })
+
+	var lastID uint64
+	if n := len(d.list); n > 0 {
+		lastID = d.list[n-1].firstID - 1
+		readSegment(d.list[n-1].path, func(e Entry) bool {
+			lastID = e.ID
+			return true
+		})
+	}
+	d.prune(time.Now())
+	return d, lastID, nil
+}
+
+func (d *segments) append(e Entry) {
+	if d.file == nil || d.size >= d.maxBytes {
+		if err := d.rotate(e.ID); err != nil {
+			d.err = err
+			return
+		}
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		d.err = err
+		return
+	}
+	line = append(line, '\n')
+	if _, err := d.w.Write(line); err != nil {
+		d.err = err
+		return
+	}
+	d.size += int64(len(line))
+}
+
+// rotate closes the current segment and starts a new one at firstID.
+func (d *segments) rotate(firstID uint64) error {
+	if err := d.close(); err != nil {
+		return err
+	}
+
+	path := filepath.Join(d.dir, segmentName(firstID))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	d.file, d.w, d.size = f, bufio.NewWriterSize(f, 64<<10), fileSize(path)
+	if n := len(d.list); n == 0 || d.list[n-1].path != path {
+		d.list = append(d.list, segment{path: path, firstID: firstID})
+	}
+	d.prune(time.Now())
+	return nil
+}
+
+func (d *segments) flush() {
+	if d.w != nil {
+		if err := d.w.Flush(); err != nil {
+			d.err = err
+		}
+	}
+}
+
+func (d *segments) close() error {
+	if d.file == nil {
+		return nil
+	}
+	d.flush()
+	err := d.file.Close()
+	d.file, d.w = nil, nil
+	return err
+}
+
+// prune deletes the oldest segments beyond the count limit and those whose last
+// write is older than the retention. The segment open for writing is kept.
+func (d *segments) prune(now time.Time) {
+	keep := d.list[:0]
+	for i, seg := range d.list {
+		last := i == len(d.list)-1
+		tooMany := d.maxCount > 0 && len(d.list)-i > d.maxCount
+		expired := false
+		if d.retention > 0 {
+			if fi, err := os.Stat(seg.path); err == nil && now.Sub(fi.ModTime()) > d.retention {
+				expired = true
+			}
+		}
+		if !last && (tooMany || expired) {
+			os.Remove(seg.path)
+			continue
+		}
+		keep = append(keep, seg)
+	}
+	d.list = keep
+}
+
+// nextFirstID returns the first ID of the segment after seg, or 0 for the last one.
+func (d *segments) nextFirstID(seg segment) uint64 {
+	for i, s := range d.list {
+		if s.path == seg.path && i+1 < len(d.list) {
+			return d.list[i+1].firstID
+		}
+	}
+	return 0
+}
+
+func (d *segments) modTime(seg segment) time.Time {
+	if fi, err := os.Stat(seg.path); err == nil {
+		return fi.ModTime()
+	}
+	return time.Time{}
+}
+
+func (d *segments) usage() (int, int64) {
+	var total int64
+	for _, seg := range d.list {
+		total += fileSize(seg.path)
+	}
+	return len(d.list), total
+}
+
+// readSegment calls fn for each entry in path, in order, until fn returns false.
+func readSegment(path string, fn func(Entry) bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), maxLineBytes)
+	for sc.Scan() {
+		var e Entry
+		if json.Unmarshal(sc.Bytes(), &e) != nil {
+			continue // torn write at a crash
+		}
+		if !fn(e) {
+			return nil
+		}
+	}
+	return sc.Err()
+}
@@ -0,0 +1,354 @@
+// Package metricstore keeps the history behind the monitoring dashboard charts.
+// Sources are sampled on an interval; every point goes to an in-memory ring buffer
+// per metric and, in batches, to SQLite where it is rolled up from the sampling
+// interval into 1 minute and 1 hour aggregates, each kept for its own retention.
+package metricstore
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"test-go/config"
+	"test-go/pkg/logger"
+)
+
+// ErrBadRange is returned for queries whose end is before their start.
+var ErrBadRange = errors.New("metricstore: range end is before its start")
+
+const (
+	flushInterval  = 10 * time.Second
+	rollupInterval = time.Minute
+	maxPending     = 100000 // raw points buffered while the database is unavailable
+	maxPoints      = 2000   // points per query; larger ranges get a wider step
+	defaultPoints  = 300    // points per query when no step is given
+)
+
+// Point aggregates the samples in [T, T+step).
+type Point struct {
+	T     int64   `json:"t"` // Unix milliseconds
+	Avg   float64 `json:"avg"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int64   `json:"-"`
+}
+
+// Series is the result of a range query.
+type Series struct {
+	Name   string  `json:"name"`
+	From   int64   `json:"from"`
+	To     int64   `json:"to"`
+	Step   int64   `json:"step_ms"`
+	Source string  `json:"source"` // "memory" or the stored resolution used, e.g. "1m0s"
+	Points []Point `json:"points"`
+}
+
+// Source returns the current value of one or more metrics by name.
+// Sources are called from a single goroutine.
+type Source func() map[string]float64
+
+// tier is a stored resolution. tiers[0] holds raw samples at the sampling interval.
+type tier struct {
+	resolution time.Duration
+	retention  time.Duration
+}
+
+type sample struct {
+	name string
+	t    int64
+	v    float64
+}
+
+// History samples sources and answers range queries from memory or SQLite.
+type History struct {
+	store      *SQLStore
+	sources    []Source
+	interval   time.Duration
+	bufferSize int
+	tiers      []tier
+	logger     *logger.Logger
+
+	mu      sync.RWMutex
+	rings   map[string]*ring
+	pending []sample
+}
+
+// New starts sampling sources every cfg.Interval. A nil store keeps history in memory only.
+func New(store *SQLStore, cfg config.HistoryConfig, l *logger.Logger, sources ...Source) *History {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Second
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 360
+	}
+
+	h := &History{
+		store:      store,
+		sources:    sources,
+		interval:   cfg.Interval,
+		bufferSize: cfg.BufferSize,
+		tiers: []tier{
+			{resolution: cfg.Interval, retention: cfg.Retention.Raw},
+			{resolution: time.Minute, retention: cfg.Retention.Minute},
+			{resolution: time.Hour, retention: cfg.Retention.Hour},
+		},
+		logger: l,
+		rings:  make(map[string]*ring),
+	}
+
+	go h.sampleLoop()
+	if store != nil {
+		go h.persistLoop()
+	}
+	return h
+}
+
+// Names lists the sampled metrics.
+func (h *History) Names() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	names := make([]string, 0, len(h.rings))
+	for name := range h.rings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Latest returns the most recent sample of name, unless it is older than three
+// sampling intervals (the source stopped reporting it).
+func (h *History) Latest(name string) (float64, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	r := h.rings[name]
+	if r == nil {
+		return 0, false
+	}
+	t, v, ok := r.latest()
+	if !ok || time.Since(time.UnixMilli(t)) > 3*h.interval {
+		return 0, false
+	}
+	return v, true
+}
+
+// Query aggregates name over [from, to] into buckets of step. A zero step picks one
+// that yields about 300 points. Recent ranges are served from memory; older ones
+// from the finest stored resolution that still covers from.
+func (h *History) Query(ctx context.Context, name string, from, to time.Time, step time.Duration) (*Series, error) {
+	if to.Before(from) {
+		return nil, ErrBadRange
+	}
+	span := to.Sub(from)
+	if step <= 0 {
+		step = span / defaultPoints
+	}
+	if min := span / maxPoints; step < min {
+		step = min
+	}
+
+	t := h.pickTier(from, step)
+	if step < t.resolution {
+		step = t.resolution
+	}
+	step = step.Truncate(time.Millisecond)
+
+	fromMs, toMs, stepMs := from.UnixMilli(), to.UnixMilli(), step.Milliseconds()
+	series := &Series{Name: name, From: fromMs, To: toMs, Step: stepMs, Source: t.resolution.String()}
+
+	// Memory answers when it holds the whole range, or when there is nothing else
+	var raw []Point
+	if t == h.tiers[0] || h.store == nil {
+		h.mu.RLock()
+		if r := h.rings[name]; r != nil && (h.store == nil || r.oldest() <= fromMs) {
+			raw = r.between(fromMs, toMs)
+			series.Source = "memory"
+		}
+		h.mu.RUnlock()
+	}
+
+	if series.Source != "memory" && h.store != nil {
+		var err error
+		if raw, err = h.store.Range(ctx, name, t.resolution, fromMs, toMs); err != nil {
+			return nil, err
+		}
+		if t == h.tiers[0] {
+			raw = append(raw, h.pendingPoints(name, fromMs, toMs)...)
+		}
+	}
+
+	series.Points = aggregate(raw, stepMs)
+	return series, nil
+}
+
+// pickTier returns the finest tier that holds from and is not finer than step,
+// or, when step is finer than any tier holding from, the finest such tier.
+func (h *History) pickTier(from time.Time, step time.Duration) tier {
+	now := time.Now()
+	holds := func(t tier) bool { return t.retention <= 0 || !from.Before(now.Add(-t.retention)) }
+
+	for _, t := range h.tiers {
+		if holds(t) && t.resolution <= step {
+			return t
+		}
+	}
+	for _, t := range h.tiers {
+		if holds(t) {
+			return t
+		}
+	}
+	return h.tiers[len(h.tiers)-1]
+}
+
+func (h *History) pendingPoints(name string, fromMs, toMs int64) []Point {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var out []Point
+	for _, s := range h.pending {
+		if s.name == name && s.t >= fromMs && s.t <= toMs {
+			out = append(out, Point{T: s.t, Avg: s.v, Min: s.v, Max: s.v, Count: 1})
+		}
+	}
+	return out
+}
+
+func (h *History) sampleLoop() {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		h.sample(now)
+	}
+}
+
+func (h *History) sample(now time.Time) {
+	t := now.UnixMilli()
+	var batch []sample
+	for _, src := range h.sources {
+		for name, v := range src() {
+			batch = append(batch, sample{name: name, t: t, v: v})
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, s := range batch {
+		r := h.rings[s.name]
+		if r == nil {
+			r = newRing(h.bufferSize)
+			h.rings[s.name] = r
+		}
+		r.add(s.t, s.v)
+	}
+	if h.store != nil {
+		h.pending = append(h.pending, batch...)
+		if over := len(h.pending) - maxPending; over > 0 {
+			h.pending = h.pending[over:]
+		}
+	}
+}
+
+func (h *History) persistLoop() {
+	flush := time.NewTicker(flushInterval)
+	defer flush.Stop()
+	rollup := time.NewTicker(rollupInterval)
+	defer rollup.Stop()
+
+	for {
+		select {
+		case <-flush.C:
+			h.flush()
+		case <-rollup.C:
+			h.flush()
+			h.rollup()
+		}
+	}
+}
+
+func (h *History) flush() {
+	h.mu.Lock()
+	batch := h.pending
+	h.pending = nil
+	h.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := h.store.Insert(ctx, h.tiers[0].resolution, batch); err != nil {
+		h.logger.Error("Failed to persist metric history", err, "points", len(batch))
+
+		// Keep the points for the next attempt
+		h.mu.Lock()
+		h.pending = append(batch, h.pending...)
+		if over := len(h.pending) - maxPending; over > 0 {
+			h.pending = h.pending[over:]
+		}
+		h.mu.Unlock()
+	}
+}
+
+// rollup aggregates each tier into the next one up to the last complete bucket,
+// then drops points older than each tier's retention.
+func (h *History) rollup() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	now := time.Now()
+	for i := 1; i < len(h.tiers); i++ {
+		src, dst := h.tiers[i-1], h.tiers[i]
+		until := now.Truncate(dst.resolution).UnixMilli()
+		if err := h.store.Rollup(ctx, src.resolution, dst.resolution, until); err != nil {
+			h.logger.Error("Failed to roll up metric history", err, "resolution", dst.resolution.String())
+		}
+	}
+
+	for _, t := range h.tiers {
+		if t.retention <= 0 {
+			continue
+		}
+		if _, err := h.store.Prune(ctx, t.resolution, now.Add(-t.retention).UnixMilli()); err != nil {
+			h.logger.Error("Failed to prune metric history", err, "resolution", t.resolution.String())
+		}
+	}
+}
+
+// aggregate merges time-ordered points into buckets of stepMs aligned to the Unix epoch.
+func aggregate(points []Point, stepMs int64) []Point {
+	sort.Slice(points, func(i, j int) bool { return points[i].T < points[j].T })
+
+	out := []Point{}
+	var sum float64
+	for _, p := range points {
+		bucket := p.T - p.T%stepMs
+		n := len(out)
+		if n == 0 || out[n-1].T != bucket {
+			if n > 0 {
+				out[n-1].Avg = sum / float64(out[n-1].Count)
+			}
+			out = append(out, Point{T: bucket, Min: p.Min, Max: p.Max})
+			sum = 0
+			n++
+		}
+		b := &out[n-1]
+		b.Count += p.Count
+		sum += p.Avg * float64(p.Count)
+		if p.Min < b.Min {
+			b.Min = p.Min
+		}
+		if p.Max > b.Max {
+			b.Max = p.Max
+		}
+	}
+	if n := len(out); n > 0 {
+		out[n-1].Avg = sum / float64(out[n-1].Count)
+	}
+	return out
+}
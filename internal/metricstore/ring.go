@@ -0,0 +1,60 @@
+package metricstore
+
+// ring holds the most recent raw samples of one metric, oldest overwritten first.
+type ring struct {
+	t    []int64
+	v    []float64
+	next int
+	full bool
+}
+
+func newRing(size int) *ring {
+	return &ring{t: make([]int64, size), v: make([]float64, size)}
+}
+
+func (r *ring) add(t int64, v float64) {
+	r.t[r.next] = t
+	r.v[r.next] = v
+	r.next = (r.next + 1) % len(r.t)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// oldest returns the time of the oldest sample held, or -1 when empty.
+func (r *ring) oldest() int64 {
+	switch {
+	case r.full:
+		return r.t[r.next]
+	case r.next > 0:
+		return r.t[0]
+	default:
+		return -1
+	}
+}
+
+// between returns the samples in [from, to] in time order.
+func (r *ring) between(from, to int64) []Point {
+	start, n := 0, r.next
+	if r.full {
+		start, n = r.next, len(r.t)
+	}
+
+	var out []Point
+	for i := 0; i < n; i++ {
+		j := (start + i) % len(r.t)
+		if r.t[j] >= from && r.t[j] <= to {
+			out = append(out, Point{T: r.t[j], Avg: r.v[j], Min: r.v[j], Max: r.v[j], Count: 1})
+		}
+	}
+	return out
+}
+
+// latest returns the most recent sample.
+func (r *ring) latest() (int64, float64, bool) {
+	if !r.full && r.next == 0 {
+		return 0, 0, false
+	}
+	i := (r.next - 1 + len(r.t)) % len(r.t)
+	return r.t[i], r.v[i], true
+}
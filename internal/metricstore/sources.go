@@ -0,0 +1,158 @@
+package metricstore
+
+import (
+	"runtime"
+	"time"
+
+	"test-go/pkg/infrastructure"
+	"test-go/pkg/metrics"
+	"test-go/pkg/sysstats"
+)
+
+// rate turns a monotonically increasing total into a per-second rate.
+// The first observation only sets the baseline.
+type rate struct {
+	last float64
+	at   time.Time
+}
+
+func (r *rate) observe(total float64, now time.Time) (float64, bool) {
+	prev, prevAt := r.last, r.at
+	r.last, r.at = total, now
+	if prevAt.IsZero() || total < prev {
+		return 0, false
+	}
+	return (total - prev) / now.Sub(prevAt).Seconds(), true
+}
+
+// SystemSource reads host (or, in a container, cgroup) CPU and memory usage, root
+// disk usage and network rates from the shared sampler.
+func SystemSource(s *sysstats.Sampler) Source {
+	return func() map[string]float64 {
+		smp, ok := s.Latest()
+		if !ok {
+			return nil
+		}
+		m := map[string]float64{
+			"system.cpu.percent":          smp.CPU.Percent,
+			"system.memory.percent":       smp.Memory.UsedPercent,
+			"system.memory.used_bytes":    float64(smp.Memory.Used),
+			"system.network.sent_per_sec": smp.Network.SentPerSec,
+			"system.network.recv_per_sec": smp.Network.RecvPerSec,
+		}
+		if smp.Disk.Total > 0 {
+			m["system.disk.percent"] = smp.Disk.UsedPercent
+		}
+		return m
+	}
+}
+
+// ProcessSource reads the CPU, resident memory and open file descriptors of this
+// process from the shared sampler.
+func ProcessSource(s *sysstats.Sampler) Source {
+	return func() map[string]float64 {
+		smp, ok := s.Latest()
+		if !ok {
+			return nil
+		}
+		return map[string]float64{
+			"process.cpu.percent":      smp.Process.CPUPercent,
+			"process.memory.rss_bytes": float64(smp.Process.RSS),
+			"process.open_fds":         float64(smp.Process.OpenFDs),
+			"process.threads":          float64(smp.Process.Threads),
+		}
+	}
+}
+
+// RuntimeSource samples goroutines, heap usage and garbage collection activity.
+func RuntimeSource() Source {
+	var gcs, pause rate
+
+	return func() map[string]float64 {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		now := time.Now()
+
+		m := map[string]float64{
+			"go.goroutines":       float64(runtime.NumGoroutine()),
+			"go.heap.alloc_bytes": float64(ms.HeapAlloc),
+			"go.heap.objects":     float64(ms.HeapObjects),
+		}
+		if v, ok := gcs.observe(float64(ms.NumGC), now); ok {
+			m["go.gc.per_sec"] = v
+		}
+		if v, ok := pause.observe(float64(ms.PauseTotalNs)/1e6, now); ok {
+			m["go.gc.pause_ms_per_sec"] = v
+		}
+		return m
+	}
+}
+
+// HTTPSource samples API request and 5xx rates, average latency and in-flight requests.
+func HTTPSource(h *metrics.HTTP) Source {
+	var requests, errors rate
+	var lastCount, lastSeconds float64
+	started := false
+
+	return func() map[string]float64 {
+		t := h.Totals()
+		now := time.Now()
+
+		m := map[string]float64{"http.requests.in_flight": t.InFlight}
+		if v, ok := requests.observe(float64(t.Requests), now); ok {
+			m["http.requests.per_sec"] = v
+		}
+		if v, ok := errors.observe(float64(t.ServerErrors), now); ok {
+			m["http.errors.per_sec"] = v
+		}
+
+		// Mean latency of the requests completed since the previous sample
+		count := float64(t.Requests)
+		if n := count - lastCount; n > 0 && started {
+			m["http.latency.avg_ms"] = (t.DurationSeconds - lastSeconds) / n * 1000
+		}
+		lastCount, lastSeconds, started = count, t.DurationSeconds, true
+		return m
+	}
+}
+
+// RedisSource samples the connection pool of r.
+func RedisSource(r *infrastructure.RedisManager) Source {
+	return func() map[string]float64 {
+		s := r.Client.PoolStats()
+		return map[string]float64{
+			"redis.pool.connections": float64(s.TotalConns),
+			"redis.pool.idle":        float64(s.IdleConns),
+		}
+	}
+}
+
+// PostgresSource samples the connection pool of p.
+func PostgresSource(p *infrastructure.PostgresManager) Source {
+	return func() map[string]float64 {
+		s := p.DB.Stats()
+		return map[string]float64{
+			"postgres.connections.open":   float64(s.OpenConnections),
+			"postgres.connections.in_use": float64(s.InUse),
+		}
+	}
+}
+
+// KafkaSource samples produce and consume rates of k.
+func KafkaSource(k *infrastructure.KafkaManager) Source {
+	var produced, consumed rate
+
+	return func() map[string]float64 {
+		s := k.Stats()
+		now := time.Now()
+
+		m := make(map[string]float64)
+		if v, ok := produced.observe(float64(s.Produced), now); ok {
+			m["kafka.produced.per_sec"] = v
+		}
+		if v, ok := consumed.observe(float64(s.Consumed), now); ok {
+			m["kafka.consumed.per_sec"] = v
+		}
+		return m
+	}
+}
@@ -0,0 +1,116 @@
+package metricstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SQLStore keeps metric points in the metric_points table of the monitoring SQLite
+// database. Each row aggregates Count samples at one resolution; raw samples are
+// rows with Count 1. Times and resolutions are stored in milliseconds.
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore creates the metric_points table if needed.
+func NewSQLStore(db *sql.DB) (*SQLStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("metric store: database is not available")
+	}
+
+	schema := `CREATE TABLE IF NOT EXISTS metric_points (
+		name TEXT NOT NULL,
+		resolution INTEGER NOT NULL,
+		ts INTEGER NOT NULL,
+		avg REAL NOT NULL,
+		min REAL NOT NULL,
+		max REAL NOT NULL,
+		count INTEGER NOT NULL,
+		PRIMARY KEY (resolution, name, ts)
+	) WITHOUT ROWID`
+	if _, err := db.Exec(schema); err != nil {
+		return nil, fmt.Errorf("metric store: failed to create schema: %w", err)
+	}
+
+	return &SQLStore{db: db}, nil
+}
+
+// Insert writes raw samples at resolution in a single transaction.
+func (s *SQLStore) Insert(ctx context.Context, resolution time.Duration, samples []sample) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT OR REPLACE INTO metric_points (name, resolution, ts, avg, min, max, count) VALUES (?, ?, ?, ?, ?, ?, 1)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	res := resolution.Milliseconds()
+	for _, p := range samples {
+		if _, err := stmt.ExecContext(ctx, p.name, res, p.t, p.v, p.v, p.v); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Rollup aggregates points at resolution src into dst buckets ending before until.
+// It starts at the latest dst bucket, which may have been written while incomplete.
+func (s *SQLStore) Rollup(ctx context.Context, src, dst time.Duration, until int64) error {
+	srcMs, dstMs := src.Milliseconds(), dst.Milliseconds()
+
+	var from int64
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT COALESCE(MAX(ts), 0) FROM metric_points WHERE resolution = ?`, dstMs).Scan(&from); err != nil {
+		return err
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT OR REPLACE INTO metric_points (name, resolution, ts, avg, min, max, count)
+		SELECT name, ?, (ts / ?) * ?, SUM(avg * count) / SUM(count), MIN(min), MAX(max), SUM(count)
+		FROM metric_points
+		WHERE resolution = ? AND ts >= ? AND ts < ?
+		GROUP BY name, ts / ?`,
+		dstMs, dstMs, dstMs, srcMs, from, until, dstMs)
+	return err
+}
+
+// Range returns the points of name at resolution within [from, to], oldest first.
+func (s *SQLStore) Range(ctx context.Context, name string, resolution time.Duration, from, to int64) ([]Point, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT ts, avg, min, max, count FROM metric_points
+		WHERE resolution = ? AND name = ? AND ts >= ? AND ts <= ?
+		ORDER BY ts`,
+		resolution.Milliseconds(), name, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []Point
+	for rows.Next() {
+		var p Point
+		if err := rows.Scan(&p.T, &p.Avg, &p.Min, &p.Max, &p.Count); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// Prune deletes points at resolution older than before and returns how many were removed.
+func (s *SQLStore) Prune(ctx context.Context, resolution time.Duration, before int64) (int64, error) {
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM metric_points WHERE resolution = ? AND ts < ?`, resolution.Milliseconds(), before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"test-go/config"
+	"test-go/pkg/logger"
+	"test-go/pkg/tracing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// DefaultAccessLogFields is used when access_log.fields is empty.
+var DefaultAccessLogFields = []string{
+	"request_id", "trace_id", "remote_ip", "method", "path", "route", "status",
+	"latency_ms", "bytes_in", "bytes_out", "user_agent",
+}
+
+// NewAccessLogWriter opens the sink configured in cfg.Output.
+// "stdout" writes JSON lines to standard output; "file" writes to a rotating file.
+func NewAccessLogWriter(cfg config.AccessLogConfig) (io.WriteCloser, error) {
+	switch strings.ToLower(cfg.Output) {
+	case "", "stdout":
+		return nopCloser{os.Stdout}, nil
+	case "file":
+		return logger.NewRotatingFile(logger.RotateConfig{
+			Path:           cfg.File.Path,
+			MaxSizeMB:      cfg.File.MaxSizeMB,
+			RotateInterval: cfg.File.RotateInterval,
+			MaxBackups:     cfg.File.MaxBackups,
+			MaxAge:         cfg.File.MaxAge,
+			Compress:       cfg.File.Compress,
+		})
+	default:
+		return nil, fmt.Errorf("unknown access log output %q", cfg.Output)
+	}
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// AccessLog writes one structured JSON event per request to w.
+//
+// Excluded path prefixes are never logged. Successful requests are sampled at
+// cfg.SampleRate; 4xx and 5xx responses are always logged.
+func AccessLog(cfg config.AccessLogConfig, w io.Writer) echo.MiddlewareFunc {
+	fields := cfg.Fields
+	if len(fields) == 0 {
+		fields = DefaultAccessLogFields
+	}
+	sampleRate := cfg.SampleRate
+	if sampleRate <= 0 || sampleRate > 1 {
+		sampleRate = 1
+	}
+
+	z := zerolog.New(w).With().Timestamp().Logger()
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if isExcludedPath(cfg.ExcludePaths, req.URL.Path) {
+				return next(c)
+			}
+
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				// Let the error handler write the response so status and size are final
+				c.Error(err)
+			}
+
+			res := c.Response()
+			if res.Status < 400 && sampleRate < 1 && rand.Float64() >= sampleRate {
+				return nil
+			}
+
+			level := zerolog.InfoLevel
+			if res.Status >= 500 {
+				level = zerolog.ErrorLevel
+			} else if res.Status >= 400 {
+				level = zerolog.WarnLevel
+			}
+
+			e := z.WithLevel(level)
+			for _, f := range fields {
+				addAccessField(e, f, c, start, err)
+			}
+			e.Msg("access")
+			return nil
+		}
+	}
+}
+
+func addAccessField(e *zerolog.Event, field string, c echo.Context, start time.Time, err error) {
+	req := c.Request()
+	res := c.Response()
+
+	switch field {
+	case "request_id":
+		id := res.Header().Get(echo.HeaderXRequestID)
+		if id == "" {
+			id = req.Header.Get(echo.HeaderXRequestID)
+		}
+		e.Str(field, id)
+	case "trace_id":
+		if id := tracing.TraceID(req.Context()); id != "" {
+			e.Str(field, id)
+		}
+	case "remote_ip":
+		e.Str(field, c.RealIP())
+	case "method":
+		e.Str(field, req.Method)
+	case "host":
+		e.Str(field, req.Host)
+	case "uri":
+		e.Str(field, req.RequestURI)
+	case "path":
+		e.Str(field, req.URL.Path)
+	case "route":
+		e.Str(field, c.Path())
+	case "query":
+		e.Str(field, req.URL.RawQuery)
+	case "protocol":
+		e.Str(field, req.Proto)
+	case "status":
+		e.Int(field, res.Status)
+	case "latency_ms":
+		e.Float64(field, float64(time.Since(start).Microseconds())/1000)
+	case "bytes_in":
+		n, _ := strconv.ParseInt(req.Header.Get(echo.HeaderContentLength), 10, 64)
+		e.Int64(field, n)
+	case "bytes_out":
+		e.Int64(field, res.Size)
+	case "user_agent":
+		e.Str(field, req.UserAgent())
+	case "referer":
+		e.Str(field, req.Referer())
+	case "error":
+		if err != nil {
+			e.Str(field, err.Error())
+		}
+	default:
+		// Any other name is read from the request headers, e.g. "x-tenant-id"
+		if v := req.Header.Get(field); v != "" {
+			e.Str(field, v)
+		}
+	}
+}
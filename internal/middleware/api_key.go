@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"test-go/config"
+	"test-go/internal/apikey"
+	"test-go/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ContextKeyAPIKey holds the authenticated *apikey.Key.
+const ContextKeyAPIKey = "api_key"
+
+// APIKeyAuth requires a valid API key on requests under cfg.ProtectedPaths. The key is read
+// from cfg.Header or an "Authorization: Bearer" header. GET and HEAD need the read scope,
+// every other method needs write. With no manager (m is nil because the key store failed
+// to open) protected paths fail closed with 503.
+func APIKeyAuth(m *apikey.Manager, cfg config.APIKeysConfig) echo.MiddlewareFunc {
+	header := cfg.Header
+	if header == "" {
+		header = "X-API-Key"
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if !pathProtected(req.URL.Path, cfg.ProtectedPaths) {
+				return next(c)
+			}
+			if m == nil {
+				return response.ServiceUnavailable(c, "API key validation is unavailable")
+			}
+
+			raw := req.Header.Get(header)
+			if raw == "" {
+				if token, ok := strings.CutPrefix(req.Header.Get(echo.HeaderAuthorization), "Bearer "); ok {
+					raw = strings.TrimSpace(token)
+				}
+			}
+			if raw == "" {
+				return response.Unauthorized(c, "API key required")
+			}
+
+			key, err := m.Authenticate(req.Context(), raw, c.RealIP())
+			switch {
+			case errors.Is(err, apikey.ErrRevoked):
+				return response.Unauthorized(c, "API key has been revoked")
+			case errors.Is(err, apikey.ErrExpired):
+				return response.Unauthorized(c, "API key has expired")
+			case errors.Is(err, apikey.ErrInvalidKey):
+				return response.Unauthorized(c, "Invalid API key")
+			case err != nil:
+				return response.ServiceUnavailable(c, "API key validation is unavailable")
+			}
+
+			scope := apikey.ScopeWrite
+			if req.Method == http.MethodGet || req.Method == http.MethodHead {
+				scope = apikey.ScopeRead
+			}
+			if !key.HasScope(scope) {
+				return response.Forbidden(c, "API key lacks the "+scope+" scope")
+			}
+
+			c.Set(ContextKeyAPIKey, key)
+			c.Set(ContextKeySubject, "apikey:"+key.Name)
+			return next(c)
+		}
+	}
+}
+
+func pathProtected(path string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if path == p || strings.HasPrefix(path, strings.TrimSuffix(p, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
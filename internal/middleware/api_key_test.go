@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"test-go/config"
+	"test-go/internal/apikey"
+	"test-go/pkg/logger"
+
+	"github.com/labstack/echo/v4"
+)
+
+// keyStore keeps API keys in memory; failing makes lookups return an error.
+type keyStore struct {
+	mu      sync.Mutex
+	keys    map[string]apikey.Key
+	failing bool
+}
+
+func (s *keyStore) Insert(_ context.Context, k apikey.Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[k.ID] = k
+	return nil
+}
+
+func (s *keyStore) Get(_ context.Context, id string) (*apikey.Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failing {
+		return nil, errors.New("store down")
+	}
+	k, ok := s.keys[id]
+	if !ok {
+		return nil, apikey.ErrNotFound
+	}
+	return &k, nil
+}
+
+func (s *keyStore) List(context.Context) ([]apikey.Key, error) { return nil, nil }
+
+func (s *keyStore) Revoke(_ context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := s.keys[id]
+	k.RevokedAt = &at
+	s.keys[id] = k
+	return nil
+}
+
+func (s *keyStore) TouchLastUsed(context.Context, string, time.Time, string) error { return nil }
+
+func TestAPIKeyAuth(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.APIKeysConfig{Header: "X-API-Key", ProtectedPaths: []string{"/api/v1/orders"}}
+	m := apikey.NewManager(&keyStore{keys: make(map[string]apikey.Key)}, "sk", time.Minute, logger.NewQuiet(false, nil))
+
+	readKey, _, _ := m.Create(ctx, "dashboard", []string{apikey.ScopeRead}, nil, "admin")
+	writeKey, _, _ := m.Create(ctx, "importer", []string{apikey.ScopeRead, apikey.ScopeWrite}, nil, "admin")
+	allKey, _, _ := m.Create(ctx, "ops", []string{apikey.ScopeAll}, nil, "admin")
+	revokedKey, revoked, _ := m.Create(ctx, "leaked", []string{apikey.ScopeAll}, nil, "admin")
+	if _, err := m.Revoke(ctx, revoked.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	failing := &keyStore{keys: make(map[string]apikey.Key), failing: true}
+	brokenManager := apikey.NewManager(failing, "sk", time.Minute, logger.NewQuiet(false, nil))
+
+	tests := []struct {
+		name        string
+		manager     *apikey.Manager
+		method      string
+		path        string
+		header      string // X-API-Key
+		bearer      string // Authorization: Bearer
+		wantStatus  int
+		wantSubject string
+	}{
+		{name: "unprotected path needs no key", manager: m, method: http.MethodGet, path: "/api/v1/tasks", wantStatus: http.StatusOK, wantSubject: "anonymous"},
+		{name: "missing key", manager: m, method: http.MethodGet, path: "/api/v1/orders", wantStatus: http.StatusUnauthorized},
+		{name: "malformed key", manager: m, method: http.MethodGet, path: "/api/v1/orders", header: "not-a-key", wantStatus: http.StatusUnauthorized},
+		{name: "key with the wrong secret", manager: m, method: http.MethodGet, path: "/api/v1/orders", header: readKey[:len(readKey)-1] + "x", wantStatus: http.StatusUnauthorized},
+		{name: "read key reads", manager: m, method: http.MethodGet, path: "/api/v1/orders/7", header: readKey, wantStatus: http.StatusOK, wantSubject: "apikey:dashboard"},
+		{name: "read key sending POST", manager: m, method: http.MethodPost, path: "/api/v1/orders", header: readKey, wantStatus: http.StatusForbidden},
+		{name: "read key sending DELETE", manager: m, method: http.MethodDelete, path: "/api/v1/orders/7", header: readKey, wantStatus: http.StatusForbidden},
+		{name: "write key sending POST", manager: m, method: http.MethodPost, path: "/api/v1/orders", header: writeKey, wantStatus: http.StatusOK, wantSubject: "apikey:importer"},
+		{name: "wildcard scope", manager: m, method: http.MethodPatch, path: "/api/v1/orders/7", header: allKey, wantStatus: http.StatusOK, wantSubject: "apikey:ops"},
+		{name: "bearer token", manager: m, method: http.MethodHead, path: "/api/v1/orders", bearer: readKey, wantStatus: http.StatusOK, wantSubject: "apikey:dashboard"},
+		{name: "revoked key", manager: m, method: http.MethodGet, path: "/api/v1/orders", header: revokedKey, wantStatus: http.StatusUnauthorized},
+		{name: "store failure", manager: brokenManager, method: http.MethodGet, path: "/api/v1/orders", header: readKey, wantStatus: http.StatusServiceUnavailable},
+		{name: "no manager fails closed", method: http.MethodGet, path: "/api/v1/orders", header: readKey, wantStatus: http.StatusServiceUnavailable},
+		{name: "no manager leaves other paths open", method: http.MethodGet, path: "/api/v1/tasks", wantStatus: http.StatusOK, wantSubject: "anonymous"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.Use(APIKeyAuth(tt.manager, cfg))
+			var subject string
+			e.Any("/*", func(c echo.Context) error {
+				subject = SubjectFromContext(c)
+				return c.NoContent(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("X-API-Key", tt.header)
+			}
+			if tt.bearer != "" {
+				req.Header.Set(echo.HeaderAuthorization, "Bearer "+tt.bearer)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if subject != tt.wantSubject {
+				t.Errorf("subject = %q, want %q", subject, tt.wantSubject)
+			}
+		})
+	}
+}
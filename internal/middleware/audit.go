@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"test-go/internal/audit"
+	"test-go/internal/redact"
+	"test-go/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ContextKeySubject is the echo context key auth middleware uses to store the
+// authenticated caller. Audit and other middlewares read it to attribute requests.
+const ContextKeySubject = "auth_subject"
+
+// Audit records every POST, PUT, PATCH and DELETE on the public API.
+// The first maxBody bytes of the request body, redacted, are kept as the "after" summary;
+// the handler still receives the whole body.
+func Audit(r *audit.Recorder, maxBody int, redactFields []string) echo.MiddlewareFunc {
+	if maxBody <= 0 {
+		maxBody = 2048
+	}
+	redactor := redact.New(nil, redactFields)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if !isWriteMethod(req.Method) {
+				return next(c)
+			}
+
+			var body []byte
+			if req.Body != nil && req.Body != http.NoBody {
+				head, err := io.ReadAll(io.LimitReader(req.Body, int64(maxBody)+1))
+				if err != nil {
+					return response.BadRequest(c, "Failed to read request body")
+				}
+				req.Body = readCloser{io.MultiReader(bytes.NewReader(head), req.Body), req.Body}
+				body = head
+			}
+
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			status := c.Response().Status
+			entry := audit.Entry{
+				Actor:     SubjectFromContext(c),
+				ActorType: audit.ActorAPI,
+				Action:    req.Method + " " + c.Path(),
+				Target:    req.URL.Path,
+				RequestID: response.CorrelationID(c),
+				IP:        c.RealIP(),
+				After:     audit.Summarize(body, maxBody, redactor),
+				Outcome:   audit.OutcomeSuccess,
+				Status:    status,
+			}
+			if status >= http.StatusBadRequest {
+				entry.Outcome = audit.OutcomeFailure
+			}
+			if err != nil {
+				entry.Error = err.Error()
+			}
+			r.Record(entry)
+			return nil
+		}
+	}
+}
+
+// SubjectFromContext returns the authenticated caller, or "anonymous".
+func SubjectFromContext(c echo.Context) string {
+	if s, ok := c.Get(ContextKeySubject).(string); ok && s != "" {
+		return s
+	}
+	return "anonymous"
+}
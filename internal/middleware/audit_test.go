@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"test-go/internal/audit"
+	"test-go/pkg/logger"
+
+	"github.com/labstack/echo/v4"
+)
+
+// auditSink hands recorded entries to the test.
+type auditSink chan audit.Entry
+
+func (s auditSink) Insert(_ context.Context, e audit.Entry) error {
+	s <- e
+	return nil
+}
+
+func (s auditSink) Search(context.Context, audit.Filter) ([]audit.Entry, int64, error) {
+	return nil, 0, nil
+}
+
+func (s auditSink) Prune(context.Context, time.Time) (int64, error) { return 0, nil }
+
+func TestAuditBody(t *testing.T) {
+	long := `{"name":"ann","password":"hunter2","note":"` + strings.Repeat("x", 200) + `"}`
+	tests := []struct {
+		name      string
+		body      string
+		wantAfter string // prefix of the summary
+		wantCut   bool
+	}{
+		{name: "small body is redacted", body: `{"name":"ann","password":"hunter2"}`, wantAfter: `{"name":"ann","password":"[REDACTED]"}`},
+		{name: "large body is cut and still redacted", body: long, wantAfter: `{"name":"ann","password":"[REDACTED]","note":"x`, wantCut: true},
+		{name: "empty body", body: "", wantAfter: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := make(auditSink, 1)
+			e := echo.New()
+			e.Use(Audit(audit.NewRecorder(sink, 0, logger.NewQuiet(false, nil)), 64, []string{"password"}))
+			var received string
+			e.POST("/users", func(c echo.Context) error {
+				b, _ := io.ReadAll(c.Request().Body)
+				received = string(b)
+				return c.NoContent(http.StatusCreated)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			e.ServeHTTP(httptest.NewRecorder(), req)
+
+			if received != tt.body {
+				t.Errorf("handler read %d bytes, want the full %d", len(received), len(tt.body))
+			}
+			entry := <-sink
+			if !strings.HasPrefix(entry.After, tt.wantAfter) || strings.Contains(entry.After, "hunter2") {
+				t.Errorf("after = %s, want %s", entry.After, tt.wantAfter)
+			}
+			if cut := strings.HasSuffix(entry.After, "…"); cut != tt.wantCut {
+				t.Errorf("after = %s, truncated = %v, want %v", entry.After, cut, tt.wantCut)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"test-go/config"
+	"test-go/internal/capture"
+	"test-go/internal/redact"
+	"test-go/pkg/logger"
+	"test-go/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Capture records full request/response pairs into store for requests that carry a
+// token from signer in cfg.Header, fall under cfg.Paths, or are picked by cfg.SampleRate.
+// Invalid tokens are ignored, so clients cannot fill the store on their own.
+// Bodies are size-capped and sensitive headers and fields are redacted before storage.
+func Capture(cfg config.CaptureConfig, store *capture.Store, signer *logger.DebugSigner) echo.MiddlewareFunc {
+	maxBody := cfg.MaxBodyBytes
+	if maxBody <= 0 {
+		maxBody = 16 * 1024
+	}
+	redactor := redact.New(cfg.RedactHeaders, cfg.RedactFields)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if isExcludedPath(cfg.ExcludePaths, req.URL.Path) {
+				return next(c)
+			}
+			trigger := captureTrigger(cfg, signer, req)
+			if trigger == "" {
+				return next(c)
+			}
+
+			start := time.Now()
+
+			// Keep the first maxBody bytes and hand the handler an untouched stream
+			var reqBody []byte
+			reqSize := req.ContentLength
+			if req.Body != nil && req.Body != http.NoBody {
+				head, _ := io.ReadAll(io.LimitReader(req.Body, int64(maxBody)+1))
+				req.Body = readCloser{io.MultiReader(bytes.NewReader(head), req.Body), req.Body}
+				reqBody = head
+				if reqSize < 0 {
+					reqSize = int64(len(head))
+				}
+			}
+			reqTruncated := len(reqBody) > maxBody
+			if reqTruncated {
+				reqBody = reqBody[:maxBody]
+			}
+
+			tee := &teeWriter{ResponseWriter: c.Response().Writer, limit: maxBody}
+			c.Response().Writer = tee
+
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			res := c.Response()
+			store.Add(&capture.Capture{
+				Time:            start,
+				DurationMs:      float64(time.Since(start).Microseconds()) / 1000,
+				Trigger:         trigger,
+				RequestID:       response.CorrelationID(c),
+				ClientIP:        c.RealIP(),
+				Method:          req.Method,
+				URL:             redactor.URL(fullURL(c)),
+				Path:            req.URL.Path,
+				Route:           c.Path(),
+				Proto:           req.Proto,
+				RequestHeaders:  redactor.Headers(req.Header),
+				RequestBody:     capture.NewBody(redactor.Body(req.Header.Get(echo.HeaderContentType), reqBody), reqSize, reqTruncated),
+				Status:          res.Status,
+				ResponseHeaders: redactor.Headers(res.Header()),
+				ResponseBody:    capture.NewBody(redactor.Body(res.Header().Get(echo.HeaderContentType), tee.body.Bytes()), tee.size, tee.size > int64(tee.body.Len())),
+			})
+			return nil
+		}
+	}
+}
+
+func captureTrigger(cfg config.CaptureConfig, signer *logger.DebugSigner, req *http.Request) string {
+	if signer != nil && cfg.Header != "" {
+		if token := req.Header.Get(cfg.Header); token != "" && signer.Verify(token) {
+			return capture.TriggerHeader
+		}
+	}
+	for _, p := range cfg.Paths {
+		if strings.HasPrefix(req.URL.Path, p) {
+			return capture.TriggerPath
+		}
+	}
+	if cfg.SampleRate > 0 && rand.Float64() < cfg.SampleRate {
+		return capture.TriggerSample
+	}
+	return ""
+}
+
+// fullURL rebuilds the absolute request URL as the client sent it.
+func fullURL(c echo.Context) *url.URL {
+	req := c.Request()
+	u := *req.URL
+	u.Scheme = c.Scheme()
+	u.Host = req.Host
+	return &u
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// teeWriter passes writes through while keeping the first limit bytes.
+type teeWriter struct {
+	http.ResponseWriter
+	body  bytes.Buffer
+	limit int
+	size  int64
+}
+
+func (w *teeWriter) Write(b []byte) (int, error) {
+	if room := w.limit - w.body.Len(); room > 0 {
+		if room > len(b) {
+			room = len(b)
+		}
+		w.body.Write(b[:room])
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *teeWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"test-go/config"
+	"test-go/internal/capture"
+	"test-go/internal/redact"
+	"test-go/pkg/logger"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestCaptureRedaction(t *testing.T) {
+	const secret = "s3cr3t-value"
+	cfg := config.CaptureConfig{
+		Header:        "X-Capture",
+		RedactHeaders: []string{"Authorization", "Cookie", "Set-Cookie", "X-API-Key"},
+		RedactFields:  []string{"password", "secret", "token", "api_key"},
+	}
+	signer := logger.NewDebugSigner("test", time.Hour)
+	token, _ := signer.Sign(time.Minute)
+
+	tests := []struct {
+		name        string
+		token       string
+		contentType string
+		body        string
+		wantStored  bool
+	}{
+		{name: "JSON body", token: token, contentType: echo.MIMEApplicationJSON, body: `{"user":"ann","password":"` + secret + `"}`, wantStored: true},
+		{name: "form body", token: token, contentType: echo.MIMEApplicationForm, body: "user=ann&password=" + secret, wantStored: true},
+		{name: "truncated JSON body", token: token, contentType: echo.MIMEApplicationJSON, body: `{"user":"ann","password":"` + secret + `","pad":"` + strings.Repeat("x", 100) + `"}`, wantStored: true},
+		{name: "forged token is ignored", token: "9999999999.forged", contentType: echo.MIMEApplicationJSON, body: `{}`},
+		{name: "no token", contentType: echo.MIMEApplicationJSON, body: `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := cfg
+			cfg.MaxBodyBytes = 64
+			store := capture.NewStore(10)
+
+			e := echo.New()
+			e.Use(Capture(cfg, store, signer))
+			e.POST("/login", func(c echo.Context) error {
+				var in map[string]interface{}
+				_ = c.Bind(&in)
+				c.Response().Header().Add("Set-Cookie", "session="+secret)
+				return c.JSON(http.StatusOK, map[string]string{"user": "ann", "access_token": secret})
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/login?api_key="+secret+"&page=1", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, tt.contentType)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+secret)
+			req.Header.Set("Cookie", "session="+secret)
+			req.Header.Set("X-API-Key", secret)
+			if tt.token != "" {
+				req.Header.Set("X-Capture", tt.token)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), secret) {
+				t.Fatalf("client got %d %s, want the unredacted response", rec.Code, rec.Body.String())
+			}
+
+			captures := store.Select(nil, capture.Filter{})
+			if !tt.wantStored {
+				if len(captures) != 0 {
+					t.Fatalf("captured %d requests, want none", len(captures))
+				}
+				return
+			}
+			if len(captures) != 1 {
+				t.Fatalf("captured %d requests, want 1", len(captures))
+			}
+
+			stored, _ := json.Marshal(captures[0])
+			har, _ := json.Marshal(capture.ToHAR(captures, "test", "1"))
+			for what, data := range map[string][]byte{"stored capture": stored, "HAR export": har} {
+				if strings.Contains(string(data), secret) {
+					t.Errorf("%s leaks the secret: %s", what, data)
+				}
+			}
+			if got := captures[0].RequestHeaders.Get(echo.HeaderAuthorization); got != redact.Mask {
+				t.Errorf("Authorization = %q, want it redacted", got)
+			}
+			if !strings.Contains(captures[0].RequestBody.Text, "ann") {
+				t.Errorf("request body %q lost its non-sensitive fields", captures[0].RequestBody.Text)
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"test-go/config"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	encodingBrotli = "br"
+	encodingZstd   = "zstd"
+	encodingGzip   = "gzip"
+)
+
+// Encoders are pooled per encoding; building a zstd or brotli encoder is not cheap.
+var encoderPools = map[string]*sync.Pool{
+	encodingGzip: {New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	}},
+	encodingBrotli: {New: func() interface{} {
+		return brotli.NewWriterLevel(io.Discard, 4) // good ratio at interactive speed
+	}},
+	encodingZstd: {New: func() interface{} {
+		w, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithEncoderConcurrency(1))
+		return w
+	}},
+}
+
+// encoder is the common surface of the pooled gzip, brotli and zstd writers.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Compress negotiates gzip, brotli or zstd from Accept-Encoding and compresses eligible responses.
+//
+// A response is compressed only when its Content-Type is in the allowlist, it reaches the
+// minimum size, and no Content-Encoding was set upstream. Event streams pass through untouched,
+// and Flush is forwarded so streaming handlers keep working.
+func Compress(cfg config.CompressionConfig) echo.MiddlewareFunc {
+	encodings := cfg.Encodings
+	if len(encodings) == 0 {
+		encodings = []string{encodingBrotli, encodingZstd, encodingGzip}
+	}
+	minSize := cfg.MinSize
+	if minSize <= 0 {
+		minSize = 1024
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if req.Method == http.MethodHead || excludedFromCompression(cfg.ExcludePaths, req.URL.Path) {
+				return next(c)
+			}
+
+			res := c.Response()
+			res.Header().Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+
+			encoding := negotiateEncoding(req.Header.Get(echo.HeaderAcceptEncoding), encodings)
+			if encoding == "" {
+				return next(c)
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: res.Writer,
+				encoding:       encoding,
+				minSize:        minSize,
+				contentTypes:   cfg.ContentTypes,
+				status:         http.StatusOK,
+			}
+			res.Writer = cw
+			defer func() {
+				cw.close()
+				res.Writer = cw.ResponseWriter
+			}()
+
+			return next(c)
+		}
+	}
+}
+
+// negotiateEncoding picks the first supported encoding (in server preference order)
+// that the client accepts with a non-zero quality.
+func negotiateEncoding(acceptEncoding string, preferred []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	accepted := make(map[string]bool)
+	wildcard := false
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if name == "*" {
+			wildcard = q > 0
+			continue
+		}
+		accepted[name] = q > 0
+	}
+
+	for _, enc := range preferred {
+		if _, ok := encoderPools[enc]; !ok {
+			continue
+		}
+		if ok, listed := accepted[enc]; (listed && ok) || (!listed && wildcard) {
+			return enc
+		}
+	}
+	return ""
+}
+
+// excludedFromCompression matches whole paths, so excluding the /api/logs stream leaves
+// /api/logs/query and /api/logs/export compressed. An entry ending in "/" excludes
+// everything under it.
+func excludedFromCompression(excluded []string, path string) bool {
+	for _, p := range excluded {
+		if path == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// compressWriter buffers output until it can tell whether compression is worth it.
+// The decision is taken when the buffer reaches minSize, on Flush, or when the handler finishes.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding     string
+	minSize      int
+	contentTypes []string
+
+	status      int
+	wroteHeader bool // WriteHeader was called by the handler
+	decided     bool
+	compressing bool
+	buf         bytes.Buffer
+	enc         encoder
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = code
+
+	// Bodiless and streaming responses are decided right away
+	if code == http.StatusNoContent || code == http.StatusNotModified || code < http.StatusOK ||
+		strings.HasPrefix(w.Header().Get(echo.HeaderContentType), "text/event-stream") {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buf.Write(b)
+		if w.buf.Len() < w.minSize {
+			return len(b), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.compressing {
+		return w.enc.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// decide commits the headers and drains the buffer, compressed or not.
+// bigEnough reports whether the minimum size threshold was met.
+func (w *compressWriter) decide(bigEnough bool) error {
+	if w.decided {
+		return nil
+	}
+	w.decided = true
+
+	h := w.Header()
+	w.compressing = bigEnough &&
+		h.Get(echo.HeaderContentEncoding) == "" &&
+		h.Get("Content-Range") == "" &&
+		w.status != http.StatusNoContent && w.status != http.StatusNotModified &&
+		w.allowedType(h.Get(echo.HeaderContentType))
+
+	if w.compressing {
+		h.Set(echo.HeaderContentEncoding, w.encoding)
+		h.Del(echo.HeaderContentLength)
+		w.enc = encoderPools[w.encoding].Get().(encoder)
+		w.enc.Reset(w.ResponseWriter)
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	var err error
+	if w.compressing {
+		_, err = w.enc.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+func (w *compressWriter) allowedType(contentType string) bool {
+	if contentType == "" {
+		return false
+	}
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	if mediaType == "text/event-stream" {
+		return false
+	}
+	for _, allowed := range w.contentTypes {
+		if mediaType == allowed || (strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// Flush sends whatever is buffered. A flush before the threshold means the handler is
+// streaming, so compression is decided on content type alone.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(true)
+	}
+	if w.compressing {
+		w.enc.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// close finishes the encoded stream and returns the encoder to its pool.
+func (w *compressWriter) close() {
+	if !w.decided {
+		if !w.wroteHeader && w.buf.Len() == 0 {
+			// Handler wrote nothing at all; leave the response untouched
+			return
+		}
+		w.decide(false)
+	}
+	if w.compressing {
+		w.enc.Close()
+		w.enc.Reset(io.Discard)
+		encoderPools[w.encoding].Put(w.enc)
+		w.enc = nil
+	}
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
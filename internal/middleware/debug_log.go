@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"test-go/pkg/logger"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DebugLog raises logging to debug for requests carrying a valid token from signer
+// in header or in the debug_log query parameter. Invalid tokens are ignored.
+func DebugLog(header string, signer *logger.DebugSigner) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token := c.Request().Header.Get(header)
+			if token == "" {
+				token = c.QueryParam("debug_log")
+			}
+			if token != "" && signer.Verify(token) {
+				req := c.Request()
+				c.SetRequest(req.WithContext(logger.WithDebug(req.Context())))
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,423 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"test-go/config"
+	"test-go/pkg/cache"
+	"test-go/pkg/infrastructure"
+	"test-go/pkg/logger"
+
+	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+)
+
+// HeaderCache reports whether a response came from the server-side cache (HIT) or not (MISS).
+const HeaderCache = "X-Cache"
+
+// CachedResponse is a stored GET response.
+type CachedResponse struct {
+	Status int                 `json:"status"`
+	Header map[string][]string `json:"header,omitempty"`
+	Body   []byte              `json:"body"`
+	ETag   string              `json:"etag"`
+}
+
+// HTTPCacheStore holds cached responses grouped by rule path.
+// Invalidate drops every entry cached under a rule path.
+type HTTPCacheStore interface {
+	Get(ctx context.Context, rulePath, key string) (*CachedResponse, error)
+	Set(ctx context.Context, rulePath, key string, entry CachedResponse, ttl time.Duration) error
+	Invalidate(ctx context.Context, rulePath string) error
+}
+
+// NewHTTPCacheStore picks the store configured in cfg.Store.
+func NewHTTPCacheStore(cfg config.HTTPCacheConfig, rdb *infrastructure.RedisManager) HTTPCacheStore {
+	if strings.EqualFold(cfg.Store, "redis") && rdb != nil {
+		return &redisHTTPCacheStore{rdb: rdb, prefix: "httpcache:"}
+	}
+	return newMemoryHTTPCacheStore()
+}
+
+// Entries are keyed by a per-rule generation number. Invalidating a rule bumps its
+// generation, so old entries are never read again and simply age out.
+
+type memoryHTTPCacheStore struct {
+	items *cache.Cache[CachedResponse]
+	gens  map[string]uint64
+	mu    sync.Mutex
+}
+
+func newMemoryHTTPCacheStore() *memoryHTTPCacheStore {
+	s := &memoryHTTPCacheStore{
+		items: cache.New[CachedResponse](),
+		gens:  make(map[string]uint64),
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.items.Cleanup()
+		}
+	}()
+
+	return s
+}
+
+func (s *memoryHTTPCacheStore) generation(rulePath string) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.gens[rulePath]
+}
+
+func (s *memoryHTTPCacheStore) Get(_ context.Context, rulePath, key string) (*CachedResponse, error) {
+	entry, ok := s.items.Get(rulePath + ":" + strconv.FormatUint(s.generation(rulePath), 10) + ":" + key)
+	if !ok {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+func (s *memoryHTTPCacheStore) Set(_ context.Context, rulePath, key string, entry CachedResponse, ttl time.Duration) error {
+	s.items.Set(rulePath+":"+strconv.FormatUint(s.generation(rulePath), 10)+":"+key, entry, ttl)
+	return nil
+}
+
+func (s *memoryHTTPCacheStore) Invalidate(_ context.Context, rulePath string) error {
+	s.mu.Lock()
+	s.gens[rulePath]++
+	s.mu.Unlock()
+	return nil
+}
+
+type redisHTTPCacheStore struct {
+	rdb    *infrastructure.RedisManager
+	prefix string
+}
+
+func (s *redisHTTPCacheStore) generation(ctx context.Context, rulePath string) (string, error) {
+	gen, err := s.rdb.Get(ctx, s.prefix+"gen:"+rulePath)
+	if errors.Is(err, redis.Nil) {
+		return "0", nil
+	}
+	return gen, err
+}
+
+func (s *redisHTTPCacheStore) Get(ctx context.Context, rulePath, key string) (*CachedResponse, error) {
+	gen, err := s.generation(ctx, rulePath)
+	if err != nil {
+		return nil, err
+	}
+	val, err := s.rdb.Get(ctx, s.prefix+rulePath+":"+gen+":"+key)
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entry CachedResponse
+	if err := json.Unmarshal([]byte(val), &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (s *redisHTTPCacheStore) Set(ctx context.Context, rulePath, key string, entry CachedResponse, ttl time.Duration) error {
+	gen, err := s.generation(ctx, rulePath)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return s.rdb.Set(ctx, s.prefix+rulePath+":"+gen+":"+key, data, ttl)
+}
+
+func (s *redisHTTPCacheStore) Invalidate(ctx context.Context, rulePath string) error {
+	_, err := s.rdb.Increment(ctx, s.prefix+"gen:"+rulePath)
+	return err
+}
+
+// HTTPCache adds conditional GET support and an optional server-side response cache.
+//
+//   - Successful GET responses under a rule get a weak ETag and the rule's Cache-Control header.
+//   - A request whose If-None-Match matches the ETag gets 304 Not Modified without a body.
+//   - Rules with a TTL cache the whole response, keyed by route, query and auth subject.
+//   - A successful POST/PUT/PATCH/DELETE under a rule path invalidates that rule's entries.
+//
+// Other GET responses are passed through unbuffered with only the default Cache-Control.
+func HTTPCache(cfg config.HTTPCacheConfig, store HTTPCacheStore, l *logger.Logger) echo.MiddlewareFunc {
+	// Longest prefix wins, so sort the rules by path length
+	rules := append([]config.HTTPCacheRule(nil), cfg.Rules...)
+	sort.Slice(rules, func(i, j int) bool { return len(rules[i].Path) > len(rules[j].Path) })
+
+	maxBody := cfg.MaxBodyKB * 1024
+	if maxBody <= 0 {
+		maxBody = 1024 * 1024
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			rule := matchCacheRule(rules, req.URL.Path)
+
+			if req.Method != http.MethodGet {
+				err := next(c)
+				if rule != nil && rule.TTL > 0 && isWriteMethod(req.Method) && err == nil && c.Response().Status < 400 {
+					if invErr := store.Invalidate(req.Context(), rule.Path); invErr != nil {
+						l.Error("Failed to invalidate response cache", invErr, "path", rule.Path)
+					}
+				}
+				return err
+			}
+
+			cacheControl := cfg.DefaultCacheControl
+			if rule == nil {
+				if cacheControl != "" {
+					res := c.Response()
+					res.Before(func() {
+						if res.Status == http.StatusOK && res.Header().Get(echo.HeaderCacheControl) == "" {
+							res.Header().Set(echo.HeaderCacheControl, cacheControl)
+						}
+					})
+				}
+				return next(c)
+			}
+			if rule.CacheControl != "" {
+				cacheControl = rule.CacheControl
+			}
+
+			serverSide := rule.TTL > 0
+			var key string
+			if serverSide {
+				key = responseCacheKey(c)
+				entry, err := store.Get(req.Context(), rule.Path, key)
+				if err != nil {
+					l.Error("Response cache lookup failed", err, "path", rule.Path)
+				} else if entry != nil {
+					return writeCachedResponse(c, entry)
+				}
+			}
+
+			// Buffer the response so the ETag can be computed before anything is sent
+			original := c.Response().Writer
+			buf := &bufferWriter{ResponseWriter: original, body: new(bytes.Buffer), status: http.StatusOK}
+			c.Response().Writer = buf
+
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+			c.Response().Writer = original
+
+			hdr := original.Header()
+			body := buf.body.Bytes()
+
+			if buf.streaming {
+				return nil
+			}
+			if buf.status != http.StatusOK {
+				return buf.flush()
+			}
+
+			if cacheControl != "" && hdr.Get(echo.HeaderCacheControl) == "" {
+				hdr.Set(echo.HeaderCacheControl, cacheControl)
+			}
+			etag := hdr.Get("ETag")
+			if cfg.ETag && etag == "" {
+				etag = computeETag(hdr.Get(echo.HeaderContentType), body)
+				hdr.Set("ETag", etag)
+			}
+
+			if serverSide && len(body) <= maxBody && hdr.Get("Set-Cookie") == "" {
+				hdr.Set(HeaderCache, "MISS")
+				setErr := store.Set(req.Context(), rule.Path, key, CachedResponse{
+					Status: buf.status,
+					Header: replayableHeaders(hdr),
+					Body:   append([]byte(nil), body...),
+					ETag:   etag,
+				}, rule.TTL)
+				if setErr != nil {
+					l.Error("Failed to store cached response", setErr, "path", rule.Path)
+				}
+			}
+
+			if etag != "" && etagMatches(req.Header.Get("If-None-Match"), etag) {
+				c.Response().Status = http.StatusNotModified
+				writeNotModified(original)
+				return nil
+			}
+
+			return buf.flush()
+		}
+	}
+}
+
+// writeCachedResponse serves a stored entry, honouring If-None-Match.
+func writeCachedResponse(c echo.Context, entry *CachedResponse) error {
+	res := c.Response()
+	for k, vals := range entry.Header {
+		res.Header()[k] = append([]string(nil), vals...)
+	}
+	res.Header().Set(HeaderCache, "HIT")
+	body := restampEnvelope(c, entry.Header, entry.Body)
+
+	if entry.ETag != "" && etagMatches(c.Request().Header.Get("If-None-Match"), entry.ETag) {
+		res.Header().Del(echo.HeaderContentType)
+		res.Header().Del(echo.HeaderContentLength)
+		res.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	res.WriteHeader(entry.Status)
+	_, err := res.Write(body)
+	return err
+}
+
+func writeNotModified(w http.ResponseWriter) {
+	w.Header().Del(echo.HeaderContentType)
+	w.Header().Del(echo.HeaderContentLength)
+	w.WriteHeader(http.StatusNotModified)
+}
+
+// computeETag returns a weak ETag for the body.
+// For the standard JSON envelope the per-response fields (timestamp, datetime, correlation_id,
+// trace_id) are left out of the hash, otherwise no two responses would ever share a tag. The tag is
+// weak because responses that differ in those fields share it.
+func computeETag(contentType string, body []byte) string {
+	hashed := body
+	if strings.Contains(contentType, echo.MIMEApplicationJSON) {
+		var envelope map[string]json.RawMessage
+		if err := json.Unmarshal(body, &envelope); err == nil {
+			delete(envelope, "timestamp")
+			delete(envelope, "datetime")
+			delete(envelope, "correlation_id")
+			delete(envelope, "trace_id")
+			if stable, err := json.Marshal(envelope); err == nil {
+				hashed = stable
+			}
+		}
+	}
+	sum := sha256.Sum256(hashed)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches implements the weak comparison If-None-Match requires.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	target := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == target {
+			return true
+		}
+	}
+	return false
+}
+
+// responseCacheKey identifies a cacheable GET: route pattern, path, normalised query and auth subject.
+func responseCacheKey(c echo.Context) string {
+	req := c.Request()
+	h := sha256.New()
+	h.Write([]byte(c.Path()))
+	h.Write([]byte{0})
+	h.Write([]byte(req.URL.Path))
+	h.Write([]byte{0})
+	h.Write([]byte(normalizedQuery(req.URL.Query())))
+	h.Write([]byte{0})
+	h.Write([]byte(authSubject(req)))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// authSubject returns the credential a response may be specific to, so users never share entries.
+func authSubject(req *http.Request) string {
+	if v := req.Header.Get(echo.HeaderAuthorization); v != "" {
+		return v
+	}
+	return req.Header.Get("X-API-Key")
+}
+
+func normalizedQuery(q url.Values) string {
+	for _, vals := range q {
+		sort.Strings(vals)
+	}
+	return q.Encode() // Encode sorts by key
+}
+
+func matchCacheRule(rules []config.HTTPCacheRule, path string) *config.HTTPCacheRule {
+	for i := range rules {
+		p := strings.TrimSuffix(rules[i].Path, "/")
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+func isWriteMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// bufferWriter holds the status and body until flush is called.
+type bufferWriter struct {
+	http.ResponseWriter
+	body      *bytes.Buffer
+	status    int
+	streaming bool // the handler flushed, so writes pass straight through
+}
+
+func (w *bufferWriter) WriteHeader(code int) {
+	if w.streaming {
+		return
+	}
+	w.status = code
+}
+
+func (w *bufferWriter) Write(b []byte) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.body.Write(b)
+}
+
+// Flush stops buffering: a handler that flushes is streaming, so what it wrote so far
+// is sent and the rest goes out without an ETag or a cache entry.
+func (w *bufferWriter) Flush() {
+	if !w.streaming {
+		w.streaming = true
+		if err := w.flush(); err != nil {
+			return
+		}
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *bufferWriter) flush() error {
+	w.ResponseWriter.Header().Del(echo.HeaderContentLength)
+	w.ResponseWriter.WriteHeader(w.status)
+	_, err := w.ResponseWriter.Write(w.body.Bytes())
+	return err
+}
@@ -0,0 +1,281 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"test-go/config"
+	"test-go/pkg/logger"
+	"test-go/pkg/response"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestComputeETag(t *testing.T) {
+	envelope := func(data, correlationID string, ts int64) []byte {
+		return []byte(`{"success":true,"data":` + data + `,"timestamp":` + strconv.FormatInt(ts, 10) +
+			`,"datetime":"x","correlation_id":"` + correlationID + `","trace_id":"` + correlationID + `-trace"}`)
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		a, b        []byte
+		wantSame    bool
+	}{
+		{
+			name:        "envelope differing only in per-response fields",
+			contentType: echo.MIMEApplicationJSON,
+			a:           envelope(`{"id":1}`, "req-1", 100),
+			b:           envelope(`{"id":1}`, "req-2", 200),
+			wantSame:    true,
+		},
+		{
+			name:        "envelope with different data",
+			contentType: echo.MIMEApplicationJSON,
+			a:           envelope(`{"id":1}`, "req-1", 100),
+			b:           envelope(`{"id":2}`, "req-1", 100),
+		},
+		{
+			name:        "plain text hashes the exact bytes",
+			contentType: echo.MIMETextPlain,
+			a:           []byte(`{"timestamp":1}`),
+			b:           []byte(`{"timestamp":2}`),
+		},
+		{
+			name:        "identical bodies",
+			contentType: echo.MIMETextPlain,
+			a:           []byte("hello"),
+			b:           []byte("hello"),
+			wantSame:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := computeETag(tt.contentType, tt.a)
+			b := computeETag(tt.contentType, tt.b)
+			if !strings.HasPrefix(a, `W/"`) || !strings.HasSuffix(a, `"`) {
+				t.Fatalf("ETag %s is not a weak tag", a)
+			}
+			if (a == b) != tt.wantSame {
+				t.Errorf("tags %s and %s: same = %v, want %v", a, b, a == b, tt.wantSame)
+			}
+		})
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	const etag = `W/"abc"`
+
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{``, false},
+		{`W/"abc"`, true},
+		{`"abc"`, true}, // If-None-Match uses the weak comparison
+		{`"other", W/"abc"`, true},
+		{`"other"`, false},
+		{`*`, true},
+	}
+
+	for _, tt := range tests {
+		if got := etagMatches(tt.ifNoneMatch, etag); got != tt.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", tt.ifNoneMatch, got, tt.want)
+		}
+	}
+}
+
+type cacheStep struct {
+	method      string
+	path        string
+	auth        string
+	requestID   string
+	conditional bool // send the previous response's ETag in If-None-Match
+
+	wantStatus       int
+	wantCache        string // X-Cache: HIT, MISS or empty
+	wantCacheControl string
+	wantETag         bool
+	wantBody         string // substring of the body
+}
+
+func TestHTTPCacheRules(t *testing.T) {
+	cfg := config.HTTPCacheConfig{
+		Enabled:             true,
+		ETag:                true,
+		DefaultCacheControl: "no-cache",
+		Rules: []config.HTTPCacheRule{
+			{Path: "/api/v1/products", CacheControl: "public, max-age=60", TTL: time.Minute},
+			{Path: "/api/v1/tasks", CacheControl: "private"},
+		},
+	}
+
+	// Tracing needs a real provider to hand out trace IDs
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	tests := []struct {
+		name      string
+		traced    bool // run the tracing middleware, which adds trace_id to every envelope
+		steps     []cacheStep
+		wantCalls int
+	}{
+		{
+			name: "routes without a rule pass through with the default Cache-Control",
+			steps: []cacheStep{
+				{method: http.MethodGet, path: "/api/v1/other", wantStatus: http.StatusOK, wantCacheControl: "no-cache"},
+			},
+			wantCalls: 1,
+		},
+		{
+			name: "rule without a TTL gets an ETag and answers If-None-Match with 304",
+			steps: []cacheStep{
+				{method: http.MethodGet, path: "/api/v1/tasks", wantStatus: http.StatusOK, wantCacheControl: "private", wantETag: true},
+				{method: http.MethodGet, path: "/api/v1/tasks", conditional: true, wantStatus: http.StatusNotModified, wantCacheControl: "private", wantETag: true},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:   "traced responses still answer If-None-Match with 304",
+			traced: true,
+			steps: []cacheStep{
+				{method: http.MethodGet, path: "/api/v1/tasks", wantStatus: http.StatusOK, wantCacheControl: "private", wantETag: true, wantBody: `"trace_id":`},
+				{method: http.MethodGet, path: "/api/v1/tasks", conditional: true, wantStatus: http.StatusNotModified, wantCacheControl: "private", wantETag: true},
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "rule with a TTL serves the cached response with the current request ID",
+			steps: []cacheStep{
+				{method: http.MethodGet, path: "/api/v1/products", requestID: "first", wantStatus: http.StatusOK, wantCache: "MISS", wantETag: true, wantBody: `"correlation_id":"first"`},
+				{method: http.MethodGet, path: "/api/v1/products", requestID: "second", wantStatus: http.StatusOK, wantCache: "HIT", wantETag: true, wantBody: `"correlation_id":"second"`},
+				{method: http.MethodGet, path: "/api/v1/products", conditional: true, wantStatus: http.StatusNotModified, wantCache: "HIT", wantETag: true},
+			},
+			wantCalls: 1,
+		},
+		{
+			name: "cached entries are per credential",
+			steps: []cacheStep{
+				{method: http.MethodGet, path: "/api/v1/products", auth: "Bearer a", wantStatus: http.StatusOK, wantCache: "MISS", wantETag: true},
+				{method: http.MethodGet, path: "/api/v1/products", auth: "Bearer b", wantStatus: http.StatusOK, wantCache: "MISS", wantETag: true},
+				{method: http.MethodGet, path: "/api/v1/products", auth: "Bearer a", wantStatus: http.StatusOK, wantCache: "HIT", wantETag: true},
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "a successful write invalidates the rule",
+			steps: []cacheStep{
+				{method: http.MethodGet, path: "/api/v1/products", wantStatus: http.StatusOK, wantCache: "MISS", wantETag: true},
+				{method: http.MethodPost, path: "/api/v1/products", wantStatus: http.StatusCreated},
+				{method: http.MethodGet, path: "/api/v1/products", wantStatus: http.StatusOK, wantCache: "MISS", wantETag: true},
+			},
+			wantCalls: 3,
+		},
+		{
+			name: "errors are neither tagged nor cached",
+			steps: []cacheStep{
+				{method: http.MethodGet, path: "/api/v1/products/missing", wantStatus: http.StatusNotFound},
+				{method: http.MethodGet, path: "/api/v1/products/missing", wantStatus: http.StatusNotFound},
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "a handler that flushes is streamed without an ETag",
+			steps: []cacheStep{
+				{method: http.MethodGet, path: "/api/v1/products/stream", wantStatus: http.StatusOK, wantBody: "one\ntwo\n"},
+				{method: http.MethodGet, path: "/api/v1/products/stream", wantStatus: http.StatusOK, wantBody: "one\ntwo\n"},
+			},
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.Use(RequestID())
+			if tt.traced {
+				e.Use(Tracing())
+			}
+			e.Use(HTTPCache(cfg, newMemoryHTTPCacheStore(), logger.NewQuiet(false, nil)))
+
+			calls := 0
+			list := func(c echo.Context) error {
+				calls++
+				return response.Success(c, []string{"a", "b"})
+			}
+			e.GET("/api/v1/other", list)
+			e.GET("/api/v1/tasks", list)
+			e.GET("/api/v1/products", list)
+			e.POST("/api/v1/products", func(c echo.Context) error {
+				calls++
+				return response.Created(c, nil)
+			})
+			e.GET("/api/v1/products/missing", func(c echo.Context) error {
+				calls++
+				return response.NotFound(c, "no such product")
+			})
+			e.GET("/api/v1/products/stream", func(c echo.Context) error {
+				calls++
+				c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextPlain)
+				c.Response().WriteHeader(http.StatusOK)
+				c.Response().Write([]byte("one\n"))
+				c.Response().Flush()
+				_, err := c.Response().Write([]byte("two\n"))
+				return err
+			})
+
+			var lastETag string
+			for i, step := range tt.steps {
+				req := httptest.NewRequest(step.method, step.path, nil)
+				if step.auth != "" {
+					req.Header.Set(echo.HeaderAuthorization, step.auth)
+				}
+				if step.requestID != "" {
+					req.Header.Set(echo.HeaderXRequestID, step.requestID)
+				}
+				if step.conditional {
+					req.Header.Set("If-None-Match", lastETag)
+				}
+				rec := httptest.NewRecorder()
+				e.ServeHTTP(rec, req)
+
+				if rec.Code != step.wantStatus {
+					t.Fatalf("step %d: status = %d, want %d", i+1, rec.Code, step.wantStatus)
+				}
+				if got := rec.Header().Get(HeaderCache); got != step.wantCache {
+					t.Errorf("step %d: X-Cache = %q, want %q", i+1, got, step.wantCache)
+				}
+				if step.wantCacheControl != "" && rec.Header().Get(echo.HeaderCacheControl) != step.wantCacheControl {
+					t.Errorf("step %d: Cache-Control = %q, want %q", i+1, rec.Header().Get(echo.HeaderCacheControl), step.wantCacheControl)
+				}
+				etag := rec.Header().Get("ETag")
+				if (etag != "") != step.wantETag {
+					t.Errorf("step %d: ETag = %q, want one: %v", i+1, etag, step.wantETag)
+				}
+				if step.wantBody != "" && !strings.Contains(rec.Body.String(), step.wantBody) {
+					t.Errorf("step %d: body %q does not contain %q", i+1, rec.Body.String(), step.wantBody)
+				}
+				if step.requestID != "" && rec.Header().Get(echo.HeaderXRequestID) != step.requestID {
+					t.Errorf("step %d: X-Request-Id = %q, want %q", i+1, rec.Header().Get(echo.HeaderXRequestID), step.requestID)
+				}
+				if rec.Code == http.StatusNotModified && rec.Body.Len() > 0 {
+					t.Errorf("step %d: 304 with a body", i+1)
+				}
+				if etag != "" {
+					lastETag = etag
+				}
+			}
+
+			if calls != tt.wantCalls {
+				t.Errorf("handlers ran %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
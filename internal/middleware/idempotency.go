@@ -0,0 +1,400 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"test-go/config"
+	"test-go/pkg/cache"
+	"test-go/pkg/infrastructure"
+	"test-go/pkg/logger"
+	"test-go/pkg/response"
+	"test-go/pkg/tracing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	idempotencyStateInFlight  = "in_flight"
+	idempotencyStateCompleted = "completed"
+
+	// HeaderIdempotentReplayed marks responses served from the idempotency store.
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// IdempotencyRecord is the stored outcome of the first request made with a key.
+type IdempotencyRecord struct {
+	State       string              `json:"state"`
+	Fingerprint string              `json:"fingerprint"`
+	Status      int                 `json:"status"`
+	Header      map[string][]string `json:"header,omitempty"`
+	Body        []byte              `json:"body,omitempty"`
+}
+
+// IdempotencyStore persists idempotency records.
+// Reserve must be atomic: of several concurrent callers with the same key, only one gets true.
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) (bool, error)
+	Get(ctx context.Context, key string) (*IdempotencyRecord, error)
+	Save(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+// NewIdempotencyStore picks the store configured in cfg.Store.
+// Redis is used only when requested and available; otherwise records live in memory.
+func NewIdempotencyStore(cfg config.IdempotencyConfig, rdb *infrastructure.RedisManager) IdempotencyStore {
+	if strings.EqualFold(cfg.Store, "redis") && rdb != nil {
+		return &redisIdempotencyStore{rdb: rdb, prefix: "idempotency:"}
+	}
+	return newMemoryIdempotencyStore()
+}
+
+// memoryIdempotencyStore keeps records in a pkg/cache instance.
+type memoryIdempotencyStore struct {
+	items *cache.Cache[IdempotencyRecord]
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	s := &memoryIdempotencyStore{items: cache.New[IdempotencyRecord]()}
+
+	// Periodically drop expired records so the map doesn't grow forever
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.items.Cleanup()
+		}
+	}()
+
+	return s
+}
+
+func (s *memoryIdempotencyStore) Reserve(_ context.Context, key string, rec IdempotencyRecord, ttl time.Duration) (bool, error) {
+	return s.items.SetIfAbsent(key, rec, ttl), nil
+}
+
+func (s *memoryIdempotencyStore) Get(_ context.Context, key string) (*IdempotencyRecord, error) {
+	rec, ok := s.items.Get(key)
+	if !ok {
+		return nil, nil
+	}
+	return &rec, nil
+}
+
+func (s *memoryIdempotencyStore) Save(_ context.Context, key string, rec IdempotencyRecord, ttl time.Duration) error {
+	s.items.Set(key, rec, ttl)
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Delete(_ context.Context, key string) error {
+	s.items.Delete(key)
+	return nil
+}
+
+// redisIdempotencyStore keeps records as JSON strings in Redis so replays work across instances.
+type redisIdempotencyStore struct {
+	rdb    *infrastructure.RedisManager
+	prefix string
+}
+
+func (s *redisIdempotencyStore) Reserve(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) (bool, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return false, err
+	}
+	return s.rdb.SetIfNotExists(ctx, s.prefix+key, data, ttl)
+}
+
+func (s *redisIdempotencyStore) Get(ctx context.Context, key string) (*IdempotencyRecord, error) {
+	val, err := s.rdb.Get(ctx, s.prefix+key)
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rec IdempotencyRecord
+	if err := json.Unmarshal([]byte(val), &rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func (s *redisIdempotencyStore) Save(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return s.rdb.Set(ctx, s.prefix+key, data, ttl)
+}
+
+func (s *redisIdempotencyStore) Delete(ctx context.Context, key string) error {
+	return s.rdb.Delete(ctx, s.prefix+key)
+}
+
+// Idempotency replays the first response for a repeated Idempotency-Key.
+//
+//   - The first request with a key reserves it, runs the handler and stores status, headers and body.
+//   - A repeat with the same body gets the stored response and an Idempotent-Replayed header.
+//   - A repeat with a different body, or one arriving while the first is still running, gets 409.
+//
+// 5xx outcomes are not stored, so clients can retry after a server failure. Keys are
+// scoped to the authenticated caller, so one client cannot replay another's response.
+// The in-flight reservation is renewed every half lock TTL while the handler runs, so a
+// slow request keeps its key, and expires one lock TTL after a crash rather than holding
+// the key for the whole replay TTL. Bodies over cfg.MaxBodyKB are rejected with 413.
+func Idempotency(cfg config.IdempotencyConfig, store IdempotencyStore, l *logger.Logger) echo.MiddlewareFunc {
+	header := cfg.Header
+	if header == "" {
+		header = "Idempotency-Key"
+	}
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	lockTTL := cfg.LockTTL
+	if lockTTL <= 0 {
+		lockTTL = time.Minute
+	}
+	maxBodyKB := cfg.MaxBodyKB
+	if maxBodyKB <= 0 {
+		maxBodyKB = 1024
+	}
+	maxBody := int64(maxBodyKB) * 1024
+	methods := make(map[string]bool)
+	for _, m := range cfg.Methods {
+		methods[strings.ToUpper(m)] = true
+	}
+	if len(methods) == 0 {
+		methods[http.MethodPost] = true
+		methods[http.MethodPatch] = true
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			idemKey := req.Header.Get(header)
+			if idemKey == "" || !methods[req.Method] {
+				return next(c)
+			}
+			if len(idemKey) > 255 {
+				return response.BadRequest(c, header+" must be at most 255 characters")
+			}
+
+			// Read the body once for hashing, then restore it for the handler
+			body, err := io.ReadAll(io.LimitReader(req.Body, maxBody+1))
+			if err != nil {
+				return response.BadRequest(c, "Failed to read request body")
+			}
+			if int64(len(body)) > maxBody {
+				return response.Error(c, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE",
+					"Requests with an "+header+" may carry at most "+strconv.Itoa(maxBodyKB)+" KB")
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := req.Context()
+			storeKey := SubjectFromContext(c) + ":" + req.Method + ":" + req.URL.Path + ":" + idemKey
+			fingerprint := requestFingerprint(req.Method, req.URL.Path, body)
+
+			inFlight := IdempotencyRecord{
+				State:       idempotencyStateInFlight,
+				Fingerprint: fingerprint,
+			}
+			reserved, err := store.Reserve(ctx, storeKey, inFlight, lockTTL)
+			if err != nil {
+				// Store unavailable: process normally rather than rejecting the request
+				l.Error("Idempotency store unavailable", err, "key", idemKey)
+				return next(c)
+			}
+
+			if !reserved {
+				return replayIdempotent(c, store, storeKey, fingerprint, header)
+			}
+
+			// We own the key: capture the response while passing it through
+			rec := &captureWriter{ResponseWriter: c.Response().Writer, body: new(bytes.Buffer)}
+			c.Response().Writer = rec
+
+			// Deferred too, so a panicking handler stops renewing and its key expires
+			stopRenewal := renewReservation(store, storeKey, inFlight, lockTTL, l)
+			defer stopRenewal()
+
+			err = next(c)
+			stopRenewal()
+			if err != nil {
+				c.Error(err)
+			}
+
+			// Record the outcome even if the client has gone away, or the key stays reserved
+			ctx = context.WithoutCancel(ctx)
+			status := c.Response().Status
+			if status >= http.StatusInternalServerError {
+				if delErr := store.Delete(ctx, storeKey); delErr != nil {
+					l.Error("Failed to release idempotency key", delErr, "key", idemKey)
+				}
+				return nil
+			}
+
+			saveErr := store.Save(ctx, storeKey, IdempotencyRecord{
+				State:       idempotencyStateCompleted,
+				Fingerprint: fingerprint,
+				Status:      status,
+				Header:      replayableHeaders(c.Response().Header()),
+				Body:        rec.body.Bytes(),
+			}, ttl)
+			if saveErr != nil {
+				l.Error("Failed to store idempotent response", saveErr, "key", idemKey)
+			}
+			return nil
+		}
+	}
+}
+
+// renewReservation re-saves the in-flight record every half lockTTL until the returned
+// function is called. The function is safe to call more than once and returns only once
+// renewal has stopped, so a late renewal cannot overwrite the completed record.
+func renewReservation(store IdempotencyStore, key string, rec IdempotencyRecord, lockTTL time.Duration, l *logger.Logger) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(lockTTL / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), lockTTL/2)
+				if err := store.Save(ctx, key, rec, lockTTL); err != nil {
+					l.Error("Failed to renew idempotency key", err, "key", key)
+				}
+				cancel()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-stopped
+	}
+}
+
+// replayIdempotent answers a request whose key is already reserved.
+func replayIdempotent(c echo.Context, store IdempotencyStore, storeKey, fingerprint, header string) error {
+	existing, err := store.Get(c.Request().Context(), storeKey)
+	if err != nil {
+		return response.ServiceUnavailable(c, "Idempotency store unavailable")
+	}
+	if existing == nil {
+		// Expired or released between Reserve and Get; ask the client to retry
+		c.Response().Header().Set("Retry-After", "1")
+		return response.Conflict(c, "Request with this "+header+" is being processed, retry shortly")
+	}
+
+	if existing.Fingerprint != fingerprint {
+		return response.Error(c, http.StatusConflict, "IDEMPOTENCY_KEY_REUSED",
+			header+" was already used with a different request payload")
+	}
+
+	if existing.State != idempotencyStateCompleted {
+		c.Response().Header().Set("Retry-After", "1")
+		return response.Error(c, http.StatusConflict, "IDEMPOTENCY_IN_PROGRESS",
+			"A request with this "+header+" is still in progress")
+	}
+
+	res := c.Response()
+	for k, vals := range existing.Header {
+		res.Header()[k] = append([]string(nil), vals...)
+	}
+	res.Header().Set(HeaderIdempotentReplayed, "true")
+	res.WriteHeader(existing.Status)
+	_, err = res.Write(restampEnvelope(c, existing.Header, existing.Body))
+	return err
+}
+
+// requestFingerprint hashes what makes two requests "the same" for idempotency purposes.
+func requestFingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replayableHeaders copies response headers except ones that must be regenerated per response.
+// Content-Encoding is left to the compression middleware, which runs on every replay, and
+// the request and trace IDs belong to the request being answered, not the one that was stored.
+func replayableHeaders(h http.Header) map[string][]string {
+	out := make(map[string][]string, len(h))
+	for k, v := range h {
+		switch k {
+		case echo.HeaderContentLength, echo.HeaderContentEncoding, echo.HeaderXRequestID, http.CanonicalHeaderKey(HeaderXTraceID), "Date", "Set-Cookie":
+			continue
+		}
+		out[k] = append([]string(nil), v...)
+	}
+	return out
+}
+
+// restampEnvelope replaces the correlation_id and trace_id of a stored JSON envelope with
+// the current request's, so a replayed body can be traced to the request it answered.
+func restampEnvelope(c echo.Context, header map[string][]string, body []byte) []byte {
+	if !strings.Contains(http.Header(header).Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+		return body
+	}
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return body
+	}
+	if _, ok := envelope["correlation_id"]; !ok {
+		return body
+	}
+	id, err := json.Marshal(response.CorrelationID(c))
+	if err != nil {
+		return body
+	}
+	envelope["correlation_id"] = id
+	if traceID := tracing.TraceID(c.Request().Context()); traceID != "" {
+		envelope["trace_id"], _ = json.Marshal(traceID)
+	} else {
+		delete(envelope, "trace_id")
+	}
+	out, err := json.Marshal(envelope)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// captureWriter passes writes through to the client while keeping a copy of the body.
+type captureWriter struct {
+	http.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
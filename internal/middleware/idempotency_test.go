@@ -0,0 +1,301 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"test-go/config"
+	"test-go/pkg/logger"
+	"test-go/pkg/response"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+type idempotencyStep struct {
+	subject      string // authenticated caller; empty is anonymous
+	body         string
+	requestID    string
+	sleep        time.Duration // before the request
+	wantStatus   int
+	wantReplayed bool
+	wantCode     string // error code of a rejected request
+}
+
+func TestIdempotencyTransitions(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   func(call int) int // status to answer the nth call with; 0 panics
+		steps     []idempotencyStep
+		wantCalls int
+	}{
+		{
+			name:    "first request runs the handler",
+			handler: func(int) int { return http.StatusCreated },
+			steps: []idempotencyStep{
+				{body: `{"a":1}`, wantStatus: http.StatusCreated},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:    "repeat with the same body is replayed",
+			handler: func(int) int { return http.StatusCreated },
+			steps: []idempotencyStep{
+				{body: `{"a":1}`, requestID: "first", wantStatus: http.StatusCreated},
+				{body: `{"a":1}`, requestID: "second", wantStatus: http.StatusCreated, wantReplayed: true},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:    "repeat with a different body is rejected",
+			handler: func(int) int { return http.StatusCreated },
+			steps: []idempotencyStep{
+				{body: `{"a":1}`, wantStatus: http.StatusCreated},
+				{body: `{"a":2}`, wantStatus: http.StatusConflict, wantCode: "IDEMPOTENCY_KEY_REUSED"},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:    "client errors are stored",
+			handler: func(int) int { return http.StatusUnprocessableEntity },
+			steps: []idempotencyStep{
+				{body: `{}`, wantStatus: http.StatusUnprocessableEntity},
+				{body: `{}`, wantStatus: http.StatusUnprocessableEntity, wantReplayed: true},
+			},
+			wantCalls: 1,
+		},
+		{
+			name: "server errors release the key",
+			handler: func(call int) int {
+				if call == 1 {
+					return http.StatusServiceUnavailable
+				}
+				return http.StatusCreated
+			},
+			steps: []idempotencyStep{
+				{body: `{"a":1}`, wantStatus: http.StatusServiceUnavailable},
+				{body: `{"a":1}`, wantStatus: http.StatusCreated},
+				{body: `{"a":1}`, wantStatus: http.StatusCreated, wantReplayed: true},
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "a crashed request holds the key for the lock TTL only",
+			handler: func(call int) int {
+				if call == 1 {
+					return 0
+				}
+				return http.StatusCreated
+			},
+			steps: []idempotencyStep{
+				{body: `{"a":1}`, wantStatus: http.StatusInternalServerError},
+				{body: `{"a":1}`, wantStatus: http.StatusConflict, wantCode: "IDEMPOTENCY_IN_PROGRESS"},
+				{body: `{"a":1}`, sleep: 80 * time.Millisecond, wantStatus: http.StatusCreated},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:    "keys are scoped to the caller",
+			handler: func(int) int { return http.StatusCreated },
+			steps: []idempotencyStep{
+				{subject: "apikey:alice", body: `{"a":1}`, wantStatus: http.StatusCreated},
+				{subject: "apikey:bob", body: `{"a":1}`, wantStatus: http.StatusCreated},
+				{subject: "apikey:alice", body: `{"a":1}`, wantStatus: http.StatusCreated, wantReplayed: true},
+			},
+			wantCalls: 2,
+		},
+	}
+
+	// Tracing needs a real provider to hand out trace IDs
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.IdempotencyConfig{TTL: time.Hour, LockTTL: 50 * time.Millisecond}
+			e := echo.New()
+			e.Use(RequestID())
+			e.Use(Tracing())
+			e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) (err error) {
+					if s := c.Request().Header.Get("X-Test-Subject"); s != "" {
+						c.Set(ContextKeySubject, s)
+					}
+					// Stands in for the server's recovery: the panic has already skipped
+					// the idempotency middleware's bookkeeping
+					defer func() {
+						if recover() != nil {
+							err = echo.ErrInternalServerError
+						}
+					}()
+					return next(c)
+				}
+			})
+			e.Use(Idempotency(cfg, newMemoryIdempotencyStore(), logger.NewQuiet(false, nil)))
+
+			calls := 0
+			e.POST("/orders", func(c echo.Context) error {
+				calls++
+				status := tt.handler(calls)
+				if status == 0 {
+					panic("handler crashed")
+				}
+				if status >= http.StatusBadRequest {
+					return response.Error(c, status, "FAILED", "failed")
+				}
+				return response.Created(c, map[string]int{"order": calls})
+			})
+
+			stored := make(map[string]map[string]interface{}) // first response per caller
+			for i, step := range tt.steps {
+				time.Sleep(step.sleep)
+
+				req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(step.body))
+				req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+				req.Header.Set("Idempotency-Key", "key-1")
+				if step.subject != "" {
+					req.Header.Set("X-Test-Subject", step.subject)
+				}
+				if step.requestID != "" {
+					req.Header.Set(echo.HeaderXRequestID, step.requestID)
+				}
+				rec := httptest.NewRecorder()
+				e.ServeHTTP(rec, req)
+
+				if rec.Code != step.wantStatus {
+					t.Fatalf("step %d: status = %d, want %d (body %s)", i+1, rec.Code, step.wantStatus, rec.Body.String())
+				}
+				if replayed := rec.Header().Get(HeaderIdempotentReplayed) == "true"; replayed != step.wantReplayed {
+					t.Fatalf("step %d: replayed = %v, want %v", i+1, replayed, step.wantReplayed)
+				}
+
+				var body map[string]interface{}
+				if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+					t.Fatalf("step %d: invalid body %q: %v", i+1, rec.Body.String(), err)
+				}
+				if step.wantCode != "" {
+					errDetail, _ := body["error"].(map[string]interface{})
+					if errDetail["code"] != step.wantCode {
+						t.Fatalf("step %d: error = %v, want code %s", i+1, body["error"], step.wantCode)
+					}
+				}
+				if step.requestID != "" {
+					if got := rec.Header().Get(echo.HeaderXRequestID); got != step.requestID {
+						t.Errorf("step %d: X-Request-Id = %q, want %q", i+1, got, step.requestID)
+					}
+					if body["correlation_id"] != step.requestID {
+						t.Errorf("step %d: correlation_id = %v, want %q", i+1, body["correlation_id"], step.requestID)
+					}
+				}
+				// Replays name the current trace, not the stored one
+				traceIDs := rec.Header().Values(HeaderXTraceID)
+				if _, ok := body["trace_id"]; (ok || step.wantReplayed) && (len(traceIDs) != 1 || body["trace_id"] != traceIDs[0]) {
+					t.Errorf("step %d: X-Trace-ID %v, trace_id %v, want one matching ID", i+1, traceIDs, body["trace_id"])
+				}
+				if step.wantReplayed {
+					first := stored[step.subject]
+					if !jsonEqual(body["data"], first["data"]) || !jsonEqual(body["error"], first["error"]) {
+						t.Errorf("step %d: replayed %s, want the stored response", i+1, rec.Body.String())
+					}
+				} else {
+					stored[step.subject] = body
+				}
+			}
+
+			if calls != tt.wantCalls {
+				t.Errorf("handler ran %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func jsonEqual(a, b interface{}) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return string(x) == string(y)
+}
+
+func TestIdempotencySlowRequestKeepsItsKey(t *testing.T) {
+	cfg := config.IdempotencyConfig{TTL: time.Hour, LockTTL: 40 * time.Millisecond}
+	e := echo.New()
+	e.Use(Idempotency(cfg, newMemoryIdempotencyStore(), logger.NewQuiet(false, nil)))
+
+	release := make(chan struct{})
+	var calls atomic.Int32
+	e.POST("/orders", func(c echo.Context) error {
+		if calls.Add(1) == 1 {
+			<-release
+		}
+		return response.Created(c, map[string]int{"order": 1})
+	})
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"a":1}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("Idempotency-Key", "slow")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- send() }()
+
+	// Several lock TTLs later the first request is still running and must still own the key
+	time.Sleep(150 * time.Millisecond)
+	if rec := send(); rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "IDEMPOTENCY_IN_PROGRESS") {
+		t.Fatalf("repeat during a slow request: %d %s, want 409 IDEMPOTENCY_IN_PROGRESS", rec.Code, rec.Body.String())
+	}
+
+	close(release)
+	if rec := <-first; rec.Code != http.StatusCreated {
+		t.Fatalf("first request: %d, want 201", rec.Code)
+	}
+	// A renewal that was in flight when the handler returned must not undo the stored response
+	time.Sleep(60 * time.Millisecond)
+	if rec := send(); rec.Code != http.StatusCreated || rec.Header().Get(HeaderIdempotentReplayed) != "true" {
+		t.Fatalf("repeat after completion: %d replayed=%q, want a replayed 201", rec.Code, rec.Header().Get(HeaderIdempotentReplayed))
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("handler ran %d times, want 1", n)
+	}
+}
+
+func TestIdempotencyBodyLimit(t *testing.T) {
+	cfg := config.IdempotencyConfig{MaxBodyKB: 1}
+	e := echo.New()
+	e.Use(Idempotency(cfg, newMemoryIdempotencyStore(), logger.NewQuiet(false, nil)))
+	e.POST("/orders", func(c echo.Context) error {
+		return c.NoContent(http.StatusCreated)
+	})
+
+	tests := []struct {
+		name       string
+		size       int
+		key        string
+		wantStatus int
+	}{
+		{"at the limit", 1024, "a", http.StatusCreated},
+		{"over the limit", 1025, "b", http.StatusRequestEntityTooLarge},
+		{"over the limit without a key", 4096, "", http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(strings.Repeat("x", tt.size)))
+			if tt.key != "" {
+				req.Header.Set("Idempotency-Key", tt.key)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
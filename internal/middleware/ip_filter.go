@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net"
+	"time"
+
+	"test-go/internal/ipfilter"
+	"test-go/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// IPFilter rejects clients whose address is denied, or missing from an allowlist,
+// for the given server scope. The client address comes from echo's IPExtractor,
+// which the filter configures to trust X-Forwarded-For only from known proxies.
+func IPFilter(f *ipfilter.Filter, scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			ip := c.RealIP()
+
+			allowed, reason := f.Check(scope, req.URL.Path, net.ParseIP(ip))
+			if allowed {
+				return next(c)
+			}
+
+			f.RecordBlocked(ipfilter.BlockedAttempt{
+				Time:      time.Now(),
+				Scope:     scope,
+				IP:        ip,
+				Method:    req.Method,
+				Path:      req.URL.Path,
+				Reason:    reason,
+				RequestID: response.CorrelationID(c),
+			})
+			return response.Forbidden(c, "Access from your network is not allowed")
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"test-go/pkg/metrics"
+	"test-go/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Metrics records request counts and latency labelled by the matched route pattern
+// (e.g. /api/v1/users/:id), so IDs in paths do not create new series.
+func Metrics(m *metrics.HTTP) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			done := m.Start()
+
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			route := c.Path()
+			if route == "" || c.Response().Status == http.StatusNotFound && strings.HasSuffix(route, "*") {
+				route = "unmatched"
+			}
+			done(route, c.Request().Method, c.Response().Status)
+			return nil
+		}
+	}
+}
+
+// MetricsHandler serves the Prometheus registry. A non-empty token must be sent as a bearer token.
+func MetricsHandler(token string) echo.HandlerFunc {
+	h := metrics.Handler()
+	return func(c echo.Context) error {
+		if token != "" {
+			got, _ := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				return response.Unauthorized(c, "Invalid metrics token")
+			}
+		}
+		h.ServeHTTP(c.Response(), c.Request())
+		return nil
+	}
+}
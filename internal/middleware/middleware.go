@@ -5,15 +5,42 @@ import (
 	"net/http"
 	"time"
 
+	"test-go/config"
+	"test-go/internal/apikey"
+	"test-go/internal/audit"
+	"test-go/internal/capture"
+	"test-go/internal/ipfilter"
+	"test-go/internal/probeguard"
+	"test-go/pkg/infrastructure"
 	"test-go/pkg/logger"
+	"test-go/pkg/metrics"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
 // Config holds middleware configuration
 type Config struct {
-	AuthType string
-	Logger   *logger.Logger
+	AuthType      string
+	Logger        *logger.Logger
+	Redis         *infrastructure.RedisManager // optional, nil when Redis is disabled
+	Idempotency   config.IdempotencyConfig
+	HTTPCache     config.HTTPCacheConfig
+	Compression   config.CompressionConfig
+	AccessLog     config.AccessLogConfig
+	Audit         config.AuditConfig
+	Auditor       *audit.Recorder   // nil when auditing is disabled
+	IPFilter      *ipfilter.Filter  // nil when IP filtering is disabled
+	ProbeGuard    *probeguard.Guard // nil when scanner detection is disabled
+	Capture       config.CaptureConfig
+	Captures      *capture.Store // nil when request capture is disabled
+	APIKeys       config.APIKeysConfig
+	KeyManager    *apikey.Manager // nil unless API keys are enabled and their store opened
+	Metrics       *metrics.HTTP   // nil when metrics are disabled
+	Tracing       bool
+	DebugHeader   string
+	DebugSigner   *logger.DebugSigner // nil when per-request debug logging is disabled
+	CaptureSigner *logger.DebugSigner // nil when capture tokens are disabled
 }
 
 // InitMiddlewares registers global middlewares and returns specific ones for use
@@ -21,24 +48,112 @@ func InitMiddlewares(e *echo.Echo, cfg Config) {
 	// Request ID
 	e.Use(RequestID())
 
+	// Server span per request (outermost, so every other middleware runs inside the trace)
+	if cfg.Tracing {
+		e.Use(Tracing())
+	}
+
+	// Prometheus request metrics (outermost, so blocked and rejected requests are counted)
+	if cfg.Metrics != nil {
+		e.Use(Metrics(cfg.Metrics))
+	}
+
+	// Structured access log (separate sink from the application log)
+	if cfg.AccessLog.Enabled {
+		w, err := NewAccessLogWriter(cfg.AccessLog)
+		if err != nil {
+			cfg.Logger.Error("Failed to open access log, access logging disabled", err)
+		} else {
+			e.Use(AccessLog(cfg.AccessLog, w))
+		}
+	}
+
+	// Network allow/deny lists (after the access log so blocked attempts are logged)
+	if cfg.IPFilter != nil {
+		e.Use(IPFilter(cfg.IPFilter, ipfilter.ScopeAPI))
+	}
+
+	// Scanner detection and temporary bans
+	if cfg.ProbeGuard != nil {
+		e.Use(ProbeGuard(cfg.ProbeGuard))
+	}
+
+	// Per-request debug logging (before the request log so it is raised too)
+	if cfg.DebugSigner != nil {
+		e.Use(DebugLog(cfg.DebugHeader, cfg.DebugSigner))
+	}
+
 	// Custom Logger Middleware
 	e.Use(Logger(cfg.Logger))
 
+	// Response compression (outside the caches so they store uncompressed bodies)
+	if cfg.Compression.Enabled {
+		e.Use(Compress(cfg.Compression))
+	}
+
+	// Request/response capture for debugging (inside compression so bodies are readable)
+	if cfg.Captures != nil {
+		e.Use(Capture(cfg.Capture, cfg.Captures, cfg.CaptureSigner))
+	}
+
 	// Global Permission Middleware (Allow all except DELETE for demo purposes)
 	// In a real app, this might be selective
 	e.Use(PermissionCheck(cfg.Logger))
+
+	// API key authentication (before the audit trail so it records the key as subject).
+	// Mounted even without a key manager so protected paths fail closed.
+	if cfg.AuthType == "apikey" {
+		e.Use(APIKeyAuth(cfg.KeyManager, cfg.APIKeys))
+	}
+
+	// Audit trail of API writes (outside idempotency so replays are audited too)
+	if cfg.Auditor != nil && cfg.Audit.APIWrites {
+		e.Use(Audit(cfg.Auditor, cfg.Audit.MaxBodyBytes, cfg.Audit.RedactFields))
+	}
+
+	// Idempotency-Key replay for mutating requests
+	if cfg.Idempotency.Enabled {
+		store := NewIdempotencyStore(cfg.Idempotency, cfg.Redis)
+		e.Use(Idempotency(cfg.Idempotency, store, cfg.Logger))
+	}
+
+	// ETags, Cache-Control and server-side response caching for GET routes
+	if cfg.HTTPCache.Enabled {
+		store := NewHTTPCacheStore(cfg.HTTPCache, cfg.Redis)
+		e.Use(HTTPCache(cfg.HTTPCache, store, cfg.Logger))
+	}
+
+	// Handler panics become 500s (innermost, so the access log, metrics and traces see them)
+	e.Use(Recover())
 }
 
+// RequestID gives every request an ID: the X-Request-ID or X-Correlation-ID the client
+// sent, or a new UUID. It is set on the response, where the access log, the response
+// envelope and the error tracker read it.
 func RequestID() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			// Basic implementation, Echo has its own middleware.RequestID() too
+			req := c.Request()
+			id := req.Header.Get(echo.HeaderXRequestID)
+			if id == "" {
+				id = req.Header.Get("X-Correlation-ID")
+			}
+			if id == "" || len(id) > maxRequestIDLength {
+				id = uuid.New().String()
+			}
+			c.Response().Header().Set(echo.HeaderXRequestID, id)
 			return next(c)
 		}
 	}
 }
 
+// maxRequestIDLength bounds client-supplied request IDs, which end up in every log line.
+const maxRequestIDLength = 128
+
+// Logger logs every request through the "http" logger. Successful requests are
+// subject to log sampling.
 func Logger(l *logger.Logger) echo.MiddlewareFunc {
+	l = l.Named("http").Sampled()
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			start := time.Now()
@@ -55,12 +170,13 @@ func Logger(l *logger.Logger) echo.MiddlewareFunc {
 
 			msg := fmt.Sprintf("%d | %s | %s | %v", status, method, path, latency)
 
+			rl := l.WithContext(req.Context())
 			if status >= 500 {
-				l.Error(msg, err)
+				rl.Error(msg, err)
 			} else if status >= 400 {
-				l.Warn(msg)
+				rl.Warn(msg)
 			} else {
-				l.Info(msg)
+				rl.Info(msg)
 			}
 			return err
 		}
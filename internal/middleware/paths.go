@@ -0,0 +1,16 @@
+package middleware
+
+import "strings"
+
+// isExcludedPath reports whether path starts with any entry of excluded. It is a plain
+// string prefix match, as used by the access_log and capture exclude_paths: "/health"
+// also covers "/health/ready" and "/healthz". Compression matches differently, see
+// excludedFromCompression.
+func isExcludedPath(excluded []string, path string) bool {
+	for _, p := range excluded {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"test-go/internal/probeguard"
+	"test-go/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ProbeGuard rejects banned clients and feeds 404s and suspicious paths to the guard,
+// which bans an address once it crosses the configured thresholds.
+func ProbeGuard(g *probeguard.Guard) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			ip := c.RealIP()
+			if g.Ignored(ip) {
+				return next(c)
+			}
+
+			ctx := req.Context()
+			if b := g.Banned(ctx, ip); b != nil {
+				retryAfter := int(time.Until(b.ExpiresAt).Seconds()) + 1
+				c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				return response.Error(c, http.StatusForbidden, "IP_BANNED",
+					"Your address is temporarily banned after repeated suspicious requests")
+			}
+
+			if g.Suspicious(req.URL.EscapedPath()) {
+				g.Record(ctx, ip, probeguard.KindSuspicious, req.URL.Path)
+			}
+
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			if c.Response().Status == http.StatusNotFound {
+				g.Record(ctx, ip, probeguard.KindNotFound, req.URL.Path)
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+
+	"test-go/internal/errtrack"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Recover turns a panic in a handler into an error carrying the panicking goroutine's
+// stack. The HTTP error handler answers it with a 500 and the error tracker groups
+// it by the function that panicked.
+func Recover() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					if r == http.ErrAbortHandler {
+						panic(r) // the server's signal to drop the connection
+					}
+					err = errtrack.Recovered(r)
+				}
+			}()
+			return next(c)
+		}
+	}
+}
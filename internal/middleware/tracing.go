@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"test-go/pkg/tracing"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HeaderXTraceID carries the trace ID of the request back to the client.
+const HeaderXTraceID = "X-Trace-ID"
+
+// Tracing starts a server span per request, continuing the trace of an incoming
+// traceparent header. The span context is stored in the request context so handlers
+// and the infrastructure managers create child spans.
+func Tracing() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+			ctx, span := tracing.Tracer().Start(ctx, req.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(req.Method),
+					semconv.URLPath(req.URL.Path),
+					semconv.ClientAddress(c.RealIP()),
+					semconv.UserAgentOriginal(req.UserAgent()),
+				),
+			)
+			defer span.End()
+
+			c.SetRequest(req.WithContext(ctx))
+			if sc := span.SpanContext(); sc.HasTraceID() {
+				c.Response().Header().Set(HeaderXTraceID, sc.TraceID().String())
+			}
+
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+				span.RecordError(err)
+			}
+
+			// The route is only known once the router has matched the request
+			if route := c.Path(); route != "" {
+				span.SetName(fmt.Sprintf("%s %s", req.Method, route))
+				span.SetAttributes(semconv.HTTPRoute(route))
+			}
+			status := c.Response().Status
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+			return nil
+		}
+	}
+}
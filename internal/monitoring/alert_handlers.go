@@ -0,0 +1,126 @@
+package monitoring
+
+import (
+	"errors"
+	"strings"
+	"test-go/internal/alerting"
+	"test-go/internal/audit"
+	"test-go/internal/monitoring/session"
+	"test-go/pkg/response"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SilenceRequest struct {
+	Rule     string `json:"rule"`     // empty silences every rule
+	Duration string `json:"duration"` // e.g. "2h"
+	Comment  string `json:"comment"`
+}
+
+func (h *Handler) getAlerts(c echo.Context) error {
+	if h.alerts == nil {
+		return response.Success(c, map[string]interface{}{
+			"enabled": false,
+			"alerts":  []alerting.Alert{},
+		})
+	}
+
+	return response.Success(c, map[string]interface{}{
+		"enabled":     true,
+		"alerts":      h.alerts.Alerts(),
+		"history":     h.alerts.History(),
+		"rules":       h.alerts.Rules(),
+		"silences":    h.alerts.Silences(),
+		"maintenance": h.alerts.Maintenance(),
+		"notifiers":   h.alerts.Notifiers(),
+	})
+}
+
+func (h *Handler) getSilences(c echo.Context) error {
+	if h.alerts == nil {
+		return response.Success(c, []alerting.Silence{})
+	}
+	return response.Success(c, h.alerts.Silences())
+}
+
+func (h *Handler) addSilence(c echo.Context) error {
+	if h.alerts == nil {
+		return response.ServiceUnavailable(c, "Alerting is disabled")
+	}
+
+	var req SilenceRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request")
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(req.Duration))
+	if err != nil || d <= 0 {
+		return response.BadRequest(c, "Invalid 'duration', expected a duration such as 2h")
+	}
+
+	var createdBy string
+	if sess, ok := c.Get("session").(*session.Session); ok {
+		createdBy = sess.Username
+	}
+
+	silence, err := h.alerts.AddSilence(c.Request().Context(), req.Rule, d, req.Comment, createdBy)
+	target := req.Rule
+	if target == "" {
+		target = "all rules"
+	}
+	h.recordAudit(c, "alerts.silence", target, "", audit.Summarize(req, 0, nil), err)
+	if errors.Is(err, alerting.ErrUnknownRule) {
+		return response.BadRequest(c, err.Error())
+	}
+	if err != nil {
+		return response.InternalServerError(c, err.Error())
+	}
+
+	return response.Created(c, silence, "Silence created")
+}
+
+func (h *Handler) removeSilence(c echo.Context) error {
+	if h.alerts == nil {
+		return response.ServiceUnavailable(c, "Alerting is disabled")
+	}
+
+	id := c.Param("id")
+	silence, err := h.alerts.RemoveSilence(c.Request().Context(), id)
+	var before string
+	if err == nil {
+		before = audit.Summarize(silence, 0, nil)
+	}
+	h.recordAudit(c, "alerts.unsilence", id, before, "", err)
+	switch {
+	case errors.Is(err, alerting.ErrSilenceNotFound):
+		return response.NotFound(c, err.Error())
+	case err != nil:
+		return response.InternalServerError(c, err.Error())
+	}
+
+	return response.Success(c, silence, "Silence removed")
+}
+
+// testAlertNotifiers sends a test notification to every configured notifier.
+func (h *Handler) testAlertNotifiers(c echo.Context) error {
+	if h.alerts == nil {
+		return response.ServiceUnavailable(c, "Alerting is disabled")
+	}
+
+	requestedBy := "the dashboard"
+	if sess, ok := c.Get("session").(*session.Session); ok {
+		requestedBy = sess.Username
+	}
+
+	failed := h.alerts.SendTest(c.Request().Context(), requestedBy)
+	var err error
+	if len(failed) > 0 {
+		err = errors.New("some notifiers failed")
+	}
+	h.recordAudit(c, "alerts.test", "notifiers", "", "", err)
+
+	return response.Success(c, map[string]interface{}{
+		"notifiers": h.alerts.Notifiers(),
+		"failed":    failed,
+	})
+}
@@ -0,0 +1,99 @@
+package monitoring
+
+import (
+	"errors"
+	"test-go/internal/apikey"
+	"test-go/internal/audit"
+	"test-go/internal/monitoring/session"
+	"test-go/pkg/response"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type CreateAPIKeyRequest struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	ExpiresIn string   `json:"expires_in"` // Go duration, e.g. "720h"; empty never expires
+}
+
+func (h *Handler) listAPIKeys(c echo.Context) error {
+	if h.apiKeys == nil {
+		return response.Success(c, map[string]interface{}{
+			"enabled": false,
+			"keys":    []apikey.Key{},
+		})
+	}
+
+	keys, err := h.apiKeys.List(c.Request().Context())
+	if err != nil {
+		return response.InternalServerError(c, err.Error())
+	}
+
+	return response.Success(c, map[string]interface{}{
+		"enabled":   true,
+		"auth_type": h.config.Auth.Type,
+		"header":    h.config.Auth.APIKeys.Header,
+		"keys":      keys,
+	})
+}
+
+// createAPIKey returns the plain-text key once; only its hash is stored.
+func (h *Handler) createAPIKey(c echo.Context) error {
+	if h.apiKeys == nil {
+		return response.ServiceUnavailable(c, "API keys are disabled")
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request")
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresIn != "" {
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || d <= 0 {
+			return response.BadRequest(c, "Invalid expiry, use values like 24h or 720h")
+		}
+		t := time.Now().Add(d)
+		expiresAt = &t
+	}
+
+	actor := "unknown"
+	if sess, ok := c.Get("session").(*session.Session); ok {
+		actor = sess.Username
+	}
+
+	plain, key, err := h.apiKeys.Create(c.Request().Context(), req.Name, req.Scopes, expiresAt, actor)
+	h.recordAudit(c, "apikey.create", key.ID, "", audit.Summarize(key, 0, nil), err)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Created(c, map[string]interface{}{
+		"key":    plain,
+		"record": key,
+	}, "API key created. Copy it now, it will not be shown again.")
+}
+
+func (h *Handler) revokeAPIKey(c echo.Context) error {
+	if h.apiKeys == nil {
+		return response.ServiceUnavailable(c, "API keys are disabled")
+	}
+
+	id := c.Param("id")
+	key, err := h.apiKeys.Revoke(c.Request().Context(), id)
+	if errors.Is(err, apikey.ErrNotFound) {
+		return response.NotFound(c, "API key not found")
+	}
+	after := ""
+	if key != nil {
+		after = audit.Summarize(key, 0, nil)
+	}
+	h.recordAudit(c, "apikey.revoke", id, "", after, err)
+	if err != nil {
+		return response.InternalServerError(c, err.Error())
+	}
+
+	return response.Success(c, key, "API key revoked")
+}
@@ -0,0 +1,100 @@
+package monitoring
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"test-go/internal/audit"
+	"test-go/internal/monitoring/session"
+	"test-go/pkg/response"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// recordAudit records a dashboard action performed by the logged-in user.
+func (h *Handler) recordAudit(c echo.Context, action, target, before, after string, err error) {
+	if h.audit == nil {
+		return
+	}
+
+	actor := "unknown"
+	if sess, ok := c.Get("session").(*session.Session); ok {
+		actor = sess.Username
+	}
+
+	entry := audit.Entry{
+		Actor:     actor,
+		ActorType: audit.ActorUser,
+		Action:    action,
+		Target:    target,
+		RequestID: response.CorrelationID(c),
+		IP:        c.RealIP(),
+		Before:    before,
+		After:     after,
+		Outcome:   audit.OutcomeSuccess,
+	}
+	if err != nil {
+		entry.Outcome = audit.OutcomeFailure
+		entry.Error = err.Error()
+	}
+	h.audit.Record(entry)
+}
+
+// contentSummary describes file content without storing it, since config
+// files may hold credentials.
+func contentSummary(content []byte) string {
+	if content == nil {
+		return ""
+	}
+	sum := sha256.Sum256(content)
+	lines := 0
+	for _, b := range content {
+		if b == '\n' {
+			lines++
+		}
+	}
+	return fmt.Sprintf(`{"sha256":"%s","bytes":%d,"lines":%d}`, hex.EncodeToString(sum[:])[:12], len(content), lines)
+}
+
+// searchAudit lists audit entries, newest first.
+// Query params: actor, action (prefix), target, outcome, q, from, to (RFC3339), page, per_page.
+func (h *Handler) searchAudit(c echo.Context) error {
+	if h.audit == nil {
+		return response.ServiceUnavailable(c, "Audit trail is disabled")
+	}
+
+	var pagination response.PaginationRequest
+	pagination.Page, _ = strconv.Atoi(c.QueryParam("page"))
+	pagination.PerPage, _ = strconv.Atoi(c.QueryParam("per_page"))
+
+	filter := audit.Filter{
+		Actor:   c.QueryParam("actor"),
+		Action:  c.QueryParam("action"),
+		Target:  c.QueryParam("target"),
+		Outcome: c.QueryParam("outcome"),
+		Query:   c.QueryParam("q"),
+		Limit:   pagination.GetPerPage(),
+		Offset:  pagination.GetOffset(),
+	}
+
+	var err error
+	if v := c.QueryParam("from"); v != "" {
+		if filter.From, err = time.Parse(time.RFC3339, v); err != nil {
+			return response.BadRequest(c, "Invalid 'from' time, expected RFC3339")
+		}
+	}
+	if v := c.QueryParam("to"); v != "" {
+		if filter.To, err = time.Parse(time.RFC3339, v); err != nil {
+			return response.BadRequest(c, "Invalid 'to' time, expected RFC3339")
+		}
+	}
+
+	entries, total, err := h.audit.Search(c.Request().Context(), filter)
+	if err != nil {
+		return response.InternalServerError(c, err.Error())
+	}
+
+	return response.SuccessWithMeta(c, entries, response.CalculateMeta(pagination.GetPage(), pagination.GetPerPage(), total))
+}
@@ -1,9 +1,12 @@
 package monitoring
 
 import (
+	"errors"
 	"strings"
+	"test-go/internal/audit"
 	"test-go/internal/monitoring/database"
 	"test-go/internal/monitoring/session"
+	"test-go/internal/monitoring/sso"
 	"test-go/pkg/response"
 	"time"
 
@@ -17,13 +20,18 @@ type LoginRequest struct {
 }
 
 // handleLogin handles user login
-func handleLogin(sessionManager *session.Manager) echo.HandlerFunc {
+func handleLogin(sessionManager *session.Manager, auditor *audit.Recorder) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		var req LoginRequest
 		if err := c.Bind(&req); err != nil {
 			return response.BadRequest(c, "Invalid request")
 		}
 
+		loginFailed := func() error {
+			recordLogin(c, auditor, req.Username, session.MethodPassword, errors.New("invalid username or password"))
+			return response.Unauthorized(c, "Invalid username or password")
+		}
+
 		// Get user settings from database
 		settings, err := database.GetUserSettings()
 		if err != nil {
@@ -31,18 +39,18 @@ func handleLogin(sessionManager *session.Manager) echo.HandlerFunc {
 		}
 
 		if settings == nil {
-			return response.Unauthorized(c, "Invalid username or password")
+			return loginFailed()
 		}
 
 		// Validate username matches database (case-insensitive)
 		if !strings.EqualFold(req.Username, settings.Username) {
-			return response.Unauthorized(c, "Invalid username or password")
+			return loginFailed()
 		}
 
 		// Validate password against database
 		err = database.VerifyPassword(req.Password)
 		if err != nil {
-			return response.Unauthorized(c, "Invalid username or password")
+			return loginFailed()
 		}
 
 		// Create session using the actual username from database
@@ -53,17 +61,36 @@ func handleLogin(sessionManager *session.Manager) echo.HandlerFunc {
 
 		// Set session cookie (24 hours)
 		session.SetCookie(c, sess.ID, int(24*time.Hour.Seconds()))
+		recordLogin(c, auditor, settings.Username, session.MethodPassword, nil)
 
 		return response.Success(c, nil, "Login successful")
 	}
 }
 
-// handleLogout handles user logout
-func handleLogout(sessionManager *session.Manager) echo.HandlerFunc {
+// handleLogout handles user logout. SSO sessions also get the identity provider's
+// logout URL so the browser can end the provider session too.
+func handleLogout(sessionManager *session.Manager, provider *sso.Provider, auditor *audit.Recorder) echo.HandlerFunc {
 	return func(c echo.Context) error {
+		var data map[string]string
+
 		// Get session cookie
 		cookie, err := c.Cookie(session.SessionCookieName)
 		if err == nil {
+			if sess, ok := sessionManager.Get(cookie.Value); ok {
+				if sess.Method == session.MethodOIDC && provider != nil {
+					if redirect := provider.LogoutURL(sess.IDToken); redirect != "" {
+						data = map[string]string{"redirect": redirect}
+					}
+				}
+				auditor.Record(audit.Entry{
+					Actor:     sess.Username,
+					ActorType: audit.ActorUser,
+					Action:    "auth.logout",
+					Target:    "monitoring",
+					RequestID: response.CorrelationID(c),
+					IP:        c.RealIP(),
+				})
+			}
 			// Delete session from manager
 			sessionManager.Delete(cookie.Value)
 		}
@@ -74,6 +101,25 @@ func handleLogout(sessionManager *session.Manager) echo.HandlerFunc {
 		// Prevent caching of logout response
 		c.Response().Header().Set("Cache-Control", "no-store, no-cache, must-revalidate")
 
-		return response.Success(c, nil, "Logged out successfully")
+		return response.Success(c, data, "Logged out successfully")
+	}
+}
+
+// recordLogin audits a login attempt; failed attempts keep the submitted username.
+func recordLogin(c echo.Context, auditor *audit.Recorder, username, method string, err error) {
+	entry := audit.Entry{
+		Actor:     username,
+		ActorType: audit.ActorUser,
+		Action:    "auth.login",
+		Target:    "monitoring",
+		After:     `{"method":"` + method + `"}`,
+		RequestID: response.CorrelationID(c),
+		IP:        c.RealIP(),
+		Outcome:   audit.OutcomeSuccess,
+	}
+	if err != nil {
+		entry.Outcome = audit.OutcomeFailure
+		entry.Error = err.Error()
 	}
+	auditor.Record(entry)
 }
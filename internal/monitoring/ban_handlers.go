@@ -0,0 +1,102 @@
+package monitoring
+
+import (
+	"net/url"
+	"test-go/internal/audit"
+	"test-go/internal/monitoring/session"
+	"test-go/internal/probeguard"
+	"test-go/pkg/response"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type BanRequest struct {
+	IP       string `json:"ip"`
+	Reason   string `json:"reason"`
+	Duration string `json:"duration"` // Go duration, e.g. "2h"; empty uses probe_guard.ban_duration
+}
+
+func (h *Handler) getBans(c echo.Context) error {
+	if h.probeGuard == nil {
+		return response.Success(c, map[string]interface{}{
+			"enabled": false,
+			"bans":    []probeguard.Ban{},
+		})
+	}
+
+	bans, err := h.probeGuard.Bans(c.Request().Context())
+	if err != nil {
+		return response.InternalServerError(c, err.Error())
+	}
+
+	return response.Success(c, map[string]interface{}{
+		"enabled":      true,
+		"ban_duration": h.probeGuard.BanDuration().String(),
+		"bans":         bans,
+	})
+}
+
+func (h *Handler) banIP(c echo.Context) error {
+	if h.probeGuard == nil {
+		return response.ServiceUnavailable(c, "Probe guard is disabled")
+	}
+
+	var req BanRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request")
+	}
+	if req.IP == c.RealIP() {
+		return response.Conflict(c, "You cannot ban your own address")
+	}
+
+	var d time.Duration
+	if req.Duration != "" {
+		var err error
+		if d, err = time.ParseDuration(req.Duration); err != nil || d <= 0 {
+			return response.BadRequest(c, "Invalid duration, use values like 30m or 2h")
+		}
+	}
+
+	actor := "unknown"
+	if sess, ok := c.Get("session").(*session.Session); ok {
+		actor = sess.Username
+	}
+
+	ban, err := h.probeGuard.BanManually(c.Request().Context(), req.IP, req.Reason, actor, d)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Created(c, ban, "IP banned")
+}
+
+func (h *Handler) unbanIP(c echo.Context) error {
+	if h.probeGuard == nil {
+		return response.ServiceUnavailable(c, "Probe guard is disabled")
+	}
+
+	ip, err := url.PathUnescape(c.Param("ip"))
+	if err != nil {
+		return response.BadRequest(c, "Invalid IP address")
+	}
+	ctx := c.Request().Context()
+
+	var before string
+	if b, _ := h.probeGuard.Bans(ctx); b != nil {
+		for _, ban := range b {
+			if ban.IP == ip {
+				before = audit.Summarize(ban, 0, nil)
+				break
+			}
+		}
+	}
+
+	err = h.probeGuard.Unban(ctx, ip)
+	h.recordAudit(c, "probeguard.unban", ip, before, "", err)
+	if err != nil {
+		return response.InternalServerError(c, err.Error())
+	}
+
+	return response.Success(c, nil, "IP unbanned")
+}
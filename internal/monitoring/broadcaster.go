@@ -2,6 +2,8 @@ package monitoring
 
 import (
 	"sync"
+	"sync/atomic"
+	"test-go/internal/logstore"
 )
 
 type LogEntry struct {
@@ -10,49 +12,76 @@ type LogEntry struct {
 	Timestamp string `json:"time"`
 }
 
+// LogEvent is one line of the live log stream. ID is the line's log store ID, or 0
+// when no store is attached.
+type LogEvent struct {
+	ID   uint64
+	Data []byte
+}
+
 type LogBroadcaster struct {
-	clients map[chan []byte]bool
+	clients map[chan LogEvent]bool
 	mu      sync.Mutex
+	dropped atomic.Uint64
+	store   *logstore.Store
 }
 
-func NewLogBroadcaster() *LogBroadcaster {
+// NewLogBroadcaster fans log lines out to dashboard clients. When store is not nil
+// every line is kept there first, so clients can search it and resume after reconnecting.
+func NewLogBroadcaster(store *logstore.Store) *LogBroadcaster {
 	return &LogBroadcaster{
-		clients: make(map[chan []byte]bool),
+		clients: make(map[chan LogEvent]bool),
+		store:   store,
 	}
 }
 
+// Store returns the log store behind the stream, or nil.
+func (b *LogBroadcaster) Store() *logstore.Store {
+	return b.store
+}
+
 // Write satisfies the io.Writer interface.
 // It assumes the input is a JSON string (from zerolog).
 func (b *LogBroadcaster) Write(p []byte) (n int, err error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	// Parse JSON to validate/format (optional, here we just broadcast raw bytes)
-	// But since we want to send SSE events, we'll keep it as bytes.
-	// We copy the slice because p is reused.
-	msg := make([]byte, len(p))
-	copy(msg, p)
+	var events []LogEvent
+	if b.store != nil {
+		// One event per stored line, so each can be resumed from
+		for _, e := range b.store.Append(p) {
+			events = append(events, LogEvent{ID: e.ID, Data: []byte(e.Raw)})
+		}
+	} else {
+		// We copy the slice because p is reused.
+		msg := make([]byte, len(p))
+		copy(msg, p)
+		events = []LogEvent{{Data: msg}}
+	}
 
 	for clientChan := range b.clients {
-		select {
-		case clientChan <- msg:
-		default:
-			// If client channel is full, drop the message or disconnect client
-			// For simplicity, we drop.
+		for _, ev := range events {
+			select {
+			case clientChan <- ev:
+			default:
+				// If client channel is full, drop the message or disconnect client
+				// For simplicity, we drop.
+				b.dropped.Add(1)
+			}
 		}
 	}
 	return len(p), nil
 }
 
-func (b *LogBroadcaster) Subscribe() chan []byte {
+func (b *LogBroadcaster) Subscribe() chan LogEvent {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	ch := make(chan []byte, 100) // Buffer log messages
+	ch := make(chan LogEvent, 100) // Buffer log messages
 	b.clients[ch] = true
 	return ch
 }
 
-func (b *LogBroadcaster) Unsubscribe(ch chan []byte) {
+func (b *LogBroadcaster) Unsubscribe(ch chan LogEvent) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	if _, ok := b.clients[ch]; ok {
@@ -60,3 +89,15 @@ func (b *LogBroadcaster) Unsubscribe(ch chan []byte) {
 		close(ch)
 	}
 }
+
+// Dropped returns how many messages were dropped because a client fell behind.
+func (b *LogBroadcaster) Dropped() uint64 {
+	return b.dropped.Load()
+}
+
+// ClientCount returns the number of connected log stream clients.
+func (b *LogBroadcaster) ClientCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.clients)
+}
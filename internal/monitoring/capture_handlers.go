@@ -0,0 +1,140 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"test-go/internal/capture"
+	"test-go/pkg/response"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type CaptureTokenRequest struct {
+	TTL string `json:"ttl"` // e.g. "15m"; capped at capture.max_ttl
+}
+
+// captureFilter reads method, status (e.g. "404" or "5xx"), q, page and per_page.
+func captureFilter(c echo.Context) (capture.Filter, response.PaginationRequest) {
+	var pagination response.PaginationRequest
+	pagination.Page, _ = strconv.Atoi(c.QueryParam("page"))
+	pagination.PerPage, _ = strconv.Atoi(c.QueryParam("per_page"))
+
+	f := capture.Filter{
+		Method: c.QueryParam("method"),
+		Query:  c.QueryParam("q"),
+		Limit:  pagination.GetPerPage(),
+		Offset: pagination.GetOffset(),
+	}
+
+	status := strings.ToLower(c.QueryParam("status"))
+	if len(status) == 3 && strings.HasSuffix(status, "xx") {
+		class, _ := strconv.Atoi(status[:1])
+		f.MinStatus, f.MaxStatus = class*100, class*100+99
+	} else if code, err := strconv.Atoi(status); err == nil {
+		f.MinStatus, f.MaxStatus = code, code
+	}
+
+	return f, pagination
+}
+
+func (h *Handler) listCaptures(c echo.Context) error {
+	if h.captures == nil {
+		return response.SuccessWithMeta(c, []capture.Summary{}, &response.Meta{
+			Extra: map[string]interface{}{"enabled": false},
+		})
+	}
+
+	f, pagination := captureFilter(c)
+	items, total := h.captures.List(f)
+	return response.SuccessWithMeta(c, items, response.CalculateMeta(pagination.GetPage(), pagination.GetPerPage(), int64(total), map[string]interface{}{
+		"enabled": true,
+		"tokens":  h.captureSigner != nil,
+		"max_ttl": h.config.Capture.MaxTTL.String(),
+	}))
+}
+
+// createCaptureToken mints a token that captures requests carrying it in capture.header.
+func (h *Handler) createCaptureToken(c echo.Context) error {
+	if h.captures == nil || h.captureSigner == nil {
+		return response.ServiceUnavailable(c, "Capture tokens are disabled")
+	}
+
+	var req CaptureTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request")
+	}
+	var ttl time.Duration
+	if v := strings.TrimSpace(req.TTL); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return response.BadRequest(c, "Invalid 'ttl', expected a duration such as 15m")
+		}
+		ttl = d
+	}
+
+	token, expires := h.captureSigner.Sign(ttl)
+	h.recordAudit(c, "captures.token", "expires "+expires.Format(time.RFC3339), "", "", nil)
+
+	return response.Created(c, map[string]interface{}{
+		"header":     h.config.Capture.Header,
+		"token":      token,
+		"expires_at": expires,
+	}, "Capture token created")
+}
+
+func (h *Handler) getCapture(c echo.Context) error {
+	if h.captures == nil {
+		return response.ServiceUnavailable(c, "Request capture is disabled")
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "Invalid capture id")
+	}
+
+	entry := h.captures.Get(id)
+	if entry == nil {
+		return response.NotFound(c, "Capture not found or already evicted")
+	}
+	return response.Success(c, entry)
+}
+
+// exportCapturesHAR downloads captures as a HAR file: the ids listed in ?ids=1,2,3,
+// or every capture matching the list filters.
+func (h *Handler) exportCapturesHAR(c echo.Context) error {
+	if h.captures == nil {
+		return response.ServiceUnavailable(c, "Request capture is disabled")
+	}
+
+	var ids []int64
+	for _, raw := range strings.Split(c.QueryParam("ids"), ",") {
+		if id, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	f, _ := captureFilter(c)
+
+	har := capture.ToHAR(h.captures.Select(ids, f), h.config.App.Name, "1.0.0")
+	data, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return response.InternalServerError(c, err.Error())
+	}
+
+	filename := fmt.Sprintf("captures-%s.har", time.Now().Format("20060102-150405"))
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, data)
+}
+
+func (h *Handler) clearCaptures(c echo.Context) error {
+	if h.captures == nil {
+		return response.ServiceUnavailable(c, "Request capture is disabled")
+	}
+
+	h.captures.Clear()
+	h.recordAudit(c, "captures.clear", "captures", "", "", nil)
+	return response.Success(c, nil, "Captures cleared")
+}
@@ -9,11 +9,20 @@ import (
 	_ "modernc.org/sqlite"
 )
 
+// FileName is the SQLite database file, relative to the working directory.
+const FileName = "monitoring_users.db"
+
 var db *sql.DB
 
-// InitDB initializes the SQLite database for user settings
+// InitDB initializes the SQLite database for user settings.
+// It is safe to call more than once; later calls are no-ops.
 func InitDB() error {
-	dbPath := "monitoring_users.db"
+	if db != nil {
+		return nil
+	}
+
+	// busy_timeout lets concurrent writers (settings, audit log) wait for the lock instead of failing
+	dbPath := FileName + "?_pragma=busy_timeout(5000)"
 
 	// Ensure database file exists
 	var err error
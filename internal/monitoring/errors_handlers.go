@@ -0,0 +1,88 @@
+package monitoring
+
+import (
+	"errors"
+	"test-go/internal/errtrack"
+	"test-go/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ErrorStatusRequest struct {
+	Status string `json:"status"` // open, resolved or ignored
+}
+
+// getErrors lists the error groups, optionally only those in ?status=.
+func (h *Handler) getErrors(c echo.Context) error {
+	if h.errors == nil {
+		return response.Success(c, map[string]interface{}{
+			"enabled": false,
+			"groups":  []errtrack.Group{},
+		})
+	}
+
+	return response.Success(c, map[string]interface{}{
+		"enabled":    true,
+		"min_status": h.errors.MinStatus(),
+		"counts":     h.errors.Counts(),
+		"groups":     h.errors.Groups(c.QueryParam("status")),
+	})
+}
+
+func (h *Handler) getErrorGroup(c echo.Context) error {
+	if h.errors == nil {
+		return response.ServiceUnavailable(c, "Error tracking is disabled")
+	}
+	g, ok := h.errors.Group(c.Param("id"))
+	if !ok {
+		return response.NotFound(c, errtrack.ErrGroupNotFound.Error())
+	}
+	return response.Success(c, g)
+}
+
+// setErrorStatus resolves, ignores or re-opens a group.
+func (h *Handler) setErrorStatus(c echo.Context) error {
+	if h.errors == nil {
+		return response.ServiceUnavailable(c, "Error tracking is disabled")
+	}
+
+	var req ErrorStatusRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request")
+	}
+	id := c.Param("id")
+	before, _ := h.errors.Group(id)
+	g, err := h.errors.SetStatus(c.Request().Context(), id, req.Status, sessionUser(c))
+	h.recordAudit(c, "errors.status", id, before.Status, req.Status, err)
+	switch {
+	case errors.Is(err, errtrack.ErrGroupNotFound):
+		return response.NotFound(c, err.Error())
+	case errors.Is(err, errtrack.ErrInvalidStatus):
+		return response.BadRequest(c, err.Error())
+	case err != nil:
+		return response.InternalServerError(c, err.Error())
+	}
+	g.Samples = nil
+	return response.Success(c, g, "Error marked "+req.Status)
+}
+
+func (h *Handler) deleteErrorGroup(c echo.Context) error {
+	if h.errors == nil {
+		return response.ServiceUnavailable(c, "Error tracking is disabled")
+	}
+
+	id := c.Param("id")
+	g, err := h.errors.Delete(c.Request().Context(), id)
+	var before string
+	if err == nil {
+		before = g.String()
+	}
+	h.recordAudit(c, "errors.delete", id, before, "", err)
+	switch {
+	case errors.Is(err, errtrack.ErrGroupNotFound):
+		return response.NotFound(c, err.Error())
+	case err != nil:
+		return response.InternalServerError(c, err.Error())
+	}
+	return response.Success(c, nil, "Error group deleted")
+}
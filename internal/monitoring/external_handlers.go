@@ -0,0 +1,38 @@
+package monitoring
+
+import (
+	"errors"
+	"test-go/internal/prober"
+	"test-go/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// externalStatuses returns the last probe result of every external service.
+func (h *Handler) externalStatuses() []prober.Status {
+	if h.prober == nil {
+		return []prober.Status{}
+	}
+	return h.prober.Statuses()
+}
+
+func (h *Handler) getExternal(c echo.Context) error {
+	return response.Success(c, h.externalStatuses())
+}
+
+// getExternalService returns the recent results, last day of hourly uptime and
+// state changes of one external service.
+func (h *Handler) getExternalService(c echo.Context) error {
+	if h.prober == nil {
+		return response.NotFound(c, prober.ErrNotFound.Error())
+	}
+
+	detail, err := h.prober.Detail(c.Param("name"))
+	if errors.Is(err, prober.ErrNotFound) {
+		return response.NotFound(c, err.Error())
+	}
+	if err != nil {
+		return response.InternalServerError(c, err.Error())
+	}
+	return response.Success(c, detail)
+}
@@ -5,9 +5,24 @@ import (
 	"os"
 	"sync"
 	"test-go/config"
+	"test-go/internal/alerting"
+	"test-go/internal/apikey"
+	"test-go/internal/audit"
+	"test-go/internal/capture"
+	"test-go/internal/collector"
+	"test-go/internal/errtrack"
+	"test-go/internal/ipfilter"
+	"test-go/internal/metricstore"
+	"test-go/internal/monitoring/session"
+	"test-go/internal/probeguard"
+	"test-go/internal/prober"
+	"test-go/internal/profiling"
+	"test-go/internal/redact"
+	"test-go/internal/statuspage"
 	"test-go/pkg/infrastructure"
+	"test-go/pkg/logger"
 	"test-go/pkg/response"
-	"test-go/pkg/utils"
+	"test-go/pkg/sysstats"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -23,8 +38,23 @@ type Handler struct {
 	cron           *infrastructure.CronManager
 	minio          *infrastructure.MinIOManager
 	system         *infrastructure.SystemManager
-	http           *infrastructure.HttpManager
 	services       []ServiceInfo
+	audit          *audit.Recorder
+	ipFilter       *ipfilter.Filter
+	probeGuard     *probeguard.Guard
+	captures       *capture.Store
+	apiKeys        *apikey.Manager
+	history        *metricstore.History
+	alerts         *alerting.Engine
+	logger         *logger.Logger
+	debugSigner    *logger.DebugSigner
+	captureSigner  *logger.DebugSigner
+	profiler       *profiling.Profiler
+	prober         *prober.Prober
+	synthetics     *prober.Synthetics
+	statusPage     *statuspage.Page
+	collector      *collector.Collector
+	errors         *errtrack.Tracker
 
 	// Dummy Logs
 	dummyMu     sync.Mutex
@@ -36,12 +66,21 @@ func (h *Handler) RegisterRoutes(g *echo.Group) {
 	g.GET("/api/status", h.getStatus)
 	g.POST("/api/restart", h.Restart)                      // Maintenance
 	g.GET("/api/monitoring/config", h.getMonitoringConfig) // New
-	g.GET("/api/config", h.getConfig)
-	g.GET("/api/config/raw", h.getRawConfig)     // New
+	// The config, stored values, captured bodies, logs, goroutine stacks and the
+	// audit trail can carry secrets, so read-only roles do not get them
+	g.GET("/api/config", h.getConfig, session.RequireAdmin())
+	g.GET("/api/config/raw", h.getRawConfig, session.RequireAdmin())
 	g.POST("/api/config", h.saveConfig)          // New
 	g.POST("/api/config/backup", h.backupConfig) // New
-	g.GET("/api/logs", h.streamLogs)
+	g.GET("/api/logs", h.streamLogs, session.RequireAdmin())
+	g.GET("/api/logs/query", h.queryLogs, session.RequireAdmin())
+	g.GET("/api/logs/export", h.exportLogs, session.RequireAdmin())
+	g.GET("/api/logging/levels", h.getLogLevels)
+	g.POST("/api/logging/levels", h.setLogLevel)
+	g.DELETE("/api/logging/levels", h.resetLogLevel)
+	g.POST("/api/logging/debug-token", h.createDebugToken)
 	g.GET("/api/cpu", h.streamCPU)
+	g.GET("/api/system/resources", h.getResources)
 	g.GET("/api/endpoints", h.getEndpoints)
 	g.GET("/api/cron", h.getCronJobs)
 	g.POST("/api/postgres/query", h.runPostgresQuery) // New: Raw Query
@@ -64,11 +103,75 @@ func (h *Handler) RegisterRoutes(g *echo.Group) {
 
 	// New Endpoints
 	g.GET("/api/redis/keys", h.getRedisKeys)
-	g.GET("/api/redis/key/:key", h.getRedisValue)
+	g.GET("/api/redis/key/:key", h.getRedisValue, session.RequireAdmin())
 	g.GET("/api/postgres/queries", h.getPostgresQueries)
 	g.GET("/api/postgres/info", h.getPostgresInfo)
 	g.GET("/api/kafka/topics", h.getKafkaTopics)
 	g.POST("/api/logs/dummy", h.toggleDummyLogs)
+
+	// Audit Trail
+	g.GET("/api/audit", h.searchAudit, session.RequireAdmin())
+
+	// IP Allow/Deny Lists
+	g.GET("/api/ipfilter", h.getIPFilter)
+	g.POST("/api/ipfilter/rules", h.addIPRule)
+	g.DELETE("/api/ipfilter/rules/:id", h.removeIPRule)
+	g.GET("/api/ipfilter/blocked", h.getBlockedAttempts)
+
+	// Scanner Bans
+	g.GET("/api/bans", h.getBans)
+	g.POST("/api/bans", h.banIP)
+	g.DELETE("/api/bans/:ip", h.unbanIP)
+
+	// Request Captures
+	g.GET("/api/captures", h.listCaptures)
+	g.GET("/api/captures/har", h.exportCapturesHAR, session.RequireAdmin())
+	g.GET("/api/captures/:id", h.getCapture, session.RequireAdmin())
+	g.POST("/api/captures/token", h.createCaptureToken)
+	g.DELETE("/api/captures", h.clearCaptures)
+
+	// API Keys
+	g.GET("/api/apikeys", h.listAPIKeys)
+	g.POST("/api/apikeys", h.createAPIKey)
+	g.DELETE("/api/apikeys/:id", h.revokeAPIKey)
+
+	// Metric History
+	g.GET("/api/metrics/names", h.getMetricNames)
+	g.GET("/api/metrics/query", h.queryMetrics)
+
+	// Alerting
+	g.GET("/api/alerts", h.getAlerts)
+	g.GET("/api/alerts/silences", h.getSilences)
+	g.POST("/api/alerts/silences", h.addSilence)
+	g.DELETE("/api/alerts/silences/:id", h.removeSilence)
+	g.POST("/api/alerts/test", h.testAlertNotifiers)
+
+	// External Services
+	g.GET("/api/external", h.getExternal)
+	g.GET("/api/external/:name", h.getExternalService)
+
+	// Synthetic Checks
+	g.GET("/api/synthetics", h.getSynthetics)
+	g.GET("/api/synthetics/:name", h.getSyntheticRuns)
+	g.POST("/api/synthetics/:name/run", h.runSynthetic)
+
+	// Status Page (incident notes are public, so only admins post them)
+	g.GET("/api/status-page", h.getStatusPage)
+	g.POST("/api/status-page/incidents", h.createIncident, session.RequireAdmin())
+	g.POST("/api/status-page/incidents/:id/updates", h.addIncidentUpdate, session.RequireAdmin())
+	g.DELETE("/api/status-page/incidents/:id", h.deleteIncident, session.RequireAdmin())
+
+	// Error Tracking
+	g.GET("/api/errors", h.getErrors)
+	g.GET("/api/errors/:id", h.getErrorGroup)
+	g.POST("/api/errors/:id/status", h.setErrorStatus)
+	g.DELETE("/api/errors/:id", h.deleteErrorGroup)
+
+	// Profiling
+	g.GET("/api/profiling", h.getProfiling)
+	g.POST("/api/profiling/captures", h.captureProfile)
+	g.GET("/api/profiling/captures/:id/download", h.downloadProfile, session.RequireAdmin())
+	g.GET("/api/profiling/goroutines", h.getGoroutines, session.RequireAdmin())
 }
 
 func (h *Handler) getDummyStatus(c echo.Context) error {
@@ -114,11 +217,14 @@ func (h *Handler) getMonitoringConfig(c echo.Context) error {
 	})
 }
 
+// runDummyLogs emits sample entries through the log pipeline, so they reach every
+// sink like real logs.
 func (h *Handler) runDummyLogs(stop chan struct{}) {
 	ticker := time.NewTicker(500 * time.Millisecond)
 	defer ticker.Stop()
 
-	levels := []string{"INFO", "WARN", "ERROR", "DEBUG"}
+	l := h.logger.Named("dummy")
+	emit := []func(string, ...interface{}){l.Info, l.Warn, func(msg string, kv ...interface{}) { l.Error(msg, nil, kv...) }, l.Debug}
 	messages := []string{
 		"User login successful",
 		"Cache miss for key user:123",
@@ -135,42 +241,32 @@ func (h *Handler) runDummyLogs(stop chan struct{}) {
 		case <-stop:
 			return
 		case <-ticker.C:
-			level := levels[time.Now().UnixNano()%int64(len(levels))]
+			log := emit[time.Now().UnixNano()%int64(len(emit))]
 			msg := messages[time.Now().UnixNano()%int64(len(messages))]
-
-			// Format as zerolog JSON-like output (or whatever format frontend expects)
-			// Frontend expects raw text.
-			// But broadcaster Write method expects []byte.
-			// We can format it nicely.
-
-			timestamp := time.Now().Format(time.RFC3339)
-			logLine := fmt.Sprintf(`{"time":"%s","level":"%s","message":"[DUMMY] %s"}`+"\n", timestamp, level, msg)
-
-			// If frontend expects raw string from `data:`, and `h.streamLogs` writes `msg` directly...
-			// The broadcaster receives `[]byte` and sends it to channel.
-			// `streamLogs` reads `msg` and writes `fmt.Fprintf(c.Response(), "data: %s\n\n", msg)`
-			// So `msg` should be the full string line.
-
-			h.broadcaster.Write([]byte(logLine))
+			log("[DUMMY] "+msg, "request_id", fmt.Sprintf("dummy-%d", time.Now().UnixNano()%1000))
 		}
 	}
 }
 
+// getStatus answers from the collector's last snapshots without touching any backend.
+// "sources" reports when each was refreshed and why its last refresh failed, if it did.
 func (h *Handler) getStatus(c echo.Context) error {
-	// Collect status from all sources
-	status := h.statusProvider.GetStatus()
-	status["redis"] = h.redis.GetStatus()
-	status["postgres"] = h.postgres.GetStatus()
-	status["kafka"] = h.kafka.GetStatus()
-	status["cron"] = h.cron.GetStatus()
-
-	// New Infrastructure
-	status["storage"] = h.minio.GetStatus()
-	status["system"] = h.system.GetStats()
-	status["system_info"] = h.system.GetHostInfo()
-	status["external"] = h.http.GetStatus()
+	snaps := h.collector.Snapshots()
 
+	status := make(map[string]interface{})
+	if app, ok := snaps["app"].Data.(map[string]interface{}); ok {
+		for k, v := range app {
+			status[k] = v
+		}
+	}
+	for name, snap := range snaps {
+		if name != "app" {
+			status[name] = snap.Data
+		}
+	}
+	status["external"] = h.externalStatuses() // cached, probed in the background
 	status["services"] = h.services
+	status["sources"] = snaps
 	return response.Success(c, status)
 }
 
@@ -200,6 +296,7 @@ func (h *Handler) getRedisKeys(c echo.Context) error {
 }
 
 func (h *Handler) Restart(c echo.Context) error {
+	h.recordAudit(c, "app.restart", "application", "", "", nil)
 	go func() {
 		time.Sleep(500 * time.Millisecond)
 		os.Exit(1)
@@ -268,6 +365,10 @@ func (h *Handler) runPostgresQuery(c echo.Context) error {
 	// }
 
 	results, err := h.postgres.ExecuteRawQuery(c.Request().Context(), req.Query)
+	h.recordAudit(c, "postgres.query", "postgres", "", audit.Summarize(map[string]interface{}{
+		"query": req.Query,
+		"rows":  len(results),
+	}, h.config.Audit.MaxBodyBytes, redact.New(nil, h.config.Audit.RedactFields)), err)
 	if err != nil {
 		return response.InternalServerError(c, err.Error())
 	}
@@ -324,8 +425,11 @@ func (h *Handler) saveBanner(c echo.Context) error {
 		path = "banner.txt"
 	}
 
+	before, _ := os.ReadFile(path)
+
 	// Write file (create if local, 0644)
 	err := os.WriteFile(path, []byte(req.Content), 0644)
+	h.recordAudit(c, "banner.save", path, contentSummary(before), contentSummary([]byte(req.Content)), err)
 	if err != nil {
 		return response.InternalServerError(c, "Failed to save banner: "+err.Error())
 	}
@@ -351,7 +455,10 @@ func (h *Handler) saveConfig(c echo.Context) error {
 		return response.BadRequest(c, "Invalid request")
 	}
 
+	before, _ := os.ReadFile("config.yaml")
+
 	err := os.WriteFile("config.yaml", []byte(req.Content), 0644)
+	h.recordAudit(c, "config.save", "config.yaml", contentSummary(before), contentSummary([]byte(req.Content)), err)
 	if err != nil {
 		return response.InternalServerError(c, "Failed to save config: "+err.Error())
 	}
@@ -367,6 +474,7 @@ func (h *Handler) backupConfig(c echo.Context) error {
 
 	backupName := fmt.Sprintf("config.yaml.bak.%d", time.Now().Unix())
 	err = os.WriteFile(backupName, input, 0644)
+	h.recordAudit(c, "config.backup", backupName, "", contentSummary(input), err)
 	if err != nil {
 		return response.InternalServerError(c, "Failed to create backup: "+err.Error())
 	}
@@ -374,18 +482,34 @@ func (h *Handler) backupConfig(c echo.Context) error {
 	return response.Success(c, nil, "Backup created: "+backupName)
 }
 
+// streamLogs sends log lines as server-sent events. With a log store, a client
+// reconnecting with Last-Event-ID (or ?last_event_id=) first receives the lines it
+// missed, and ?backfill=N replays the last N lines to a new client.
 func (h *Handler) streamLogs(c echo.Context) error {
 	c.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
 	c.Response().Header().Set(echo.HeaderCacheControl, "no-cache")
 	c.Response().Header().Set(echo.HeaderConnection, "keep-alive")
 
+	// Subscribe before replaying so nothing falls between the two
 	logs := h.broadcaster.Subscribe()
 	defer h.broadcaster.Unsubscribe(logs)
 
+	var sent uint64
+	if store := h.broadcaster.Store(); store != nil {
+		for _, e := range logBackfill(c, store) {
+			writeLogEvent(c, LogEvent{ID: e.ID, Data: []byte(e.Raw)})
+			sent = e.ID
+		}
+		c.Response().Flush()
+	}
+
 	for {
 		select {
-		case msg := <-logs:
-			fmt.Fprintf(c.Response(), "data: %s\n\n", msg)
+		case ev := <-logs:
+			if ev.ID != 0 && ev.ID <= sent {
+				continue // already replayed
+			}
+			writeLogEvent(c, ev)
 			c.Response().Flush()
 		case <-c.Request().Context().Done():
 			return nil
@@ -393,19 +517,20 @@ func (h *Handler) streamLogs(c echo.Context) error {
 	}
 }
 
+// streamCPU pushes the CPU usage of every shared sample; clients never trigger a
+// measurement themselves.
 func (h *Handler) streamCPU(c echo.Context) error {
 	c.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
 	c.Response().Header().Set(echo.HeaderCacheControl, "no-cache")
 	c.Response().Header().Set(echo.HeaderConnection, "keep-alive")
 
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
+	samples, cancel := sysstats.Default().Subscribe()
+	defer cancel()
 
 	for {
 		select {
-		case <-ticker.C:
-			stats, _ := utils.GetSystemStats()
-			fmt.Fprintf(c.Response(), "data: %.2f\n\n", stats["cpu_percent"])
+		case smp := <-samples:
+			fmt.Fprintf(c.Response(), "data: %.2f\n\n", smp.CPU.Percent)
 			c.Response().Flush()
 		case <-c.Request().Context().Done():
 			return nil
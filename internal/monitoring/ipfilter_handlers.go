@@ -0,0 +1,110 @@
+package monitoring
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"test-go/internal/audit"
+	"test-go/internal/ipfilter"
+	"test-go/internal/monitoring/session"
+	"test-go/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+type IPRuleRequest struct {
+	Scope  string `json:"scope"`
+	Group  string `json:"group"`
+	Action string `json:"action"`
+	CIDR   string `json:"cidr"`
+	Note   string `json:"note"`
+}
+
+func (h *Handler) getIPFilter(c echo.Context) error {
+	if h.ipFilter == nil {
+		return response.Success(c, map[string]interface{}{
+			"enabled": false,
+			"rules":   []ipfilter.Rule{},
+		})
+	}
+
+	return response.Success(c, map[string]interface{}{
+		"enabled":         true,
+		"trusted_proxies": h.ipFilter.TrustedProxies(),
+		"client_ip":       c.RealIP(),
+		"rules":           h.ipFilter.Rules(),
+	})
+}
+
+func (h *Handler) addIPRule(c echo.Context) error {
+	if h.ipFilter == nil {
+		return response.ServiceUnavailable(c, "IP filter is disabled")
+	}
+
+	var req IPRuleRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request")
+	}
+
+	rule := ipfilter.Rule{
+		Scope:  req.Scope,
+		Group:  req.Group,
+		Action: req.Action,
+		CIDR:   req.CIDR,
+		Note:   req.Note,
+	}
+	if sess, ok := c.Get("session").(*session.Session); ok {
+		rule.CreatedBy = sess.Username
+	}
+
+	rule, err := h.ipFilter.AddRule(c.Request().Context(), rule, net.ParseIP(c.RealIP()))
+	h.recordAudit(c, "ipfilter.add", req.Scope+" "+req.Group, "", audit.Summarize(req, 0, nil), err)
+	if err != nil {
+		if errors.Is(err, ipfilter.ErrLockout) {
+			return response.Conflict(c, err.Error())
+		}
+		return response.BadRequest(c, err.Error())
+	}
+
+	return response.Created(c, rule, "Rule added")
+}
+
+func (h *Handler) removeIPRule(c echo.Context) error {
+	if h.ipFilter == nil {
+		return response.ServiceUnavailable(c, "IP filter is disabled")
+	}
+
+	id := c.Param("id")
+	rule, err := h.ipFilter.RemoveRule(c.Request().Context(), id, net.ParseIP(c.RealIP()))
+	var before string
+	if err == nil {
+		before = audit.Summarize(rule, 0, nil)
+	}
+	h.recordAudit(c, "ipfilter.remove", id, before, "", err)
+	switch {
+	case errors.Is(err, ipfilter.ErrRuleNotFound):
+		return response.NotFound(c, err.Error())
+	case errors.Is(err, ipfilter.ErrReadOnlyRule), errors.Is(err, ipfilter.ErrLockout):
+		return response.Conflict(c, err.Error())
+	case err != nil:
+		return response.InternalServerError(c, err.Error())
+	}
+
+	return response.Success(c, rule, "Rule removed")
+}
+
+func (h *Handler) getBlockedAttempts(c echo.Context) error {
+	if h.ipFilter == nil {
+		return response.Success(c, []ipfilter.BlockedAttempt{})
+	}
+
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if limit <= 0 {
+		limit = 100
+	}
+
+	attempts, total := h.ipFilter.Blocked(limit)
+	return response.SuccessWithMeta(c, attempts, &response.Meta{
+		Total: int64(total),
+	})
+}
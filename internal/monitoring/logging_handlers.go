@@ -0,0 +1,136 @@
+package monitoring
+
+import (
+	"strings"
+	"test-go/internal/audit"
+	"test-go/internal/monitoring/session"
+	"test-go/pkg/response"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type LogLevelRequest struct {
+	Module      string `json:"module"` // empty = root logger
+	Level       string `json:"level"`
+	RevertAfter string `json:"revert_after"` // e.g. "15m"; empty keeps the level until changed
+}
+
+type DebugTokenRequest struct {
+	TTL string `json:"ttl"` // e.g. "15m"; capped at log.debug_override.max_ttl
+}
+
+func (h *Handler) getLogLevels(c echo.Context) error {
+	if h.logger == nil {
+		return response.ServiceUnavailable(c, "Logging control is unavailable")
+	}
+
+	dc := h.config.Log.DebugOverride
+	return response.Success(c, map[string]interface{}{
+		"levels": h.logger.Levels(),
+		"debug_override": map[string]interface{}{
+			"enabled": h.debugSigner != nil,
+			"header":  dc.Header,
+			"max_ttl": dc.MaxTTL.String(),
+		},
+	})
+}
+
+// setLogLevel changes the root or a module level, optionally reverting it later.
+func (h *Handler) setLogLevel(c echo.Context) error {
+	if h.logger == nil {
+		return response.ServiceUnavailable(c, "Logging control is unavailable")
+	}
+
+	var req LogLevelRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request")
+	}
+	req.Module = strings.TrimSpace(req.Module)
+
+	var ttl time.Duration
+	if v := strings.TrimSpace(req.RevertAfter); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return response.BadRequest(c, "Invalid 'revert_after', expected a duration such as 15m")
+		}
+		ttl = d
+	}
+
+	before := h.findLogLevel(req.Module)
+	st, err := h.logger.SetLevel(req.Module, req.Level, ttl)
+	h.recordAudit(c, "logging.level", logModuleTarget(req.Module), before, audit.Summarize(req, 0, nil), err)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	h.logger.Warn("Log level changed", "module", logModuleTarget(req.Module), "level", st.Level, "revert_after", ttl.String(), "by", sessionUser(c))
+	return response.Success(c, st, "Log level changed")
+}
+
+// resetLogLevel restores the configured level of ?module= (the root logger when empty).
+func (h *Handler) resetLogLevel(c echo.Context) error {
+	if h.logger == nil {
+		return response.ServiceUnavailable(c, "Logging control is unavailable")
+	}
+
+	module := strings.TrimSpace(c.QueryParam("module"))
+	before := h.findLogLevel(module)
+	st := h.logger.ResetLevel(module)
+	h.recordAudit(c, "logging.reset", logModuleTarget(module), before, st.Level, nil)
+
+	h.logger.Warn("Log level reset", "module", logModuleTarget(module), "level", st.Level, "by", sessionUser(c))
+	return response.Success(c, st, "Log level reset")
+}
+
+// createDebugToken mints a token that logs requests carrying it at debug level.
+func (h *Handler) createDebugToken(c echo.Context) error {
+	if h.debugSigner == nil {
+		return response.ServiceUnavailable(c, "Per-request debug logging is disabled")
+	}
+
+	var req DebugTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request")
+	}
+	var ttl time.Duration
+	if v := strings.TrimSpace(req.TTL); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return response.BadRequest(c, "Invalid 'ttl', expected a duration such as 15m")
+		}
+		ttl = d
+	}
+
+	token, expires := h.debugSigner.Sign(ttl)
+	h.recordAudit(c, "logging.debug_token", "expires "+expires.Format(time.RFC3339), "", "", nil)
+
+	return response.Created(c, map[string]interface{}{
+		"header":     h.config.Log.DebugOverride.Header,
+		"token":      token,
+		"expires_at": expires,
+	}, "Debug token created")
+}
+
+func (h *Handler) findLogLevel(module string) string {
+	for _, st := range h.logger.Levels() {
+		if st.Module == module {
+			return st.Level
+		}
+	}
+	return ""
+}
+
+func logModuleTarget(module string) string {
+	if module == "" {
+		return "root"
+	}
+	return module
+}
+
+func sessionUser(c echo.Context) string {
+	if sess, ok := c.Get("session").(*session.Session); ok {
+		return sess.Username
+	}
+	return ""
+}
@@ -0,0 +1,196 @@
+package monitoring
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"test-go/internal/logstore"
+	"test-go/pkg/response"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	maxLogBackfill = 1000
+	maxLogExport   = 100000
+)
+
+// logBackfill returns the entries a log stream client should receive before live
+// lines: those after Last-Event-ID when resuming, or the last ?backfill=N lines.
+func logBackfill(c echo.Context, store *logstore.Store) []logstore.Entry {
+	lastID := c.Request().Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.QueryParam("last_event_id")
+	}
+	if id, err := strconv.ParseUint(lastID, 10, 64); err == nil && id > 0 {
+		return store.Since(id, maxLogBackfill)
+	}
+
+	n, _ := strconv.Atoi(c.QueryParam("backfill"))
+	if n <= 0 {
+		return nil
+	}
+	if n > maxLogBackfill {
+		n = maxLogBackfill
+	}
+	res, _ := store.Query(logstore.Filter{Limit: n})
+	return res.Entries
+}
+
+func writeLogEvent(c echo.Context, ev LogEvent) {
+	data := bytes.TrimRight(ev.Data, "\r\n")
+	if ev.ID > 0 {
+		fmt.Fprintf(c.Response(), "id: %d\n", ev.ID)
+	}
+	fmt.Fprintf(c.Response(), "data: %s\n\n", data)
+}
+
+// parseLogFilter reads the shared query parameters of the log search and export:
+// level (minimum), from/to (as for metric history), q (substring), regex,
+// field=key:value (repeatable), after, before and limit.
+func parseLogFilter(c echo.Context) (logstore.Filter, error) {
+	f := logstore.Filter{
+		Level: c.QueryParam("level"),
+		Text:  c.QueryParam("q"),
+	}
+
+	now := time.Now()
+	var err error
+	if f.From, err = parseQueryTime(c.QueryParam("from"), now, time.Time{}); err != nil {
+		return f, errors.New("Invalid 'from' time")
+	}
+	if f.To, err = parseQueryTime(c.QueryParam("to"), now, time.Time{}); err != nil {
+		return f, errors.New("Invalid 'to' time")
+	}
+
+	if v := c.QueryParam("regex"); v != "" {
+		if f.Regex, err = regexp.Compile(v); err != nil {
+			return f, fmt.Errorf("Invalid 'regex': %v", err)
+		}
+	}
+
+	for _, v := range c.QueryParams()["field"] {
+		key, val, ok := strings.Cut(v, ":")
+		if !ok || key == "" {
+			return f, errors.New("Invalid 'field', expected key:value")
+		}
+		if f.Fields == nil {
+			f.Fields = make(map[string]string)
+		}
+		f.Fields[key] = val
+	}
+
+	for name, dst := range map[string]*uint64{"after": &f.After, "before": &f.Before} {
+		if v := c.QueryParam(name); v != "" {
+			if *dst, err = strconv.ParseUint(v, 10, 64); err != nil {
+				return f, fmt.Errorf("Invalid '%s' ID", name)
+			}
+		}
+	}
+	if v := c.QueryParam("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit <= 0 {
+			return f, errors.New("Invalid 'limit'")
+		}
+	}
+	return f, nil
+}
+
+// queryLogs searches stored log lines. Without after, the newest matches are
+// returned; pass before= the oldest ID of a page to load older lines.
+func (h *Handler) queryLogs(c echo.Context) error {
+	store := h.broadcaster.Store()
+	if store == nil {
+		return response.ServiceUnavailable(c, "Log store is disabled")
+	}
+
+	f, err := parseLogFilter(c)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	res, err := store.Query(f)
+	if errors.Is(err, logstore.ErrBadRange) {
+		return response.BadRequest(c, "'to' must not be before 'from'")
+	}
+	if err != nil {
+		return response.InternalServerError(c, "Failed to query logs")
+	}
+	if res.Entries == nil {
+		res.Entries = []logstore.Entry{}
+	}
+
+	return response.Success(c, map[string]interface{}{
+		"entries": res.Entries,
+		"more":    res.More,
+		"stats":   store.Stats(),
+	})
+}
+
+// exportLogs downloads the matching log lines as NDJSON (default) or CSV.
+func (h *Handler) exportLogs(c echo.Context) error {
+	store := h.broadcaster.Store()
+	if store == nil {
+		return response.ServiceUnavailable(c, "Log store is disabled")
+	}
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "ndjson"
+	}
+	if format != "ndjson" && format != "csv" {
+		return response.BadRequest(c, "Invalid 'format', expected ndjson or csv")
+	}
+
+	f, err := parseLogFilter(c)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+	if f.Limit == 0 {
+		f.Limit = maxLogExport
+	}
+
+	res, err := store.Query(f)
+	if errors.Is(err, logstore.ErrBadRange) {
+		return response.BadRequest(c, "'to' must not be before 'from'")
+	}
+	if err != nil {
+		return response.InternalServerError(c, "Failed to query logs")
+	}
+
+	name := fmt.Sprintf("logs-%s.%s", time.Now().Format("20060102-150405"), format)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", name))
+
+	if format == "csv" {
+		c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+		c.Response().WriteHeader(http.StatusOK)
+		w := csv.NewWriter(c.Response())
+		w.Write([]string{"id", "time", "level", "message", "fields"})
+		for _, e := range res.Entries {
+			var fields string
+			if len(e.Fields) > 0 {
+				b, _ := json.Marshal(e.Fields)
+				fields = string(b)
+			}
+			w.Write([]string{strconv.FormatUint(e.ID, 10), e.Time.Format(time.RFC3339Nano), e.Level, e.Message, fields})
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "application/x-ndjson")
+	c.Response().WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(c.Response())
+	for _, e := range res.Entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package monitoring
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"test-go/internal/metricstore"
+	"test-go/pkg/response"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) getMetricNames(c echo.Context) error {
+	if h.history == nil {
+		return response.Success(c, map[string]interface{}{"enabled": false, "names": []string{}})
+	}
+	return response.Success(c, map[string]interface{}{"enabled": true, "names": h.history.Names()})
+}
+
+// queryMetrics returns one metric aggregated over a time range.
+// from and to accept RFC3339, Unix milliseconds, "now" or a duration ago such as
+// "24h"; step accepts a duration such as "5m" or seconds. Defaults: the last hour
+// and about 300 points.
+func (h *Handler) queryMetrics(c echo.Context) error {
+	if h.history == nil {
+		return response.ServiceUnavailable(c, "Metric history is disabled")
+	}
+
+	name := c.QueryParam("name")
+	if name == "" {
+		return response.BadRequest(c, "Query parameter 'name' is required")
+	}
+
+	now := time.Now()
+	from, err := parseQueryTime(c.QueryParam("from"), now, now.Add(-time.Hour))
+	if err != nil {
+		return response.BadRequest(c, "Invalid 'from' time")
+	}
+	to, err := parseQueryTime(c.QueryParam("to"), now, now)
+	if err != nil {
+		return response.BadRequest(c, "Invalid 'to' time")
+	}
+
+	var step time.Duration
+	if v := c.QueryParam("step"); v != "" {
+		if secs, err := strconv.ParseFloat(v, 64); err == nil {
+			step = time.Duration(secs * float64(time.Second))
+		} else if step, err = time.ParseDuration(v); err != nil {
+			return response.BadRequest(c, "Invalid 'step', expected a duration such as 1m")
+		}
+	}
+
+	series, err := h.history.Query(c.Request().Context(), name, from, to, step)
+	if errors.Is(err, metricstore.ErrBadRange) {
+		return response.BadRequest(c, "'to' must not be before 'from'")
+	}
+	if err != nil {
+		return response.InternalServerError(c, "Failed to query metric history")
+	}
+	return response.Success(c, series)
+}
+
+func parseQueryTime(v string, now, def time.Time) (time.Time, error) {
+	v = strings.TrimSpace(v)
+	switch {
+	case v == "":
+		return def, nil
+	case v == "now":
+		return now, nil
+	}
+
+	if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	if d, err := time.ParseDuration(strings.TrimPrefix(v, "-")); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
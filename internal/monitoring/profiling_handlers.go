@@ -0,0 +1,86 @@
+package monitoring
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"test-go/internal/audit"
+	"test-go/internal/profiling"
+	"test-go/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ProfileRequest struct {
+	Kind    string `json:"kind"`    // cpu, heap, goroutine, mutex or block
+	Seconds int    `json:"seconds"` // for cpu, mutex and block
+}
+
+func (h *Handler) getProfiling(c echo.Context) error {
+	if h.profiler == nil {
+		return response.Success(c, map[string]interface{}{
+			"enabled":  false,
+			"captures": []profiling.Capture{},
+		})
+	}
+
+	return response.Success(c, map[string]interface{}{
+		"enabled":     true,
+		"pprof":       h.config.Monitoring.Profiling.Pprof,
+		"kinds":       profiling.Kinds,
+		"max_seconds": h.profiler.MaxSeconds(),
+		"captures":    h.profiler.Captures(),
+		"auto":        h.profiler.Auto(),
+	})
+}
+
+// captureProfile starts a capture in the background; poll getProfiling for its status.
+func (h *Handler) captureProfile(c echo.Context) error {
+	if h.profiler == nil {
+		return response.ServiceUnavailable(c, "Profiling is disabled")
+	}
+
+	var req ProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request")
+	}
+
+	capture, err := h.profiler.Start(req.Kind, req.Seconds, "manual", sessionUser(c))
+	h.recordAudit(c, "profiling.capture", req.Kind, "", audit.Summarize(req, 0, nil), err)
+	switch {
+	case errors.Is(err, profiling.ErrUnknownKind):
+		return response.BadRequest(c, err.Error())
+	case errors.Is(err, profiling.ErrBusy):
+		return response.Conflict(c, err.Error())
+	case err != nil:
+		return response.InternalServerError(c, err.Error())
+	}
+
+	return response.Created(c, capture, "Profile capture started")
+}
+
+// downloadProfile returns a finished capture for `go tool pprof`. The route needs the
+// admin role, like /debug/pprof, as heap profiles can reveal secrets held in memory.
+func (h *Handler) downloadProfile(c echo.Context) error {
+	if h.profiler == nil {
+		return response.ServiceUnavailable(c, "Profiling is disabled")
+	}
+
+	capture, data, err := h.profiler.Data(c.Param("id"))
+	if err != nil {
+		return response.NotFound(c, err.Error())
+	}
+	h.recordAudit(c, "profiling.download", capture.Kind+" "+capture.ID, "", "", nil)
+
+	name := fmt.Sprintf("%s-%s.pprof", capture.Kind, capture.StartedAt.Format("20060102-150405"))
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", name))
+	return c.Blob(http.StatusOK, "application/octet-stream", data)
+}
+
+// getGoroutines returns the current goroutines grouped by stack.
+func (h *Handler) getGoroutines(c echo.Context) error {
+	if h.profiler == nil {
+		return response.ServiceUnavailable(c, "Profiling is disabled")
+	}
+	return response.Success(c, profiling.Goroutines())
+}
@@ -0,0 +1,52 @@
+package monitoring
+
+import (
+	"test-go/internal/monitoring/database"
+	"test-go/pkg/response"
+	"test-go/pkg/sysstats"
+
+	"github.com/labstack/echo/v4"
+)
+
+// getResources reports what this process itself uses: its own CPU, memory, file
+// descriptors and threads, the Go heap and GC, the cgroup's limits, and the
+// filesystems holding the files the application writes.
+func (h *Handler) getResources(c echo.Context) error {
+	sampler := sysstats.Default()
+	smp, _ := sampler.Latest()
+
+	var cgroup interface{}
+	if st, ok := sampler.Cgroup(); ok {
+		cgroup = st
+	}
+
+	return response.Success(c, map[string]interface{}{
+		"process":     smp.Process,
+		"runtime":     sysstats.ReadRuntime(),
+		"cgroup":      cgroup,
+		"filesystems": sysstats.Filesystems(h.appPaths()),
+	})
+}
+
+// appPaths lists the directories and files the application writes to.
+func (h *Handler) appPaths() []sysstats.FilesystemPath {
+	uploadDir := h.config.Monitoring.UploadDir
+	if uploadDir == "" {
+		uploadDir = "web/monitoring/uploads"
+	}
+	paths := []sysstats.FilesystemPath{
+		{Label: "Working directory", Path: "."},
+		{Label: "Upload directory", Path: uploadDir},
+		{Label: "SQLite database", Path: database.FileName},
+	}
+	if ls := h.config.Monitoring.LogStore; ls.Enabled && ls.Dir != "" {
+		paths = append(paths, sysstats.FilesystemPath{Label: "Log store", Path: ls.Dir})
+	}
+	if f := h.config.Log.File; f.Enabled && f.Path != "" {
+		paths = append(paths, sysstats.FilesystemPath{Label: "Application log", Path: f.Path})
+	}
+	if al := h.config.AccessLog; al.Enabled && al.Output == "file" && al.File.Path != "" {
+		paths = append(paths, sysstats.FilesystemPath{Label: "Access log", Path: al.File.Path})
+	}
+	return paths
+}
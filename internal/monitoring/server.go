@@ -1,14 +1,30 @@
 package monitoring
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"net/http/pprof"
 	"test-go/config"
+	"test-go/internal/alerting"
+	"test-go/internal/apikey"
+	"test-go/internal/audit"
+	"test-go/internal/capture"
+	"test-go/internal/errtrack"
+	"test-go/internal/ipfilter"
+	"test-go/internal/metricstore"
 	"test-go/internal/monitoring/database"
 	"test-go/internal/monitoring/session"
+	"test-go/internal/monitoring/sso"
+	"test-go/internal/probeguard"
+	"test-go/internal/prober"
+	"test-go/internal/profiling"
+	"test-go/internal/statuspage"
 	"test-go/pkg/infrastructure"
+	"test-go/pkg/logger"
 	"time"
 
+	appMiddleware "test-go/internal/middleware"
 	monMiddleware "test-go/internal/monitoring/middleware"
 
 	"github.com/labstack/echo/v4"
@@ -19,6 +35,24 @@ type StatusProvider interface {
 	GetStatus() map[string]interface{}
 }
 
+// Options carries subsystems shared with the API server. Nil fields are disabled.
+type Options struct {
+	Auditor       *audit.Recorder
+	IPFilter      *ipfilter.Filter
+	ProbeGuard    *probeguard.Guard
+	Captures      *capture.Store
+	APIKeys       *apikey.Manager
+	History       *metricstore.History
+	Alerts        *alerting.Engine
+	Logger        *logger.Logger
+	Debug         *logger.DebugSigner // nil when per-request debug logging is disabled
+	CaptureTokens *logger.DebugSigner // nil when capture tokens are disabled
+	Profiler      *profiling.Profiler // nil when profiling is disabled
+	Prober        *prober.Prober      // nil when no external services are configured
+	Synthetics    *prober.Synthetics  // nil when no synthetic checks are configured
+	Errors        *errtrack.Tracker   // nil when error tracking is disabled
+}
+
 type ServiceInfo struct {
 	Name       string   `json:"name"`
 	StructName string   `json:"struct_name"`
@@ -36,6 +70,7 @@ func Start(
 	kafka *infrastructure.KafkaManager,
 	cron *infrastructure.CronManager,
 	services []ServiceInfo,
+	opts Options,
 ) {
 	// Initialize database
 	if err := database.InitDB(); err != nil {
@@ -72,7 +107,10 @@ func Start(
 	}
 
 	systemMgr := infrastructure.NewSystemManager()
-	httpMgr := infrastructure.NewHttpManager(appConfig.Monitoring.External)
+
+	// Status collection (each source refreshes in the background on its own interval)
+	statusCollector := newStatusCollector(cfg.Status, statusProvider, redis, postgres, kafka, cron, minioMgr, systemMgr, opts.Logger.Named("collector"))
+	statusCollector.Start(context.Background())
 
 	// Initialize session manager
 	sessionManager := session.NewManager(24 * time.Hour)
@@ -81,13 +119,34 @@ func Start(
 	e.HideBanner = true
 	e.HidePort = true
 
+	// Client addresses honour forwarding headers only from trusted proxies
+	extractor, err := ipfilter.NewIPExtractor(appConfig.IPFilter.TrustedProxies)
+	if err != nil {
+		fmt.Printf("⚠️  Warning: Ignoring trusted proxies: %v\n", err)
+		extractor = echo.ExtractIPDirect()
+	}
+	e.IPExtractor = extractor
+
 	// Middleware
 	e.Use(middleware.Recover())
+	if appConfig.Tracing.Enabled {
+		e.Use(appMiddleware.Tracing())
+	}
+	if opts.IPFilter != nil {
+		e.Use(appMiddleware.IPFilter(opts.IPFilter, ipfilter.ScopeMonitoring))
+	}
+	if opts.ProbeGuard != nil {
+		e.Use(appMiddleware.ProbeGuard(opts.ProbeGuard))
+	}
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins:  []string{"*"},
 		AllowHeaders:  []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, "X-Correlation-ID"},
 		ExposeHeaders: []string{"X-Obfuscated"},
 	}))
+	// Compression wraps the obfuscator so the Base64 output is what gets compressed
+	if appConfig.Compression.Enabled {
+		e.Use(appMiddleware.Compress(appConfig.Compression))
+	}
 	e.Use(monMiddleware.Obfuscator(cfg.ObfuscateAPI))
 
 	// Public routes (no auth required)
@@ -96,13 +155,56 @@ func Start(
 	})
 	e.Static("/assets", "web/monitoring/assets")
 
+	// Public status page (component names and states only, no internal details)
+	var statusPage *statuspage.Page
+	if cfg.StatusPage.Enabled {
+		var store statuspage.Store
+		if sqlStore, err := statuspage.NewSQLStore(database.GetDB()); err != nil {
+			fmt.Printf("⚠️  Warning: Status page uptime and incidents will not persist: %v\n", err)
+		} else {
+			store = sqlStore
+		}
+		sources := statusPageSources(statusCollector, services, opts)
+		if statusPage, err = statuspage.New(cfg.StatusPage, sources, store, opts.Logger.Named("statuspage")); err != nil {
+			fmt.Printf("⚠️  Warning: Status page disabled: %v\n", err)
+		} else {
+			statusPage.Start(context.Background())
+			e.GET("/status", func(c echo.Context) error {
+				return c.File("web/monitoring/status.html")
+			})
+			e.GET("/status.json", getPublicStatus(statusPage))
+			fmt.Println("✅ Status page enabled at /status")
+		}
+	}
+
+	// Single sign-on (password login stays available as a break-glass fallback)
+	var ssoProvider *sso.Provider
+	if cfg.OIDC.Enabled {
+		if ssoProvider, err = sso.New(cfg.OIDC); err != nil {
+			fmt.Printf("⚠️  Warning: Single sign-on disabled: %v\n", err)
+		} else {
+			fmt.Println("✅ Single sign-on enabled", cfg.OIDC.Issuer)
+		}
+	}
+
+	// Prometheus scrape endpoint (public, optionally token protected)
+	if appConfig.Metrics.Enabled && appConfig.Metrics.Server == "monitoring" {
+		e.GET(appConfig.Metrics.Path, appMiddleware.MetricsHandler(appConfig.Metrics.Token))
+	}
+
 	// Auth endpoints
-	e.POST("/login", handleLogin(sessionManager))
-	e.POST("/logout", handleLogout(sessionManager))
+	e.POST("/login", handleLogin(sessionManager, opts.Auditor))
+	e.POST("/logout", handleLogout(sessionManager, ssoProvider, opts.Auditor))
+	e.GET("/auth/oidc", handleOIDCInfo(ssoProvider))
+	if ssoProvider != nil {
+		e.GET("/auth/oidc/login", handleOIDCLogin(ssoProvider))
+		e.GET("/auth/oidc/callback", handleOIDCCallback(ssoProvider, sessionManager, opts.Auditor))
+	}
 
-	// Protected routes group (require session)
+	// Protected routes group (require session; viewers are read-only)
 	protected := e.Group("")
 	protected.Use(session.Middleware(sessionManager))
+	protected.Use(session.RequireWrite())
 
 	// Dashboard and API routes (protected)
 	protected.GET("/dashboard", func(c echo.Context) error {
@@ -122,10 +224,37 @@ func Start(
 		services:       services,
 		minio:          minioMgr,
 		system:         systemMgr,
-		http:           httpMgr,
+		audit:          opts.Auditor,
+		ipFilter:       opts.IPFilter,
+		probeGuard:     opts.ProbeGuard,
+		captures:       opts.Captures,
+		apiKeys:        opts.APIKeys,
+		history:        opts.History,
+		alerts:         opts.Alerts,
+		logger:         opts.Logger,
+		debugSigner:    opts.Debug,
+		captureSigner:  opts.CaptureTokens,
+		profiler:       opts.Profiler,
+		prober:         opts.Prober,
+		synthetics:     opts.Synthetics,
+		statusPage:     statusPage,
+		collector:      statusCollector,
+		errors:         opts.Errors,
 	}
 	h.RegisterRoutes(protected)
 
+	// net/http/pprof, for admins only (profiles can reveal secrets held in memory)
+	if opts.Profiler != nil && cfg.Profiling.Pprof {
+		dbg := protected.Group("/debug/pprof", session.RequireAdmin())
+		index := echo.WrapHandler(http.HandlerFunc(pprof.Index))
+		dbg.GET("", index)
+		dbg.GET("/*", index)
+		dbg.GET("/cmdline", echo.WrapHandler(http.HandlerFunc(pprof.Cmdline)))
+		dbg.GET("/profile", echo.WrapHandler(http.HandlerFunc(pprof.Profile)))
+		dbg.Match([]string{http.MethodGet, http.MethodPost}, "/symbol", echo.WrapHandler(http.HandlerFunc(pprof.Symbol)))
+		dbg.GET("/trace", echo.WrapHandler(http.HandlerFunc(pprof.Trace)))
+	}
+
 	fmt.Printf("📊 Monitoring UI running on http://localhost:%s\n", cfg.Port)
 	if err := e.Start(":" + cfg.Port); err != nil && err != http.ErrServerClosed {
 		fmt.Printf("Failed to start monitoring server: %v\n", err)
@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"time"
 
+	"test-go/pkg/response"
+
 	"github.com/labstack/echo/v4"
 )
 
@@ -36,6 +38,36 @@ func Middleware(manager *Manager) echo.MiddlewareFunc {
 	}
 }
 
+// RequireWrite rejects state-changing requests from read-only sessions.
+// It must run after Middleware.
+func RequireWrite() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			switch c.Request().Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				return next(c)
+			}
+			if sess, ok := c.Get("session").(*Session); ok && sess.ReadOnly() {
+				return response.Forbidden(c, "Your role has read-only access")
+			}
+			return next(c)
+		}
+	}
+}
+
+// RequireAdmin rejects every request from read-only sessions, for routes that expose
+// process internals. It must run after Middleware.
+func RequireAdmin() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if sess, ok := c.Get("session").(*Session); ok && sess.ReadOnly() {
+				return response.Forbidden(c, "This requires the admin role")
+			}
+			return next(c)
+		}
+	}
+}
+
 // SetCookie sets the session cookie
 func SetCookie(c echo.Context, sessionID string, maxAge int) {
 	cookie := new(http.Cookie)
@@ -7,14 +7,34 @@ import (
 	"time"
 )
 
+// Dashboard roles. Viewers can browse but not change anything.
+const (
+	RoleAdmin  = "admin"
+	RoleViewer = "viewer"
+)
+
+// Login methods
+const (
+	MethodPassword = "password"
+	MethodOIDC     = "oidc"
+)
+
 // Session represents a user session
 type Session struct {
 	ID        string
 	Username  string
+	Role      string
+	Method    string
+	IDToken   string // raw OIDC ID token, used as id_token_hint on logout
 	CreatedAt time.Time
 	ExpiresAt time.Time
 }
 
+// ReadOnly reports whether the session may only read.
+func (s *Session) ReadOnly() bool {
+	return s.Role != RoleAdmin
+}
+
 // Manager manages user sessions
 type Manager struct {
 	sessions map[string]*Session
@@ -39,8 +59,13 @@ func NewManager(ttl time.Duration) *Manager {
 	return m
 }
 
-// Create creates a new session
+// Create creates a new admin session for a password login
 func (m *Manager) Create(username string) (*Session, error) {
+	return m.CreateWith(username, RoleAdmin, MethodPassword, "")
+}
+
+// CreateWith creates a new session with the given role and login method
+func (m *Manager) CreateWith(username, role, method, idToken string) (*Session, error) {
 	sessionID, err := generateSessionID()
 	if err != nil {
 		return nil, err
@@ -49,6 +74,9 @@ func (m *Manager) Create(username string) (*Session, error) {
 	session := &Session{
 		ID:        sessionID,
 		Username:  username,
+		Role:      role,
+		Method:    method,
+		IDToken:   idToken,
 		CreatedAt: time.Now(),
 		ExpiresAt: time.Now().Add(m.ttl),
 	}
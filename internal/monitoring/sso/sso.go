@@ -0,0 +1,271 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"test-go/config"
+	"test-go/internal/monitoring/session"
+	"test-go/pkg/cache"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// StateCookieName binds a login attempt to the browser that started it.
+const StateCookieName = "gobp_oidc_state"
+
+// loginTimeout bounds how long a user may take at the identity provider.
+const loginTimeout = 10 * time.Minute
+
+var (
+	ErrUnknownState = errors.New("login attempt expired or was not started here")
+	ErrNoRole       = errors.New("your account is not mapped to a dashboard role")
+)
+
+// Identity is a user authenticated by the identity provider.
+type Identity struct {
+	Subject  string
+	Username string
+	Email    string
+	Groups   []string
+	Role     string
+	IDToken  string
+}
+
+// pendingLogin holds the per-attempt secrets until the callback arrives.
+type pendingLogin struct {
+	nonce    string
+	verifier string
+}
+
+// Provider runs the OpenID Connect authorization code flow with PKCE. Discovery is
+// done lazily so the dashboard starts even while the identity provider is down.
+type Provider struct {
+	cfg     config.OIDCConfig
+	pending *cache.Cache[pendingLogin]
+
+	mu         sync.Mutex
+	oauth      *oauth2.Config
+	verifier   *oidc.IDTokenVerifier
+	endSession string
+}
+
+// New validates cfg and creates a Provider.
+func New(cfg config.OIDCConfig) (*Provider, error) {
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("oidc: issuer, client_id and redirect_url are required")
+	}
+	for role := range cfg.Roles {
+		if role != session.RoleAdmin && role != session.RoleViewer {
+			return nil, fmt.Errorf("oidc: unknown role %q in roles", role)
+		}
+	}
+	switch cfg.DefaultRole {
+	case "", session.RoleAdmin, session.RoleViewer:
+	default:
+		return nil, fmt.Errorf("oidc: unknown default_role %q", cfg.DefaultRole)
+	}
+
+	p := &Provider{cfg: cfg, pending: cache.New[pendingLogin]()}
+	go func() {
+		ticker := time.NewTicker(loginTimeout)
+		defer ticker.Stop()
+		for range ticker.C {
+			p.pending.Cleanup()
+		}
+	}()
+	return p, nil
+}
+
+// Label is the text for the login button.
+func (p *Provider) Label() string {
+	return p.cfg.ButtonLabel
+}
+
+func (p *Provider) discover(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.oauth != nil {
+		return nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, p.cfg.Issuer)
+	if err != nil {
+		return fmt.Errorf("oidc discovery failed: %w", err)
+	}
+
+	var meta struct {
+		EndSession string `json:"end_session_endpoint"`
+	}
+	_ = provider.Claims(&meta)
+
+	scopes := p.cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID}
+	}
+
+	p.oauth = &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		RedirectURL:  p.cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       scopes,
+	}
+	p.verifier = provider.Verifier(&oidc.Config{ClientID: p.cfg.ClientID})
+	p.endSession = meta.EndSession
+	return nil
+}
+
+// AuthURL starts a login and returns the identity provider URL and the state to
+// store in StateCookieName.
+func (p *Provider) AuthURL(ctx context.Context) (string, string, error) {
+	if err := p.discover(ctx); err != nil {
+		return "", "", err
+	}
+
+	state, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	p.pending.Set(state, pendingLogin{nonce: nonce, verifier: verifier}, loginTimeout)
+	return p.oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), state, nil
+}
+
+// Exchange completes a login: it redeems code, verifies the ID token and maps
+// its claims to a role. Each state can be used once.
+func (p *Provider) Exchange(ctx context.Context, state, code string) (*Identity, error) {
+	pending, ok := p.pending.Get(state)
+	if !ok {
+		return nil, ErrUnknownState
+	}
+	p.pending.Delete(state)
+
+	if err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	token, err := p.oauth.Exchange(ctx, code, oauth2.VerifierOption(pending.verifier))
+	if err != nil {
+		return nil, fmt.Errorf("code exchange failed: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, fmt.Errorf("token response has no id_token")
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id_token: %w", err)
+	}
+	if idToken.Nonce != pending.nonce {
+		return nil, fmt.Errorf("id_token nonce mismatch")
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("invalid id_token claims: %w", err)
+	}
+
+	id := &Identity{
+		Subject: idToken.Subject,
+		Email:   stringClaim(claims, "email"),
+		Groups:  listClaim(claims, p.cfg.GroupsClaim),
+		IDToken: rawIDToken,
+	}
+	id.Username = stringClaim(claims, p.cfg.UsernameClaim)
+	if id.Username == "" {
+		id.Username = id.Email
+	}
+	if id.Username == "" {
+		id.Username = id.Subject
+	}
+
+	id.Role = p.role(id.Groups)
+	if id.Role == "" {
+		return id, ErrNoRole
+	}
+	return id, nil
+}
+
+// role picks the most privileged role any of groups maps to, else the default role.
+func (p *Provider) role(groups []string) string {
+	for _, role := range []string{session.RoleAdmin, session.RoleViewer} {
+		for _, want := range p.cfg.Roles[role] {
+			for _, g := range groups {
+				if g == want {
+					return role
+				}
+			}
+		}
+	}
+	return p.cfg.DefaultRole
+}
+
+// LogoutURL returns the identity provider's end-session URL, or "" when it has none.
+func (p *Provider) LogoutURL(idToken string) string {
+	p.mu.Lock()
+	endSession := p.endSession
+	p.mu.Unlock()
+	if endSession == "" {
+		return ""
+	}
+
+	u, err := url.Parse(endSession)
+	if err != nil {
+		return ""
+	}
+	q := u.Query()
+	if idToken != "" {
+		q.Set("id_token_hint", idToken)
+	}
+	q.Set("client_id", p.cfg.ClientID)
+	if p.cfg.PostLogoutRedirectURL != "" {
+		q.Set("post_logout_redirect_uri", p.cfg.PostLogoutRedirectURL)
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func stringClaim(claims map[string]interface{}, name string) string {
+	s, _ := claims[name].(string)
+	return s
+}
+
+// listClaim reads a claim that may be a list or a single (space or comma separated) string.
+func listClaim(claims map[string]interface{}, name string) []string {
+	switch v := claims[name].(type) {
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	case string:
+		return strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	}
+	return nil
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
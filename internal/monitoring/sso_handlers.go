@@ -0,0 +1,92 @@
+package monitoring
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"test-go/internal/audit"
+	"test-go/internal/monitoring/session"
+	"test-go/internal/monitoring/sso"
+	"test-go/pkg/response"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// handleOIDCInfo tells the login page whether to offer single sign-on.
+func handleOIDCInfo(provider *sso.Provider) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if provider == nil {
+			return response.Success(c, map[string]interface{}{"enabled": false})
+		}
+		return response.Success(c, map[string]interface{}{
+			"enabled": true,
+			"label":   provider.Label(),
+		})
+	}
+}
+
+// handleOIDCLogin redirects to the identity provider.
+func handleOIDCLogin(provider *sso.Provider) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		authURL, state, err := provider.AuthURL(c.Request().Context())
+		if err != nil {
+			return redirectLoginError(c, "Single sign-on is unavailable, use password login")
+		}
+
+		c.SetCookie(&http.Cookie{
+			Name:     sso.StateCookieName,
+			Value:    state,
+			Path:     "/auth/oidc",
+			MaxAge:   int((10 * time.Minute).Seconds()),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+		return c.Redirect(http.StatusFound, authURL)
+	}
+}
+
+// handleOIDCCallback completes the login and creates a dashboard session.
+func handleOIDCCallback(provider *sso.Provider, sessionManager *session.Manager, auditor *audit.Recorder) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.SetCookie(&http.Cookie{Name: sso.StateCookieName, Value: "", Path: "/auth/oidc", MaxAge: -1, HttpOnly: true})
+
+		if e := c.QueryParam("error"); e != "" {
+			recordLogin(c, auditor, "", session.MethodOIDC, errors.New("identity provider: "+e))
+			return redirectLoginError(c, "Sign-in was cancelled or refused by the identity provider")
+		}
+
+		state := c.QueryParam("state")
+		cookie, err := c.Cookie(sso.StateCookieName)
+		if err != nil || cookie.Value != state {
+			recordLogin(c, auditor, "", session.MethodOIDC, sso.ErrUnknownState)
+			return redirectLoginError(c, "Sign-in expired, please try again")
+		}
+
+		identity, err := provider.Exchange(c.Request().Context(), state, c.QueryParam("code"))
+		if err != nil {
+			username := ""
+			if identity != nil {
+				username = identity.Username
+			}
+			recordLogin(c, auditor, username, session.MethodOIDC, err)
+			if errors.Is(err, sso.ErrNoRole) {
+				return redirectLoginError(c, "Your account has no access to this dashboard")
+			}
+			return redirectLoginError(c, "Sign-in failed, please try again")
+		}
+
+		sess, err := sessionManager.CreateWith(identity.Username, identity.Role, session.MethodOIDC, identity.IDToken)
+		if err != nil {
+			return redirectLoginError(c, "Failed to create session")
+		}
+
+		session.SetCookie(c, sess.ID, int(24*time.Hour.Seconds()))
+		recordLogin(c, auditor, identity.Username, session.MethodOIDC, nil)
+		return c.Redirect(http.StatusFound, "/dashboard")
+	}
+}
+
+func redirectLoginError(c echo.Context, message string) error {
+	return c.Redirect(http.StatusFound, "/?sso_error="+url.QueryEscape(message))
+}
@@ -0,0 +1,42 @@
+package monitoring
+
+import (
+	"context"
+	"test-go/config"
+	"test-go/internal/collector"
+	"test-go/pkg/infrastructure"
+	"test-go/pkg/logger"
+	"time"
+)
+
+// newStatusCollector registers the /api/status sources. The managers' status calls do
+// not take a context, so a hung backend is abandoned at the timeout rather than cancelled.
+func newStatusCollector(
+	cfg config.StatusConfig,
+	app StatusProvider,
+	redis *infrastructure.RedisManager,
+	postgres *infrastructure.PostgresManager,
+	kafka *infrastructure.KafkaManager,
+	cron *infrastructure.CronManager,
+	minio *infrastructure.MinIOManager,
+	system *infrastructure.SystemManager,
+	l *logger.Logger,
+) *collector.Collector {
+	status := func(get func() map[string]interface{}) collector.Func {
+		return func(context.Context) (interface{}, error) { return get(), nil }
+	}
+
+	c := collector.New(cfg, l)
+	c.Register("app", 0, 0, status(app.GetStatus))
+	c.Register("redis", 0, 0, status(redis.GetStatus))
+	c.Register("postgres", 0, 0, status(postgres.GetStatus))
+	c.Register("kafka", 0, 0, status(kafka.GetStatus))
+	c.Register("cron", 0, 0, status(cron.GetStatus))
+	// Storage lists bucket objects and host info does not change, so both refresh slowly
+	c.Register("storage", time.Minute, 10*time.Second, status(minio.GetStatus))
+	c.Register("system", 0, 0, status(system.GetStats))
+	c.Register("system_info", 10*time.Minute, 0, func(context.Context) (interface{}, error) {
+		return system.GetHostInfo(), nil
+	})
+	return c
+}
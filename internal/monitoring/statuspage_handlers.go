@@ -0,0 +1,176 @@
+package monitoring
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"test-go/internal/audit"
+	"test-go/internal/collector"
+	"test-go/internal/prober"
+	"test-go/internal/statuspage"
+	"test-go/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// statusPageSources maps every source a status page component may name to a function
+// reporting its state. Dependencies are read from the status collector's snapshots;
+// services match on their name or type, such as ServiceD.
+func statusPageSources(coll *collector.Collector, services []ServiceInfo, opts Options) map[string]statuspage.Source {
+	connected := func(name string) statuspage.Source {
+		return func() string {
+			snap, _ := coll.Get(name)
+			if snap.Data == nil {
+				return statuspage.StateUnknown
+			}
+			if snap.Stale {
+				return statuspage.StateOutage // the backend stopped answering
+			}
+			if data, _ := snap.Data.(map[string]interface{}); data["connected"] == true {
+				return statuspage.StateOperational
+			}
+			return statuspage.StateOutage
+		}
+	}
+	sources := map[string]statuspage.Source{
+		"redis":    connected("redis"),
+		"postgres": connected("postgres"),
+		"kafka":    connected("kafka"),
+		"storage":  connected("storage"),
+	}
+
+	for _, svc := range services {
+		state := statuspage.StateOutage
+		if svc.Active {
+			state = statuspage.StateOperational
+		}
+		src := func() string { return state }
+		sources["service:"+svc.Name] = src
+		sources["service:"+svc.StructName[strings.LastIndex(svc.StructName, ".")+1:]] = src
+	}
+
+	if opts.Prober != nil {
+		for _, st := range opts.Prober.Statuses() {
+			sources["external:"+st.Name] = func() string {
+				for _, cur := range opts.Prober.Statuses() {
+					if cur.Name != st.Name {
+						continue
+					}
+					switch cur.Status {
+					case prober.StatusUp:
+						return statuspage.StateOperational
+					case prober.StatusDegraded:
+						return statuspage.StateDegraded
+					case prober.StatusDown:
+						return statuspage.StateOutage
+					}
+				}
+				return statuspage.StateUnknown
+			}
+		}
+	}
+
+	if opts.Synthetics != nil {
+		for _, st := range opts.Synthetics.Statuses() {
+			sources["synthetic:"+st.Name] = func() string {
+				_, runs, err := opts.Synthetics.Runs(st.Name)
+				switch {
+				case err != nil || len(runs) == 0:
+					return statuspage.StateUnknown
+				case runs[0].Status == prober.RunPassed:
+					return statuspage.StateOperational
+				default:
+					return statuspage.StateDegraded
+				}
+			}
+		}
+	}
+	return sources
+}
+
+// getPublicStatus serves /status.json without authentication.
+func getPublicStatus(page *statuspage.Page) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.JSON(http.StatusOK, page.Public())
+	}
+}
+
+// getStatusPage returns the public view with the component sources and incident
+// authors, for the dashboard.
+func (h *Handler) getStatusPage(c echo.Context) error {
+	if h.statusPage == nil {
+		return response.Success(c, map[string]interface{}{"enabled": false})
+	}
+	return response.Success(c, map[string]interface{}{
+		"enabled":    true,
+		"page":       h.statusPage.Public(),
+		"components": h.statusPage.Components(),
+		"incidents":  h.statusPage.Incidents(),
+	})
+}
+
+type incidentRequest struct {
+	Title      string   `json:"title"`
+	Impact     string   `json:"impact"`
+	Status     string   `json:"status"`
+	Message    string   `json:"message"`
+	Components []string `json:"components"`
+}
+
+func (h *Handler) createIncident(c echo.Context) error {
+	if h.statusPage == nil {
+		return response.ServiceUnavailable(c, "Status page is disabled")
+	}
+
+	var req incidentRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+	inc, err := h.statusPage.CreateIncident(c.Request().Context(), strings.TrimSpace(req.Title), req.Impact, req.Status,
+		strings.TrimSpace(req.Message), req.Components, sessionUser(c))
+	h.recordAudit(c, "status_page.incident.create", inc.ID, "", audit.Summarize(req, 0, nil), err)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+	return response.Created(c, inc, "Incident posted")
+}
+
+func (h *Handler) addIncidentUpdate(c echo.Context) error {
+	if h.statusPage == nil {
+		return response.ServiceUnavailable(c, "Status page is disabled")
+	}
+
+	var req incidentRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+	id := c.Param("id")
+	inc, err := h.statusPage.AddUpdate(c.Request().Context(), id, req.Status, strings.TrimSpace(req.Message), sessionUser(c))
+	h.recordAudit(c, "status_page.incident.update", id, "", audit.Summarize(req, 0, nil), err)
+	switch {
+	case errors.Is(err, statuspage.ErrIncidentNotFound):
+		return response.NotFound(c, err.Error())
+	case errors.Is(err, statuspage.ErrIncidentResolved):
+		return response.Conflict(c, err.Error())
+	case err != nil:
+		return response.BadRequest(c, err.Error())
+	}
+	return response.Success(c, inc, "Incident updated")
+}
+
+func (h *Handler) deleteIncident(c echo.Context) error {
+	if h.statusPage == nil {
+		return response.ServiceUnavailable(c, "Status page is disabled")
+	}
+
+	id := c.Param("id")
+	inc, err := h.statusPage.DeleteIncident(c.Request().Context(), id)
+	h.recordAudit(c, "status_page.incident.delete", id, inc.Title, "", err)
+	switch {
+	case errors.Is(err, statuspage.ErrIncidentNotFound):
+		return response.NotFound(c, err.Error())
+	case err != nil:
+		return response.InternalServerError(c, err.Error())
+	}
+	return response.Success(c, nil, "Incident deleted")
+}
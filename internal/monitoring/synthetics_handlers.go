@@ -0,0 +1,56 @@
+package monitoring
+
+import (
+	"errors"
+	"test-go/internal/prober"
+	"test-go/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) getSynthetics(c echo.Context) error {
+	if h.synthetics == nil {
+		return response.Success(c, []prober.SyntheticStatus{})
+	}
+	return response.Success(c, h.synthetics.Statuses())
+}
+
+// getSyntheticRuns returns a check with its kept runs, newest first.
+func (h *Handler) getSyntheticRuns(c echo.Context) error {
+	if h.synthetics == nil {
+		return response.NotFound(c, prober.ErrCheckNotFound.Error())
+	}
+
+	status, runs, err := h.synthetics.Runs(c.Param("name"))
+	if err != nil {
+		return response.NotFound(c, err.Error())
+	}
+	return response.Success(c, map[string]interface{}{
+		"check": status,
+		"runs":  runs,
+	})
+}
+
+// runSynthetic starts a run outside the schedule; poll getSyntheticRuns for the result.
+func (h *Handler) runSynthetic(c echo.Context) error {
+	if h.synthetics == nil {
+		return response.NotFound(c, prober.ErrCheckNotFound.Error())
+	}
+
+	name := c.Param("name")
+	trigger := "manual"
+	if user := sessionUser(c); user != "" {
+		trigger += " by " + user
+	}
+	err := h.synthetics.Start(name, trigger)
+	h.recordAudit(c, "synthetics.run", name, "", "", err)
+	switch {
+	case errors.Is(err, prober.ErrCheckNotFound):
+		return response.NotFound(c, err.Error())
+	case errors.Is(err, prober.ErrRunning):
+		return response.Conflict(c, err.Error())
+	case err != nil:
+		return response.InternalServerError(c, err.Error())
+	}
+	return response.Success(c, nil, "Synthetic check started")
+}
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 	"test-go/internal/monitoring/database"
+	"test-go/internal/monitoring/session"
 	"test-go/pkg/response"
 	"time"
 
@@ -21,17 +22,29 @@ func (h *Handler) getUserSettings(c echo.Context) error {
 	if err != nil {
 		return response.InternalServerError(c, err.Error())
 	}
-	if settings == nil {
-		return response.Success(c, map[string]string{
-			"username":   "Admin",
-			"photo_path": "",
-		})
+
+	data := map[string]string{
+		"username":   "Admin",
+		"photo_path": "",
+		"role":       session.RoleAdmin,
+		"method":     session.MethodPassword,
+	}
+	if settings != nil {
+		data["username"] = settings.Username
+		data["photo_path"] = settings.PhotoPath
 	}
 
-	return response.Success(c, map[string]string{
-		"username":   settings.Username,
-		"photo_path": settings.PhotoPath,
-	})
+	// Single sign-on users are not the local account; show who they are instead
+	if sess, ok := c.Get("session").(*session.Session); ok {
+		data["role"] = sess.Role
+		data["method"] = sess.Method
+		if sess.Method == session.MethodOIDC {
+			data["username"] = sess.Username
+			data["photo_path"] = ""
+		}
+	}
+
+	return response.Success(c, data)
 }
 
 // updateUserSettings updates the username
@@ -49,7 +62,14 @@ func (h *Handler) updateUserSettings(c echo.Context) error {
 		return response.BadRequest(c, "Username cannot be empty")
 	}
 
-	if err := database.UpdateUsername(req.Username); err != nil {
+	var before string
+	if settings, _ := database.GetUserSettings(); settings != nil {
+		before = settings.Username
+	}
+
+	err := database.UpdateUsername(req.Username)
+	h.recordAudit(c, "user.username", "user_settings", before, req.Username, err)
+	if err != nil {
 		return response.InternalServerError(c, err.Error())
 	}
 
@@ -76,7 +96,9 @@ func (h *Handler) changePassword(c echo.Context) error {
 		return response.BadRequest(c, "New password must be at least 4 characters")
 	}
 
-	if err := database.UpdatePassword(req.CurrentPassword, req.NewPassword); err != nil {
+	err := database.UpdatePassword(req.CurrentPassword, req.NewPassword)
+	h.recordAudit(c, "user.password", "user_settings", "", "", err)
+	if err != nil {
 		if strings.Contains(err.Error(), "incorrect") {
 			return response.Unauthorized(c, "Current password is incorrect")
 		}
@@ -152,7 +174,9 @@ func (h *Handler) uploadPhoto(c echo.Context) error {
 
 	// Update database
 	photoPath := filename
-	if err := database.UpdatePhotoPath(photoPath); err != nil {
+	err = database.UpdatePhotoPath(photoPath)
+	h.recordAudit(c, "user.photo.upload", "user_settings", "", photoPath, err)
+	if err != nil {
 		return response.InternalServerError(c, "Failed to update database")
 	}
 
@@ -180,7 +204,9 @@ func (h *Handler) deleteUserPhoto(c echo.Context) error {
 	}
 
 	// Update database
-	if err := database.DeletePhoto(); err != nil {
+	err = database.DeletePhoto()
+	h.recordAudit(c, "user.photo.delete", "user_settings", "", "", err)
+	if err != nil {
 		return response.InternalServerError(c, err.Error())
 	}
 
@@ -0,0 +1,238 @@
+package probeguard
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"test-go/config"
+	"test-go/internal/audit"
+	"test-go/pkg/infrastructure"
+	"test-go/pkg/logger"
+)
+
+// DefaultSuspiciousPatterns is used when probe_guard.suspicious_patterns is empty.
+// Matching is a case-insensitive substring test on the raw and decoded request path.
+var DefaultSuspiciousPatterns = []string{
+	"/.env", "/.git", "/.aws", "/.ssh", "/.htaccess", "/.ds_store",
+	"/wp-admin", "/wp-login", "/wp-content", "/xmlrpc.php",
+	"/phpmyadmin", "/pma", "/cgi-bin", "/server-status", "/actuator",
+	"/etc/passwd", "/proc/self", "/boot.ini", "/win.ini",
+	"../", "..\\", "%2e%2e", "%252e",
+}
+
+// Offense kinds.
+const (
+	KindNotFound   = "not_found"
+	KindSuspicious = "suspicious"
+)
+
+// Ban is an active temporary ban.
+type Ban struct {
+	IP        string    `json:"ip"`
+	Reason    string    `json:"reason"`
+	Hits      int       `json:"hits"`
+	LastPath  string    `json:"last_path"`
+	Manual    bool      `json:"manual,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Store keeps sliding-window counters and bans.
+type Store interface {
+	// Hit records one offense and returns the number within the trailing window.
+	Hit(ctx context.Context, ip, kind string, now time.Time, window time.Duration) (int, error)
+	Ban(ctx context.Context, b Ban) error
+	Banned(ctx context.Context, ip string) (*Ban, error)
+	Unban(ctx context.Context, ip string) error
+	Bans(ctx context.Context) ([]Ban, error)
+}
+
+// NewStore picks the store configured in cfg.Store.
+// Redis is used only when requested and available so bans are shared across instances.
+func NewStore(cfg config.ProbeGuardConfig, rdb *infrastructure.RedisManager) Store {
+	if strings.EqualFold(cfg.Store, "redis") && rdb != nil {
+		return &redisStore{rdb: rdb, prefix: "probeguard:"}
+	}
+	return newMemoryStore()
+}
+
+// Guard counts 404s and suspicious requests per client IP and bans offenders.
+type Guard struct {
+	cfg      config.ProbeGuardConfig
+	store    Store
+	auditor  *audit.Recorder
+	logger   *logger.Logger
+	patterns []string
+	ignore   []*net.IPNet
+}
+
+// New builds a Guard. auditor may be nil.
+func New(cfg config.ProbeGuardConfig, store Store, auditor *audit.Recorder, l *logger.Logger) (*Guard, error) {
+	if cfg.Window <= 0 {
+		cfg.Window = time.Minute
+	}
+	if cfg.BanDuration <= 0 {
+		cfg.BanDuration = time.Hour
+	}
+
+	patterns := cfg.SuspiciousPatterns
+	if len(patterns) == 0 {
+		patterns = DefaultSuspiciousPatterns
+	}
+
+	g := &Guard{
+		cfg:     cfg,
+		store:   store,
+		auditor: auditor,
+		logger:  l,
+	}
+	for _, p := range patterns {
+		g.patterns = append(g.patterns, strings.ToLower(p))
+	}
+	for _, cidr := range cfg.IgnoreCIDRs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("probe guard: invalid ignore CIDR %q", cidr)
+		}
+		g.ignore = append(g.ignore, network)
+	}
+
+	return g, nil
+}
+
+// Ignored reports whether ip is exempt from counting and bans.
+func (g *Guard) Ignored(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range g.ignore {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// Suspicious reports whether the request path, as sent or decoded, matches a probe
+// pattern. The query string is not checked: parameters may legitimately hold paths.
+func (g *Guard) Suspicious(path string) bool {
+	candidates := []string{strings.ToLower(path)}
+	if decoded, err := url.PathUnescape(path); err == nil {
+		candidates = append(candidates, strings.ToLower(decoded))
+	}
+	for _, c := range candidates {
+		for _, p := range g.patterns {
+			if strings.Contains(c, p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Banned returns the active ban for ip, if any. Store errors fail open.
+func (g *Guard) Banned(ctx context.Context, ip string) *Ban {
+	b, err := g.store.Banned(ctx, ip)
+	if err != nil {
+		g.logger.Error("Probe guard store unavailable", err)
+		return nil
+	}
+	return b
+}
+
+// Record counts one offense by ip and bans it once the kind's threshold is reached.
+func (g *Guard) Record(ctx context.Context, ip, kind, path string) {
+	threshold := g.cfg.NotFoundThreshold
+	if kind == KindSuspicious {
+		threshold = g.cfg.SuspiciousThreshold
+	}
+	if threshold <= 0 {
+		return
+	}
+
+	now := time.Now()
+	hits, err := g.store.Hit(ctx, ip, kind, now, g.cfg.Window)
+	if err != nil {
+		g.logger.Error("Probe guard store unavailable", err)
+		return
+	}
+
+	g.logger.Warn("Probe detected", "ip", ip, "kind", kind, "path", path, "hits", hits)
+	if hits < threshold {
+		return
+	}
+	if existing, _ := g.store.Banned(ctx, ip); existing != nil {
+		// A concurrent request already crossed the threshold
+		return
+	}
+
+	reason := fmt.Sprintf("%d %s requests within %s", hits, strings.ReplaceAll(kind, "_", " "), g.cfg.Window)
+	g.ban(ctx, Ban{
+		IP:        ip,
+		Reason:    reason,
+		Hits:      hits,
+		LastPath:  path,
+		CreatedAt: now,
+		ExpiresAt: now.Add(g.cfg.BanDuration),
+	}, "system", audit.ActorSystem)
+}
+
+// BanManually bans ip for d (the configured duration when d <= 0).
+func (g *Guard) BanManually(ctx context.Context, ip, reason, actor string, d time.Duration) (Ban, error) {
+	if net.ParseIP(ip) == nil {
+		return Ban{}, fmt.Errorf("invalid IP address %q", ip)
+	}
+	if d <= 0 {
+		d = g.cfg.BanDuration
+	}
+	if reason == "" {
+		reason = "manual ban"
+	}
+
+	now := time.Now()
+	b := Ban{IP: ip, Reason: reason, Manual: true, CreatedAt: now, ExpiresAt: now.Add(d)}
+	return b, g.ban(ctx, b, actor, audit.ActorUser)
+}
+
+func (g *Guard) ban(ctx context.Context, b Ban, actor, actorType string) error {
+	err := g.store.Ban(ctx, b)
+	if err != nil {
+		g.logger.Error("Failed to store ban", err, "ip", b.IP)
+	} else {
+		g.logger.Warn("IP banned", "ip", b.IP, "reason", b.Reason, "until", b.ExpiresAt.Format(time.RFC3339))
+	}
+
+	entry := audit.Entry{
+		Actor:     actor,
+		ActorType: actorType,
+		Action:    "probeguard.ban",
+		Target:    b.IP,
+		After:     audit.Summarize(b, 0, nil),
+	}
+	if err != nil {
+		entry.Outcome = audit.OutcomeFailure
+		entry.Error = err.Error()
+	}
+	g.auditor.Record(entry)
+	return err
+}
+
+// Unban lifts a ban before it expires.
+func (g *Guard) Unban(ctx context.Context, ip string) error {
+	return g.store.Unban(ctx, ip)
+}
+
+// Bans lists active bans.
+func (g *Guard) Bans(ctx context.Context) ([]Ban, error) {
+	return g.store.Bans(ctx)
+}
+
+// BanDuration returns the configured automatic ban length.
+func (g *Guard) BanDuration() time.Duration {
+	return g.cfg.BanDuration
+}
@@ -0,0 +1,177 @@
+package probeguard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"test-go/config"
+	"test-go/pkg/logger"
+)
+
+type offense struct {
+	ip    string
+	kind  string
+	sleep time.Duration // before the offense
+}
+
+func TestRecordBansAtThreshold(t *testing.T) {
+	base := config.ProbeGuardConfig{
+		Window:              time.Minute,
+		NotFoundThreshold:   3,
+		SuspiciousThreshold: 2,
+		BanDuration:         time.Hour,
+	}
+
+	tests := []struct {
+		name       string
+		cfg        func(*config.ProbeGuardConfig)
+		offenses   []offense
+		wantBanned []string
+		wantFree   []string
+	}{
+		{
+			name:     "below the not-found threshold",
+			offenses: repeat(offense{ip: "203.0.113.1", kind: KindNotFound}, 2),
+			wantFree: []string{"203.0.113.1"},
+		},
+		{
+			name:       "at the not-found threshold",
+			offenses:   repeat(offense{ip: "203.0.113.1", kind: KindNotFound}, 3),
+			wantBanned: []string{"203.0.113.1"},
+		},
+		{
+			name:       "suspicious paths have their own lower threshold",
+			offenses:   repeat(offense{ip: "203.0.113.1", kind: KindSuspicious}, 2),
+			wantBanned: []string{"203.0.113.1"},
+		},
+		{
+			name: "kinds are counted separately",
+			offenses: []offense{
+				{ip: "203.0.113.1", kind: KindNotFound},
+				{ip: "203.0.113.1", kind: KindNotFound},
+				{ip: "203.0.113.1", kind: KindSuspicious},
+			},
+			wantFree: []string{"203.0.113.1"},
+		},
+		{
+			name: "addresses are counted separately",
+			offenses: []offense{
+				{ip: "203.0.113.1", kind: KindNotFound},
+				{ip: "203.0.113.2", kind: KindNotFound},
+				{ip: "203.0.113.1", kind: KindNotFound},
+				{ip: "203.0.113.2", kind: KindNotFound},
+				{ip: "203.0.113.1", kind: KindNotFound},
+			},
+			wantBanned: []string{"203.0.113.1"},
+			wantFree:   []string{"203.0.113.2"},
+		},
+		{
+			name: "offenses older than the window are forgotten",
+			cfg:  func(c *config.ProbeGuardConfig) { c.Window = 50 * time.Millisecond },
+			offenses: []offense{
+				{ip: "203.0.113.1", kind: KindNotFound},
+				{ip: "203.0.113.1", kind: KindNotFound},
+				{ip: "203.0.113.1", kind: KindNotFound, sleep: 80 * time.Millisecond},
+			},
+			wantFree: []string{"203.0.113.1"},
+		},
+		{
+			name:     "a zero threshold disables the kind",
+			cfg:      func(c *config.ProbeGuardConfig) { c.NotFoundThreshold = 0 },
+			offenses: repeat(offense{ip: "203.0.113.1", kind: KindNotFound}, 10),
+			wantFree: []string{"203.0.113.1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			if tt.cfg != nil {
+				tt.cfg(&cfg)
+			}
+			g, err := New(cfg, newMemoryStore(), nil, logger.NewQuiet(false, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := context.Background()
+			for _, o := range tt.offenses {
+				time.Sleep(o.sleep)
+				g.Record(ctx, o.ip, o.kind, "/probe")
+			}
+
+			for _, ip := range tt.wantBanned {
+				b := g.Banned(ctx, ip)
+				if b == nil {
+					t.Errorf("%s is not banned", ip)
+					continue
+				}
+				if got := b.ExpiresAt.Sub(b.CreatedAt); got != cfg.BanDuration {
+					t.Errorf("%s banned for %s, want %s", ip, got, cfg.BanDuration)
+				}
+			}
+			for _, ip := range tt.wantFree {
+				if b := g.Banned(ctx, ip); b != nil {
+					t.Errorf("%s is banned: %s", ip, b.Reason)
+				}
+			}
+		})
+	}
+}
+
+func TestSuspicious(t *testing.T) {
+	g, err := New(config.ProbeGuardConfig{}, newMemoryStore(), nil, logger.NewQuiet(false, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/api/v1/tasks", false},
+		{"/.env", true},
+		{"/WP-Admin/install.php", true},
+		{"/static/%2e%2e/%2e%2e/etc/passwd", true},
+		{"/files/..%2f..%2fsecret", true},
+		{"/api/v1/files", false},
+	}
+
+	for _, tt := range tests {
+		if got := g.Suspicious(tt.path); got != tt.want {
+			t.Errorf("Suspicious(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestIgnored(t *testing.T) {
+	g, err := New(config.ProbeGuardConfig{IgnoreCIDRs: []string{"10.0.0.0/8", "::1/128"}}, newMemoryStore(), nil, logger.NewQuiet(false, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"10.1.2.3", true},
+		{"::1", true},
+		{"203.0.113.1", false},
+		{"not-an-ip", false},
+	}
+
+	for _, tt := range tests {
+		if got := g.Ignored(tt.ip); got != tt.want {
+			t.Errorf("Ignored(%q) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func repeat(o offense, n int) []offense {
+	out := make([]offense, n)
+	for i := range out {
+		out[i] = o
+	}
+	return out
+}
@@ -0,0 +1,199 @@
+package probeguard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"test-go/pkg/infrastructure"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// memoryStore keeps counters and bans in process memory.
+type memoryStore struct {
+	mu   sync.Mutex
+	hits map[string][]time.Time // "kind|ip" -> offense times, oldest first
+	bans map[string]Ban
+}
+
+func newMemoryStore() *memoryStore {
+	s := &memoryStore{
+		hits: make(map[string][]time.Time),
+		bans: make(map[string]Ban),
+	}
+
+	// Periodically drop idle counters and expired bans so the maps don't grow forever
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.cleanup()
+		}
+	}()
+
+	return s
+}
+
+func (s *memoryStore) Hit(_ context.Context, ip, kind string, now time.Time, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := kind + "|" + ip
+	times := trimBefore(s.hits[key], now.Add(-window))
+	times = append(times, now)
+	s.hits[key] = times
+	return len(times), nil
+}
+
+func trimBefore(times []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(times) && times[i].Before(cutoff) {
+		i++
+	}
+	return times[i:]
+}
+
+func (s *memoryStore) Ban(_ context.Context, b Ban) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bans[b.IP] = b
+	return nil
+}
+
+func (s *memoryStore) Banned(_ context.Context, ip string) (*Ban, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.bans[ip]
+	if !ok {
+		return nil, nil
+	}
+	if time.Now().After(b.ExpiresAt) {
+		delete(s.bans, ip)
+		return nil, nil
+	}
+	return &b, nil
+}
+
+func (s *memoryStore) Unban(_ context.Context, ip string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.hits {
+		if strings.HasSuffix(key, "|"+ip) {
+			delete(s.hits, key)
+		}
+	}
+	delete(s.bans, ip)
+	return nil
+}
+
+func (s *memoryStore) Bans(_ context.Context) ([]Ban, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	out := make([]Ban, 0, len(s.bans))
+	for _, b := range s.bans {
+		if now.Before(b.ExpiresAt) {
+			out = append(out, b)
+		}
+	}
+	sortBans(out)
+	return out, nil
+}
+
+func (s *memoryStore) cleanup() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, times := range s.hits {
+		// Counters older than an hour can't matter for any sensible window
+		if len(times) == 0 || now.Sub(times[len(times)-1]) > time.Hour {
+			delete(s.hits, key)
+		}
+	}
+	for ip, b := range s.bans {
+		if now.After(b.ExpiresAt) {
+			delete(s.bans, ip)
+		}
+	}
+}
+
+// redisStore shares counters and bans across instances.
+// Counters are sorted sets; bans are JSON strings expiring with the ban.
+type redisStore struct {
+	rdb    *infrastructure.RedisManager
+	prefix string
+}
+
+func (s *redisStore) Hit(ctx context.Context, ip, kind string, now time.Time, window time.Duration) (int, error) {
+	n, err := s.rdb.SlidingWindowAdd(ctx, s.prefix+"hits:"+kind+":"+ip, now, window)
+	return int(n), err
+}
+
+func (s *redisStore) Ban(ctx context.Context, b Ban) error {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	return s.rdb.Set(ctx, s.prefix+"ban:"+b.IP, data, time.Until(b.ExpiresAt))
+}
+
+func (s *redisStore) Banned(ctx context.Context, ip string) (*Ban, error) {
+	val, err := s.rdb.Get(ctx, s.prefix+"ban:"+ip)
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var b Ban
+	if err := json.Unmarshal([]byte(val), &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (s *redisStore) Unban(ctx context.Context, ip string) error {
+	for _, kind := range []string{KindNotFound, KindSuspicious} {
+		if err := s.rdb.Delete(ctx, s.prefix+"hits:"+kind+":"+ip); err != nil {
+			return err
+		}
+	}
+	return s.rdb.Delete(ctx, s.prefix+"ban:"+ip)
+}
+
+func (s *redisStore) Bans(ctx context.Context) ([]Ban, error) {
+	keys, err := s.rdb.ScanKeys(ctx, s.prefix+"ban:*")
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Ban, 0, len(keys))
+	for _, key := range keys {
+		b, err := s.Banned(ctx, strings.TrimPrefix(key, s.prefix+"ban:"))
+		if err != nil {
+			return nil, err
+		}
+		if b != nil {
+			out = append(out, *b)
+		}
+	}
+	sortBans(out)
+	return out, nil
+}
+
+// sortBans orders bans newest first.
+func sortBans(bans []Ban) {
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].CreatedAt.After(bans[j].CreatedAt)
+	})
+}
@@ -0,0 +1,231 @@
+package prober
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"test-go/config"
+	"test-go/pkg/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const maxBodySize = 1 << 20 // bytes read for body and JSON assertions
+
+// client has no timeout of its own; every probe runs under a context deadline.
+var client = &http.Client{}
+
+// check probes svc once. Services that cannot be reached or fail an assertion are
+// down; a TLS certificate expiring within tls_expiry_days makes them degraded. Each
+// probe is a client span.
+func check(ctx context.Context, svc config.ExternalService) Result {
+	ctx, span := tracing.Tracer().Start(ctx, svc.Type+".probe "+svc.Name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("probe.type", svc.Type)),
+	)
+
+	start := time.Now()
+	var res Result
+	var err error
+	switch svc.Type {
+	case TypeTCP:
+		err = checkTCP(ctx, svc)
+	case TypeDNS:
+		err = checkDNS(ctx, svc)
+	case TypeHTTP:
+		res, err = checkHTTP(ctx, svc, span)
+	default:
+		err = fmt.Errorf("unknown check type %q", svc.Type)
+	}
+	res.Time = start
+	res.LatencyMs = time.Since(start).Milliseconds()
+
+	switch {
+	case err != nil:
+		res.Status = StatusDown
+		res.Error = err.Error()
+	case res.Status == "":
+		res.Status = StatusUp
+	}
+	span.SetAttributes(attribute.String("probe.status", res.Status))
+	tracing.End(span, err)
+	return res
+}
+
+func checkHTTP(ctx context.Context, svc config.ExternalService, span trace.Span) (Result, error) {
+	var res Result
+	method := strings.ToUpper(svc.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	span.SetAttributes(semconv.HTTPRequestMethodKey.String(method), semconv.URLFull(svc.URL))
+
+	req, err := http.NewRequestWithContext(ctx, method, svc.URL, nil)
+	if err != nil {
+		return res, err
+	}
+	for k, v := range svc.Headers {
+		req.Header.Set(k, v)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return res, err
+	}
+	defer resp.Body.Close()
+	res.StatusCode = resp.StatusCode
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+
+	ok, err := statusMatches(svc.ExpectedStatus, resp.StatusCode)
+	if err != nil {
+		return res, err
+	}
+	if !ok {
+		return res, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	if svc.BodyContains != "" || len(svc.JSON) > 0 {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+		if err != nil {
+			return res, fmt.Errorf("failed to read body: %w", err)
+		}
+		if svc.BodyContains != "" && !strings.Contains(string(body), svc.BodyContains) {
+			return res, fmt.Errorf("body does not contain %q", svc.BodyContains)
+		}
+		if len(svc.JSON) > 0 {
+			if err := assertJSON(body, svc.JSON); err != nil {
+				return res, err
+			}
+		}
+	}
+
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		res.CertExpiresAt = resp.TLS.PeerCertificates[0].NotAfter
+		if svc.TLSExpiryDays > 0 && time.Until(res.CertExpiresAt) < time.Duration(svc.TLSExpiryDays)*24*time.Hour {
+			res.Status = StatusDegraded
+			res.Error = "TLS certificate expires " + res.CertExpiresAt.Format(time.RFC3339)
+		}
+	}
+	return res, nil
+}
+
+func checkTCP(ctx context.Context, svc config.ExternalService) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", svc.Address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func checkDNS(ctx context.Context, svc config.ExternalService) error {
+	r := net.DefaultResolver
+	if svc.Resolver != "" {
+		r = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, svc.Resolver)
+			},
+		}
+	}
+	addrs, err := r.LookupHost(ctx, svc.Host)
+	if err != nil {
+		return err
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("no addresses for %s", svc.Host)
+	}
+	return nil
+}
+
+// statusMatches checks code against a comma-separated list of codes and ranges,
+// such as "200-299,304". An empty spec accepts any 2xx.
+func statusMatches(spec string, code int) (bool, error) {
+	if strings.TrimSpace(spec) == "" {
+		return code >= 200 && code < 300, nil
+	}
+	for _, part := range strings.Split(spec, ",") {
+		first, last, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		lo, err := strconv.Atoi(strings.TrimSpace(first))
+		if err != nil {
+			return false, fmt.Errorf("invalid expected_status %q", spec)
+		}
+		hi := lo
+		if isRange {
+			if hi, err = strconv.Atoi(strings.TrimSpace(last)); err != nil {
+				return false, fmt.Errorf("invalid expected_status %q", spec)
+			}
+		}
+		if code >= lo && code <= hi {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// assertJSON checks every assertion against the decoded body.
+func assertJSON(body []byte, assertions []config.ExternalAssertion) error {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("body is not JSON: %w", err)
+	}
+	for _, a := range assertions {
+		v, ok := lookupJSON(doc, a.Path)
+		if !ok {
+			return fmt.Errorf("JSON path %s not found", a.Path)
+		}
+		if a.Equals != "" && jsonString(v) != a.Equals {
+			return fmt.Errorf("JSON path %s is %s, expected %s", a.Path, jsonString(v), a.Equals)
+		}
+	}
+	return nil
+}
+
+// lookupJSON follows a dotted path through decoded JSON; numeric segments index arrays.
+func lookupJSON(v interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return v, true
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			next, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// jsonString formats a decoded value the way it is written in config: strings
+// without quotes, everything else as JSON.
+func jsonString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
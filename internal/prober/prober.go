@@ -0,0 +1,425 @@
+// Package prober checks the external services in monitoring.external in the
+// background, each on its own interval, and keeps their latest result, recent
+// latencies, hourly uptime and state changes. Readers never wait for a probe.
+//
+// Synthetics runs scripted multi-step HTTP checks from monitoring.synthetics on cron
+// schedules, with the same assertions.
+package prober
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"test-go/config"
+	"test-go/pkg/logger"
+)
+
+// Check states. Degraded services answer correctly but need attention, such as a
+// TLS certificate close to expiry; they count as available in uptime.
+const (
+	StatusUnknown  = "unknown" // not probed yet
+	StatusUp       = "up"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+// Check types.
+const (
+	TypeHTTP = "http"
+	TypeTCP  = "tcp"
+	TypeDNS  = "dns"
+)
+
+const eventsKept = 50 // state changes kept in memory per service
+
+var ErrNotFound = errors.New("no external service with that name")
+
+// Result is the outcome of one probe.
+type Result struct {
+	Time          time.Time `json:"time"`
+	Status        string    `json:"status"`
+	LatencyMs     int64     `json:"latency_ms"`
+	StatusCode    int       `json:"status_code,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	CertExpiresAt time.Time `json:"cert_expires_at,omitzero"`
+}
+
+// Event records a change of state.
+type Event struct {
+	Service string    `json:"service"`
+	Time    time.Time `json:"time"`
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// Bucket aggregates the probes of one service in one hour.
+type Bucket struct {
+	Hour      time.Time `json:"hour"`
+	Checks    int       `json:"checks"`
+	Up        int       `json:"up"` // up or degraded
+	LatencyMs int64     `json:"latency_ms"`
+}
+
+// Uptime is the share of available probes, in percent, over the last day, week
+// and 30 days. A window without probes reports -1.
+type Uptime struct {
+	Day   float64 `json:"24h"`
+	Week  float64 `json:"7d"`
+	Month float64 `json:"30d"`
+}
+
+// Status is the cached view of one service.
+type Status struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Target   string `json:"target"`
+	Interval string `json:"interval"`
+	Result
+	Since  time.Time `json:"since,omitzero"` // when the current state began
+	Uptime Uptime    `json:"uptime"`
+}
+
+// Detail adds the recent results, hourly buckets and state changes of a service.
+type Detail struct {
+	Status
+	History []Result `json:"history"` // oldest first
+	Buckets []Bucket `json:"buckets"` // last 24 hours, oldest first
+	Events  []Event  `json:"events"`  // newest first
+}
+
+// Store persists hourly buckets and state changes across restarts.
+type Store interface {
+	Load(ctx context.Context, since time.Time) (map[string][]Bucket, []Event, error)
+	SaveBucket(ctx context.Context, service string, b Bucket) error
+	SaveEvent(ctx context.Context, e Event) error
+	Prune(ctx context.Context, before time.Time) error
+}
+
+type target struct {
+	svc      config.ExternalService
+	interval time.Duration
+	timeout  time.Duration
+
+	last    Result
+	since   time.Time
+	history []Result // ring, next write at pos
+	pos     int
+	buckets []Bucket // oldest first
+	events  []Event  // oldest first
+}
+
+// Prober runs the configured checks.
+type Prober struct {
+	targets     []*target
+	historySize int
+	retention   time.Duration
+	store       Store
+	logger      *logger.Logger
+
+	mu sync.RWMutex
+}
+
+// New builds a prober for cfg.Services. store may be nil to keep uptime in memory
+// only. Call Start to begin probing.
+func New(cfg config.ExternalConfig, store Store, l *logger.Logger) *Prober {
+	p := &Prober{
+		historySize: cfg.HistorySize,
+		retention:   cfg.Retention,
+		store:       store,
+		logger:      l,
+	}
+	if p.historySize <= 0 {
+		p.historySize = 120
+	}
+	if p.retention <= 0 {
+		p.retention = 90 * 24 * time.Hour
+	}
+
+	for _, svc := range cfg.Services {
+		t := &target{svc: svc, interval: svc.Interval, timeout: svc.Timeout, last: Result{Status: StatusUnknown}}
+		if t.svc.Type == "" {
+			t.svc.Type = TypeHTTP
+		}
+		if t.interval <= 0 {
+			t.interval = cfg.Interval
+		}
+		if t.interval <= 0 {
+			t.interval = 30 * time.Second
+		}
+		if t.timeout <= 0 {
+			t.timeout = cfg.Timeout
+		}
+		if t.timeout <= 0 {
+			t.timeout = 5 * time.Second
+		}
+		p.targets = append(p.targets, t)
+	}
+	return p
+}
+
+// Start loads the stored uptime and probes every service on its interval until ctx
+// is done, the first time right away.
+func (p *Prober) Start(ctx context.Context) {
+	p.load(ctx)
+
+	for _, t := range p.targets {
+		go func() {
+			ticker := time.NewTicker(t.interval)
+			defer ticker.Stop()
+			for {
+				p.probe(ctx, t)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+	go p.pruneLoop(ctx)
+}
+
+func (p *Prober) load(ctx context.Context) {
+	if p.store == nil {
+		return
+	}
+	buckets, events, err := p.store.Load(ctx, time.Now().Add(-p.retention))
+	if err != nil {
+		p.logger.Warn("Failed to load external service uptime", "error", err.Error())
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, t := range p.targets {
+		t.buckets = buckets[t.svc.Name]
+		for _, e := range events {
+			if e.Service == t.svc.Name {
+				t.events = append(t.events, e)
+			}
+		}
+		if n := len(t.events); n > eventsKept {
+			t.events = t.events[n-eventsKept:]
+		}
+	}
+}
+
+func (p *Prober) probe(ctx context.Context, t *target) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	res := check(ctx, t.svc)
+	cancel()
+
+	p.mu.Lock()
+	prev := t.last.Status
+	t.last = res
+	if len(t.history) < p.historySize {
+		t.history = append(t.history, res)
+	} else {
+		t.history[t.pos] = res
+		t.pos = (t.pos + 1) % p.historySize
+	}
+	bucket := t.addBucket(res, time.Now().Add(-p.retention))
+
+	var event *Event
+	if res.Status != prev {
+		t.since = res.Time
+		if prev != StatusUnknown || res.Status != StatusUp {
+			event = &Event{Service: t.svc.Name, Time: res.Time, From: prev, To: res.Status, Error: res.Error}
+			t.events = append(t.events, *event)
+			if len(t.events) > eventsKept {
+				t.events = t.events[1:]
+			}
+		}
+	}
+	p.mu.Unlock()
+
+	if event != nil {
+		if event.To == StatusUp {
+			p.logger.Info("External service recovered", "service", t.svc.Name, "from", event.From)
+		} else {
+			p.logger.Warn("External service state changed", "service", t.svc.Name, "from", event.From, "to", event.To, "error", event.Error)
+		}
+	}
+	if p.store == nil {
+		return
+	}
+	if err := p.store.SaveBucket(context.Background(), t.svc.Name, bucket); err != nil {
+		p.logger.Warn("Failed to save external service uptime", "service", t.svc.Name, "error", err.Error())
+	}
+	if event != nil {
+		if err := p.store.SaveEvent(context.Background(), *event); err != nil {
+			p.logger.Warn("Failed to save external service state change", "service", t.svc.Name, "error", err.Error())
+		}
+	}
+}
+
+// addBucket counts res in its hour and drops buckets older than cutoff. It returns
+// the updated bucket. The caller holds p.mu.
+func (t *target) addBucket(res Result, cutoff time.Time) Bucket {
+	hour := res.Time.Truncate(time.Hour)
+	n := len(t.buckets)
+	if n == 0 || !t.buckets[n-1].Hour.Equal(hour) {
+		t.buckets = append(t.buckets, Bucket{Hour: hour})
+		n++
+	}
+	b := &t.buckets[n-1]
+	b.Checks++
+	if res.Status != StatusDown {
+		b.Up++
+	}
+	b.LatencyMs += res.LatencyMs
+
+	i := 0
+	for i < len(t.buckets) && t.buckets[i].Hour.Before(cutoff) {
+		i++
+	}
+	t.buckets = t.buckets[i:]
+	return t.buckets[len(t.buckets)-1]
+}
+
+func (p *Prober) pruneLoop(ctx context.Context) {
+	if p.store == nil {
+		return
+	}
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := p.store.Prune(ctx, now.Add(-p.retention)); err != nil {
+				p.logger.Warn("Failed to prune external service uptime", "error", err.Error())
+			}
+		}
+	}
+}
+
+// Statuses returns the latest result of every service, in configuration order.
+func (p *Prober) Statuses() []Status {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	now := time.Now()
+	out := make([]Status, 0, len(p.targets))
+	for _, t := range p.targets {
+		out = append(out, t.status(now))
+	}
+	return out
+}
+
+// Detail returns the status and history of the named service.
+func (p *Prober) Detail(name string) (Detail, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	t := p.find(name)
+	if t == nil {
+		return Detail{}, ErrNotFound
+	}
+	now := time.Now()
+	d := Detail{Status: t.status(now)}
+
+	d.History = make([]Result, 0, len(t.history))
+	d.History = append(d.History, t.history[t.pos:]...)
+	d.History = append(d.History, t.history[:t.pos]...)
+
+	d.Buckets = []Bucket{}
+	for _, b := range t.buckets {
+		if now.Sub(b.Hour) < 24*time.Hour {
+			d.Buckets = append(d.Buckets, b)
+		}
+	}
+
+	d.Events = make([]Event, len(t.events))
+	for i, e := range t.events {
+		d.Events[len(t.events)-1-i] = e
+	}
+	return d, nil
+}
+
+// Buckets returns the hourly buckets of the named service since from, oldest first.
+func (p *Prober) Buckets(name string, from time.Time) ([]Bucket, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	t := p.find(name)
+	if t == nil {
+		return nil, ErrNotFound
+	}
+	i := sort.Search(len(t.buckets), func(i int) bool { return !t.buckets[i].Hour.Before(from.Truncate(time.Hour)) })
+	return append([]Bucket(nil), t.buckets[i:]...), nil
+}
+
+// Healthy reports whether the last probe of the named service found it available.
+// Services that have not been probed yet count as healthy.
+func (p *Prober) Healthy(name string) (bool, string) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	t := p.find(name)
+	if t == nil {
+		return false, ErrNotFound.Error()
+	}
+	if t.last.Status != StatusDown {
+		return true, ""
+	}
+	return false, t.last.Error
+}
+
+// find returns the named target. The caller holds p.mu.
+func (p *Prober) find(name string) *target {
+	for _, t := range p.targets {
+		if t.svc.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// status builds the cached view. The caller holds p.mu.
+func (t *target) status(now time.Time) Status {
+	return Status{
+		Name:     t.svc.Name,
+		Type:     t.svc.Type,
+		Target:   t.address(),
+		Interval: t.interval.String(),
+		Result:   t.last,
+		Since:    t.since,
+		Uptime: Uptime{
+			Day:   t.uptime(now.Add(-24 * time.Hour)),
+			Week:  t.uptime(now.Add(-7 * 24 * time.Hour)),
+			Month: t.uptime(now.Add(-30 * 24 * time.Hour)),
+		},
+	}
+}
+
+func (t *target) uptime(from time.Time) float64 {
+	from = from.Truncate(time.Hour)
+	var checks, up int
+	for _, b := range t.buckets {
+		if !b.Hour.Before(from) {
+			checks += b.Checks
+			up += b.Up
+		}
+	}
+	if checks == 0 {
+		return -1
+	}
+	return float64(up) * 100 / float64(checks)
+}
+
+func (t *target) address() string {
+	switch t.svc.Type {
+	case TypeTCP:
+		return t.svc.Address
+	case TypeDNS:
+		return t.svc.Host
+	default:
+		return t.svc.URL
+	}
+}
@@ -0,0 +1,133 @@
+package prober
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SQLStore keeps hourly buckets and state changes in the monitoring SQLite database.
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore creates the probe_buckets and probe_events tables if needed.
+func NewSQLStore(db *sql.DB) (*SQLStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("probe store: database is not available")
+	}
+
+	schema := []string{
+		`CREATE TABLE IF NOT EXISTS probe_buckets (
+			service TEXT NOT NULL,
+			hour BIGINT NOT NULL,
+			checks INTEGER NOT NULL,
+			up INTEGER NOT NULL,
+			latency_ms BIGINT NOT NULL,
+			PRIMARY KEY (service, hour)
+		)`,
+		`CREATE TABLE IF NOT EXISTS probe_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			service TEXT NOT NULL,
+			time BIGINT NOT NULL,
+			from_status TEXT NOT NULL,
+			to_status TEXT NOT NULL,
+			error TEXT
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_probe_events_time ON probe_events (time)`,
+	}
+	for _, stmt := range schema {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, fmt.Errorf("probe store: failed to create schema: %w", err)
+		}
+	}
+
+	return &SQLStore{db: db}, nil
+}
+
+func (s *SQLStore) Load(ctx context.Context, since time.Time) (map[string][]Bucket, []Event, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT service, hour, checks, up, latency_ms
+		FROM probe_buckets
+		WHERE hour >= ?
+		ORDER BY hour
+	`, since.Truncate(time.Hour).UnixMilli())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	buckets := make(map[string][]Bucket)
+	for rows.Next() {
+		var service string
+		var b Bucket
+		var hour int64
+		if err := rows.Scan(&service, &hour, &b.Checks, &b.Up, &b.LatencyMs); err != nil {
+			return nil, nil, err
+		}
+		b.Hour = time.UnixMilli(hour)
+		buckets[service] = append(buckets[service], b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT service, time, from_status, to_status, COALESCE(error, '')
+		FROM probe_events
+		WHERE time >= ?
+		ORDER BY time
+	`, since.UnixMilli())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var e Event
+		var t int64
+		if err := rows.Scan(&e.Service, &t, &e.From, &e.To, &e.Error); err != nil {
+			return nil, nil, err
+		}
+		e.Time = time.UnixMilli(t)
+		events = append(events, e)
+	}
+	return buckets, events, rows.Err()
+}
+
+func (s *SQLStore) SaveBucket(ctx context.Context, service string, b Bucket) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO probe_buckets (service, hour, checks, up, latency_ms)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (service, hour) DO UPDATE SET
+			checks = excluded.checks, up = excluded.up, latency_ms = excluded.latency_ms
+	`, service, b.Hour.UnixMilli(), b.Checks, b.Up, b.LatencyMs)
+	if err != nil {
+		return fmt.Errorf("failed to save probe bucket: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) SaveEvent(ctx context.Context, e Event) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO probe_events (service, time, from_status, to_status, error)
+		VALUES (?, ?, ?, ?, ?)
+	`, e.Service, e.Time.UnixMilli(), e.From, e.To, e.Error)
+	if err != nil {
+		return fmt.Errorf("failed to save probe event: %w", err)
+	}
+	return nil
+}
+
+// Prune deletes buckets and state changes older than before.
+func (s *SQLStore) Prune(ctx context.Context, before time.Time) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM probe_buckets WHERE hour < ?`, before.Truncate(time.Hour).UnixMilli()); err != nil {
+		return fmt.Errorf("failed to prune probe buckets: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM probe_events WHERE time < ?`, before.UnixMilli()); err != nil {
+		return fmt.Errorf("failed to prune probe events: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,519 @@
+package prober
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"test-go/config"
+	"test-go/pkg/infrastructure"
+	"test-go/pkg/logger"
+	"test-go/pkg/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Synthetic run outcomes.
+const (
+	RunPassed = "passed"
+	RunFailed = "failed"
+)
+
+const (
+	defaultBudget      = 30 * time.Second
+	defaultStepTimeout = 10 * time.Second
+	snippetSize        = 512 // bytes of a failed step's response body kept
+)
+
+var (
+	ErrCheckNotFound = errors.New("no synthetic check with that name")
+	ErrRunning       = errors.New("a run of this check is already in progress")
+)
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// StepResult is the outcome of one step of a run.
+type StepResult struct {
+	Name       string `json:"name"`
+	Method     string `json:"method"`
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+	Snippet    string `json:"snippet,omitempty"` // start of the response body when the step failed
+}
+
+// Run is one execution of a synthetic check. Steps after a failed one are skipped.
+type Run struct {
+	ID         string       `json:"id"`
+	Check      string       `json:"check"`
+	Trigger    string       `json:"trigger"` // "schedule" or "manual by <user>"
+	StartedAt  time.Time    `json:"started_at"`
+	DurationMs int64        `json:"duration_ms"`
+	Status     string       `json:"status"`
+	FailedStep string       `json:"failed_step,omitempty"`
+	Error      string       `json:"error,omitempty"`
+	Steps      []StepResult `json:"steps"`
+}
+
+// SyntheticStatus describes a check and its last run.
+type SyntheticStatus struct {
+	Name     string   `json:"name"`
+	Schedule string   `json:"schedule"`
+	Budget   string   `json:"budget"`
+	Steps    []string `json:"steps"`
+	Running  bool     `json:"running"`
+	Last     *Run     `json:"last,omitempty"`
+	PassRate float64  `json:"pass_rate"` // percent of the kept runs; -1 without runs
+	Runs     int      `json:"runs"`
+}
+
+type synthetic struct {
+	cfg     config.SyntheticCheck
+	budget  time.Duration
+	runs    []Run // oldest first
+	running bool
+}
+
+// Synthetics runs the scripted checks of monitoring.synthetics.
+type Synthetics struct {
+	checks  []*synthetic
+	history int
+	logger  *logger.Logger
+
+	mu sync.RWMutex
+}
+
+// NewSynthetics validates cfg. Call Schedule to run the checks on their schedules.
+func NewSynthetics(cfg config.SyntheticsConfig, l *logger.Logger) (*Synthetics, error) {
+	s := &Synthetics{history: cfg.History, logger: l}
+	if s.history <= 0 {
+		s.history = 50
+	}
+
+	seen := make(map[string]bool)
+	for _, c := range cfg.Checks {
+		if c.Name == "" {
+			return nil, fmt.Errorf("synthetics: a check has no name")
+		}
+		if seen[c.Name] {
+			return nil, fmt.Errorf("synthetics: duplicate check name %q", c.Name)
+		}
+		seen[c.Name] = true
+		if len(c.Steps) == 0 {
+			return nil, fmt.Errorf("synthetics: check %q has no steps", c.Name)
+		}
+		for i, step := range c.Steps {
+			if err := validateStep(step); err != nil {
+				return nil, fmt.Errorf("synthetics: check %q step %d: %w", c.Name, i+1, err)
+			}
+		}
+
+		budget := c.Budget
+		if budget <= 0 {
+			budget = defaultBudget
+		}
+		s.checks = append(s.checks, &synthetic{cfg: c, budget: budget})
+	}
+	return s, nil
+}
+
+// validateStep checks that the step has a URL and that its templates parse.
+func validateStep(step config.SyntheticStep) error {
+	if step.URL == "" {
+		return fmt.Errorf("no url")
+	}
+	texts := []string{step.URL, step.Body, step.BodyContains}
+	for _, v := range step.Headers {
+		texts = append(texts, v)
+	}
+	for _, a := range step.JSON {
+		texts = append(texts, a.Equals)
+	}
+	for _, text := range texts {
+		if _, err := template.New("").Funcs(templateFuncs).Parse(text); err != nil {
+			return err
+		}
+	}
+	if _, err := statusMatches(step.ExpectedStatus, 200); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Schedule adds a job per check to cron. Failed runs count as failed jobs.
+func (s *Synthetics) Schedule(cron *infrastructure.CronManager) error {
+	for _, c := range s.checks {
+		name := c.cfg.Name
+		_, err := cron.AddJobFunc("synthetic:"+name, c.cfg.Schedule, func() error {
+			run, err := s.Run(name, "schedule")
+			if err != nil {
+				return err
+			}
+			if run.Status == RunFailed {
+				return fmt.Errorf("%s: %s", run.FailedStep, run.Error)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("synthetics: check %q: invalid schedule %q: %w", name, c.cfg.Schedule, err)
+		}
+	}
+	return nil
+}
+
+// Run executes the named check and waits for it to finish.
+func (s *Synthetics) Run(name, trigger string) (Run, error) {
+	c, err := s.begin(name)
+	if err != nil {
+		return Run{}, err
+	}
+	return s.execute(c, trigger), nil
+}
+
+// Start executes the named check in the background.
+func (s *Synthetics) Start(name, trigger string) error {
+	c, err := s.begin(name)
+	if err != nil {
+		return err
+	}
+	go s.execute(c, trigger)
+	return nil
+}
+
+func (s *Synthetics) begin(name string) (*synthetic, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.find(name)
+	if c == nil {
+		return nil, ErrCheckNotFound
+	}
+	if c.running {
+		return nil, ErrRunning
+	}
+	c.running = true
+	return c, nil
+}
+
+func (s *Synthetics) execute(c *synthetic, trigger string) Run {
+	run := Run{ID: runID(), Check: c.cfg.Name, Trigger: trigger, StartedAt: time.Now(), Status: RunPassed, Steps: []StepResult{}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.budget)
+	defer cancel()
+	ctx, span := tracing.Tracer().Start(ctx, "synthetic "+c.cfg.Name,
+		trace.WithAttributes(attribute.String("synthetic.trigger", trigger)),
+	)
+
+	vars := map[string]string{"run_id": run.ID}
+	for k, v := range c.cfg.Variables {
+		vars[k] = v
+	}
+
+	for _, step := range c.cfg.Steps {
+		res, err := runStep(ctx, step, vars)
+		run.Steps = append(run.Steps, res)
+		if err != nil {
+			run.Status = RunFailed
+			run.FailedStep = res.Name
+			run.Error = err.Error()
+			if ctx.Err() != nil {
+				run.Error = fmt.Sprintf("duration budget of %s exceeded", c.budget)
+			}
+			break
+		}
+	}
+	run.DurationMs = time.Since(run.StartedAt).Milliseconds()
+
+	var err error
+	if run.Status == RunFailed {
+		err = fmt.Errorf("%s: %s", run.FailedStep, run.Error)
+	}
+	span.SetAttributes(attribute.String("synthetic.status", run.Status))
+	tracing.End(span, err)
+
+	s.mu.Lock()
+	prev := ""
+	if n := len(c.runs); n > 0 {
+		prev = c.runs[n-1].Status
+	}
+	c.runs = append(c.runs, run)
+	if len(c.runs) > s.history {
+		c.runs = c.runs[len(c.runs)-s.history:]
+	}
+	c.running = false
+	s.mu.Unlock()
+
+	switch {
+	case run.Status == RunFailed && prev != RunFailed:
+		s.logger.Warn("Synthetic check failed", "check", run.Check, "step", run.FailedStep, "error", run.Error, "duration_ms", run.DurationMs)
+	case run.Status == RunPassed && prev == RunFailed:
+		s.logger.Info("Synthetic check recovered", "check", run.Check, "duration_ms", run.DurationMs)
+	}
+	return run
+}
+
+// runStep sends one request and applies the step's assertions and extractions to
+// vars. The request is a client span of ctx.
+func runStep(ctx context.Context, step config.SyntheticStep, vars map[string]string) (StepResult, error) {
+	res := StepResult{Name: stepName(step), Method: stepMethod(step)}
+
+	start := time.Now()
+	body, err := sendStep(ctx, step, vars, &res)
+	res.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		res.Error = err.Error()
+		if len(body) > snippetSize {
+			body = body[:snippetSize]
+		}
+		res.Snippet = string(body)
+	}
+	return res, err
+}
+
+func sendStep(ctx context.Context, step config.SyntheticStep, vars map[string]string, res *StepResult) ([]byte, error) {
+	url, err := render(step.URL, vars)
+	if err != nil {
+		return nil, err
+	}
+	res.URL = url
+	reqBody, err := render(step.Body, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := step.Timeout
+	if timeout <= 0 {
+		timeout = defaultStepTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ctx, span := tracing.Tracer().Start(ctx, "synthetic.step "+res.Name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.HTTPRequestMethodKey.String(res.Method), semconv.URLFull(url)),
+	)
+
+	body, err := func() ([]byte, error) {
+		var reader io.Reader
+		if reqBody != "" {
+			reader = strings.NewReader(reqBody)
+		}
+		req, err := http.NewRequestWithContext(ctx, res.Method, url, reader)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range step.Headers {
+			value, err := render(v, vars)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set(k, value)
+		}
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		res.StatusCode = resp.StatusCode
+		span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+		if err != nil {
+			return body, fmt.Errorf("failed to read body: %w", err)
+		}
+		return body, checkStep(step, vars, resp.StatusCode, body)
+	}()
+	tracing.End(span, err)
+	return body, err
+}
+
+// checkStep applies the assertions of step to a response, then stores the extracted
+// variables in vars.
+func checkStep(step config.SyntheticStep, vars map[string]string, code int, body []byte) error {
+	ok, err := statusMatches(step.ExpectedStatus, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("unexpected status %d", code)
+	}
+
+	if step.BodyContains != "" {
+		want, err := render(step.BodyContains, vars)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(string(body), want) {
+			return fmt.Errorf("body does not contain %q", want)
+		}
+	}
+
+	if len(step.JSON) > 0 {
+		assertions := make([]config.ExternalAssertion, len(step.JSON))
+		for i, a := range step.JSON {
+			a.Equals, err = render(a.Equals, vars)
+			if err != nil {
+				return err
+			}
+			assertions[i] = a
+		}
+		if err := assertJSON(body, assertions); err != nil {
+			return err
+		}
+	}
+
+	if len(step.Extract) > 0 {
+		var doc interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return fmt.Errorf("body is not JSON: %w", err)
+		}
+		for name, path := range step.Extract {
+			v, ok := lookupJSON(doc, path)
+			if !ok {
+				return fmt.Errorf("extract %s: JSON path %s not found", name, path)
+			}
+			vars[name] = jsonString(v)
+		}
+	}
+	return nil
+}
+
+// render executes text as a template over vars; unknown variables are errors.
+func render(text string, vars map[string]string) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	t, err := template.New("").Option("missingkey=error").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, vars); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Statuses returns every check with its last run, in configuration order.
+func (s *Synthetics) Statuses() []SyntheticStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]SyntheticStatus, 0, len(s.checks))
+	for _, c := range s.checks {
+		out = append(out, c.status())
+	}
+	return out
+}
+
+// Runs returns the status and kept runs of the named check, newest first.
+func (s *Synthetics) Runs(name string) (SyntheticStatus, []Run, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c := s.find(name)
+	if c == nil {
+		return SyntheticStatus{}, nil, ErrCheckNotFound
+	}
+	runs := make([]Run, len(c.runs))
+	for i, r := range c.runs {
+		runs[len(c.runs)-1-i] = r
+	}
+	return c.status(), runs, nil
+}
+
+// Healthy reports whether the last run of the named check passed. Checks that have
+// not run yet count as healthy.
+func (s *Synthetics) Healthy(name string) (bool, string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c := s.find(name)
+	if c == nil {
+		return false, ErrCheckNotFound.Error()
+	}
+	n := len(c.runs)
+	if n == 0 || c.runs[n-1].Status == RunPassed {
+		return true, ""
+	}
+	last := c.runs[n-1]
+	return false, last.FailedStep + ": " + last.Error
+}
+
+// find returns the named check. The caller holds s.mu.
+func (s *Synthetics) find(name string) *synthetic {
+	for _, c := range s.checks {
+		if c.cfg.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// status builds the summary of c. The caller holds s.mu.
+func (c *synthetic) status() SyntheticStatus {
+	st := SyntheticStatus{
+		Name:     c.cfg.Name,
+		Schedule: c.cfg.Schedule,
+		Budget:   c.budget.String(),
+		Running:  c.running,
+		PassRate: -1,
+		Runs:     len(c.runs),
+	}
+	for _, step := range c.cfg.Steps {
+		st.Steps = append(st.Steps, stepName(step))
+	}
+	if n := len(c.runs); n > 0 {
+		last := c.runs[n-1]
+		st.Last = &last
+		passed := 0
+		for _, r := range c.runs {
+			if r.Status == RunPassed {
+				passed++
+			}
+		}
+		st.PassRate = float64(passed) * 100 / float64(n)
+	}
+	return st
+}
+
+func stepMethod(step config.SyntheticStep) string {
+	if step.Method == "" {
+		return http.MethodGet
+	}
+	return strings.ToUpper(step.Method)
+}
+
+// stepName defaults to the method and URL template.
+func stepName(step config.SyntheticStep) string {
+	if step.Name != "" {
+		return step.Name
+	}
+	return stepMethod(step) + " " + step.URL
+}
+
+func runID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,113 @@
+package profiling
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+type autoState struct {
+	mu            sync.Mutex
+	lastCPU       float64
+	lastCheck     time.Time
+	lastTriggered time.Time
+	triggers      int
+}
+
+// AutoStatus describes the CPU threshold scheduler.
+type AutoStatus struct {
+	Enabled       bool      `json:"enabled"`
+	CPUThreshold  float64   `json:"cpu_threshold"`
+	Cooldown      string    `json:"cooldown"`
+	Kinds         []string  `json:"kinds"`
+	LastCPU       float64   `json:"last_cpu"` // percent of all cores
+	LastCheck     time.Time `json:"last_check,omitzero"`
+	LastTriggered time.Time `json:"last_triggered,omitzero"`
+	Triggers      int       `json:"triggers"`
+}
+
+// StartAuto samples the process CPU usage every check interval until ctx is done
+// and captures the configured profiles when it reaches the threshold, at most once
+// per cooldown. It does nothing unless auto profiling is enabled.
+func (p *Profiler) StartAuto(ctx context.Context) {
+	ac := p.cfg.Auto
+	if !ac.Enabled {
+		return
+	}
+	proc, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		p.logger.Error("Automatic profiling disabled", err)
+		return
+	}
+	interval := ac.CheckInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		proc.Percent(0) // the first call only sets the baseline
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				pct, err := proc.Percent(0)
+				if err != nil {
+					continue
+				}
+				p.checkCPU(pct/float64(runtime.NumCPU()), now)
+			}
+		}
+	}()
+}
+
+func (p *Profiler) checkCPU(cpu float64, now time.Time) {
+	ac := p.cfg.Auto
+
+	p.auto.mu.Lock()
+	p.auto.lastCPU, p.auto.lastCheck = cpu, now
+	fire := cpu >= ac.CPUThreshold && (p.auto.lastTriggered.IsZero() || now.Sub(p.auto.lastTriggered) >= ac.Cooldown)
+	if fire {
+		p.auto.lastTriggered = now
+		p.auto.triggers++
+	}
+	p.auto.mu.Unlock()
+
+	if !fire {
+		return
+	}
+
+	trigger := fmt.Sprintf("cpu %.0f%% >= %.0f%%", cpu, ac.CPUThreshold)
+	p.logger.Warn("CPU threshold reached, capturing profiles", "cpu_percent", cpu, "threshold", ac.CPUThreshold, "kinds", ac.Kinds)
+	for _, kind := range ac.Kinds {
+		if _, err := p.Start(kind, ac.Seconds, trigger, ""); err != nil {
+			p.logger.Warn("Automatic profile capture skipped", "kind", kind, "error", err.Error())
+		}
+	}
+}
+
+// Auto returns the state of the CPU threshold scheduler.
+func (p *Profiler) Auto() AutoStatus {
+	ac := p.cfg.Auto
+	p.auto.mu.Lock()
+	defer p.auto.mu.Unlock()
+
+	return AutoStatus{
+		Enabled:       ac.Enabled,
+		CPUThreshold:  ac.CPUThreshold,
+		Cooldown:      ac.Cooldown.String(),
+		Kinds:         ac.Kinds,
+		LastCPU:       p.auto.lastCPU,
+		LastCheck:     p.auto.lastCheck,
+		LastTriggered: p.auto.lastTriggered,
+		Triggers:      p.auto.triggers,
+	}
+}
@@ -0,0 +1,114 @@
+package profiling
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"runtime/pprof"
+	"sort"
+	"strings"
+)
+
+// goroutine 18 [chan receive, 2 minutes]:
+var goroutineHeader = regexp.MustCompile(`^goroutine (\d+)(?: [^\[]*)?\[([^\]]*)\]:$`)
+
+// GoroutineGroup is a set of goroutines with the same stack.
+type GoroutineGroup struct {
+	Count  int            `json:"count"`
+	States map[string]int `json:"states"` // e.g. "chan receive": 3, without wait times
+	Top    string         `json:"top"`    // innermost function
+	Stack  []Frame        `json:"stack"`  // innermost first
+}
+
+// Frame is one function call in a stack.
+type Frame struct {
+	Func string `json:"func"`
+	File string `json:"file"` // path:line
+}
+
+// GoroutineDump is the parsed stack dump of every goroutine, grouped by stack with
+// the largest groups first.
+type GoroutineDump struct {
+	Total  int              `json:"total"`
+	Groups []GoroutineGroup `json:"groups"`
+}
+
+// Goroutines returns the current goroutines grouped by stack.
+func Goroutines() GoroutineDump {
+	var buf bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&buf, 2)
+	return parseGoroutines(buf.Bytes())
+}
+
+// parseGoroutines groups a dump in the format of runtime.Stack (pprof debug=2).
+func parseGoroutines(dump []byte) GoroutineDump {
+	groups := make(map[string]*GoroutineGroup)
+	var order []string
+	var total int
+
+	var state string
+	var frames []Frame
+	flush := func() {
+		if state == "" {
+			return
+		}
+		total++
+		keys := make([]string, len(frames))
+		for i, f := range frames {
+			keys[i] = f.Func + "@" + f.File
+		}
+		key := strings.Join(keys, "\n")
+
+		g, ok := groups[key]
+		if !ok {
+			g = &GoroutineGroup{States: make(map[string]int), Stack: frames}
+			if len(frames) > 0 {
+				g.Top = frames[0].Func
+			}
+			groups[key] = g
+			order = append(order, key)
+		}
+		g.Count++
+		g.States[state]++
+		state, frames = "", nil
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(dump))
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for sc.Scan() {
+		line := sc.Text()
+		if m := goroutineHeader.FindStringSubmatch(line); m != nil {
+			flush()
+			state, _, _ = strings.Cut(m[2], ",")
+			continue
+		}
+		if state == "" || strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "\t") {
+			// File line of the previous function: "\t/path/file.go:123 +0x1d"
+			if n := len(frames); n > 0 {
+				file, _, _ := strings.Cut(strings.TrimSpace(line), " ")
+				frames[n-1].File = file
+			}
+			continue
+		}
+		fn := line
+		if after, ok := strings.CutPrefix(fn, "created by "); ok {
+			fn, _, _ = strings.Cut(after, " in goroutine ")
+			fn = "created by " + fn
+		} else if i := strings.LastIndexByte(fn, '('); i > 0 {
+			fn = fn[:i] // drop arguments
+		}
+		frames = append(frames, Frame{Func: fn})
+	}
+	flush()
+
+	out := GoroutineDump{Total: total, Groups: make([]GoroutineGroup, 0, len(order))}
+	for _, key := range order {
+		out.Groups = append(out.Groups, *groups[key])
+	}
+	sort.SliceStable(out.Groups, func(i, j int) bool { return out.Groups[i].Count > out.Groups[j].Count })
+	return out
+}
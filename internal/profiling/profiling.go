@@ -0,0 +1,253 @@
+// Package profiling captures pprof profiles of the running process, on demand from
+// the dashboard or when the process CPU usage crosses a threshold, and keeps the
+// last few in memory for download.
+package profiling
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"test-go/config"
+	"test-go/pkg/logger"
+)
+
+// Profile kinds that can be captured.
+const (
+	KindCPU       = "cpu"
+	KindHeap      = "heap"
+	KindGoroutine = "goroutine"
+	KindMutex     = "mutex"
+	KindBlock     = "block"
+)
+
+// Kinds lists the capturable profiles. CPU, mutex and block profiles are recorded
+// over a number of seconds; heap and goroutine profiles are snapshots.
+var Kinds = []string{KindCPU, KindHeap, KindGoroutine, KindMutex, KindBlock}
+
+// Capture states.
+const (
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+const (
+	// Sampling rates while a mutex or block capture runs
+	mutexFraction = 5
+	blockRate     = 10000 // one sample per 10µs spent blocked
+)
+
+var (
+	ErrUnknownKind = errors.New("profiling: unknown profile kind")
+	ErrBusy        = errors.New("profiling: a capture of this kind is already running")
+	ErrNotFound    = errors.New("profiling: capture not found")
+)
+
+// Capture describes one profile. The profile itself is returned by Data.
+type Capture struct {
+	ID          string    `json:"id"`
+	Kind        string    `json:"kind"`
+	Seconds     int       `json:"seconds,omitempty"` // 0 for snapshots
+	Trigger     string    `json:"trigger"`           // "manual" or the automatic trigger reason
+	RequestedBy string    `json:"requested_by,omitempty"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+	FinishedAt  time.Time `json:"finished_at,omitzero"`
+	Size        int       `json:"size"`
+
+	data []byte
+}
+
+// Profiler runs captures and keeps the most recent ones.
+type Profiler struct {
+	cfg    config.ProfilingConfig
+	logger *logger.Logger
+
+	mu       sync.Mutex
+	captures []*Capture      // oldest first
+	running  map[string]bool // by kind; only one capture per kind at a time
+
+	auto autoState
+}
+
+// New creates a profiler. Call StartAuto to enable the CPU threshold scheduler.
+func New(cfg config.ProfilingConfig, l *logger.Logger) *Profiler {
+	if cfg.MaxCaptures <= 0 {
+		cfg.MaxCaptures = 10
+	}
+	if cfg.MaxSeconds <= 0 {
+		cfg.MaxSeconds = 60
+	}
+	return &Profiler{cfg: cfg, logger: l, running: make(map[string]bool)}
+}
+
+// MaxSeconds returns the longest allowed duration of a timed capture.
+func (p *Profiler) MaxSeconds() int {
+	return p.cfg.MaxSeconds
+}
+
+// Start begins a capture in the background and returns it in the running state.
+// seconds applies to CPU, mutex and block profiles and is clamped to MaxSeconds.
+func (p *Profiler) Start(kind string, seconds int, trigger, requestedBy string) (Capture, error) {
+	if !validKind(kind) {
+		return Capture{}, ErrUnknownKind
+	}
+	if timed(kind) {
+		if seconds <= 0 {
+			seconds = 10
+		}
+		if seconds > p.cfg.MaxSeconds {
+			seconds = p.cfg.MaxSeconds
+		}
+	} else {
+		seconds = 0
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.running[kind] {
+		return Capture{}, ErrBusy
+	}
+	p.running[kind] = true
+
+	c := &Capture{
+		ID:          newID(),
+		Kind:        kind,
+		Seconds:     seconds,
+		Trigger:     trigger,
+		RequestedBy: requestedBy,
+		Status:      StatusRunning,
+		StartedAt:   time.Now(),
+	}
+	p.captures = append(p.captures, c)
+	p.prune()
+
+	go p.run(c)
+	return *c, nil
+}
+
+// Captures returns the kept captures, newest first.
+func (p *Profiler) Captures() []Capture {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := make([]Capture, 0, len(p.captures))
+	for i := len(p.captures) - 1; i >= 0; i-- {
+		c := *p.captures[i]
+		c.data = nil
+		out = append(out, c)
+	}
+	return out
+}
+
+// Data returns a finished capture and its profile in pprof format.
+func (p *Profiler) Data(id string) (Capture, []byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, c := range p.captures {
+		if c.ID == id && c.Status == StatusDone {
+			return *c, c.data, nil
+		}
+	}
+	return Capture{}, nil, ErrNotFound
+}
+
+func (p *Profiler) run(c *Capture) {
+	data, err := collect(c.Kind, time.Duration(c.Seconds)*time.Second)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.running, c.Kind)
+	c.FinishedAt = time.Now()
+	if err != nil {
+		c.Status = StatusFailed
+		c.Error = err.Error()
+		p.logger.Error("Profile capture failed", err, "kind", c.Kind, "trigger", c.Trigger)
+		return
+	}
+	c.Status = StatusDone
+	c.data = data
+	c.Size = len(data)
+	p.logger.Info("Profile captured", "kind", c.Kind, "seconds", c.Seconds, "trigger", c.Trigger, "bytes", c.Size)
+}
+
+// prune drops the oldest finished captures beyond the limit. The caller holds p.mu.
+func (p *Profiler) prune() {
+	for i := 0; len(p.captures) > p.cfg.MaxCaptures && i < len(p.captures); {
+		if p.captures[i].Status == StatusRunning {
+			i++
+			continue
+		}
+		p.captures = append(p.captures[:i], p.captures[i+1:]...)
+	}
+}
+
+// collect records one profile in pprof's protobuf format.
+func collect(kind string, d time.Duration) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch kind {
+	case KindCPU:
+		if err := pprof.StartCPUProfile(&buf); err != nil {
+			return nil, err
+		}
+		time.Sleep(d)
+		pprof.StopCPUProfile()
+		return buf.Bytes(), nil
+
+	case KindMutex:
+		prev := runtime.SetMutexProfileFraction(mutexFraction)
+		time.Sleep(d)
+		err := pprof.Lookup("mutex").WriteTo(&buf, 0)
+		runtime.SetMutexProfileFraction(prev)
+		return buf.Bytes(), err
+
+	case KindBlock:
+		runtime.SetBlockProfileRate(blockRate)
+		time.Sleep(d)
+		err := pprof.Lookup("block").WriteTo(&buf, 0)
+		runtime.SetBlockProfileRate(0)
+		return buf.Bytes(), err
+
+	case KindHeap:
+		runtime.GC() // up-to-date live heap, as with ?gc=1
+		fallthrough
+	default:
+		prof := pprof.Lookup(kind)
+		if prof == nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownKind, kind)
+		}
+		err := prof.WriteTo(&buf, 0)
+		return buf.Bytes(), err
+	}
+}
+
+func validKind(kind string) bool {
+	for _, k := range Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func timed(kind string) bool {
+	return kind == KindCPU || kind == KindMutex || kind == KindBlock
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
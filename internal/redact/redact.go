@@ -0,0 +1,136 @@
+// Package redact masks credentials and other sensitive values before requests are
+// stored, so captures and audit entries share one definition of "sensitive".
+package redact
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Mask replaces sensitive values.
+const Mask = "[REDACTED]"
+
+// Redactor masks configured headers, and JSON keys, form fields and query
+// parameters whose names contain one of the configured field names.
+type Redactor struct {
+	headers map[string]bool
+	fields  []string
+}
+
+// New builds a Redactor. Header names are matched exactly (case-insensitive),
+// field names by case-insensitive substring.
+func New(headers, fields []string) *Redactor {
+	r := &Redactor{headers: make(map[string]bool, len(headers))}
+	for _, h := range headers {
+		r.headers[http.CanonicalHeaderKey(h)] = true
+	}
+	for _, f := range fields {
+		r.fields = append(r.fields, strings.ToLower(f))
+	}
+	return r
+}
+
+// Headers returns a copy of h with sensitive values masked.
+func (r *Redactor) Headers(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for k, v := range h {
+		if r.headers[http.CanonicalHeaderKey(k)] {
+			out[k] = []string{Mask}
+			continue
+		}
+		out[k] = append([]string(nil), v...)
+	}
+	return out
+}
+
+// URL returns u as a string with sensitive query parameters masked.
+func (r *Redactor) URL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	masked := *u
+	q := u.Query()
+	for k := range q {
+		if r.sensitive(k) {
+			q[k] = []string{Mask}
+		}
+	}
+	masked.RawQuery = q.Encode()
+	return masked.String()
+}
+
+// jsonPair matches a "key": value pair, for JSON that can't be parsed (e.g. truncated bodies).
+var jsonPair = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"(\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+
+// Body masks sensitive fields in JSON and form bodies. Other content is returned unchanged.
+func (r *Redactor) Body(contentType string, b []byte) []byte {
+	if len(b) == 0 || len(r.fields) == 0 {
+		return b
+	}
+
+	switch {
+	case strings.Contains(contentType, "json"):
+		var v interface{}
+		if err := json.Unmarshal(b, &v); err == nil {
+			if out, err := json.Marshal(r.value(v)); err == nil {
+				return out
+			}
+		}
+		// Not parseable, most likely truncated: mask pair by pair
+		return jsonPair.ReplaceAllFunc(b, func(m []byte) []byte {
+			sub := jsonPair.FindSubmatch(m)
+			if !r.sensitive(string(sub[1])) {
+				return m
+			}
+			return []byte(`"` + string(sub[1]) + `"` + string(sub[2]) + `"` + Mask + `"`)
+		})
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		pairs := strings.Split(string(b), "&")
+		for i, pair := range pairs {
+			key, _, _ := strings.Cut(pair, "=")
+			if unescaped, err := url.QueryUnescape(key); err == nil {
+				key = unescaped
+			}
+			if r.sensitive(key) {
+				k, _, _ := strings.Cut(pair, "=")
+				pairs[i] = k + "=" + url.QueryEscape(Mask)
+			}
+		}
+		return []byte(strings.Join(pairs, "&"))
+	}
+	return b
+}
+
+func (r *Redactor) value(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			if r.sensitive(k) {
+				t[k] = Mask
+			} else {
+				t[k] = r.value(val)
+			}
+		}
+		return t
+	case []interface{}:
+		for i := range t {
+			t[i] = r.value(t[i])
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+func (r *Redactor) sensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, f := range r.fields {
+		if strings.Contains(name, f) {
+			return true
+		}
+	}
+	return false
+}
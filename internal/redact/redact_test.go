@@ -0,0 +1,136 @@
+package redact
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func testRedactor() *Redactor {
+	return New(
+		[]string{"Authorization", "cookie", "Set-Cookie", "X-API-Key"},
+		[]string{"password", "Secret", "token", "api_key"},
+	)
+}
+
+func TestRedactorHeaders(t *testing.T) {
+	r := testRedactor()
+	in := http.Header{
+		"Authorization": {"Bearer abc123"},
+		"Cookie":        {"session=abc123"},
+		"Set-Cookie":    {"a=abc123", "b=abc123"},
+		"x-api-key":     {"sk_abc123"}, // not canonical, as when set directly on the map
+		"Content-Type":  {"application/json"},
+	}
+
+	out := r.Headers(in)
+	for k, v := range out {
+		if k == "Content-Type" {
+			continue
+		}
+		if len(v) != 1 || v[0] != Mask {
+			t.Errorf("%s = %v, want it redacted", k, v)
+		}
+	}
+	if got := out.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want it kept", got)
+	}
+	if in.Get("Authorization") != "Bearer abc123" {
+		t.Error("input headers were modified")
+	}
+}
+
+func TestRedactorURL(t *testing.T) {
+	r := testRedactor()
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"https://example.com/api/tasks", "https://example.com/api/tasks"},
+		{"https://example.com/api/tasks?page=2", "https://example.com/api/tasks?page=2"},
+		{"https://example.com/login?access_token=abc123&page=2", "https://example.com/login?access_token=%5BREDACTED%5D&page=2"},
+		{"https://example.com/hook?API_KEY=abc123", "https://example.com/hook?API_KEY=%5BREDACTED%5D"},
+	}
+
+	for _, tt := range tests {
+		u, err := url.Parse(tt.raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := r.URL(u); got != tt.want {
+			t.Errorf("URL(%s) = %s, want %s", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestRedactorBody(t *testing.T) {
+	r := testRedactor()
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{
+			name:        "top-level JSON field",
+			contentType: "application/json",
+			body:        `{"user":"ann","password":"abc123"}`,
+			want:        `{"password":"[REDACTED]","user":"ann"}`,
+		},
+		{
+			name:        "nested objects and arrays",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"items":[{"refresh_token":"abc123","id":1}],"auth":{"ClientSecret":{"v":"abc123"}}}`,
+			want:        `{"auth":{"ClientSecret":"[REDACTED]"},"items":[{"id":1,"refresh_token":"[REDACTED]"}]}`,
+		},
+		{
+			name:        "vendor JSON type",
+			contentType: "application/vnd.api+json",
+			body:        `{"api_key":"abc123"}`,
+			want:        `{"api_key":"[REDACTED]"}`,
+		},
+		{
+			name:        "truncated JSON",
+			contentType: "application/json",
+			body:        `{"user":"ann","password":"abc123","note":"unfinish`,
+			want:        `{"user":"ann","password":"[REDACTED]","note":"unfinish`,
+		},
+		{
+			name:        "truncated inside a sensitive value",
+			contentType: "application/json",
+			body:        `{"user":"ann","token":"abc1`,
+			want:        `{"user":"ann","token":"[REDACTED]"`,
+		},
+		{
+			name:        "form fields",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "user=ann&password=abc123&remember=1",
+			want:        "user=ann&password=%5BREDACTED%5D&remember=1",
+		},
+		{
+			name:        "escaped form field name",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "user=ann&pass%77ord=abc123",
+			want:        "user=ann&pass%77ord=%5BREDACTED%5D",
+		},
+		{
+			name:        "other content is left alone",
+			contentType: "text/plain",
+			body:        "password=abc123",
+			want:        "password=abc123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(r.Body(tt.contentType, []byte(tt.body)))
+			if got != tt.want {
+				t.Errorf("Body = %s, want %s", got, tt.want)
+			}
+			if tt.contentType != "text/plain" && strings.Contains(got, "abc1") {
+				t.Errorf("secret survived redaction: %s", got)
+			}
+		})
+	}
+}
@@ -1,20 +1,40 @@
 package server
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
 	"os"
 	"reflect"
+	"runtime"
 	"test-go/config"
+	"test-go/internal/alerting"
+	"test-go/internal/apikey"
+	"test-go/internal/audit"
+	"test-go/internal/capture"
+	"test-go/internal/errtrack"
+	"test-go/internal/ipfilter"
+	"test-go/internal/metricstore"
 	"test-go/internal/middleware"
 	"test-go/internal/monitoring"
+	"test-go/internal/monitoring/database"
+	"test-go/internal/probeguard"
+	"test-go/internal/prober"
+	"test-go/internal/profiling"
 	"test-go/internal/services"
 	"test-go/internal/services/modules"
 	"test-go/pkg/infrastructure"
 	"test-go/pkg/logger"
+	"test-go/pkg/metrics"
 	"test-go/pkg/response"
+	"test-go/pkg/sysstats"
+	"test-go/pkg/tracing"
 	"test-go/pkg/utils"
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 type Server struct {
@@ -26,6 +46,21 @@ type Server struct {
 	postgresManager *infrastructure.PostgresManager
 	cronManager     *infrastructure.CronManager
 	broadcaster     *monitoring.LogBroadcaster
+	auditor         *audit.Recorder
+	ipFilter        *ipfilter.Filter
+	probeGuard      *probeguard.Guard
+	captures        *capture.Store
+	apiKeys         *apikey.Manager
+	httpMetrics     *metrics.HTTP
+	history         *metricstore.History
+	alerts          *alerting.Engine
+	prober          *prober.Prober
+	synthetics      *prober.Synthetics
+	debugSigner     *logger.DebugSigner
+	captureSigner   *logger.DebugSigner
+	profiler        *profiling.Profiler
+	errTracker      *errtrack.Tracker
+	stopTracing     func(context.Context) error
 }
 
 func New(cfg *config.Config, l *logger.Logger, b *monitoring.LogBroadcaster) *Server {
@@ -33,9 +68,28 @@ func New(cfg *config.Config, l *logger.Logger, b *monitoring.LogBroadcaster) *Se
 	e.HideBanner = true
 	e.HidePort = true
 
+	// Rate limits, bans and logs key on RealIP, so forwarding headers are honoured only
+	// from trusted proxies whether or not the IP filter is enabled
+	extractor, err := ipfilter.NewIPExtractor(cfg.IPFilter.TrustedProxies)
+	if err != nil {
+		l.Error("Ignoring trusted proxies", err)
+		extractor = echo.ExtractIPDirect()
+	}
+	e.IPExtractor = extractor
+
+	s := &Server{
+		echo:        e,
+		config:      cfg,
+		logger:      l,
+		broadcaster: b,
+	}
+
 	// Custom HTTP Error Handler for JSON responses
 	e.HTTPErrorHandler = func(err error, c echo.Context) {
 		l.Error("HTTP Error", err)
+		if s.errTracker != nil {
+			s.trackError(err, c)
+		}
 
 		// Handle HTTP errors with JSON response
 		if he, ok := err.(*echo.HTTPError); ok {
@@ -66,18 +120,50 @@ func New(cfg *config.Config, l *logger.Logger, b *monitoring.LogBroadcaster) *Se
 		response.InternalServerError(c, "An unexpected error occurred")
 	}
 
-	return &Server{
-		echo:        e,
-		config:      cfg,
-		logger:      l,
-		broadcaster: b,
+	return s
+}
+
+// trackError hands err to the error tracker, grouped under the handler of the route.
+// An HTTP error wrapping another error is tracked as that error.
+func (s *Server) trackError(err error, c echo.Context) {
+	status := http.StatusInternalServerError
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		status = he.Code
+		if he.Internal != nil {
+			err = he.Internal
+		}
+	}
+	var handler string
+	if h := c.Handler(); h != nil {
+		handler = runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
 	}
+	s.errTracker.Capture(errtrack.Event{
+		Err:       err,
+		Status:    status,
+		Method:    c.Request().Method,
+		Route:     c.Path(),
+		Path:      c.Request().URL.Path,
+		RequestID: response.CorrelationID(c),
+		Handler:   handler,
+	})
 }
 
 func (s *Server) Start() error {
 	// 1. Init Infrastructure
 	s.logger.Info("Initializing Infrastructure...")
 
+	// Tracing (first, so the infrastructure clients below pick up the provider)
+	stop, err := tracing.Init(context.Background(), s.config.Tracing, s.config.App.Name)
+	if err != nil {
+		s.logger.Error("Failed to initialize tracing", err)
+	} else {
+		s.stopTracing = stop
+		if s.config.Tracing.Enabled {
+			s.logger.Info("Tracing enabled", "endpoint", s.config.Tracing.Endpoint, "sample_ratio", s.config.Tracing.SampleRatio)
+		}
+	}
+
 	// Redis
 	if s.config.Redis.Enabled {
 		rdb, err := infrastructure.NewRedisClient(s.config.Redis)
@@ -112,6 +198,63 @@ func (s *Server) Start() error {
 		}
 	}
 
+	// Audit Trail
+	if s.config.Audit.Enabled {
+		auditor, err := s.initAudit()
+		if err != nil {
+			s.logger.Error("Failed to initialize audit trail", err)
+		} else {
+			s.auditor = auditor
+			s.logger.Info("Audit trail initialized", "store", s.config.Audit.Store)
+		}
+	}
+
+	// API Keys
+	if s.config.Auth.APIKeys.Enabled {
+		apiKeys, err := s.initAPIKeys()
+		if err != nil {
+			s.logger.Error("Failed to initialize API keys; protected paths will answer 503", err)
+		} else {
+			s.apiKeys = apiKeys
+			s.logger.Info("API keys initialized", "store", s.config.Auth.APIKeys.Store)
+		}
+	}
+
+	// IP Allow/Deny Lists
+	if s.config.IPFilter.Enabled {
+		ipFilter, err := s.initIPFilter()
+		if err != nil {
+			s.logger.Error("Failed to initialize IP filter", err)
+		} else {
+			s.ipFilter = ipFilter
+			s.logger.Info("IP filter initialized", "rules", len(ipFilter.Rules()))
+		}
+	}
+
+	// Scanner Detection
+	if s.config.ProbeGuard.Enabled {
+		store := probeguard.NewStore(s.config.ProbeGuard, s.redisManager)
+		guard, err := probeguard.New(s.config.ProbeGuard, store, s.auditor, s.logger)
+		if err != nil {
+			s.logger.Error("Failed to initialize probe guard", err)
+		} else {
+			s.probeGuard = guard
+			s.logger.Info("Probe guard initialized", "store", s.config.ProbeGuard.Store)
+		}
+	}
+
+	// Request Capture
+	if s.config.Capture.Enabled {
+		s.captures = capture.NewStore(s.config.Capture.MaxEntries)
+		if cc := s.config.Capture; cc.Header != "" {
+			if cc.Secret == "" {
+				s.logger.Warn("No capture.secret set, capture tokens are valid until restart")
+			}
+			s.captureSigner = logger.NewDebugSigner(cc.Secret, cc.MaxTTL)
+		}
+		s.logger.Info("Request capture enabled", "sample_rate", s.config.Capture.SampleRate)
+	}
+
 	// Cron Jobs
 	if s.config.Cron.Enabled {
 		s.cronManager = infrastructure.NewCronManager()
@@ -134,11 +277,93 @@ func (s *Server) Start() error {
 		s.cronManager.Start()
 	}
 
+	// Synthetic checks (on the cron manager, created for them when cron jobs are disabled)
+	if len(s.config.Monitoring.Synthetics.Checks) > 0 {
+		if err := s.initSynthetics(); err != nil {
+			s.logger.Error("Failed to initialize synthetic checks", err)
+		} else {
+			s.logger.Info("Synthetic checks scheduled", "checks", len(s.config.Monitoring.Synthetics.Checks))
+		}
+	}
+
+	// Prometheus Metrics
+	if s.config.Metrics.Enabled {
+		if err := s.initMetrics(); err != nil {
+			s.logger.Error("Failed to initialize metrics", err)
+		} else {
+			s.logger.Info("Metrics enabled", "path", s.config.Metrics.Path, "server", s.config.Metrics.Server)
+		}
+	}
+
+	// Metric History (after metrics, so HTTP request rates can be sampled)
+	if s.config.Monitoring.Enabled && s.config.Monitoring.History.Enabled {
+		s.history = s.initHistory()
+		s.logger.Info("Metric history enabled", "interval", s.config.Monitoring.History.Interval.String())
+	}
+
+	// External service probes (before alerting, whose external:<name> checks read them)
+	if len(s.config.Monitoring.External.Services) > 0 {
+		s.prober = s.initProber()
+		s.prober.Start(context.Background())
+		s.logger.Info("External service probes started", "services", len(s.config.Monitoring.External.Services))
+	}
+
+	// Alerting (after the history, which metric rules read)
+	if s.config.Alerting.Enabled {
+		if alerts, err := s.initAlerting(); err != nil {
+			s.logger.Error("Failed to initialize alerting", err)
+		} else {
+			s.alerts = alerts
+			s.alerts.Start(context.Background())
+			s.logger.Info("Alerting enabled", "rules", len(s.config.Alerting.Rules), "interval", s.config.Alerting.EvaluationInterval.String())
+		}
+	}
+
+	// Profiling (dashboard captures and the CPU threshold scheduler)
+	if s.config.Monitoring.Enabled && s.config.Monitoring.Profiling.Enabled {
+		s.profiler = profiling.New(s.config.Monitoring.Profiling, s.logger.Named("profiling"))
+		s.profiler.StartAuto(context.Background())
+		s.logger.Info("Profiling enabled", "pprof", s.config.Monitoring.Profiling.Pprof, "auto", s.config.Monitoring.Profiling.Auto.Enabled)
+	}
+
+	// Error tracking (fed by the HTTP error handler)
+	if s.config.Monitoring.Enabled && s.config.Monitoring.Errors.Enabled {
+		s.errTracker = s.initErrors()
+		s.errTracker.Start(context.Background())
+		s.logger.Info("Error tracking enabled", "min_status", s.errTracker.MinStatus())
+	}
+
+	// Per-request debug logging tokens
+	if dc := s.config.Log.DebugOverride; dc.Enabled {
+		if dc.Secret == "" {
+			s.logger.Warn("No log.debug_override.secret set, debug tokens are valid until restart")
+		}
+		s.debugSigner = logger.NewDebugSigner(dc.Secret, dc.MaxTTL)
+	}
+
 	// 2. Init Middleware
 	s.logger.Info("Initializing Middleware...")
 	middleware.InitMiddlewares(s.echo, middleware.Config{
-		AuthType: s.config.Auth.Type,
-		Logger:   s.logger,
+		AuthType:      s.config.Auth.Type,
+		Logger:        s.logger,
+		Redis:         s.redisManager,
+		Idempotency:   s.config.Idempotency,
+		HTTPCache:     s.config.HTTPCache,
+		Compression:   s.config.Compression,
+		AccessLog:     s.config.AccessLog,
+		Audit:         s.config.Audit,
+		Auditor:       s.auditor,
+		IPFilter:      s.ipFilter,
+		ProbeGuard:    s.probeGuard,
+		Capture:       s.config.Capture,
+		Captures:      s.captures,
+		APIKeys:       s.config.Auth.APIKeys,
+		KeyManager:    s.apiKeys,
+		Metrics:       s.httpMetrics,
+		Tracing:       s.config.Tracing.Enabled && s.stopTracing != nil,
+		DebugHeader:   s.config.Log.DebugOverride.Header,
+		DebugSigner:   s.debugSigner,
+		CaptureSigner: s.captureSigner,
 	})
 
 	// 3. Init Services
@@ -150,6 +375,11 @@ func (s *Server) Start() error {
 		return response.Success(c, map[string]string{"status": "ok"})
 	})
 
+	// Prometheus scrape endpoint, unless it is served on the monitoring port
+	if s.httpMetrics != nil && s.config.Metrics.Server != "monitoring" {
+		s.echo.GET(s.config.Metrics.Path, middleware.MetricsHandler(s.config.Metrics.Token))
+	}
+
 	// Restart Endpoint (Maintenance)
 	s.echo.POST("/restart", func(c echo.Context) error {
 		go func() {
@@ -163,7 +393,7 @@ func (s *Server) Start() error {
 	registry.Register(modules.NewServiceA(s.config.Services.IsEnabled("service_a")))
 	registry.Register(modules.NewServiceB(s.config.Services.IsEnabled("service_b")))
 	registry.Register(modules.NewServiceC(s.config.Services.IsEnabled("service_c")))
-	registry.Register(modules.NewServiceD(s.postgresManager, s.config.Services.IsEnabled("service_d")))
+	registry.Register(modules.NewServiceD(s.postgresManager, s.config.Services.IsEnabled("service_d"), s.logger.Named("service_d")))
 
 	registry.Boot(s.echo)
 
@@ -185,7 +415,22 @@ func (s *Server) Start() error {
 				Endpoints:  fullEndpoints,
 			})
 		}
-		go monitoring.Start(s.config.Monitoring, s.config, s, s.broadcaster, s.redisManager, s.postgresManager, s.kafkaManager, s.cronManager, servicesList)
+		go monitoring.Start(s.config.Monitoring, s.config, s, s.broadcaster, s.redisManager, s.postgresManager, s.kafkaManager, s.cronManager, servicesList, monitoring.Options{
+			Auditor:       s.auditor,
+			IPFilter:      s.ipFilter,
+			ProbeGuard:    s.probeGuard,
+			Captures:      s.captures,
+			APIKeys:       s.apiKeys,
+			History:       s.history,
+			Alerts:        s.alerts,
+			Logger:        s.logger,
+			Debug:         s.debugSigner,
+			CaptureTokens: s.captureSigner,
+			Profiler:      s.profiler,
+			Prober:        s.prober,
+			Synthetics:    s.synthetics,
+			Errors:        s.errTracker,
+		})
 		s.logger.Info("Monitoring interface started", "port", s.config.Monitoring.Port)
 	}
 
@@ -198,6 +443,273 @@ func (s *Server) Start() error {
 	return s.echo.Start(":" + port)
 }
 
+// Shutdown flushes buffered telemetry and logs. The HTTP listeners stop with the process.
+func (s *Server) Shutdown(ctx context.Context) error {
+	var err error
+	if s.stopTracing != nil {
+		err = s.stopTracing(ctx)
+	}
+	if s.broadcaster != nil && s.broadcaster.Store() != nil {
+		err = errors.Join(err, s.broadcaster.Store().Close())
+	}
+	return err
+}
+
+// initAudit opens the audit store: Postgres when configured and connected, otherwise the monitoring SQLite.
+func (s *Server) initAudit() (*audit.Recorder, error) {
+	var store audit.Store
+	var err error
+
+	if s.config.Audit.Store == "postgres" && s.postgresManager != nil {
+		store, err = audit.NewSQLStore(s.postgresManager.DB, audit.DialectPostgres)
+	} else {
+		if err = database.InitDB(); err != nil {
+			return nil, err
+		}
+		store, err = audit.NewSQLStore(database.GetDB(), audit.DialectSQLite)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	retention := time.Duration(s.config.Audit.RetentionDays) * 24 * time.Hour
+	return audit.NewRecorder(store, retention, s.logger), nil
+}
+
+// initAPIKeys opens the configured API key store. A store that is unknown or not
+// connected is an error rather than a silent fallback, so keys are never split across databases.
+func (s *Server) initAPIKeys() (*apikey.Manager, error) {
+	cfg := s.config.Auth.APIKeys
+
+	var store apikey.Store
+	var err error
+	switch cfg.Store {
+	case "postgres":
+		if s.postgresManager == nil {
+			return nil, fmt.Errorf("api keys: store is postgres but Postgres is not connected")
+		}
+		store, err = apikey.NewSQLStore(s.postgresManager.DB, apikey.DialectPostgres)
+	case "sqlite", "":
+		if err = database.InitDB(); err != nil {
+			return nil, err
+		}
+		store, err = apikey.NewSQLStore(database.GetDB(), apikey.DialectSQLite)
+	default:
+		return nil, fmt.Errorf("api keys: unknown store %q", cfg.Store)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return apikey.NewManager(store, cfg.Prefix, cfg.CacheTTL, s.logger), nil
+}
+
+// initMetrics registers request metrics and collectors for the connected infrastructure.
+func (s *Server) initMetrics() error {
+	httpMetrics, err := metrics.NewHTTP(s.config.Metrics.Buckets)
+	if err != nil {
+		return err
+	}
+	s.httpMetrics = httpMetrics
+
+	if s.redisManager != nil {
+		if err := metrics.RegisterRedis(s.redisManager); err != nil {
+			return err
+		}
+	}
+	if s.postgresManager != nil {
+		if err := metrics.RegisterPostgres(s.postgresManager); err != nil {
+			return err
+		}
+	}
+	if s.kafkaManager != nil {
+		if err := metrics.RegisterKafka(s.kafkaManager); err != nil {
+			return err
+		}
+	}
+	if s.cronManager != nil {
+		if err := metrics.RegisterCron(s.cronManager); err != nil {
+			return err
+		}
+	}
+	if s.broadcaster != nil {
+		metrics.MustRegister(
+			prometheus.NewCounterFunc(prometheus.CounterOpts{
+				Namespace: metrics.Namespace, Subsystem: "log_stream", Name: "dropped_messages_total",
+				Help: "Log lines dropped because a dashboard client fell behind.",
+			}, func() float64 { return float64(s.broadcaster.Dropped()) }),
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Namespace: metrics.Namespace, Subsystem: "log_stream", Name: "clients",
+				Help: "Connected dashboard log stream clients.",
+			}, func() float64 { return float64(s.broadcaster.ClientCount()) }),
+		)
+	}
+	return nil
+}
+
+// initHistory samples the metrics of every enabled subsystem. Without the
+// monitoring database the history is kept in memory only.
+func (s *Server) initHistory() *metricstore.History {
+	var store *metricstore.SQLStore
+	err := database.InitDB()
+	if err == nil {
+		store, err = metricstore.NewSQLStore(database.GetDB())
+	}
+	if err != nil {
+		s.logger.Error("Metric history will not be persisted", err)
+	}
+
+	sources := []metricstore.Source{
+		metricstore.SystemSource(sysstats.Default()),
+		metricstore.ProcessSource(sysstats.Default()),
+		metricstore.RuntimeSource(),
+	}
+	if s.httpMetrics != nil {
+		sources = append(sources, metricstore.HTTPSource(s.httpMetrics))
+	}
+	if s.redisManager != nil {
+		sources = append(sources, metricstore.RedisSource(s.redisManager))
+	}
+	if s.postgresManager != nil {
+		sources = append(sources, metricstore.PostgresSource(s.postgresManager))
+	}
+	if s.kafkaManager != nil {
+		sources = append(sources, metricstore.KafkaSource(s.kafkaManager))
+	}
+	if s.broadcaster != nil {
+		sources = append(sources, func() map[string]float64 {
+			return map[string]float64{"logs.stream.clients": float64(s.broadcaster.ClientCount())}
+		})
+	}
+
+	return metricstore.New(store, s.config.Monitoring.History, s.logger, sources...)
+}
+
+// initProber builds the external service prober; hourly uptime and state changes
+// persist in the monitoring SQLite when it is available.
+func (s *Server) initProber() *prober.Prober {
+	var store prober.Store
+	if err := database.InitDB(); err != nil {
+		s.logger.Warn("External service uptime will not persist", "error", err.Error())
+	} else if sqlStore, err := prober.NewSQLStore(database.GetDB()); err != nil {
+		s.logger.Warn("External service uptime will not persist", "error", err.Error())
+	} else {
+		store = sqlStore
+	}
+
+	return prober.New(s.config.Monitoring.External, store, s.logger.Named("prober"))
+}
+
+// initSynthetics schedules the synthetic checks on the cron manager.
+func (s *Server) initSynthetics() error {
+	synthetics, err := prober.NewSynthetics(s.config.Monitoring.Synthetics, s.logger.Named("synthetic"))
+	if err != nil {
+		return err
+	}
+	if s.cronManager == nil {
+		s.cronManager = infrastructure.NewCronManager()
+		s.cronManager.Start()
+	}
+	if err := synthetics.Schedule(s.cronManager); err != nil {
+		return err
+	}
+	s.synthetics = synthetics
+	return nil
+}
+
+// initErrors creates the error tracker, keeping its groups in the monitoring SQLite.
+func (s *Server) initErrors() *errtrack.Tracker {
+	var store errtrack.Store
+	if err := database.InitDB(); err != nil {
+		s.logger.Warn("Error groups will not persist", "error", err.Error())
+	} else if sqlStore, err := errtrack.NewSQLStore(database.GetDB()); err != nil {
+		s.logger.Warn("Error groups will not persist", "error", err.Error())
+	} else {
+		store = sqlStore
+	}
+	return errtrack.New(s.config.Monitoring.Errors, store, s.logger.Named("errors"))
+}
+
+// initAlerting builds the alert engine with a health check for each enabled dependency,
+// external service and synthetic check. Silences persist in the monitoring SQLite when it is available.
+func (s *Server) initAlerting() (*alerting.Engine, error) {
+	checks := make(map[string]alerting.HealthCheck)
+	if s.config.Postgres.Enabled {
+		checks["postgres"] = func(ctx context.Context) (bool, string) {
+			if s.postgresManager == nil || s.postgresManager.DB == nil {
+				return false, "not connected"
+			}
+			if err := s.postgresManager.DB.PingContext(ctx); err != nil {
+				return false, err.Error()
+			}
+			return true, ""
+		}
+	}
+	if s.config.Redis.Enabled {
+		checks["redis"] = func(ctx context.Context) (bool, string) {
+			if s.redisManager == nil || s.redisManager.Client == nil {
+				return false, "not connected"
+			}
+			if err := s.redisManager.Client.Ping(ctx).Err(); err != nil {
+				return false, err.Error()
+			}
+			return true, ""
+		}
+	}
+	if s.config.Kafka.Enabled {
+		checks["kafka"] = func(ctx context.Context) (bool, string) {
+			if connected, _ := s.kafkaManager.GetStatus()["connected"].(bool); !connected {
+				return false, "not connected"
+			}
+			return true, ""
+		}
+	}
+	if s.prober != nil {
+		for _, svc := range s.config.Monitoring.External.Services {
+			checks["external:"+svc.Name] = func(context.Context) (bool, string) {
+				return s.prober.Healthy(svc.Name)
+			}
+		}
+	}
+	if s.synthetics != nil {
+		for _, sc := range s.config.Monitoring.Synthetics.Checks {
+			checks["synthetic:"+sc.Name] = func(context.Context) (bool, string) {
+				return s.synthetics.Healthy(sc.Name)
+			}
+		}
+	}
+
+	var metrics alerting.MetricReader
+	if s.history != nil {
+		metrics = s.history
+	}
+
+	var store alerting.Store
+	if err := database.InitDB(); err != nil {
+		s.logger.Warn("Alert silences will not persist", "error", err.Error())
+	} else if sqlStore, err := alerting.NewSQLStore(database.GetDB()); err != nil {
+		s.logger.Warn("Alert silences will not persist", "error", err.Error())
+	} else {
+		store = sqlStore
+	}
+
+	return alerting.New(s.config.Alerting, s.config.App.Name, metrics, checks, store, s.logger)
+}
+
+// initIPFilter builds the IP filter; runtime rules persist in the monitoring SQLite when it is available.
+func (s *Server) initIPFilter() (*ipfilter.Filter, error) {
+	var store ipfilter.Store
+	if err := database.InitDB(); err != nil {
+		s.logger.Warn("IP filter rules added at runtime will not persist", "error", err.Error())
+	} else if sqlStore, err := ipfilter.NewSQLStore(database.GetDB()); err != nil {
+		s.logger.Warn("IP filter rules added at runtime will not persist", "error", err.Error())
+	} else {
+		store = sqlStore
+	}
+
+	return ipfilter.New(s.config.IPFilter, store, s.logger)
+}
+
 // GetStatus satisfies monitoring.StatusProvider
 func (s *Server) GetStatus() map[string]interface{} {
 	diskStats, _ := utils.GetDiskUsage()
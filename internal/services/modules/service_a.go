@@ -1,25 +1,41 @@
 package modules
 
 import (
+	"test-go/pkg/metrics"
 	"test-go/pkg/request"
 	"test-go/pkg/response"
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 type ServiceA struct {
-	enabled bool
+	enabled      bool
+	usersCreated prometheus.Counter
 }
 
 func NewServiceA(enabled bool) *ServiceA {
-	return &ServiceA{enabled: enabled}
+	return &ServiceA{
+		enabled: enabled,
+		usersCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "users",
+			Name:      "created_total",
+			Help:      "Users created through the API.",
+		}),
+	}
 }
 
 func (s *ServiceA) Name() string        { return "Service A (Users)" }
 func (s *ServiceA) Enabled() bool       { return s.enabled }
 func (s *ServiceA) Endpoints() []string { return []string{"/users", "/users/:id"} }
 
+// Collectors exports the service's own metrics (see services.MetricsProvider)
+func (s *ServiceA) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{s.usersCreated}
+}
+
 func (s *ServiceA) RegisterRoutes(g *echo.Group) {
 	sub := g.Group("/users")
 
@@ -129,6 +145,7 @@ func (s *ServiceA) CreateUser(c echo.Context) error {
 		CreatedAt: time.Now().Unix(),
 	}
 
+	s.usersCreated.Inc()
 	return response.Created(c, user, "User created successfully")
 }
 
@@ -1,9 +1,9 @@
 package modules
 
 import (
-	"fmt"
 	"strconv"
 	"test-go/pkg/infrastructure"
+	"test-go/pkg/logger"
 	"test-go/pkg/response"
 
 	"github.com/labstack/echo/v4"
@@ -20,18 +20,20 @@ type Task struct {
 type ServiceD struct {
 	db      *infrastructure.PostgresManager
 	enabled bool
+	logger  *logger.Logger
 }
 
-func NewServiceD(db *infrastructure.PostgresManager, enabled bool) *ServiceD {
+func NewServiceD(db *infrastructure.PostgresManager, enabled bool, l *logger.Logger) *ServiceD {
 	if enabled && db != nil && db.ORM != nil {
 		// Auto-migrate the schema
 		if err := db.ORM.AutoMigrate(&Task{}); err != nil {
-			fmt.Printf("Error migrating Task model: %v\n", err)
+			l.Error("Failed to migrate Task model", err)
 		}
 	}
 	return &ServiceD{
 		db:      db,
 		enabled: enabled,
+		logger:  l,
 	}
 }
 
@@ -70,6 +72,7 @@ func (s *ServiceD) createTask(c echo.Context) error {
 	if result := s.db.ORM.Create(task); result.Error != nil {
 		return response.InternalServerError(c, result.Error.Error())
 	}
+	s.logger.WithContext(c.Request().Context()).Debug("Task created", "id", task.ID)
 
 	return response.Created(c, task)
 }
@@ -86,6 +89,7 @@ func (s *ServiceD) updateTask(c echo.Context) error {
 	}
 
 	s.db.ORM.Save(&task)
+	s.logger.WithContext(c.Request().Context()).Debug("Task updated", "id", task.ID, "completed", task.Completed)
 	return response.Success(c, task)
 }
 
@@ -94,5 +98,6 @@ func (s *ServiceD) deleteTask(c echo.Context) error {
 	if result := s.db.ORM.Delete(&Task{}, id); result.Error != nil {
 		return response.InternalServerError(c, result.Error.Error())
 	}
+	s.logger.WithContext(c.Request().Context()).Debug("Task deleted", "id", id)
 	return response.Success(c, nil, "Task deleted")
 }
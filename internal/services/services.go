@@ -2,8 +2,10 @@ package services
 
 import (
 	"test-go/pkg/logger"
+	"test-go/pkg/metrics"
 
 	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // Service defines a module that can register routes
@@ -14,6 +16,12 @@ type Service interface {
 	Endpoints() []string
 }
 
+// MetricsProvider is implemented by services that export their own Prometheus metrics.
+// The collectors are registered when the service boots.
+type MetricsProvider interface {
+	Collectors() []prometheus.Collector
+}
+
 // Registry holds available services
 type Registry struct {
 	services []Service
@@ -46,6 +54,13 @@ func (r *Registry) Boot(e *echo.Echo) {
 		if s.Enabled() {
 			r.logger.Info("Starting Service...", "service", s.Name())
 			s.RegisterRoutes(api)
+			if mp, ok := s.(MetricsProvider); ok {
+				for _, c := range mp.Collectors() {
+					if err := metrics.Register(c); err != nil {
+						r.logger.Error("Failed to register service metrics", err, "service", s.Name())
+					}
+				}
+			}
 			r.logger.Info("Service Started", "service", s.Name())
 		} else {
 			r.logger.Warn("Service Skipped (Disabled via config)", "service", s.Name())
@@ -0,0 +1,211 @@
+package statuspage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Incident states, in the order they usually progress.
+const (
+	IncidentInvestigating = "investigating"
+	IncidentIdentified    = "identified"
+	IncidentMonitoring    = "monitoring"
+	IncidentResolved      = "resolved"
+)
+
+// Incident impacts.
+const (
+	ImpactMinor    = "minor"
+	ImpactMajor    = "major"
+	ImpactCritical = "critical"
+)
+
+var (
+	ErrIncidentNotFound = errors.New("no incident with that id")
+	ErrIncidentResolved = errors.New("incident is already resolved")
+)
+
+// Update is a note posted on an incident.
+type Update struct {
+	Time    time.Time `json:"time"`
+	Status  string    `json:"status"`
+	Message string    `json:"message"`
+	Author  string    `json:"author,omitempty"` // dashboard only
+}
+
+// Incident is an admin-written notice shown on the status page.
+type Incident struct {
+	ID         string    `json:"id"`
+	Title      string    `json:"title"`
+	Impact     string    `json:"impact"`
+	Status     string    `json:"status"`
+	Components []string  `json:"components"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	ResolvedAt time.Time `json:"resolved_at,omitzero"`
+	Updates    []Update  `json:"updates"` // newest first
+}
+
+// public returns a copy without the authors.
+func (inc Incident) public() Incident {
+	updates := make([]Update, len(inc.Updates))
+	for i, u := range inc.Updates {
+		u.Author = ""
+		updates[i] = u
+	}
+	inc.Updates = updates
+	inc.Components = slices.Clone(inc.Components)
+	return inc
+}
+
+// Incidents returns every stored incident, newest first, with authors.
+func (p *Page) Incidents() []Incident {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	out := make([]Incident, len(p.incidents))
+	copy(out, p.incidents)
+	return out
+}
+
+// CreateIncident opens an incident with its first update.
+func (p *Page) CreateIncident(ctx context.Context, title, impact, status, message string, components []string, author string) (Incident, error) {
+	if title == "" || message == "" {
+		return Incident{}, fmt.Errorf("title and message are required")
+	}
+	if impact == "" {
+		impact = ImpactMinor
+	}
+	if !slices.Contains([]string{ImpactMinor, ImpactMajor, ImpactCritical}, impact) {
+		return Incident{}, fmt.Errorf("invalid impact %q", impact)
+	}
+	if status == "" {
+		status = IncidentInvestigating
+	}
+	if err := validStatus(status); err != nil {
+		return Incident{}, err
+	}
+	if components == nil {
+		components = []string{}
+	}
+	for _, name := range components {
+		if !p.hasComponent(name) {
+			return Incident{}, fmt.Errorf("unknown component %q", name)
+		}
+	}
+
+	now := time.Now().UTC()
+	inc := Incident{
+		ID:         newID(),
+		Title:      title,
+		Impact:     impact,
+		Status:     status,
+		Components: components,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Updates:    []Update{{Time: now, Status: status, Message: message, Author: author}},
+	}
+	if status == IncidentResolved {
+		inc.ResolvedAt = now
+	}
+	if err := p.save(ctx, inc); err != nil {
+		return Incident{}, err
+	}
+
+	p.mu.Lock()
+	p.incidents = append([]Incident{inc}, p.incidents...)
+	p.mu.Unlock()
+	return inc, nil
+}
+
+// AddUpdate posts a note on an open incident and moves it to status. Resolving an
+// incident closes it.
+func (p *Page) AddUpdate(ctx context.Context, id, status, message, author string) (Incident, error) {
+	if message == "" {
+		return Incident{}, fmt.Errorf("message is required")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := slices.IndexFunc(p.incidents, func(inc Incident) bool { return inc.ID == id })
+	if i < 0 {
+		return Incident{}, ErrIncidentNotFound
+	}
+	inc := p.incidents[i]
+	if !inc.ResolvedAt.IsZero() {
+		return Incident{}, ErrIncidentResolved
+	}
+	if status == "" {
+		status = inc.Status
+	}
+	if err := validStatus(status); err != nil {
+		return Incident{}, err
+	}
+
+	now := time.Now().UTC()
+	inc.Status = status
+	inc.UpdatedAt = now
+	if status == IncidentResolved {
+		inc.ResolvedAt = now
+	}
+	inc.Updates = append([]Update{{Time: now, Status: status, Message: message, Author: author}}, inc.Updates...)
+	if err := p.save(ctx, inc); err != nil {
+		return Incident{}, err
+	}
+	p.incidents[i] = inc
+	return inc, nil
+}
+
+// DeleteIncident removes an incident, for notes posted by mistake.
+func (p *Page) DeleteIncident(ctx context.Context, id string) (Incident, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := slices.IndexFunc(p.incidents, func(inc Incident) bool { return inc.ID == id })
+	if i < 0 {
+		return Incident{}, ErrIncidentNotFound
+	}
+	inc := p.incidents[i]
+	if p.store != nil {
+		if err := p.store.DeleteIncident(ctx, id); err != nil {
+			return Incident{}, err
+		}
+	}
+	p.incidents = slices.Delete(p.incidents, i, i+1)
+	return inc, nil
+}
+
+func (p *Page) save(ctx context.Context, inc Incident) error {
+	if p.store == nil {
+		return nil
+	}
+	return p.store.SaveIncident(ctx, inc)
+}
+
+func (p *Page) hasComponent(name string) bool {
+	for _, c := range p.components {
+		if c.cfg.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func validStatus(status string) error {
+	if !slices.Contains([]string{IncidentInvestigating, IncidentIdentified, IncidentMonitoring, IncidentResolved}, status) {
+		return fmt.Errorf("invalid status %q", status)
+	}
+	return nil
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
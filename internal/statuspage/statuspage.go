@@ -0,0 +1,287 @@
+// Package statuspage backs the public status page. It samples the state of each
+// configured component on an interval, keeps 90 days of daily uptime and stores the
+// incident notes admins post. The public view only carries component display names,
+// states and incident text, never hostnames, addresses or configuration.
+package statuspage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"test-go/config"
+	"test-go/pkg/logger"
+)
+
+// Component states, best first.
+const (
+	StateOperational = "operational"
+	StateDegraded    = "degraded"
+	StateOutage      = "outage"
+	StateUnknown     = "unknown" // not sampled yet or source unavailable
+)
+
+const (
+	historyDays   = 90
+	resolvedShown = 14 * 24 * time.Hour // resolved incidents stay on the page this long
+	dayLayout     = "2006-01-02"
+)
+
+// Source returns the current state of a component.
+type Source func() string
+
+// Day is the uptime of a component on one UTC day, -1 without samples.
+type Day struct {
+	Date   string  `json:"date"`
+	Uptime float64 `json:"uptime"`
+}
+
+// ComponentView is a component as shown on the page.
+type ComponentView struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Status      string  `json:"status"`
+	Uptime      float64 `json:"uptime"` // over the last 90 days, -1 without samples
+	Days        []Day   `json:"days"`   // oldest first, today last
+}
+
+// View is the public status page.
+type View struct {
+	Title      string          `json:"title"`
+	Status     string          `json:"status"` // the worst component state
+	UpdatedAt  time.Time       `json:"updated_at,omitzero"`
+	Components []ComponentView `json:"components"`
+	Incidents  []Incident      `json:"incidents"` // open ones and those resolved in the last 14 days, newest first
+}
+
+// DayCount holds the samples of one component on one UTC day.
+type DayCount struct {
+	Day     string
+	Samples int
+	Up      int // operational or degraded
+}
+
+// Store persists daily uptime and incidents across restarts.
+type Store interface {
+	LoadDays(ctx context.Context, since string) (map[string][]DayCount, error)
+	SaveDay(ctx context.Context, component string, d DayCount) error
+	PruneDays(ctx context.Context, before string) error
+	LoadIncidents(ctx context.Context) ([]Incident, error)
+	SaveIncident(ctx context.Context, inc Incident) error
+	DeleteIncident(ctx context.Context, id string) error
+}
+
+type component struct {
+	cfg    config.StatusComponent
+	source Source
+	state  string
+	days   []DayCount // oldest first
+}
+
+// Page samples the components and serves the public view.
+type Page struct {
+	title      string
+	interval   time.Duration
+	components []*component
+	store      Store
+	logger     *logger.Logger
+
+	mu        sync.RWMutex
+	updatedAt time.Time
+	incidents []Incident // newest first
+}
+
+// New checks that every component has a known source. store may be nil to keep
+// uptime and incidents in memory only. Call Start to begin sampling.
+func New(cfg config.StatusPageConfig, sources map[string]Source, store Store, l *logger.Logger) (*Page, error) {
+	p := &Page{title: cfg.Title, interval: cfg.Interval, store: store, logger: l}
+	if p.interval <= 0 {
+		p.interval = time.Minute
+	}
+
+	seen := make(map[string]bool)
+	for _, c := range cfg.Components {
+		if c.Name == "" {
+			return nil, fmt.Errorf("status page: a component has no name")
+		}
+		if seen[c.Name] {
+			return nil, fmt.Errorf("status page: duplicate component %q", c.Name)
+		}
+		seen[c.Name] = true
+		src, ok := sources[c.Source]
+		if !ok {
+			return nil, fmt.Errorf("status page: component %q: unknown source %q", c.Name, c.Source)
+		}
+		p.components = append(p.components, &component{cfg: c, source: src, state: StateUnknown})
+	}
+	return p, nil
+}
+
+// Start loads the stored uptime and incidents and samples the components every
+// interval until ctx is done, the first time right away.
+func (p *Page) Start(ctx context.Context) {
+	p.load(ctx)
+
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			p.sample(time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (p *Page) load(ctx context.Context) {
+	if p.store == nil {
+		return
+	}
+	since := time.Now().UTC().AddDate(0, 0, -historyDays).Format(dayLayout)
+	days, err := p.store.LoadDays(ctx, since)
+	if err != nil {
+		p.logger.Warn("Failed to load status page uptime", "error", err.Error())
+	}
+	incidents, err := p.store.LoadIncidents(ctx)
+	if err != nil {
+		p.logger.Warn("Failed to load status page incidents", "error", err.Error())
+	}
+	if err := p.store.PruneDays(ctx, since); err != nil {
+		p.logger.Warn("Failed to prune status page uptime", "error", err.Error())
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, c := range p.components {
+		c.days = days[c.cfg.Name]
+	}
+	p.incidents = incidents
+}
+
+// sample reads every source and counts the result in today's uptime. Sources run
+// outside the lock as they may do I/O.
+func (p *Page) sample(now time.Time) {
+	states := make([]string, len(p.components))
+	for i, c := range p.components {
+		states[i] = c.source()
+	}
+	day := now.UTC().Format(dayLayout)
+	cutoff := now.UTC().AddDate(0, 0, -historyDays).Format(dayLayout)
+
+	p.mu.Lock()
+	saved := make(map[string]DayCount)
+	for i, c := range p.components {
+		c.state = states[i]
+		if c.state == StateUnknown {
+			continue
+		}
+		n := len(c.days)
+		if n == 0 || c.days[n-1].Day != day {
+			c.days = append(c.days, DayCount{Day: day})
+			n++
+		}
+		d := &c.days[n-1]
+		d.Samples++
+		if c.state != StateOutage {
+			d.Up++
+		}
+		for len(c.days) > 0 && c.days[0].Day < cutoff {
+			c.days = c.days[1:]
+		}
+		saved[c.cfg.Name] = *d
+	}
+	p.updatedAt = now
+	p.mu.Unlock()
+
+	if p.store == nil {
+		return
+	}
+	for name, d := range saved {
+		if err := p.store.SaveDay(context.Background(), name, d); err != nil {
+			p.logger.Warn("Failed to save status page uptime", "component", name, "error", err.Error())
+		}
+	}
+}
+
+// Public returns the page as shown to visitors.
+func (p *Page) Public() View {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	now := time.Now().UTC()
+	v := View{
+		Title:      p.title,
+		Status:     StateOperational,
+		UpdatedAt:  p.updatedAt,
+		Components: make([]ComponentView, 0, len(p.components)),
+		Incidents:  []Incident{},
+	}
+	for _, c := range p.components {
+		cv := c.view(now)
+		v.Status = worse(v.Status, cv.Status)
+		v.Components = append(v.Components, cv)
+	}
+	for _, inc := range p.incidents {
+		if inc.ResolvedAt.IsZero() || now.Sub(inc.ResolvedAt) < resolvedShown {
+			v.Incidents = append(v.Incidents, inc.public())
+		}
+	}
+	return v
+}
+
+// Components returns the configured components with their sources and states, for
+// the dashboard.
+func (p *Page) Components() []map[string]string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	out := make([]map[string]string, 0, len(p.components))
+	for _, c := range p.components {
+		out = append(out, map[string]string{
+			"name":   c.cfg.Name,
+			"source": c.cfg.Source,
+			"status": c.state,
+		})
+	}
+	return out
+}
+
+// view builds the public row of c with one Day per calendar day. The caller holds p.mu.
+func (c *component) view(now time.Time) ComponentView {
+	byDay := make(map[string]DayCount, len(c.days))
+	for _, d := range c.days {
+		byDay[d.Day] = d
+	}
+
+	cv := ComponentView{Name: c.cfg.Name, Description: c.cfg.Description, Status: c.state, Uptime: -1}
+	var samples, up int
+	for i := historyDays - 1; i >= 0; i-- {
+		day := now.AddDate(0, 0, -i).Format(dayLayout)
+		d, ok := byDay[day]
+		if !ok || d.Samples == 0 {
+			cv.Days = append(cv.Days, Day{Date: day, Uptime: -1})
+			continue
+		}
+		cv.Days = append(cv.Days, Day{Date: day, Uptime: float64(d.Up) * 100 / float64(d.Samples)})
+		samples += d.Samples
+		up += d.Up
+	}
+	if samples > 0 {
+		cv.Uptime = float64(up) * 100 / float64(samples)
+	}
+	return cv
+}
+
+// worse returns the worse of two states; unknown ranks below operational so an
+// unsampled component does not mark the whole page as affected.
+func worse(a, b string) string {
+	rank := map[string]int{StateUnknown: 0, StateOperational: 1, StateDegraded: 2, StateOutage: 3}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
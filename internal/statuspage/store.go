@@ -0,0 +1,163 @@
+package statuspage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SQLStore keeps daily uptime and incidents in the monitoring SQLite database.
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore creates the status_page_days and status_incidents tables if needed.
+func NewSQLStore(db *sql.DB) (*SQLStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("status page store: database is not available")
+	}
+
+	schema := []string{
+		`CREATE TABLE IF NOT EXISTS status_page_days (
+			component TEXT NOT NULL,
+			day TEXT NOT NULL,
+			samples INTEGER NOT NULL,
+			up INTEGER NOT NULL,
+			PRIMARY KEY (component, day)
+		)`,
+		`CREATE TABLE IF NOT EXISTS status_incidents (
+			id TEXT PRIMARY KEY,
+			title TEXT NOT NULL,
+			impact TEXT NOT NULL,
+			status TEXT NOT NULL,
+			components TEXT NOT NULL,
+			created_at BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL,
+			resolved_at BIGINT,
+			updates TEXT NOT NULL
+		)`,
+	}
+	for _, stmt := range schema {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, fmt.Errorf("status page store: failed to create schema: %w", err)
+		}
+	}
+
+	return &SQLStore{db: db}, nil
+}
+
+func (s *SQLStore) LoadDays(ctx context.Context, since string) (map[string][]DayCount, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT component, day, samples, up
+		FROM status_page_days
+		WHERE day >= ?
+		ORDER BY day
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := make(map[string][]DayCount)
+	for rows.Next() {
+		var component string
+		var d DayCount
+		if err := rows.Scan(&component, &d.Day, &d.Samples, &d.Up); err != nil {
+			return nil, err
+		}
+		days[component] = append(days[component], d)
+	}
+	return days, rows.Err()
+}
+
+func (s *SQLStore) SaveDay(ctx context.Context, component string, d DayCount) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO status_page_days (component, day, samples, up)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (component, day) DO UPDATE SET
+			samples = excluded.samples, up = excluded.up
+	`, component, d.Day, d.Samples, d.Up)
+	if err != nil {
+		return fmt.Errorf("failed to save status page day: %w", err)
+	}
+	return nil
+}
+
+// PruneDays deletes daily uptime older than before.
+func (s *SQLStore) PruneDays(ctx context.Context, before string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM status_page_days WHERE day < ?`, before); err != nil {
+		return fmt.Errorf("failed to prune status page days: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) LoadIncidents(ctx context.Context) ([]Incident, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, title, impact, status, components, created_at, updated_at, COALESCE(resolved_at, 0), updates
+		FROM status_incidents
+		ORDER BY created_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var incidents []Incident
+	for rows.Next() {
+		var inc Incident
+		var components, updates string
+		var created, updated, resolved int64
+		if err := rows.Scan(&inc.ID, &inc.Title, &inc.Impact, &inc.Status, &components, &created, &updated, &resolved, &updates); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(components), &inc.Components); err != nil {
+			return nil, fmt.Errorf("incident %s: invalid components: %w", inc.ID, err)
+		}
+		if err := json.Unmarshal([]byte(updates), &inc.Updates); err != nil {
+			return nil, fmt.Errorf("incident %s: invalid updates: %w", inc.ID, err)
+		}
+		inc.CreatedAt = time.UnixMilli(created).UTC()
+		inc.UpdatedAt = time.UnixMilli(updated).UTC()
+		if resolved > 0 {
+			inc.ResolvedAt = time.UnixMilli(resolved).UTC()
+		}
+		incidents = append(incidents, inc)
+	}
+	return incidents, rows.Err()
+}
+
+func (s *SQLStore) SaveIncident(ctx context.Context, inc Incident) error {
+	components, err := json.Marshal(inc.Components)
+	if err != nil {
+		return err
+	}
+	updates, err := json.Marshal(inc.Updates)
+	if err != nil {
+		return err
+	}
+	var resolved sql.NullInt64
+	if !inc.ResolvedAt.IsZero() {
+		resolved = sql.NullInt64{Int64: inc.ResolvedAt.UnixMilli(), Valid: true}
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO status_incidents (id, title, impact, status, components, created_at, updated_at, resolved_at, updates)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			status = excluded.status, updated_at = excluded.updated_at,
+			resolved_at = excluded.resolved_at, updates = excluded.updates
+	`, inc.ID, inc.Title, inc.Impact, inc.Status, string(components), inc.CreatedAt.UnixMilli(), inc.UpdatedAt.UnixMilli(), resolved, string(updates))
+	if err != nil {
+		return fmt.Errorf("failed to save incident: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) DeleteIncident(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM status_incidents WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete incident: %w", err)
+	}
+	return nil
+}
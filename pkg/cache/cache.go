@@ -39,6 +39,31 @@ func (c *Cache[T]) Set(key string, value T, ttl time.Duration) {
 	}
 }
 
+// SetIfAbsent adds an item only if the key is missing or expired.
+// Returns true if the item was stored. The check and write happen under one lock,
+// so concurrent callers racing on the same key see exactly one winner.
+func (c *Cache[T]) SetIfAbsent(key string, value T, ttl time.Duration) bool {
+	var exp int64
+	if ttl > 0 {
+		exp = time.Now().Add(ttl).UnixNano()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if item, found := c.items[key]; found {
+		if item.Expiration == 0 || time.Now().UnixNano() <= item.Expiration {
+			return false
+		}
+	}
+
+	c.items[key] = Item[T]{
+		Value:      value,
+		Expiration: exp,
+	}
+	return true
+}
+
 // Get retrieves an item from the cache.
 // Returns the value and true if found and not expired.
 // Returns zero value and false otherwise.
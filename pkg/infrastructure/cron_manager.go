@@ -14,6 +14,13 @@ type CronJob struct {
 	LastRun  time.Time `json:"last_run"`
 	NextRun  time.Time `json:"next_run"`
 	EntryID  cron.EntryID
+
+	// Run statistics since startup
+	Runs          uint64        `json:"runs"`
+	Failures      uint64        `json:"failures"`
+	LastDuration  time.Duration `json:"last_duration"`
+	TotalDuration time.Duration `json:"total_duration"`
+	LastError     string        `json:"last_error,omitempty"`
 }
 
 type CronManager struct {
@@ -38,16 +45,38 @@ func (c *CronManager) Stop() {
 }
 
 func (c *CronManager) AddJob(name, schedule string, cmd func()) (int, error) {
+	return c.AddJobFunc(name, schedule, func() error {
+		cmd()
+		return nil
+	})
+}
+
+// AddJobFunc schedules a job whose returned error counts as a failed run.
+func (c *CronManager) AddJobFunc(name, schedule string, cmd func() error) (int, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	// Wrap cmd to update LastRun
+	job := &CronJob{
+		Name:     name,
+		Schedule: schedule,
+	}
+
+	// Wrap cmd to record run statistics; Prev/Next come from c.cron.Entry(id)
 	wrappedCmd := func() {
-		// We need to look up the entry to update it
-		// This is tricky because the function closes over variables.
-		// For simplicity, we won't update LastRun inside the job execution here
-		// because we can get Prev/Next from c.cron.Entry(id).
-		cmd()
+		start := time.Now()
+		err := cmd()
+		elapsed := time.Since(start)
+
+		c.mu.Lock()
+		job.Runs++
+		job.LastDuration = elapsed
+		job.TotalDuration += elapsed
+		job.LastError = ""
+		if err != nil {
+			job.Failures++
+			job.LastError = err.Error()
+		}
+		c.mu.Unlock()
 	}
 
 	id, err := c.cron.AddFunc(schedule, wrappedCmd)
@@ -55,12 +84,9 @@ func (c *CronManager) AddJob(name, schedule string, cmd func()) (int, error) {
 		return 0, err
 	}
 
-	c.jobs[id] = &CronJob{
-		ID:       int(id),
-		Name:     name,
-		Schedule: schedule,
-		EntryID:  id,
-	}
+	job.ID = int(id)
+	job.EntryID = id
+	c.jobs[id] = job
 
 	return int(id), nil
 }
@@ -1,16 +1,32 @@
 package infrastructure
 
 import (
+	"context"
 	"net/http"
 	"test-go/config"
+	"test-go/pkg/tracing"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// HttpManager probes external services on demand.
+//
+// Deprecated: the server now checks monitoring.external in the background with
+// internal/prober, which adds TCP and DNS checks, assertions, uptime and history.
+// HttpManager only probes over HTTP when asked and is kept for existing importers.
 type HttpManager struct {
 	Services []config.ExternalService
 	Client   *http.Client
 }
 
+// NewHttpManager creates a manager for cfg.Services.
+//
+// Deprecated: see HttpManager.
 func NewHttpManager(cfg config.ExternalConfig) *HttpManager {
 	return &HttpManager{
 		Services: cfg.Services,
@@ -20,34 +36,68 @@ func NewHttpManager(cfg config.ExternalConfig) *HttpManager {
 	}
 }
 
-func (h *HttpManager) GetStatus() []map[string]interface{} {
+// GetStatus probes every configured service. Each probe is a client span of ctx
+// and forwards the trace context in its request headers.
+func (h *HttpManager) GetStatus(ctx context.Context) []map[string]interface{} {
 	results := []map[string]interface{}{}
-
 	for _, svc := range h.Services {
-		start := time.Now()
-		resp, err := h.Client.Get(svc.URL)
-		latency := time.Since(start).Milliseconds()
-
-		status := "down"
-		statusCode := 0
-		if err == nil {
-			statusCode = resp.StatusCode
-			resp.Body.Close()
-			if statusCode >= 200 && statusCode < 300 {
-				status = "up"
-			} else {
-				status = "degraded"
-			}
+		results = append(results, h.ProbeService(ctx, svc))
+	}
+	return results
+}
+
+// ProbeService requests svc once. Status is "up" for 2xx, "degraded" for other
+// responses and "down" when the request fails.
+func (h *HttpManager) ProbeService(ctx context.Context, svc config.ExternalService) map[string]interface{} {
+	start := time.Now()
+	resp, err := h.probe(ctx, svc)
+	latency := time.Since(start).Milliseconds()
+
+	status := "down"
+	statusCode := 0
+	if err == nil {
+		statusCode = resp.StatusCode
+		resp.Body.Close()
+		if statusCode >= 200 && statusCode < 300 {
+			status = "up"
+		} else {
+			status = "degraded"
 		}
+	}
 
-		results = append(results, map[string]interface{}{
-			"name":        svc.Name,
-			"url":         svc.URL,
-			"status":      status,
-			"status_code": statusCode,
-			"latency_ms":  latency,
-		})
+	result := map[string]interface{}{
+		"name":        svc.Name,
+		"url":         svc.URL,
+		"status":      status,
+		"status_code": statusCode,
+		"latency_ms":  latency,
 	}
+	if err != nil {
+		result["error"] = err.Error()
+	}
+	return result
+}
 
-	return results
+func (h *HttpManager) probe(ctx context.Context, svc config.ExternalService) (*http.Response, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "http.probe "+svc.Name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.HTTPRequestMethodGet, semconv.URLFull(svc.URL)),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, svc.URL, nil)
+	if err != nil {
+		tracing.End(span, err)
+		return nil, err
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := h.Client.Do(req)
+	if err == nil {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+		if resp.StatusCode >= 500 {
+			span.SetStatus(codes.Error, resp.Status)
+		}
+	}
+	tracing.End(span, err)
+	return resp, err
 }
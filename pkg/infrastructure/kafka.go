@@ -2,17 +2,35 @@ package infrastructure
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sync/atomic"
 	"test-go/config"
+	"test-go/pkg/tracing"
 
 	"github.com/IBM/sarama"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type KafkaManager struct {
-	Producer sarama.SyncProducer
+	Producer sarama.SyncProducer // counts what it sends, whether through Publish or directly
 	Brokers  []string
 	GroupID  string
+
+	produced      atomic.Uint64
+	produceErrors atomic.Uint64
+	consumed      atomic.Uint64
+	consumeErrors atomic.Uint64
+}
+
+// KafkaStats counts messages since startup.
+type KafkaStats struct {
+	Produced      uint64 `json:"produced"`
+	ProduceErrors uint64 `json:"produce_errors"`
+	Consumed      uint64 `json:"consumed"`
+	ConsumeErrors uint64 `json:"consume_errors"`
 }
 
 func NewKafkaManager(cfg config.KafkaConfig) (*KafkaManager, error) {
@@ -30,11 +48,43 @@ func NewKafkaManager(cfg config.KafkaConfig) (*KafkaManager, error) {
 		return nil, fmt.Errorf("failed to start kafka producer: %w", err)
 	}
 
-	return &KafkaManager{
-		Producer: producer,
-		Brokers:  cfg.Brokers,
-		GroupID:  cfg.GroupID,
-	}, nil
+	k := &KafkaManager{
+		Brokers: cfg.Brokers,
+		GroupID: cfg.GroupID,
+	}
+	k.Producer = &countingProducer{SyncProducer: producer, manager: k}
+	return k, nil
+}
+
+// countingProducer updates the manager's produce counters for every message sent.
+type countingProducer struct {
+	sarama.SyncProducer
+	manager *KafkaManager
+}
+
+func (p *countingProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	partition, offset, err := p.SyncProducer.SendMessage(msg)
+	if err != nil {
+		p.manager.produceErrors.Add(1)
+	} else {
+		p.manager.produced.Add(1)
+	}
+	return partition, offset, err
+}
+
+func (p *countingProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	err := p.SyncProducer.SendMessages(msgs)
+	var failed sarama.ProducerErrors
+	switch {
+	case err == nil:
+		p.manager.produced.Add(uint64(len(msgs)))
+	case errors.As(err, &failed):
+		p.manager.produceErrors.Add(uint64(len(failed)))
+		p.manager.produced.Add(uint64(len(msgs) - len(failed)))
+	default:
+		p.manager.produceErrors.Add(uint64(len(msgs)))
+	}
+	return err
 }
 
 func (k *KafkaManager) GetStatus() map[string]interface{} {
@@ -56,9 +106,50 @@ func (k *KafkaManager) GetStatus() map[string]interface{} {
 	return stats
 }
 
+// Publish sends a message and waits for the broker acknowledgement.
+// The trace context of ctx travels in the message headers.
+func (k *KafkaManager) Publish(ctx context.Context, topic string, key, value []byte) error {
+	ctx, span := tracing.Tracer().Start(ctx, "kafka.publish "+topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(semconv.MessagingSystemKafka, semconv.MessagingOperationTypeSend, semconv.MessagingDestinationName(topic)),
+	)
+
+	msg := &sarama.ProducerMessage{Topic: topic, Value: sarama.ByteEncoder(value)}
+	if key != nil {
+		msg.Key = sarama.ByteEncoder(key)
+	}
+	tracing.InjectKafka(ctx, msg)
+
+	_, _, err := k.Producer.SendMessage(msg)
+	tracing.End(span, err)
+	if err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", topic, err)
+	}
+	return nil
+}
+
+// Stats returns message counts since startup.
+func (k *KafkaManager) Stats() KafkaStats {
+	return KafkaStats{
+		Produced:      k.produced.Load(),
+		ProduceErrors: k.produceErrors.Load(),
+		Consumed:      k.consumed.Load(),
+		ConsumeErrors: k.consumeErrors.Load(),
+	}
+}
+
 // Consume starts a consumer group for the given topic.
 // NOTE: This blocks the calling goroutine. Run in a separate goroutine.
 func (k *KafkaManager) Consume(ctx context.Context, topic string, handler func(key, value []byte) error) error {
+	return k.ConsumeContext(ctx, topic, func(_ context.Context, key, value []byte) error {
+		return handler(key, value)
+	})
+}
+
+// ConsumeContext is Consume with a handler context that carries the producer's
+// trace context, so handler spans join the producer's trace.
+// NOTE: This blocks the calling goroutine. Run in a separate goroutine.
+func (k *KafkaManager) ConsumeContext(ctx context.Context, topic string, handler func(ctx context.Context, key, value []byte) error) error {
 	config := sarama.NewConfig()
 	config.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
 	config.Consumer.Offsets.Initial = sarama.OffsetOldest
@@ -71,6 +162,7 @@ func (k *KafkaManager) Consume(ctx context.Context, topic string, handler func(k
 
 	consumer := &consumerHandler{
 		handler: handler,
+		manager: k,
 	}
 
 	for {
@@ -87,14 +179,24 @@ func (k *KafkaManager) Consume(ctx context.Context, topic string, handler func(k
 
 // consumerHandler implements sarama.ConsumerGroupHandler
 type consumerHandler struct {
-	handler func(key, value []byte) error
+	handler func(ctx context.Context, key, value []byte) error
+	manager *KafkaManager
 }
 
 func (h *consumerHandler) Setup(sarama.ConsumerGroupSession) error   { return nil }
 func (h *consumerHandler) Cleanup(sarama.ConsumerGroupSession) error { return nil }
 func (h *consumerHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
 	for message := range claim.Messages() {
-		if err := h.handler(message.Key, message.Value); err != nil {
+		h.manager.consumed.Add(1)
+		ctx, span := tracing.Tracer().Start(tracing.ExtractKafka(session.Context(), message), "kafka.consume "+message.Topic,
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(semconv.MessagingSystemKafka, semconv.MessagingOperationTypeProcess,
+				semconv.MessagingDestinationName(message.Topic), semconv.MessagingKafkaOffset(int(message.Offset))),
+		)
+		err := h.handler(ctx, message.Key, message.Value)
+		tracing.End(span, err)
+		if err != nil {
+			h.manager.consumeErrors.Add(1)
 			log.Printf("Error handling message: %v", err)
 		}
 		session.MarkMessage(message, "")
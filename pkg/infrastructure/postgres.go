@@ -5,8 +5,11 @@ import (
 	"database/sql"
 	"fmt"
 	"test-go/config"
+	"test-go/pkg/tracing"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -41,6 +44,9 @@ func NewPostgresDB(cfg config.PostgresConfig) (*PostgresManager, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize GORM: %w", err)
 	}
+	if err := gormDB.Use(tracing.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("failed to instrument GORM: %w", err)
+	}
 
 	return &PostgresManager{
 		DB:  sqlDB,
@@ -69,19 +75,36 @@ func (p *PostgresManager) GetStatus() map[string]interface{} {
 	return stats
 }
 
+// startSpan starts a client span for a query; the span covers the call, not row iteration.
+func (p *PostgresManager) startSpan(ctx context.Context, op, query string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "postgres."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNamePostgreSQL, semconv.DBOperationName(op), semconv.DBQueryText(query)),
+	)
+}
+
 // Query executes a query that returns rows, typically a SELECT.
 func (p *PostgresManager) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
-	return p.DB.QueryContext(ctx, query, args...)
+	ctx, span := p.startSpan(ctx, "query", query)
+	rows, err := p.DB.QueryContext(ctx, query, args...)
+	tracing.End(span, err)
+	return rows, err
 }
 
 // QueryRow executes a query that is expected to return at most one row.
 func (p *PostgresManager) QueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
-	return p.DB.QueryRowContext(ctx, query, args...)
+	ctx, span := p.startSpan(ctx, "query_row", query)
+	row := p.DB.QueryRowContext(ctx, query, args...)
+	tracing.End(span, row.Err())
+	return row
 }
 
 // Exec executes a query without returning any rows.
 func (p *PostgresManager) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	return p.DB.ExecContext(ctx, query, args...)
+	ctx, span := p.startSpan(ctx, "exec", query)
+	res, err := p.DB.ExecContext(ctx, query, args...)
+	tracing.End(span, err)
+	return res, err
 }
 
 // Select is a semantic alias for Query.
@@ -104,6 +127,10 @@ func (p *PostgresManager) ExecuteRawQuery(ctx context.Context, query string) ([]
 		return nil, fmt.Errorf("database connection is nil")
 	}
 
+	ctx, span := p.startSpan(ctx, "raw_query", query)
+	var err error
+	defer func() { tracing.End(span, err) }()
+
 	rows, err := p.DB.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
@@ -126,7 +153,7 @@ func (p *PostgresManager) ExecuteRawQuery(ctx context.Context, query string) ([]
 			valuePtrs[i] = &values[i]
 		}
 
-		if err := rows.Scan(valuePtrs...); err != nil {
+		if err = rows.Scan(valuePtrs...); err != nil {
 			return nil, err
 		}
 
@@ -3,9 +3,11 @@ package infrastructure
 import (
 	"context"
 	"fmt"
+	"math/rand/v2"
 	"test-go/config"
 	"time"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -24,6 +26,11 @@ func NewRedisClient(cfg config.RedisConfig) (*RedisManager, error) {
 		DB:       cfg.DB,
 	})
 
+	// Child spans for every command; traced when the context carries a span
+	if err := redisotel.InstrumentTracing(client, redisotel.WithDBStatement(false)); err != nil {
+		return nil, fmt.Errorf("failed to instrument redis: %w", err)
+	}
+
 	// Test connection
 	if err := client.Ping(context.Background()).Err(); err != nil {
 		return nil, fmt.Errorf("failed to connect to redis: %w", err)
@@ -52,6 +59,37 @@ func (r *RedisManager) Replace(ctx context.Context, key string, value interface{
 	return r.Client.SetXX(ctx, key, value, ttl).Err()
 }
 
+// SetIfNotExists adds a key only if it does not exist (NX).
+// Returns true if the key was set.
+func (r *RedisManager) SetIfNotExists(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	return r.Client.SetNX(ctx, key, value, ttl).Result()
+}
+
+// Increment atomically increments the integer value of a key by one.
+func (r *RedisManager) Increment(ctx context.Context, key string) (int64, error) {
+	return r.Client.Incr(ctx, key).Result()
+}
+
+// SlidingWindowAdd records an event under key and returns how many events fall within
+// the trailing window. Events are kept in a sorted set scored by Unix milliseconds.
+func (r *RedisManager) SlidingWindowAdd(ctx context.Context, key string, now time.Time, window time.Duration) (int64, error) {
+	nowMs := now.UnixMilli()
+	member := fmt.Sprintf("%d-%d", now.UnixNano(), rand.Int64())
+
+	var card *redis.IntCmd
+	_, err := r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, key, "-inf", fmt.Sprintf("%d", nowMs-window.Milliseconds()))
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(nowMs), Member: member})
+		card = pipe.ZCard(ctx, key)
+		pipe.PExpire(ctx, key, window)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return card.Val(), nil
+}
+
 func (r *RedisManager) GetStatus() map[string]interface{} {
 	stats := make(map[string]interface{})
 	if r == nil || r.Client == nil {
@@ -5,11 +5,8 @@ import (
 	"net"
 	"os"
 	"runtime"
-	"time"
 
-	"github.com/shirou/gopsutil/v3/cpu"
-	"github.com/shirou/gopsutil/v3/disk"
-	"github.com/shirou/gopsutil/v3/mem"
+	"test-go/pkg/sysstats"
 )
 
 type SystemManager struct{}
@@ -18,46 +15,36 @@ func NewSystemManager() *SystemManager {
 	return &SystemManager{}
 }
 
+// GetStats formats the latest shared sample for the System tab. Inside a container
+// CPU and memory are relative to the cgroup quota and limit.
 func (s *SystemManager) GetStats() map[string]interface{} {
 	stats := make(map[string]interface{})
-
-	// Memory
-	vm, err := mem.VirtualMemory()
-	if err == nil {
-		stats["memory"] = map[string]interface{}{
-			"total_gb":     fmt.Sprintf("%.1f", float64(vm.Total)/1024/1024/1024),
-			"used_gb":      fmt.Sprintf("%.1f", float64(vm.Used)/1024/1024/1024),
-			"used_percent": fmt.Sprintf("%.1f", vm.UsedPercent),
-			"free_gb":      fmt.Sprintf("%.1f", float64(vm.Free)/1024/1024/1024),
-		}
+	smp, ok := sysstats.Default().Latest()
+	if !ok {
+		return stats
 	}
 
-	// CPU
-	percent, err := cpu.Percent(time.Second*0, false) // 0 wait for instant check
-	if err == nil && len(percent) > 0 {
-		stats["cpu"] = map[string]interface{}{
-			"usage_percent": fmt.Sprintf("%.1f", percent[0]),
-			"cores":         len(percent), // simple count if percpu=true, but here we used false so it's total
-		}
-		// Get cores count separate
-		cores, _ := cpu.Counts(true)
-		stats["cpu"].(map[string]interface{})["cores"] = cores
+	stats["memory"] = map[string]interface{}{
+		"total_gb":     fmt.Sprintf("%.1f", float64(smp.Memory.Total)/1024/1024/1024),
+		"used_gb":      fmt.Sprintf("%.1f", float64(smp.Memory.Used)/1024/1024/1024),
+		"used_percent": fmt.Sprintf("%.1f", smp.Memory.UsedPercent),
+		"free_gb":      fmt.Sprintf("%.1f", float64(smp.Memory.Total-min(smp.Memory.Used, smp.Memory.Total))/1024/1024/1024),
+		"limited":      smp.Memory.Limited,
 	}
-
-	// Disk
-	parts, err := disk.Partitions(false)
-	if err == nil && len(parts) > 0 {
-		// Just check C: or root
-		usage, err := disk.Usage(parts[0].Mountpoint)
-		if err == nil {
-			stats["disk"] = map[string]interface{}{
-				"path":         usage.Path,
-				"total_gb":     fmt.Sprintf("%.1f", float64(usage.Total)/1024/1024/1024),
-				"used_gb":      fmt.Sprintf("%.1f", float64(usage.Used)/1024/1024/1024),
-				"used_percent": fmt.Sprintf("%.1f", usage.UsedPercent),
-			}
+	stats["cpu"] = map[string]interface{}{
+		"usage_percent": fmt.Sprintf("%.1f", smp.CPU.Percent),
+		"cores":         smp.CPU.Cores,
+		"limit_cores":   smp.CPU.LimitCores,
+	}
+	if smp.Disk.Total > 0 {
+		stats["disk"] = map[string]interface{}{
+			"path":         smp.Disk.Path,
+			"total_gb":     fmt.Sprintf("%.1f", float64(smp.Disk.Total)/1024/1024/1024),
+			"used_gb":      fmt.Sprintf("%.1f", float64(smp.Disk.Used)/1024/1024/1024),
+			"used_percent": fmt.Sprintf("%.1f", smp.Disk.UsedPercent),
 		}
 	}
+	stats["network"] = smp.Network
 
 	return stats
 }
@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type debugKey struct{}
+
+// WithDebug marks ctx so that loggers derived from it with WithContext write debug
+// entries and skip sampling, whatever their level. Sink levels still apply.
+func WithDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugKey{}, true)
+}
+
+// DebugEnabled reports whether ctx was marked with WithDebug.
+func DebugEnabled(ctx context.Context) bool {
+	on, _ := ctx.Value(debugKey{}).(bool)
+	return on
+}
+
+// DebugSigner issues and checks the expiring tokens that raise logging to debug for
+// a single request.
+type DebugSigner struct {
+	key    []byte
+	maxTTL time.Duration
+}
+
+// NewDebugSigner creates a signer. Without a secret a random key is used, so tokens
+// are only valid until the process restarts.
+func NewDebugSigner(secret string, maxTTL time.Duration) *DebugSigner {
+	key := []byte(secret)
+	if secret == "" {
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+	if maxTTL <= 0 {
+		maxTTL = time.Hour
+	}
+	return &DebugSigner{key: key, maxTTL: maxTTL}
+}
+
+// Sign returns a token valid for ttl, capped at the signer's maximum.
+func (s *DebugSigner) Sign(ttl time.Duration) (string, time.Time) {
+	if ttl <= 0 || ttl > s.maxTTL {
+		ttl = s.maxTTL
+	}
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return exp + "." + s.mac(exp), expires
+}
+
+// Verify reports whether token was issued by s and has not expired.
+func (s *DebugSigner) Verify(token string) bool {
+	exp, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(s.mac(exp)))
+}
+
+func (s *DebugSigner) mac(exp string) string {
+	h := hmac.New(sha256.New, s.key)
+	h.Write([]byte("debug-log:" + exp))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
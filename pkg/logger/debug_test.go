@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDebugSignerVerify(t *testing.T) {
+	signer := NewDebugSigner("secret", time.Hour)
+	valid, _ := signer.Sign(time.Minute)
+	exp, sig, _ := strings.Cut(valid, ".")
+
+	expired := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	later := strconv.FormatInt(time.Now().Add(2*time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name   string
+		signer *DebugSigner
+		token  string
+		want   bool
+	}{
+		{"valid token", signer, valid, true},
+		{"same secret after a restart", NewDebugSigner("secret", time.Hour), valid, true},
+		{"different secret", NewDebugSigner("other", time.Hour), valid, false},
+		{"random key without a secret", NewDebugSigner("", time.Hour), valid, false},
+		{"expired", signer, expired + "." + signer.mac(expired), false},
+		{"expiry moved later", signer, later + "." + sig, false},
+		{"tampered signature", signer, exp + "." + strings.ToUpper(sig), false},
+		{"no separator", signer, exp + sig, false},
+		{"non-numeric expiry", signer, "soon." + signer.mac("soon"), false},
+		{"empty", signer, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.signer.Verify(tt.token); got != tt.want {
+				t.Errorf("Verify(%q) = %v, want %v", tt.token, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDebugSignerTTL(t *testing.T) {
+	signer := NewDebugSigner("secret", 10*time.Minute)
+
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		want time.Duration
+	}{
+		{"within the maximum", 5 * time.Minute, 5 * time.Minute},
+		{"capped at the maximum", 2 * time.Hour, 10 * time.Minute},
+		{"zero uses the maximum", 0, 10 * time.Minute},
+		{"negative uses the maximum", -time.Minute, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, expires := signer.Sign(tt.ttl)
+			if !signer.Verify(token) {
+				t.Fatalf("fresh token %q does not verify", token)
+			}
+			// Expiry is truncated to the second
+			if got := time.Until(expires); got > tt.want || got < tt.want-2*time.Second {
+				t.Errorf("expires in %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithDebug(t *testing.T) {
+	ctx := context.Background()
+	if DebugEnabled(ctx) {
+		t.Error("plain context has debug enabled")
+	}
+	if !DebugEnabled(WithDebug(ctx)) {
+		t.Error("WithDebug context does not have debug enabled")
+	}
+}
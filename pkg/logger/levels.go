@@ -0,0 +1,160 @@
+package logger
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// override is a level set at runtime, reverted to the configured one at expires.
+type override struct {
+	level   zerolog.Level
+	expires time.Time // zero = until changed again
+	timer   *time.Timer
+}
+
+// LevelStatus describes the level of the root logger ("") or of one named logger.
+type LevelStatus struct {
+	Module     string    `json:"module"`
+	Level      string    `json:"level"`
+	Configured string    `json:"configured"` // level from config; empty for modules without one
+	Override   bool      `json:"override"`
+	ExpiresAt  time.Time `json:"expires_at,omitzero"`
+}
+
+// Levels returns the root level first, then every named logger seen so far or
+// configured, by name.
+func (l *Logger) Levels() []LevelStatus {
+	p := l.pipe
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	names := make(map[string]bool, len(p.names))
+	for n := range p.names {
+		names[n] = true
+	}
+	for n := range p.baseModules {
+		names[n] = true
+	}
+	for n := range p.overrides {
+		names[n] = true
+	}
+	delete(names, "")
+
+	sorted := make([]string, 0, len(names))
+	for n := range names {
+		sorted = append(sorted, n)
+	}
+	sort.Strings(sorted)
+
+	out := []LevelStatus{p.status("")}
+	for _, n := range sorted {
+		out = append(out, p.status(n))
+	}
+	return out
+}
+
+// SetLevel changes the level of the named logger, or of the root logger (and so of
+// every named logger without its own level) when module is empty. With ttl > 0 the
+// configured level is restored after ttl.
+func (l *Logger) SetLevel(module, level string, ttl time.Duration) (LevelStatus, error) {
+	if strings.TrimSpace(level) == "" {
+		return LevelStatus{}, errors.New("log level is required")
+	}
+	lvl, err := parseLevel(level, zerolog.NoLevel)
+	if err != nil {
+		return LevelStatus{}, err
+	}
+
+	p := l.pipe
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.clearOverride(module)
+	o := &override{level: lvl}
+	if ttl > 0 {
+		o.expires = time.Now().Add(ttl)
+		o.timer = time.AfterFunc(ttl, func() { p.expire(module, o) })
+	}
+	p.overrides[module] = o
+	p.apply(module, lvl)
+	return p.status(module), nil
+}
+
+// ResetLevel restores the configured level of the named or root logger.
+func (l *Logger) ResetLevel(module string) LevelStatus {
+	p := l.pipe
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.restore(module)
+	return p.status(module)
+}
+
+// expire restores the configured level unless o was replaced in the meantime.
+func (p *pipeline) expire(module string, o *override) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.overrides[module] == o {
+		p.restore(module)
+	}
+}
+
+// restore drops the override of module and applies its configured level. The
+// caller holds p.mu.
+func (p *pipeline) restore(module string) {
+	p.clearOverride(module)
+	if module == "" {
+		p.level = p.baseLevel
+		return
+	}
+	if lvl, ok := p.baseModules[module]; ok {
+		p.modules[module] = lvl
+	} else {
+		delete(p.modules, module)
+	}
+}
+
+func (p *pipeline) clearOverride(module string) {
+	if o, ok := p.overrides[module]; ok {
+		if o.timer != nil {
+			o.timer.Stop()
+		}
+		delete(p.overrides, module)
+	}
+}
+
+func (p *pipeline) apply(module string, lvl zerolog.Level) {
+	if module == "" {
+		p.level = lvl
+	} else {
+		p.modules[module] = lvl
+	}
+}
+
+// status reports one logger. The caller holds p.mu.
+func (p *pipeline) status(module string) LevelStatus {
+	st := LevelStatus{Module: module}
+	if module == "" {
+		st.Level = p.level.String()
+		st.Configured = p.baseLevel.String()
+	} else {
+		if lvl, ok := p.modules[module]; ok {
+			st.Level = lvl.String()
+		} else {
+			st.Level = p.levelForLocked(module).String()
+		}
+		if lvl, ok := p.baseModules[module]; ok {
+			st.Configured = lvl.String()
+		}
+	}
+	if o, ok := p.overrides[module]; ok {
+		st.Override = true
+		st.ExpiresAt = o.expires
+	}
+	return st
+}
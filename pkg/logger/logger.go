@@ -1,26 +1,36 @@
 package logger
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"strings"
-	"time"
+
+	"test-go/config"
 
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// Logger wraps the zerolog logger
+// Logger wraps the zerolog logger. Every entry is one JSON event (time, level,
+// logger, message and fields) fanned out to the configured sinks, each of which
+// filters by its own level and renders it in its own format.
 type Logger struct {
 	z     zerolog.Logger
 	quiet bool
+	name  string
+	debug bool // debug forced for one request, see WithDebug
+	pipe  *pipeline
 }
 
 // LoggerConfig contains configuration for the logger
 type LoggerConfig struct {
 	Debug       bool
-	Quiet       bool // suppress console output (logs still go to broadcaster)
+	Quiet       bool // suppress console output (logs still go to broadcaster and TUI)
 	Broadcaster io.Writer
+	TUI         io.Writer        // live TUI, if running
+	Pipeline    config.LogConfig // sink levels and formats, module levels and sampling
 }
 
 // New creates a new fancy logger
@@ -41,73 +51,90 @@ func NewQuiet(debug bool, broadcaster io.Writer) *Logger {
 	})
 }
 
-// NewWithConfig creates a new logger with full configuration
+// NewWithConfig creates a new logger with full configuration. Invalid levels and
+// formats and an unusable log file are reported through the logger itself.
 func NewWithConfig(cfg LoggerConfig) *Logger {
-	zerolog.TimeFieldFormat = time.RFC3339
-
-	// Console Output (Fancy)
-	consoleOutput := zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: "15:04:05"}
-	consoleOutput.FormatLevel = func(i interface{}) string {
-		var l string
-		if ll, ok := i.(string); ok {
-			switch ll {
-			case "debug":
-				l = "\x1b[36m[ DEBUG ]\x1b[0m"
-			case "info":
-				l = "\x1b[32m[ INFO  ]\x1b[0m"
-			case "warn":
-				l = "\x1b[33m[ WARN  ]\x1b[0m"
-			case "error":
-				l = "\x1b[31m[ ERROR ]\x1b[0m"
-			case "fatal":
-				l = "\x1b[31m[ FATAL ]\x1b[0m"
-			case "panic":
-				l = "\x1b[31m[ PANIC ]\x1b[0m"
-			default:
-				l = strings.ToUpper(ll)
-			}
-		} else {
-			if i == nil {
-				l = strings.ToUpper(fmt.Sprintf("%s", i))
-			} else {
-				l = strings.ToUpper(fmt.Sprintf("%s", i))
-			}
-		}
-		return l
-	}
-	consoleOutput.FormatMessage = func(i interface{}) string {
-		return fmt.Sprintf("\x1b[1m%s\x1b[0m", i)
+	zerolog.TimeFieldFormat = "2006-01-02T15:04:05.000Z07:00"
+
+	pc := cfg.Pipeline
+	p := &pipeline{
+		modules:     make(map[string]zerolog.Level),
+		overrides:   make(map[string]*override),
+		names:       make(map[string]bool),
+		sampling:    pc.Sampling,
+		baseModules: make(map[string]zerolog.Level),
 	}
+	var problems []error
 
-	var multi zerolog.LevelWriter
+	var err error
+	if p.level, err = parseLevel(pc.Level, zerolog.InfoLevel); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.Debug && p.level > zerolog.DebugLevel {
+		p.level = zerolog.DebugLevel
+	}
+	for name, level := range pc.Modules {
+		l, err := parseLevel(level, p.level)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("logger %s: %w", name, err))
+			continue
+		}
+		p.modules[name] = l
+		p.baseModules[name] = l
+	}
+	p.baseLevel = p.level
 
-	if cfg.Quiet {
-		// Quiet mode: only write to broadcaster (if available), not to console
-		if cfg.Broadcaster != nil {
-			// Create a simple console writer for the broadcaster (without stdout)
-			broadcasterOutput := zerolog.ConsoleWriter{Out: cfg.Broadcaster, TimeFormat: "15:04:05", NoColor: true}
-			multi = zerolog.MultiLevelWriter(broadcasterOutput)
-		} else {
-			// No broadcaster and quiet mode = discard all logs
-			multi = zerolog.MultiLevelWriter(zerolog.ConsoleWriter{Out: io.Discard})
+	var writers []io.Writer
+	addSink := func(name string, w io.Writer, sc config.LogSinkConfig, def string) {
+		s, err := newSink(w, sc, def)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s sink: %w", name, err))
+			if s, err = newSink(w, config.LogSinkConfig{}, def); err != nil {
+				return
+			}
 		}
-	} else {
-		// Normal mode: write to console and broadcaster
-		if cfg.Broadcaster != nil {
-			multi = zerolog.MultiLevelWriter(consoleOutput, cfg.Broadcaster)
+		writers = append(writers, s)
+	}
+
+	if !cfg.Quiet {
+		addSink("console", os.Stdout, pc.Console, "pretty")
+	}
+	if cfg.TUI != nil {
+		addSink("tui", cfg.TUI, pc.TUI, "json")
+	}
+	if cfg.Broadcaster != nil {
+		addSink("broadcast", cfg.Broadcaster, pc.Broadcast, "json")
+	}
+	if pc.File.Enabled {
+		f, err := NewRotatingFile(RotateConfig{
+			Path:           pc.File.Path,
+			MaxSizeMB:      pc.File.MaxSizeMB,
+			RotateInterval: pc.File.RotateInterval,
+			MaxBackups:     pc.File.MaxBackups,
+			MaxAge:         pc.File.MaxAge,
+			Compress:       pc.File.Compress,
+		})
+		if err != nil {
+			problems = append(problems, err)
 		} else {
-			multi = zerolog.MultiLevelWriter(consoleOutput)
+			p.closers = append(p.closers, f)
+			addSink("file", f, pc.File.LogSinkConfig, "json")
 		}
 	}
 
-	logLevel := zerolog.InfoLevel
-	if cfg.Debug {
-		logLevel = zerolog.DebugLevel
+	var out io.Writer = io.Discard
+	if len(writers) > 0 {
+		out = zerolog.MultiLevelWriter(writers...)
 	}
 
-	z := zerolog.New(multi).Level(logLevel).With().Timestamp().Logger()
+	// Levels are checked per entry against the pipeline, so the zerolog level stays open
+	p.root = zerolog.New(out).Level(zerolog.TraceLevel).With().Timestamp().Logger()
 
-	return &Logger{z: z, quiet: cfg.Quiet}
+	l := &Logger{z: p.root, quiet: cfg.Quiet, pipe: p}
+	for _, err := range problems {
+		l.Warn("Log configuration ignored", "error", err.Error())
+	}
+	return l
 }
 
 // IsQuiet returns whether the logger is in quiet mode
@@ -115,28 +142,102 @@ func (l *Logger) IsQuiet() bool {
 	return l.quiet
 }
 
+// Named returns a logger for one module. Its entries carry logger=name and use the
+// level configured for the name under log.modules, if any. Names of nested loggers
+// are joined with dots.
+func (l *Logger) Named(name string) *Logger {
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	l.pipe.mu.Lock()
+	l.pipe.names[name] = true
+	l.pipe.mu.Unlock()
+
+	z := l.pipe.root.With().Str("logger", name).Logger()
+	return &Logger{z: z, quiet: l.quiet, name: name, pipe: l.pipe}
+}
+
+// Sampled returns a logger for hot paths whose debug and info entries are thinned
+// as configured under log.sampling. Warnings and errors are always written.
+func (l *Logger) Sampled() *Logger {
+	s := l.pipe.sampling
+	if !s.Enabled {
+		return l
+	}
+
+	sampler := &zerolog.BurstSampler{Burst: s.Burst, Period: s.Period}
+	if s.Every > 0 {
+		sampler.NextSampler = &zerolog.BasicSampler{N: s.Every}
+	}
+	z := l.z.Sample(zerolog.LevelSampler{TraceSampler: sampler, DebugSampler: sampler, InfoSampler: sampler})
+	return &Logger{z: z, quiet: l.quiet, name: l.name, debug: l.debug, pipe: l.pipe}
+}
+
+// Close closes the log file, if one is configured. It is shared by every logger
+// derived from the same root.
+func (l *Logger) Close() error {
+	var errs []error
+	for _, c := range l.pipe.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// WithContext returns a logger that adds the trace_id and span_id of the span in
+// ctx to every entry and writes debug entries when ctx was marked with WithDebug,
+// or l itself when neither applies.
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	sc := trace.SpanContextFromContext(ctx)
+	debug := DebugEnabled(ctx)
+	if !sc.IsValid() && !debug {
+		return l
+	}
+
+	zc := l.z.With()
+	if sc.IsValid() {
+		zc = zc.Str("trace_id", sc.TraceID().String()).Str("span_id", sc.SpanID().String())
+	}
+	if debug {
+		zc = zc.Bool("debug_override", true)
+	}
+	z := zc.Logger()
+	if debug {
+		z = z.Sample(nil)
+	}
+	return &Logger{z: z, quiet: l.quiet, name: l.name, debug: l.debug || debug, pipe: l.pipe}
+}
+
+// event starts an entry at level, or returns nil (on which zerolog's methods are
+// no-ops) when the level is below the logger's.
+func (l *Logger) event(level zerolog.Level) *zerolog.Event {
+	if level < l.pipe.levelFor(l.name) && !(l.debug && level >= zerolog.DebugLevel) {
+		return nil
+	}
+	return l.z.WithLevel(level)
+}
+
 // Info logs an info message
 func (l *Logger) Info(msg string, keyvals ...interface{}) {
-	l.log(l.z.Info(), msg, keyvals...)
+	l.log(l.event(zerolog.InfoLevel), msg, keyvals...)
 }
 
 // Error logs an error message
 func (l *Logger) Error(msg string, err error, keyvals ...interface{}) {
 	if err != nil {
-		l.z.Error().Err(err).Fields(keyvals).Msg(msg)
+		l.log(l.event(zerolog.ErrorLevel).Err(err), msg, keyvals...)
 	} else {
-		l.log(l.z.Error(), msg, keyvals...)
+		l.log(l.event(zerolog.ErrorLevel), msg, keyvals...)
 	}
 }
 
 // Debug logs a debug message
 func (l *Logger) Debug(msg string, keyvals ...interface{}) {
-	l.log(l.z.Debug(), msg, keyvals...)
+	l.log(l.event(zerolog.DebugLevel), msg, keyvals...)
 }
 
 // Warn logs a warning message
 func (l *Logger) Warn(msg string, keyvals ...interface{}) {
-	l.log(l.z.Warn(), msg, keyvals...)
+	l.log(l.event(zerolog.WarnLevel), msg, keyvals...)
 }
 
 // Fatal logs a fatal message and exits
@@ -149,6 +250,9 @@ func (l *Logger) Fatal(msg string, err error) {
 }
 
 func (l *Logger) log(e *zerolog.Event, msg string, keyvals ...interface{}) {
+	if e == nil {
+		return
+	}
 	if len(keyvals)%2 != 0 {
 		e.Msg(msg + " (odd number of keyvals caused metadata drop)")
 		return
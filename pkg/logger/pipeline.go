@@ -0,0 +1,136 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"test-go/config"
+
+	"github.com/rs/zerolog"
+)
+
+// pipeline holds the levels shared by a root logger and every logger derived from it.
+type pipeline struct {
+	mu        sync.RWMutex
+	level     zerolog.Level            // for loggers without a module level
+	modules   map[string]zerolog.Level // by logger name
+	overrides map[string]*override     // set at runtime, by logger name ("" = root)
+	names     map[string]bool          // every name passed to Named
+	sampling  config.LogSamplingConfig
+
+	// As configured, restored when an override is reset or expires
+	baseLevel   zerolog.Level
+	baseModules map[string]zerolog.Level
+
+	root    zerolog.Logger // without a logger name, for Named
+	closers []io.Closer
+}
+
+// levelFor returns the level of the named logger. A level set for "a" applies to
+// "a.b" unless "a.b" has its own.
+func (p *pipeline) levelFor(name string) zerolog.Level {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.levelForLocked(name)
+}
+
+func (p *pipeline) levelForLocked(name string) zerolog.Level {
+	for n := name; n != ""; {
+		if l, ok := p.modules[n]; ok {
+			return l
+		}
+		i := strings.LastIndexByte(n, '.')
+		if i < 0 {
+			break
+		}
+		n = n[:i]
+	}
+	return p.level
+}
+
+// sink is one destination of the pipeline with its own minimum level.
+type sink struct {
+	w     io.Writer
+	level zerolog.Level
+}
+
+func (s sink) Write(p []byte) (int, error) {
+	return s.w.Write(p)
+}
+
+func (s sink) WriteLevel(l zerolog.Level, p []byte) (int, error) {
+	if l < s.level {
+		return len(p), nil
+	}
+	return s.w.Write(p)
+}
+
+// newSink renders the JSON events for w in cfg.Format, or def when it is empty.
+func newSink(w io.Writer, cfg config.LogSinkConfig, def string) (sink, error) {
+	level, err := parseLevel(cfg.Level, zerolog.TraceLevel)
+	if err != nil {
+		return sink{}, err
+	}
+
+	format := cfg.Format
+	if format == "" {
+		format = def
+	}
+	switch format {
+	case "json":
+	case "text":
+		w = zerolog.ConsoleWriter{Out: w, TimeFormat: "15:04:05", NoColor: true}
+	case "pretty":
+		w = prettyWriter(w)
+	default:
+		return sink{}, fmt.Errorf("unknown log format %q", format)
+	}
+	return sink{w: w, level: level}, nil
+}
+
+// prettyWriter is the coloured console rendering with bracketed levels.
+func prettyWriter(w io.Writer) zerolog.ConsoleWriter {
+	out := zerolog.ConsoleWriter{Out: w, TimeFormat: "15:04:05"}
+	out.FormatLevel = func(i interface{}) string {
+		ll, _ := i.(string)
+		switch ll {
+		case "trace":
+			return "\x1b[90m[ TRACE ]\x1b[0m"
+		case "debug":
+			return "\x1b[36m[ DEBUG ]\x1b[0m"
+		case "info":
+			return "\x1b[32m[ INFO  ]\x1b[0m"
+		case "warn":
+			return "\x1b[33m[ WARN  ]\x1b[0m"
+		case "error":
+			return "\x1b[31m[ ERROR ]\x1b[0m"
+		case "fatal":
+			return "\x1b[31m[ FATAL ]\x1b[0m"
+		case "panic":
+			return "\x1b[31m[ PANIC ]\x1b[0m"
+		}
+		return strings.ToUpper(fmt.Sprintf("%s", i))
+	}
+	out.FormatMessage = func(i interface{}) string {
+		return fmt.Sprintf("\x1b[1m%s\x1b[0m", i)
+	}
+	return out
+}
+
+// parseLevel parses a level name, returning def for an empty one.
+func parseLevel(s string, def zerolog.Level) (zerolog.Level, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return def, nil
+	}
+	if s == "warning" {
+		s = "warn"
+	}
+	l, err := zerolog.ParseLevel(s)
+	if err != nil || l == zerolog.NoLevel || l == zerolog.Disabled {
+		return def, fmt.Errorf("unknown log level %q", s)
+	}
+	return l, nil
+}
@@ -0,0 +1,206 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotateConfig configures a RotatingFile.
+type RotateConfig struct {
+	Path           string        // active log file, e.g. "logs/access.log"
+	MaxSizeMB      int           // rotate when the file grows past this size (0 = no size limit)
+	RotateInterval time.Duration // rotate when the file is older than this (0 = no time limit)
+	MaxBackups     int           // rotated files to keep (0 = keep all)
+	MaxAge         time.Duration // delete rotated files older than this (0 = keep forever)
+	Compress       bool          // gzip rotated files
+}
+
+// RotatingFile is an io.WriteCloser that rotates the underlying file by size and age.
+// Rotated files are renamed with a timestamp suffix and optionally gzipped in the background.
+type RotatingFile struct {
+	cfg      RotateConfig
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	mu       sync.Mutex
+}
+
+// NewRotatingFile opens (or creates) cfg.Path for appending.
+func NewRotatingFile(cfg RotateConfig) (*RotatingFile, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("rotating file: path is required")
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0755); err != nil {
+		return nil, fmt.Errorf("rotating file: failed to create directory: %w", err)
+	}
+
+	r := &RotatingFile{cfg: cfg}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("rotating file: failed to open %s: %w", r.cfg.Path, err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("rotating file: failed to stat %s: %w", r.cfg.Path, err)
+	}
+
+	r.file = f
+	r.size = info.Size()
+	r.openedAt = time.Now()
+	if r.size > 0 {
+		// Age an existing file from its last write, not from when we reopened it
+		r.openedAt = info.ModTime()
+	}
+	return nil
+}
+
+// Write appends p, rotating first if it would exceed the size limit or the file is too old.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if r.shouldRotate(int64(len(p))) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *RotatingFile) shouldRotate(incoming int64) bool {
+	if r.size == 0 {
+		return false
+	}
+	if r.cfg.MaxSizeMB > 0 && r.size+incoming > int64(r.cfg.MaxSizeMB)*1024*1024 {
+		return true
+	}
+	if r.cfg.RotateInterval > 0 && time.Since(r.openedAt) >= r.cfg.RotateInterval {
+		return true
+	}
+	return false
+}
+
+// Rotate forces a rotation regardless of size or age.
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rotate()
+}
+
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("rotating file: failed to close: %w", err)
+	}
+
+	ext := filepath.Ext(r.cfg.Path)
+	base := strings.TrimSuffix(r.cfg.Path, ext)
+	backup := fmt.Sprintf("%s-%s%s", base, time.Now().Format("20060102T150405.000"), ext)
+	if err := os.Rename(r.cfg.Path, backup); err != nil {
+		return fmt.Errorf("rotating file: failed to rename: %w", err)
+	}
+
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	go r.postRotate(backup)
+	return nil
+}
+
+// postRotate compresses the freshly rotated file and prunes old backups.
+func (r *RotatingFile) postRotate(backup string) {
+	if r.cfg.Compress {
+		if err := gzipFile(backup); err == nil {
+			os.Remove(backup)
+		}
+	}
+	r.prune()
+}
+
+func (r *RotatingFile) prune() {
+	if r.cfg.MaxBackups <= 0 && r.cfg.MaxAge <= 0 {
+		return
+	}
+
+	ext := filepath.Ext(r.cfg.Path)
+	base := strings.TrimSuffix(filepath.Base(r.cfg.Path), ext)
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(r.cfg.Path), base+"-*"+ext+"*"))
+	if err != nil {
+		return
+	}
+
+	// Timestamp suffix makes lexical order chronological; newest first
+	sort.Sort(sort.Reverse(sort.StringSlice(matches)))
+	for i, path := range matches {
+		expired := false
+		if r.cfg.MaxAge > 0 {
+			if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > r.cfg.MaxAge {
+				expired = true
+			}
+		}
+		if expired || (r.cfg.MaxBackups > 0 && i >= r.cfg.MaxBackups) {
+			os.Remove(path)
+		}
+	}
+}
+
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path + ".gz")
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		gz.Close()
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// Close closes the active file.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
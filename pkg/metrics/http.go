@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// HTTP holds request metrics labelled by route pattern, method and status.
+type HTTP struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+}
+
+// NewHTTP registers the HTTP request metrics. Nil buckets use the Prometheus defaults.
+func NewHTTP(buckets []float64) (*HTTP, error) {
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+
+	h := &HTTP{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests by route pattern, method and status.",
+		}, []string{"route", "method", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by route pattern, method and status.",
+			Buckets:   buckets,
+		}, []string{"route", "method", "status"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "requests_in_flight",
+			Help:      "HTTP requests currently being served.",
+		}),
+	}
+
+	for _, c := range []prometheus.Collector{h.requests, h.duration, h.inFlight} {
+		if err := registry.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// Start marks a request as in flight; call the returned func when it completes.
+func (h *HTTP) Start() func(route, method string, status int) {
+	start := time.Now()
+	h.inFlight.Inc()
+	return func(route, method string, status int) {
+		h.inFlight.Dec()
+		code := strconv.Itoa(status)
+		h.requests.WithLabelValues(route, method, code).Inc()
+		h.duration.WithLabelValues(route, method, code).Observe(time.Since(start).Seconds())
+	}
+}
+
+// HTTPTotals sums the request metrics over all routes.
+type HTTPTotals struct {
+	Requests        uint64
+	ServerErrors    uint64 // 5xx responses
+	DurationSeconds float64
+	InFlight        float64
+}
+
+// Totals reads the current request counters, e.g. for sampling rates over time.
+func (h *HTTP) Totals() HTTPTotals {
+	var t HTTPTotals
+
+	ch := make(chan prometheus.Metric, 64)
+	go func() {
+		h.requests.Collect(ch)
+		h.duration.Collect(ch)
+		h.inFlight.Collect(ch)
+		close(ch)
+	}()
+
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			continue
+		}
+		switch {
+		case pb.Counter != nil:
+			n := uint64(pb.Counter.GetValue())
+			t.Requests += n
+			for _, l := range pb.Label {
+				if l.GetName() == "status" && strings.HasPrefix(l.GetValue(), "5") {
+					t.ServerErrors += n
+				}
+			}
+		case pb.Histogram != nil:
+			t.DurationSeconds += pb.Histogram.GetSampleSum()
+		case pb.Gauge != nil:
+			t.InFlight = pb.Gauge.GetValue()
+		}
+	}
+	return t
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"test-go/pkg/infrastructure"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/redis/go-redis/v9"
+)
+
+// RegisterRedis exports the connection pool statistics of r.
+func RegisterRedis(r *infrastructure.RedisManager) error {
+	stat := func(pick func(*redis.PoolStats) uint32) func() float64 {
+		return func() float64 { return float64(pick(r.Client.PoolStats())) }
+	}
+	counter := func(name, help string, pick func(*redis.PoolStats) uint32) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: Namespace, Subsystem: "redis_pool", Name: name, Help: help,
+		}, stat(pick))
+	}
+	gauge := func(name, help string, pick func(*redis.PoolStats) uint32) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: Namespace, Subsystem: "redis_pool", Name: name, Help: help,
+		}, stat(pick))
+	}
+
+	for _, c := range []prometheus.Collector{
+		counter("hits_total", "Free connections found in the pool.", func(s *redis.PoolStats) uint32 { return s.Hits }),
+		counter("misses_total", "Free connections not found in the pool.", func(s *redis.PoolStats) uint32 { return s.Misses }),
+		counter("timeouts_total", "Waits for a connection that timed out.", func(s *redis.PoolStats) uint32 { return s.Timeouts }),
+		counter("stale_connections_total", "Stale connections removed from the pool.", func(s *redis.PoolStats) uint32 { return s.StaleConns }),
+		gauge("connections", "Connections in the pool.", func(s *redis.PoolStats) uint32 { return s.TotalConns }),
+		gauge("idle_connections", "Idle connections in the pool.", func(s *redis.PoolStats) uint32 { return s.IdleConns }),
+	} {
+		if err := Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RegisterPostgres exports the database/sql pool statistics of p as go_sql_* metrics.
+func RegisterPostgres(p *infrastructure.PostgresManager) error {
+	return Register(collectors.NewDBStatsCollector(p.DB, "postgres"))
+}
+
+// RegisterKafka exports produce and consume counts of k.
+func RegisterKafka(k *infrastructure.KafkaManager) error {
+	counter := func(name, help string, value func(infrastructure.KafkaStats) uint64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: Namespace, Subsystem: "kafka", Name: name, Help: help,
+		}, func() float64 { return float64(value(k.Stats())) })
+	}
+
+	for _, c := range []prometheus.Collector{
+		counter("produced_messages_total", "Messages published.", func(s infrastructure.KafkaStats) uint64 { return s.Produced }),
+		counter("produce_errors_total", "Messages that failed to publish.", func(s infrastructure.KafkaStats) uint64 { return s.ProduceErrors }),
+		counter("consumed_messages_total", "Messages received by consumers.", func(s infrastructure.KafkaStats) uint64 { return s.Consumed }),
+		counter("consume_errors_total", "Messages whose handler returned an error.", func(s infrastructure.KafkaStats) uint64 { return s.ConsumeErrors }),
+	} {
+		if err := Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RegisterCron exports per-job run counts, failures and durations of c.
+func RegisterCron(c *infrastructure.CronManager) error {
+	return Register(&cronCollector{cron: c})
+}
+
+var (
+	cronRunsDesc = prometheus.NewDesc(prometheus.BuildFQName(Namespace, "cron", "job_runs_total"),
+		"Completed runs of a cron job.", []string{"job"}, nil)
+	cronFailuresDesc = prometheus.NewDesc(prometheus.BuildFQName(Namespace, "cron", "job_failures_total"),
+		"Runs of a cron job that returned an error.", []string{"job"}, nil)
+	cronDurationDesc = prometheus.NewDesc(prometheus.BuildFQName(Namespace, "cron", "job_duration_seconds_total"),
+		"Total time spent running a cron job.", []string{"job"}, nil)
+	cronLastDurationDesc = prometheus.NewDesc(prometheus.BuildFQName(Namespace, "cron", "job_last_duration_seconds"),
+		"Duration of the most recent run of a cron job.", []string{"job"}, nil)
+)
+
+// cronCollector reads job statistics at scrape time so jobs added later are included.
+type cronCollector struct {
+	cron *infrastructure.CronManager
+}
+
+func (cc *cronCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cronRunsDesc
+	ch <- cronFailuresDesc
+	ch <- cronDurationDesc
+	ch <- cronLastDurationDesc
+}
+
+func (cc *cronCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, job := range cc.cron.GetJobs() {
+		ch <- prometheus.MustNewConstMetric(cronRunsDesc, prometheus.CounterValue, float64(job.Runs), job.Name)
+		ch <- prometheus.MustNewConstMetric(cronFailuresDesc, prometheus.CounterValue, float64(job.Failures), job.Name)
+		ch <- prometheus.MustNewConstMetric(cronDurationDesc, prometheus.CounterValue, job.TotalDuration.Seconds(), job.Name)
+		ch <- prometheus.MustNewConstMetric(cronLastDurationDesc, prometheus.GaugeValue, job.LastDuration.Seconds(), job.Name)
+	}
+}
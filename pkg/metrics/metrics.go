@@ -0,0 +1,56 @@
+// Package metrics exposes application metrics in Prometheus format. Everything is
+// registered on one registry, which services can add their own collectors to.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace prefixes the application's own metric names.
+const Namespace = "gobp"
+
+var registry = newRegistry()
+
+func newRegistry() *prometheus.Registry {
+	r := prometheus.NewRegistry()
+	r.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return r
+}
+
+// Registry returns the registry served by Handler.
+func Registry() *prometheus.Registry {
+	return registry
+}
+
+// Register adds a collector. Registering a collector that is already registered is not an error,
+// so services may register on every boot.
+func Register(c prometheus.Collector) error {
+	if err := registry.Register(c); err != nil {
+		if _, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// MustRegister adds collectors and panics if one conflicts with an existing metric.
+func MustRegister(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		if err := Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
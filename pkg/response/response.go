@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"time"
 
+	"test-go/pkg/tracing"
+
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
@@ -16,9 +18,10 @@ type Response struct {
 	Data          interface{}  `json:"data,omitempty"`
 	Error         *ErrorDetail `json:"error,omitempty"`
 	Meta          *Meta        `json:"meta,omitempty"`
-	Timestamp     int64        `json:"timestamp"`      // Unix Timestamp
-	Datetime      string       `json:"datetime"`       // ISO8601 Datetime
-	CorrelationID string       `json:"correlation_id"` // Request ID for tracking
+	Timestamp     int64        `json:"timestamp"`          // Unix Timestamp
+	Datetime      string       `json:"datetime"`           // ISO8601 Datetime
+	CorrelationID string       `json:"correlation_id"`     // Request ID for tracking
+	TraceID       string       `json:"trace_id,omitempty"` // OpenTelemetry trace ID when tracing is enabled
 }
 
 // ErrorDetail represents detailed error information
@@ -92,6 +95,7 @@ func Success(c echo.Context, data interface{}, message ...string) error {
 		Timestamp:     time.Now().Unix(),
 		Datetime:      time.Now().Format(time.RFC3339),
 		CorrelationID: getCorrelationID(c),
+		TraceID:       tracing.TraceID(c.Request().Context()),
 	})
 }
 
@@ -111,6 +115,7 @@ func SuccessWithMeta(c echo.Context, data interface{}, meta *Meta, message ...st
 		Timestamp:     time.Now().Unix(),
 		Datetime:      time.Now().Format(time.RFC3339),
 		CorrelationID: getCorrelationID(c),
+		TraceID:       tracing.TraceID(c.Request().Context()),
 	})
 }
 
@@ -129,6 +134,7 @@ func Created(c echo.Context, data interface{}, message ...string) error {
 		Timestamp:     time.Now().Unix(),
 		Datetime:      time.Now().Format(time.RFC3339),
 		CorrelationID: getCorrelationID(c),
+		TraceID:       tracing.TraceID(c.Request().Context()),
 	})
 }
 
@@ -220,9 +226,15 @@ func Error(c echo.Context, statusCode int, errorCode string, message string, det
 		Timestamp:     time.Now().Unix(),
 		Datetime:      time.Now().Format(time.RFC3339),
 		CorrelationID: getCorrelationID(c),
+		TraceID:       tracing.TraceID(c.Request().Context()),
 	})
 }
 
+// CorrelationID returns the request's correlation ID, generating and setting one if missing.
+func CorrelationID(c echo.Context) string {
+	return getCorrelationID(c)
+}
+
 // getCorrelationID extracts or generates the correlation ID
 func getCorrelationID(c echo.Context) string {
 	// Try standard Echo request ID
@@ -0,0 +1,273 @@
+package sysstats
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+// cgroup reads the CPU quota, CPU usage and memory of the cgroup this process runs
+// in, for v2 (unified) and v1 hierarchies.
+type cgroup struct {
+	version int
+	cpuDir  string // v2: the unified directory for everything
+	memDir  string
+}
+
+// detectCgroup returns nil outside Linux or when no cgroup filesystem is mounted.
+func detectCgroup() *cgroup {
+	paths := selfCgroups()
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err == nil {
+		dir := controllerDir(cgroupRoot, paths[""], "cgroup.procs")
+		return &cgroup{version: 2, cpuDir: dir, memDir: dir}
+	}
+	cpuMount := filepath.Join(cgroupRoot, "cpu,cpuacct")
+	if _, err := os.Stat(cpuMount); err != nil {
+		cpuMount = filepath.Join(cgroupRoot, "cpu")
+	}
+	memMount := filepath.Join(cgroupRoot, "memory")
+	if _, err := os.Stat(memMount); err != nil {
+		return nil
+	}
+	return &cgroup{
+		version: 1,
+		cpuDir:  controllerDir(cpuMount, paths["cpu"], "cpu.cfs_quota_us"),
+		memDir:  controllerDir(memMount, paths["memory"], "memory.limit_in_bytes"),
+	}
+}
+
+// selfCgroups maps controllers to this process's cgroup path from /proc/self/cgroup;
+// the v2 unified path is under "".
+func selfCgroups() map[string]string {
+	paths := make(map[string]string)
+	f, err := os.Open("/proc/self/cgroup")
+	if err != nil {
+		return paths
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		parts := strings.SplitN(sc.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[1] == "" {
+			paths[""] = parts[2]
+			continue
+		}
+		for _, ctrl := range strings.Split(parts[1], ",") {
+			paths[ctrl] = parts[2]
+		}
+	}
+	return paths
+}
+
+// controllerDir joins the process's cgroup path onto mount when that directory
+// exists. Inside a container with its own cgroup namespace the path is already the
+// mount root, or points at a host path that is not visible.
+func controllerDir(mount, path, probe string) string {
+	if path != "" && path != "/" {
+		dir := filepath.Join(mount, path)
+		if _, err := os.Stat(filepath.Join(dir, probe)); err == nil {
+			return dir
+		}
+	}
+	return mount
+}
+
+// cpuLimit returns the CPU quota in cores, 0 without a quota.
+func (c *cgroup) cpuLimit() float64 {
+	if c.version == 2 {
+		fields := strings.Fields(readString(filepath.Join(c.cpuDir, "cpu.max")))
+		if len(fields) != 2 || fields[0] == "max" {
+			return 0
+		}
+		quota, err1 := strconv.ParseFloat(fields[0], 64)
+		period, err2 := strconv.ParseFloat(fields[1], 64)
+		if err1 != nil || err2 != nil || period <= 0 {
+			return 0
+		}
+		return quota / period
+	}
+	quota, ok1 := readInt(filepath.Join(c.cpuDir, "cpu.cfs_quota_us"))
+	period, ok2 := readInt(filepath.Join(c.cpuDir, "cpu.cfs_period_us"))
+	if !ok1 || !ok2 || quota <= 0 || period <= 0 {
+		return 0
+	}
+	return float64(quota) / float64(period)
+}
+
+// cpuUsage returns the CPU time used by the cgroup since it was created.
+func (c *cgroup) cpuUsage() (time.Duration, bool) {
+	if c.version == 2 {
+		usec, ok := statValue(filepath.Join(c.cpuDir, "cpu.stat"), "usage_usec")
+		return time.Duration(usec) * time.Microsecond, ok
+	}
+	ns, ok := readInt(filepath.Join(c.cpuDir, "cpuacct.usage"))
+	if !ok {
+		// cpuacct may be mounted apart from cpu
+		ns, ok = readInt(filepath.Join(cgroupRoot, "cpuacct", "cpuacct.usage"))
+	}
+	return time.Duration(ns), ok
+}
+
+// memory returns the working set (usage without reclaimable inactive file pages,
+// as Kubernetes counts it) and the limit, 0 without one.
+func (c *cgroup) memory() (usage, limit uint64, ok bool) {
+	var inactive uint64
+	if c.version == 2 {
+		current, ok := readInt(filepath.Join(c.memDir, "memory.current"))
+		if !ok {
+			return 0, 0, false
+		}
+		usage = uint64(current)
+		if v, ok := statValue(filepath.Join(c.memDir, "memory.stat"), "inactive_file"); ok {
+			inactive = uint64(v)
+		}
+		if lim, ok := readInt(filepath.Join(c.memDir, "memory.max")); ok {
+			limit = uint64(lim)
+		}
+	} else {
+		current, ok := readInt(filepath.Join(c.memDir, "memory.usage_in_bytes"))
+		if !ok {
+			return 0, 0, false
+		}
+		usage = uint64(current)
+		if v, ok := statValue(filepath.Join(c.memDir, "memory.stat"), "total_inactive_file"); ok {
+			inactive = uint64(v)
+		}
+		// v1 reports "no limit" as a huge page-aligned number
+		if lim, ok := readInt(filepath.Join(c.memDir, "memory.limit_in_bytes")); ok && lim < 1<<62 {
+			limit = uint64(lim)
+		}
+	}
+	if inactive < usage {
+		usage -= inactive
+	}
+	return usage, limit, true
+}
+
+// CgroupStats are the limits and usage of the cgroup this process runs in. Values
+// a hierarchy does not provide stay zero; limits are 0 when unlimited.
+type CgroupStats struct {
+	Version int    `json:"version"`
+	Path    string `json:"path"`
+
+	CPU struct {
+		LimitCores       float64 `json:"limit_cores"`
+		Weight           int64   `json:"weight"` // v2 cpu.weight, or v1 cpu.shares
+		UsageSeconds     float64 `json:"usage_seconds"`
+		Periods          int64   `json:"periods"`
+		ThrottledPeriods int64   `json:"throttled_periods"`
+		ThrottledSeconds float64 `json:"throttled_seconds"`
+	} `json:"cpu"`
+
+	Memory struct {
+		Current    uint64 `json:"current_bytes"`
+		WorkingSet uint64 `json:"working_set_bytes"`
+		Max        uint64 `json:"max_bytes"`
+		High       uint64 `json:"high_bytes"` // v2 only
+		Swap       uint64 `json:"swap_bytes"`
+		OOMEvents  int64  `json:"oom_events"`
+		OOMKills   int64  `json:"oom_kills"`
+	} `json:"memory"`
+
+	Pids struct {
+		Current int64 `json:"current"`
+		Max     int64 `json:"max"`
+	} `json:"pids"`
+}
+
+// stats reads everything in CgroupStats. It touches a dozen small files, so it is
+// read on demand rather than every sample.
+func (c *cgroup) stats() CgroupStats {
+	var st CgroupStats
+	st.Version = c.version
+	st.Path = c.memDir
+	st.CPU.LimitCores = c.cpuLimit()
+	if usage, ok := c.cpuUsage(); ok {
+		st.CPU.UsageSeconds = usage.Seconds()
+	}
+	st.Memory.WorkingSet, st.Memory.Max, _ = c.memory()
+
+	if c.version == 2 {
+		st.CPU.Weight, _ = readInt(filepath.Join(c.cpuDir, "cpu.weight"))
+		stat := filepath.Join(c.cpuDir, "cpu.stat")
+		st.CPU.Periods, _ = statValue(stat, "nr_periods")
+		st.CPU.ThrottledPeriods, _ = statValue(stat, "nr_throttled")
+		if usec, ok := statValue(stat, "throttled_usec"); ok {
+			st.CPU.ThrottledSeconds = float64(usec) / 1e6
+		}
+
+		if v, ok := readInt(filepath.Join(c.memDir, "memory.current")); ok {
+			st.Memory.Current = uint64(v)
+		}
+		if v, ok := readInt(filepath.Join(c.memDir, "memory.high")); ok {
+			st.Memory.High = uint64(v)
+		}
+		if v, ok := readInt(filepath.Join(c.memDir, "memory.swap.current")); ok {
+			st.Memory.Swap = uint64(v)
+		}
+		events := filepath.Join(c.memDir, "memory.events")
+		st.Memory.OOMEvents, _ = statValue(events, "oom")
+		st.Memory.OOMKills, _ = statValue(events, "oom_kill")
+
+		st.Pids.Current, _ = readInt(filepath.Join(c.memDir, "pids.current"))
+		st.Pids.Max, _ = readInt(filepath.Join(c.memDir, "pids.max"))
+		return st
+	}
+
+	st.CPU.Weight, _ = readInt(filepath.Join(c.cpuDir, "cpu.shares"))
+	stat := filepath.Join(c.cpuDir, "cpu.stat")
+	st.CPU.Periods, _ = statValue(stat, "nr_periods")
+	st.CPU.ThrottledPeriods, _ = statValue(stat, "nr_throttled")
+	if ns, ok := statValue(stat, "throttled_time"); ok {
+		st.CPU.ThrottledSeconds = float64(ns) / 1e9
+	}
+
+	if v, ok := readInt(filepath.Join(c.memDir, "memory.usage_in_bytes")); ok {
+		st.Memory.Current = uint64(v)
+	}
+	if v, ok := statValue(filepath.Join(c.memDir, "memory.stat"), "total_swap"); ok {
+		st.Memory.Swap = uint64(v)
+	}
+	st.Memory.OOMKills, _ = statValue(filepath.Join(c.memDir, "memory.oom_control"), "oom_kill")
+
+	paths := selfCgroups()
+	pidsDir := controllerDir(filepath.Join(cgroupRoot, "pids"), paths["pids"], "pids.current")
+	st.Pids.Current, _ = readInt(filepath.Join(pidsDir, "pids.current"))
+	st.Pids.Max, _ = readInt(filepath.Join(pidsDir, "pids.max"))
+	return st
+}
+
+func readString(path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// readInt parses a single-number file; "max" and missing files report false.
+func readInt(path string) (int64, bool) {
+	v, err := strconv.ParseInt(readString(path), 10, 64)
+	return v, err == nil
+}
+
+// statValue returns a key of a flat "key value" stat file such as cpu.stat.
+func statValue(path, key string) (int64, bool) {
+	for _, line := range strings.Split(readString(path), "\n") {
+		k, v, found := strings.Cut(line, " ")
+		if found && k == key {
+			n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			return n, err == nil
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,181 @@
+package sysstats
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// Runtime is the Go runtime's view of this process: goroutines, the heap and the
+// garbage collector.
+type Runtime struct {
+	Goroutines int `json:"goroutines"`
+	GOMAXPROCS int `json:"gomaxprocs"`
+
+	Heap struct {
+		Alloc    uint64 `json:"alloc_bytes"`    // live objects
+		Inuse    uint64 `json:"inuse_bytes"`    // spans holding objects
+		Idle     uint64 `json:"idle_bytes"`     // spans waiting for reuse
+		Released uint64 `json:"released_bytes"` // idle spans returned to the OS
+		Sys      uint64 `json:"sys_bytes"`
+		Objects  uint64 `json:"objects"`
+		NextGC   uint64 `json:"next_gc_bytes"`
+	} `json:"heap"`
+	StackInuse uint64 `json:"stack_inuse_bytes"`
+	Sys        uint64 `json:"sys_bytes"` // everything obtained from the OS
+
+	GC struct {
+		Count        uint32    `json:"count"`
+		LastAt       time.Time `json:"last_at,omitzero"`
+		LastPauseMs  float64   `json:"last_pause_ms"`
+		PauseTotalMs float64   `json:"pause_total_ms"`
+		// over the most recent pauses the runtime keeps, up to 256
+		P50PauseMs  float64 `json:"p50_pause_ms"`
+		P99PauseMs  float64 `json:"p99_pause_ms"`
+		MaxPauseMs  float64 `json:"max_pause_ms"`
+		CPUFraction float64 `json:"cpu_fraction"`
+	} `json:"gc"`
+}
+
+// ReadRuntime reads the runtime statistics. It briefly stops the world, so it is
+// meant for on-demand views rather than tight loops.
+func ReadRuntime() Runtime {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	var rt Runtime
+	rt.Goroutines = runtime.NumGoroutine()
+	rt.GOMAXPROCS = runtime.GOMAXPROCS(0)
+	rt.Heap.Alloc = ms.HeapAlloc
+	rt.Heap.Inuse = ms.HeapInuse
+	rt.Heap.Idle = ms.HeapIdle
+	rt.Heap.Released = ms.HeapReleased
+	rt.Heap.Sys = ms.HeapSys
+	rt.Heap.Objects = ms.HeapObjects
+	rt.Heap.NextGC = ms.NextGC
+	rt.StackInuse = ms.StackInuse
+	rt.Sys = ms.Sys
+
+	rt.GC.Count = ms.NumGC
+	rt.GC.PauseTotalMs = float64(ms.PauseTotalNs) / 1e6
+	rt.GC.CPUFraction = ms.GCCPUFraction
+	if ms.NumGC == 0 {
+		return rt
+	}
+	rt.GC.LastAt = time.Unix(0, int64(ms.LastGC))
+	rt.GC.LastPauseMs = float64(ms.PauseNs[(ms.NumGC+255)%256]) / 1e6
+
+	n := min(int(ms.NumGC), len(ms.PauseNs))
+	pauses := make([]uint64, n)
+	copy(pauses, ms.PauseNs[:n])
+	sort.Slice(pauses, func(i, j int) bool { return pauses[i] < pauses[j] })
+	rt.GC.P50PauseMs = float64(pauses[n*50/100]) / 1e6
+	rt.GC.P99PauseMs = float64(pauses[min(n*99/100, n-1)]) / 1e6
+	rt.GC.MaxPauseMs = float64(pauses[n-1]) / 1e6
+	return rt
+}
+
+// Cgroup reads the limits and usage of the cgroup this process runs in; ok is false
+// outside one.
+func (s *Sampler) Cgroup() (CgroupStats, bool) {
+	if s.cgroup == nil {
+		return CgroupStats{}, false
+	}
+	return s.cgroup.stats(), true
+}
+
+// FilesystemPath is a path the application uses, such as its upload directory.
+type FilesystemPath struct {
+	Label string `json:"label"`
+	Path  string `json:"path"`
+}
+
+// Filesystem is the usage of one mounted filesystem and the paths that live on it.
+type Filesystem struct {
+	Mountpoint        string           `json:"mountpoint"`
+	Device            string           `json:"device"`
+	Fstype            string           `json:"fstype"`
+	Total             uint64           `json:"total_bytes"`
+	Used              uint64           `json:"used_bytes"`
+	Free              uint64           `json:"free_bytes"`
+	UsedPercent       float64          `json:"used_percent"`
+	InodesUsedPercent float64          `json:"inodes_used_percent"`
+	Paths             []FilesystemPath `json:"paths"`
+	Error             string           `json:"error,omitempty"`
+}
+
+// Filesystems reports the filesystem each of paths lives on, once per mountpoint.
+// A path that does not exist yet is resolved through its nearest existing parent,
+// so a log directory that is created on first write is still accounted for.
+func Filesystems(paths []FilesystemPath) []Filesystem {
+	parts, _ := disk.Partitions(true)
+
+	var out []Filesystem
+	index := make(map[string]int)
+	for _, p := range paths {
+		if p.Path == "" {
+			continue
+		}
+		abs, err := filepath.Abs(p.Path)
+		if err != nil {
+			continue
+		}
+		p.Path = abs
+		existing := existingParent(abs)
+
+		var part disk.PartitionStat
+		for _, cand := range parts {
+			if within(existing, cand.Mountpoint) && len(cand.Mountpoint) > len(part.Mountpoint) {
+				part = cand
+			}
+		}
+		if part.Mountpoint == "" {
+			part.Mountpoint = existing
+		}
+
+		if i, ok := index[part.Mountpoint]; ok {
+			out[i].Paths = append(out[i].Paths, p)
+			continue
+		}
+		fs := Filesystem{Mountpoint: part.Mountpoint, Device: part.Device, Fstype: part.Fstype, Paths: []FilesystemPath{p}}
+		if du, err := disk.Usage(existing); err == nil {
+			fs.Total, fs.Used, fs.Free = du.Total, du.Used, du.Free
+			fs.UsedPercent, fs.InodesUsedPercent = du.UsedPercent, du.InodesUsedPercent
+			if fs.Fstype == "" {
+				fs.Fstype = du.Fstype
+			}
+		} else {
+			fs.Error = err.Error()
+		}
+		index[part.Mountpoint] = len(out)
+		out = append(out, fs)
+	}
+	return out
+}
+
+// existingParent walks up from path to the first component that exists.
+func existingParent(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// within reports whether path is mount or below it.
+func within(path, mount string) bool {
+	if mount == "/" || path == mount {
+		return true
+	}
+	return strings.HasPrefix(path, strings.TrimSuffix(mount, string(filepath.Separator))+string(filepath.Separator))
+}
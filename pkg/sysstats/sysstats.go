@@ -0,0 +1,274 @@
+// Package sysstats samples CPU, memory, disk, network and process statistics once
+// per interval in a single goroutine and publishes each sample to any number of
+// subscribers, so readers never block on a measurement. Inside a container the CPU
+// and memory figures are those of the cgroup, relative to its quota and limit.
+package sysstats
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/mem"
+	psnet "github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// CPU usage. Percent is of the cgroup quota when there is one, otherwise of all
+// host cores.
+type CPU struct {
+	Percent     float64 `json:"percent"`
+	HostPercent float64 `json:"host_percent"`
+	Cores       int     `json:"cores"`       // logical host cores
+	LimitCores  float64 `json:"limit_cores"` // cgroup quota, 0 without one
+}
+
+// Memory usage. Total is the cgroup limit when there is one.
+type Memory struct {
+	Total       uint64  `json:"total_bytes"`
+	Used        uint64  `json:"used_bytes"`
+	UsedPercent float64 `json:"used_percent"`
+	HostTotal   uint64  `json:"host_total_bytes"`
+	HostUsed    uint64  `json:"host_used_bytes"`
+	Limited     bool    `json:"limited"` // a cgroup memory limit applies
+}
+
+// Disk usage of the root filesystem.
+type Disk struct {
+	Path        string  `json:"path"`
+	Total       uint64  `json:"total_bytes"`
+	Used        uint64  `json:"used_bytes"`
+	UsedPercent float64 `json:"used_percent"`
+}
+
+// Network totals over all interfaces, and rates over the last interval.
+type Network struct {
+	BytesSent  uint64  `json:"bytes_sent"`
+	BytesRecv  uint64  `json:"bytes_recv"`
+	SentPerSec float64 `json:"sent_per_sec"`
+	RecvPerSec float64 `json:"recv_per_sec"`
+}
+
+// Process describes this process. CPUPercent is of one core, so it can exceed 100.
+type Process struct {
+	PID        int     `json:"pid"`
+	CPUPercent float64 `json:"cpu_percent"`
+	RSS        uint64  `json:"rss_bytes"`
+	OpenFDs    int32   `json:"open_fds"`
+	Threads    int32   `json:"threads"`
+	Goroutines int     `json:"goroutines"`
+}
+
+// Sample is one measurement of everything.
+type Sample struct {
+	Time    time.Time `json:"time"`
+	CPU     CPU       `json:"cpu"`
+	Memory  Memory    `json:"memory"`
+	Disk    Disk      `json:"disk"`
+	Network Network   `json:"network"`
+	Process Process   `json:"process"`
+	Cgroup  int       `json:"cgroup"` // cgroup version, 0 when none was found
+}
+
+// Sampler takes a Sample every interval.
+type Sampler struct {
+	interval time.Duration
+	cgroup   *cgroup
+	proc     *process.Process
+	diskPath string
+	start    sync.Once
+
+	// previous readings for rates, only touched by the sampling goroutine
+	prevAt       time.Time
+	prevCgroup   time.Duration
+	prevNet      Network
+	cgroupUsable bool
+
+	mu     sync.RWMutex
+	latest Sample
+	subs   map[chan Sample]struct{}
+}
+
+var (
+	defaultOnce    sync.Once
+	defaultSampler *Sampler
+)
+
+// Default returns the process-wide sampler, sampling every second from the first call.
+func Default() *Sampler {
+	defaultOnce.Do(func() {
+		defaultSampler = New(time.Second)
+		defaultSampler.Start(context.Background())
+	})
+	return defaultSampler
+}
+
+// New returns a sampler; call Start to begin sampling.
+func New(interval time.Duration) *Sampler {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	s := &Sampler{
+		interval: interval,
+		cgroup:   detectCgroup(),
+		diskPath: "/",
+		subs:     make(map[chan Sample]struct{}),
+	}
+	if runtime.GOOS == "windows" {
+		s.diskPath = "C:\\"
+	}
+	if p, err := process.NewProcess(int32(os.Getpid())); err == nil {
+		s.proc = p
+	}
+	return s
+}
+
+// Start takes a sample right away and then every interval until ctx is done. Later
+// calls do nothing.
+func (s *Sampler) Start(ctx context.Context) {
+	s.start.Do(func() {
+		s.sample()
+		go func() {
+			ticker := time.NewTicker(s.interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					s.sample()
+				}
+			}
+		}()
+	})
+}
+
+// Interval returns the sampling interval.
+func (s *Sampler) Interval() time.Duration { return s.interval }
+
+// Latest returns the most recent sample; ok is false before the first one.
+func (s *Sampler) Latest() (Sample, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.latest, !s.latest.Time.IsZero()
+}
+
+// Subscribe returns a channel receiving every new sample and a function that ends
+// the subscription. A subscriber that falls behind gets the newest sample only.
+func (s *Sampler) Subscribe() (<-chan Sample, func()) {
+	ch := make(chan Sample, 1)
+	s.mu.Lock()
+	s.subs[ch] = struct{}{}
+	s.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.subs, ch)
+			s.mu.Unlock()
+		})
+	}
+}
+
+func (s *Sampler) sample() {
+	now := time.Now()
+	smp := Sample{Time: now}
+	elapsed := now.Sub(s.prevAt).Seconds()
+	first := s.prevAt.IsZero()
+	s.prevAt = now
+
+	// Host CPU: a zero interval measures since the previous call, i.e. the last interval
+	smp.CPU.Cores, _ = cpu.Counts(true)
+	if p, err := cpu.Percent(0, false); err == nil && len(p) > 0 {
+		smp.CPU.HostPercent = p[0]
+	}
+	smp.CPU.Percent = smp.CPU.HostPercent
+
+	if vm, err := mem.VirtualMemory(); err == nil {
+		smp.Memory = Memory{Total: vm.Total, Used: vm.Used, UsedPercent: vm.UsedPercent, HostTotal: vm.Total, HostUsed: vm.Used}
+	}
+
+	if s.cgroup != nil {
+		smp.Cgroup = s.cgroup.version
+		s.sampleCgroup(&smp, elapsed, first)
+	}
+
+	if du, err := disk.Usage(s.diskPath); err == nil {
+		smp.Disk = Disk{Path: du.Path, Total: du.Total, Used: du.Used, UsedPercent: du.UsedPercent}
+	}
+
+	if io, err := psnet.IOCounters(false); err == nil && len(io) > 0 {
+		smp.Network.BytesSent, smp.Network.BytesRecv = io[0].BytesSent, io[0].BytesRecv
+		if !first && elapsed > 0 && smp.Network.BytesSent >= s.prevNet.BytesSent && smp.Network.BytesRecv >= s.prevNet.BytesRecv {
+			smp.Network.SentPerSec = float64(smp.Network.BytesSent-s.prevNet.BytesSent) / elapsed
+			smp.Network.RecvPerSec = float64(smp.Network.BytesRecv-s.prevNet.BytesRecv) / elapsed
+		}
+		s.prevNet = smp.Network
+	}
+
+	smp.Process = Process{PID: os.Getpid(), Goroutines: runtime.NumGoroutine()}
+	if s.proc != nil {
+		if pct, err := s.proc.Percent(0); err == nil {
+			smp.Process.CPUPercent = pct
+		}
+		if mi, err := s.proc.MemoryInfo(); err == nil {
+			smp.Process.RSS = mi.RSS
+		}
+		if n, err := s.proc.NumFDs(); err == nil {
+			smp.Process.OpenFDs = n
+		}
+		if n, err := s.proc.NumThreads(); err == nil {
+			smp.Process.Threads = n
+		}
+	}
+
+	s.publish(smp)
+}
+
+// sampleCgroup replaces the host CPU and memory figures with the cgroup's. CPU usage
+// is relative to the quota, or to all host cores without one.
+func (s *Sampler) sampleCgroup(smp *Sample, elapsed float64, first bool) {
+	smp.CPU.LimitCores = s.cgroup.cpuLimit()
+	if usage, ok := s.cgroup.cpuUsage(); ok {
+		capacity := smp.CPU.LimitCores
+		if capacity <= 0 {
+			capacity = float64(smp.CPU.Cores)
+		}
+		if !first && s.cgroupUsable && elapsed > 0 && capacity > 0 && usage >= s.prevCgroup {
+			smp.CPU.Percent = (usage - s.prevCgroup).Seconds() / elapsed / capacity * 100
+		}
+		s.prevCgroup, s.cgroupUsable = usage, true
+	}
+
+	if used, limit, ok := s.cgroup.memory(); ok && limit > 0 && (smp.Memory.HostTotal == 0 || limit < smp.Memory.HostTotal) {
+		smp.Memory.Total = limit
+		smp.Memory.Used = used
+		smp.Memory.UsedPercent = float64(used) / float64(limit) * 100
+		smp.Memory.Limited = true
+	}
+}
+
+// publish stores smp and hands it to every subscriber, replacing a sample a slow
+// subscriber has not read yet.
+func (s *Sampler) publish(smp Sample) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latest = smp
+	for ch := range s.subs {
+		select {
+		case ch <- smp:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- smp
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+// GormPlugin creates a client span for every GORM operation, as a child of the
+// span in the statement context (use db.WithContext(ctx)).
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string { return "tracing" }
+
+func (p GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	hooks := []struct {
+		op     string
+		before func(string, func(*gorm.DB)) error
+		after  func(string, func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+
+	for _, h := range hooks {
+		op := h.op
+		if err := h.before("tracing:before_"+op, func(tx *gorm.DB) { p.before(tx, op) }); err != nil {
+			return err
+		}
+		if err := h.after("tracing:after_"+op, p.after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (GormPlugin) before(tx *gorm.DB, op string) {
+	if tx.Statement == nil || tx.Statement.Context == nil {
+		return
+	}
+	name := "gorm." + op
+	if tx.Statement.Table != "" {
+		name += " " + tx.Statement.Table
+	}
+	ctx, span := Tracer().Start(tx.Statement.Context, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNamePostgreSQL, semconv.DBOperationName(op)),
+	)
+	tx.Statement.Context = ctx
+	tx.InstanceSet(gormSpanKey, span)
+}
+
+func (GormPlugin) after(tx *gorm.DB) {
+	v, ok := tx.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span := v.(trace.Span)
+
+	if sql := strings.TrimSpace(tx.Statement.SQL.String()); sql != "" {
+		span.SetAttributes(semconv.DBQueryText(sql))
+	}
+	span.SetAttributes(attribute.Int64("db.rows_affected", tx.Statement.RowsAffected))
+
+	err := tx.Error
+	if err == gorm.ErrRecordNotFound {
+		err = nil
+	}
+	End(span, err)
+}
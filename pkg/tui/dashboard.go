@@ -6,11 +6,11 @@ import (
 	"strings"
 	"time"
 
+	"test-go/pkg/sysstats"
+
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/shirou/gopsutil/v3/cpu"
-	"github.com/shirou/gopsutil/v3/mem"
 )
 
 // DashboardConfig contains configuration for the dashboard TUI
@@ -163,14 +163,12 @@ func (m DashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.lastUpdate = time.Now()
 		m.goroutines = runtime.NumGoroutine()
 
-		// Update system stats
-		if v, err := mem.VirtualMemory(); err == nil {
-			m.memPercent = v.UsedPercent
-			m.memUsed = v.Used / 1024 / 1024
-			m.memTotal = v.Total / 1024 / 1024
-		}
-		if c, err := cpu.Percent(0, false); err == nil && len(c) > 0 {
-			m.cpuPercent = c[0]
+		// Update system stats from the shared sampler
+		if smp, ok := sysstats.Default().Latest(); ok {
+			m.memPercent = smp.Memory.UsedPercent
+			m.memUsed = smp.Memory.Used / 1024 / 1024
+			m.memTotal = smp.Memory.Total / 1024 / 1024
+			m.cpuPercent = smp.CPU.Percent
 		}
 
 		return m, tea.Batch(m.spinner.Tick, dashTickCmd())
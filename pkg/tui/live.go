@@ -1,7 +1,10 @@
 package tui
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -364,10 +367,17 @@ func (t *LiveTUI) Write(p []byte) (n int, err error) {
 	return len(p), nil
 }
 
-// parseLogLine extracts the level and clean message from a zerolog console output line
+// parseLogLine extracts the level and clean message from a log line. JSON events from
+// the log pipeline are preferred; zerolog console output is still accepted.
 // Example input: "15:00:51 INF Scheduled Cron Job job=health_check schedule="*/10 * * * * *""
 // Returns: level="info", message="Scheduled Cron Job job=health_check schedule="*/10 * * * * *""
 func parseLogLine(line string) (level, message string) {
+	if strings.HasPrefix(line, "{") {
+		if level, message, ok := parseLogEvent(line); ok {
+			return level, message
+		}
+	}
+
 	level = "info" // default
 
 	// Split by space to find components
@@ -418,6 +428,57 @@ func parseLogLine(line string) (level, message string) {
 	return level, message
 }
 
+// parseLogEvent renders a JSON log event as "[logger] message key=value ...", with
+// the fields sorted by key.
+func parseLogEvent(line string) (level, message string, ok bool) {
+	var ev map[string]interface{}
+	if json.Unmarshal([]byte(line), &ev) != nil {
+		return "", "", false
+	}
+
+	level, _ = ev["level"].(string)
+	switch level {
+	case "":
+		level = "info"
+	case "trace":
+		level = "debug"
+	case "panic":
+		level = "fatal"
+	}
+
+	var b strings.Builder
+	if name, _ := ev["logger"].(string); name != "" {
+		b.WriteString("[" + name + "] ")
+	}
+	msg, _ := ev["message"].(string)
+	b.WriteString(msg)
+
+	keys := make([]string, 0, len(ev))
+	for k := range ev {
+		switch k {
+		case "time", "level", "message", "logger":
+		default:
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		var v string
+		switch val := ev[k].(type) {
+		case string:
+			v = val
+			if strings.ContainsAny(v, " \t\"") {
+				v = strconv.Quote(v)
+			}
+		default:
+			raw, _ := json.Marshal(val)
+			v = string(raw)
+		}
+		fmt.Fprintf(&b, " %s=%s", k, v)
+	}
+	return level, b.String(), true
+}
+
 // RunLiveTUI runs the live TUI and blocks until quit
 func RunLiveTUI(cfg LiveConfig) error {
 	model := NewLiveModel(cfg)
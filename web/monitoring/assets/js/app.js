@@ -131,7 +131,15 @@ function app() {
                 name: 'General',
                 items: [
                     { id: 'dashboard', label: 'Dashboard', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><rect x="3" y="3" width="7" height="7"></rect><rect x="14" y="3" width="7" height="7"></rect><rect x="14" y="14" width="7" height="7"></rect><rect x="3" y="14" width="7" height="7"></rect></svg>' },
-                    { id: 'endpoints', label: 'Endpoints', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><polyline points="22 12 18 12 15 21 9 3 6 12 2 12"></polyline></svg>' }
+                    { id: 'endpoints', label: 'Endpoints', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><polyline points="22 12 18 12 15 21 9 3 6 12 2 12"></polyline></svg>' },
+                    { id: 'history', label: 'History', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><path d="M3 3v18h18"></path><polyline points="7 14 11 10 14 13 20 7"></polyline></svg>' }
+                ]
+            },
+            {
+                name: 'Alerting',
+                items: [
+                    { id: 'alerts', label: 'Alerts', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><path d="M18 8A6 6 0 0 0 6 8c0 7-3 9-3 9h18s-3-2-3-9"></path><path d="M13.73 21a2 2 0 0 1-3.46 0"></path></svg>' },
+                    { id: 'statuspage', label: 'Status Page', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><rect x="2" y="3" width="20" height="14" rx="2" ry="2"></rect><line x1="8" y1="21" x2="16" y2="21"></line><line x1="12" y1="17" x2="12" y2="21"></line></svg>' }
                 ]
             },
             {
@@ -142,7 +150,8 @@ function app() {
                     { id: 'kafka', label: 'Kafka', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><path d="M21 15v4a2 2 0 0 1-2 2H5a2 2 0 0 1-2-2v-4"></path><polyline points="7 10 12 15 17 10"></polyline><line x1="12" y1="15" x2="12" y2="3"></line></svg>' },
                     { id: 'storage', label: 'Storage', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><path d="M21 15v4a2 2 0 0 1-2 2H5a2 2 0 0 1-2-2v-4"></path><polyline points="21 12 21 12"></polyline><rect width="20" height="8" x="2" y="4" rx="2" ry="2"></rect><line x1="10" y1="8" x2="14" y2="8"></line></svg>' },
                     { id: 'system', label: 'System', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><rect x="2" y="2" width="20" height="8" rx="2" ry="2"></rect><rect x="2" y="14" width="20" height="8" rx="2" ry="2"></rect><line x1="6" y1="6" x2="6.01" y2="6"></line><line x1="6" y1="18" x2="6.01" y2="18"></line></svg>' },
-                    { id: 'external', label: 'External', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><circle cx="12" cy="12" r="10"></circle><line x1="2" y1="12" x2="22" y2="12"></line><path d="M12 2a15.3 15.3 0 0 1 4 10 15.3 15.3 0 0 1-4 10 15.3 15.3 0 0 1-4-10 15.3 15.3 0 0 1 4-10z"></path></svg>' }
+                    { id: 'external', label: 'External', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><circle cx="12" cy="12" r="10"></circle><line x1="2" y1="12" x2="22" y2="12"></line><path d="M12 2a15.3 15.3 0 0 1 4 10 15.3 15.3 0 0 1-4 10 15.3 15.3 0 0 1-4-10 15.3 15.3 0 0 1 4-10z"></path></svg>' },
+                    { id: 'synthetics', label: 'Synthetics', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><polyline points="9 11 12 14 22 4"></polyline><path d="M21 12v7a2 2 0 0 1-2 2H5a2 2 0 0 1-2-2V5a2 2 0 0 1 2-2h11"></path></svg>' }
                 ]
             },
             {
@@ -151,6 +160,24 @@ function app() {
                     { id: 'cron', label: 'Cron Jobs', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><circle cx="12" cy="12" r="10"></circle><polyline points="12 6 12 12 16 14"></polyline></svg>' }
                 ]
             },
+            {
+                name: 'Security',
+                items: [
+                    { id: 'audit', label: 'Audit Trail', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><path d="M12 22s8-4 8-10V5l-8-3-8 3v7c0 6 8 10 8 10z"></path><polyline points="9 12 11 14 15 10"></polyline></svg>' },
+                    { id: 'ipfilter', label: 'Network Access', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><circle cx="12" cy="12" r="10"></circle><line x1="4.93" y1="4.93" x2="19.07" y2="19.07"></line></svg>' },
+                    { id: 'apikeys', label: 'API Keys', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><path d="M21 2l-2 2m-7.61 7.61a5.5 5.5 0 1 1-7.778 7.778 5.5 5.5 0 0 1 7.777-7.777zm0 0L15.5 7.5m0 0l3 3L22 7l-3-3m-3.5 3.5L19 4"></path></svg>' }
+                ]
+            },
+            {
+                name: 'Debugging',
+                items: [
+                    { id: 'errors', label: 'Errors', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><path d="m21.73 18-8-14a2 2 0 0 0-3.48 0l-8 14A2 2 0 0 0 4 21h16a2 2 0 0 0 1.73-3Z"></path><line x1="12" y1="9" x2="12" y2="13"></line><line x1="12" y1="17" x2="12.01" y2="17"></line></svg>' },
+                    { id: 'logs', label: 'Log Search', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><path d="M14 2H6a2 2 0 0 0-2 2v16a2 2 0 0 0 2 2h12a2 2 0 0 0 2-2V8z"></path><polyline points="14 2 14 8 20 8"></polyline><line x1="16" y1="13" x2="8" y2="13"></line><line x1="16" y1="17" x2="8" y2="17"></line></svg>' },
+                    { id: 'loglevels', label: 'Log Levels', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><line x1="4" y1="21" x2="4" y2="14"></line><line x1="4" y1="10" x2="4" y2="3"></line><line x1="12" y1="21" x2="12" y2="12"></line><line x1="12" y1="8" x2="12" y2="3"></line><line x1="20" y1="21" x2="20" y2="16"></line><line x1="20" y1="12" x2="20" y2="3"></line><line x1="1" y1="14" x2="7" y2="14"></line><line x1="9" y1="8" x2="15" y2="8"></line><line x1="17" y1="16" x2="23" y2="16"></line></svg>' },
+                    { id: 'profiling', label: 'Profiling', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><circle cx="12" cy="12" r="10"></circle><polyline points="12 6 12 12 16 14"></polyline></svg>' },
+                    { id: 'captures', label: 'Captures', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><polyline points="16 18 22 12 16 6"></polyline><polyline points="8 6 2 12 8 18"></polyline></svg>' }
+                ]
+            },
             {
                 name: 'Other',
                 items: [
@@ -166,13 +193,25 @@ function app() {
         tabs: [
             { id: 'dashboard', label: 'Dashboard', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><rect x="3" y="3" width="7" height="7"></rect><rect x="14" y="3" width="7" height="7"></rect><rect x="14" y="14" width="7" height="7"></rect><rect x="3" y="14" width="7" height="7"></rect></svg>' },
             { id: 'endpoints', label: 'Endpoints', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><polyline points="22 12 18 12 15 21 9 3 6 12 2 12"></polyline></svg>' },
+            { id: 'history', label: 'History', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><path d="M3 3v18h18"></path><polyline points="7 14 11 10 14 13 20 7"></polyline></svg>' },
+            { id: 'alerts', label: 'Alerts', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><path d="M18 8A6 6 0 0 0 6 8c0 7-3 9-3 9h18s-3-2-3-9"></path><path d="M13.73 21a2 2 0 0 1-3.46 0"></path></svg>' },
+            { id: 'statuspage', label: 'Status Page', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><rect x="2" y="3" width="20" height="14" rx="2" ry="2"></rect><line x1="8" y1="21" x2="16" y2="21"></line><line x1="12" y1="17" x2="12" y2="21"></line></svg>' },
             { id: 'redis', label: 'Redis', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><circle cx="12" cy="12" r="10"></circle><line x1="12" y1="8" x2="12" y2="12"></line><line x1="12" y1="16" x2="12.01" y2="16"></line></svg>' },
             { id: 'postgres', label: 'Postgres', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><path d="M2 12h20"></path><path d="M12 2v20"></path><path d="M20 20a1 1 0 1 0 2 0a1 1 0 1 0-2 0"></path><path d="M4 20a1 1 0 1 0 2 0a1 1 0 1 0-2 0"></path><path d="M20 4a1 1 0 1 0 2 0a1 1 0 1 0-2 0"></path><path d="M4 4a1 1 0 1 0 2 0a1 1 0 1 0-2 0"></path></svg>' },
             { id: 'kafka', label: 'Kafka', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><path d="M21 15v4a2 2 0 0 1-2 2H5a2 2 0 0 1-2-2v-4"></path><polyline points="7 10 12 15 17 10"></polyline><line x1="12" y1="15" x2="12" y2="3"></line></svg>' },
             { id: 'storage', label: 'Storage', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><path d="M21 15v4a2 2 0 0 1-2 2H5a2 2 0 0 1-2-2v-4"></path><polyline points="21 12 21 12"></polyline><rect width="20" height="8" x="2" y="4" rx="2" ry="2"></rect><line x1="10" y1="8" x2="14" y2="8"></line></svg>' },
             { id: 'system', label: 'System', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><rect x="2" y="2" width="20" height="8" rx="2" ry="2"></rect><rect x="2" y="14" width="20" height="8" rx="2" ry="2"></rect><line x1="6" y1="6" x2="6.01" y2="6"></line><line x1="6" y1="18" x2="6.01" y2="18"></line></svg>' },
             { id: 'external', label: 'External', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><circle cx="12" cy="12" r="10"></circle><line x1="2" y1="12" x2="22" y2="12"></line><path d="M12 2a15.3 15.3 0 0 1 4 10 15.3 15.3 0 0 1-4 10 15.3 15.3 0 0 1-4-10 15.3 15.3 0 0 1 4-10z"></path></svg>' },
+            { id: 'synthetics', label: 'Synthetics', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><polyline points="9 11 12 14 22 4"></polyline><path d="M21 12v7a2 2 0 0 1-2 2H5a2 2 0 0 1-2-2V5a2 2 0 0 1 2-2h11"></path></svg>' },
             { id: 'cron', label: 'Cron Jobs', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><circle cx="12" cy="12" r="10"></circle><polyline points="12 6 12 12 16 14"></polyline></svg>' },
+            { id: 'audit', label: 'Audit Trail', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><path d="M12 22s8-4 8-10V5l-8-3-8 3v7c0 6 8 10 8 10z"></path><polyline points="9 12 11 14 15 10"></polyline></svg>' },
+            { id: 'ipfilter', label: 'Network Access', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><circle cx="12" cy="12" r="10"></circle><line x1="4.93" y1="4.93" x2="19.07" y2="19.07"></line></svg>' },
+            { id: 'apikeys', label: 'API Keys', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><path d="M21 2l-2 2m-7.61 7.61a5.5 5.5 0 1 1-7.778 7.778 5.5 5.5 0 0 1 7.777-7.777zm0 0L15.5 7.5m0 0l3 3L22 7l-3-3m-3.5 3.5L19 4"></path></svg>' },
+            { id: 'errors', label: 'Errors', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><path d="m21.73 18-8-14a2 2 0 0 0-3.48 0l-8 14A2 2 0 0 0 4 21h16a2 2 0 0 0 1.73-3Z"></path><line x1="12" y1="9" x2="12" y2="13"></line><line x1="12" y1="17" x2="12.01" y2="17"></line></svg>' },
+            { id: 'logs', label: 'Log Search', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><path d="M14 2H6a2 2 0 0 0-2 2v16a2 2 0 0 0 2 2h12a2 2 0 0 0 2-2V8z"></path><polyline points="14 2 14 8 20 8"></polyline><line x1="16" y1="13" x2="8" y2="13"></line><line x1="16" y1="17" x2="8" y2="17"></line></svg>' },
+            { id: 'loglevels', label: 'Log Levels', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><line x1="4" y1="21" x2="4" y2="14"></line><line x1="4" y1="10" x2="4" y2="3"></line><line x1="12" y1="21" x2="12" y2="12"></line><line x1="12" y1="8" x2="12" y2="3"></line><line x1="20" y1="21" x2="20" y2="16"></line><line x1="20" y1="12" x2="20" y2="3"></line><line x1="1" y1="14" x2="7" y2="14"></line><line x1="9" y1="8" x2="15" y2="8"></line><line x1="17" y1="16" x2="23" y2="16"></line></svg>' },
+            { id: 'profiling', label: 'Profiling', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><circle cx="12" cy="12" r="10"></circle><polyline points="12 6 12 12 16 14"></polyline></svg>' },
+            { id: 'captures', label: 'Captures', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><polyline points="16 18 22 12 16 6"></polyline><polyline points="8 6 2 12 8 18"></polyline></svg>' },
             { id: 'config', label: 'Config', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><path d="M12.22 2h-.44a2 2 0 0 0-2 2v.18a2 2 0 0 1-1 1.73l-.43.25a2 2 0 0 1-2 0l-.15-.08a2 2 0 0 0-2.73.73l-.22.38a2 2 0 0 0 .73 2.73l.15.1a2 2 0 0 1 1 1.72v.51a2 2 0 0 1-1 1.74l-.15.09a2 2 0 0 0-.73 2.73l.22.38a2 2 0 0 0 2.73.73l.15-.08a2 2 0 0 1 2 0l.43.25a2 2 0 0 1 1 1.73V20a2 2 0 0 0 2 2h.44a2 2 0 0 0 2-2v-.18a2 2 0 0 1 1-1.73l.43-.25a2 2 0 0 1 2 0l.15.08a2 2 0 0 0 2.73-.73l.22-.39a2 2 0 0 0-.73-2.73l-.15-.1a2 2 0 0 1-1-1.74v-.47a2 2 0 0 1 1-1.74l.15-.1a2 2 0 0 0 .73-2.73l-.22-.38a2 2 0 0 0-2.73-.73l-.15.08a2 2 0 0 1-2 0l-.43-.25a2 2 0 0 1-1-1.73V4a2 2 0 0 0-2-2z"></path><circle cx="12" cy="12" r="3"></circle></svg>' },
             { id: 'banner', label: 'Banner', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><path d="M4 19.5v-15A2.5 2.5 0 0 1 6.5 2H20v20H6.5a2.5 2.5 0 0 1 0-5H20"></path></svg>' },
             { id: 'settings', label: 'User Settings', icon: '<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"><path d="M19 21v-2a4 4 0 0 0-4-4H9a4 4 0 0 0-4 4v2"></path><circle cx="12" cy="7" r="4"></circle></svg>' },
@@ -193,12 +232,89 @@ function app() {
         bannerContent: '',
         monitoringConfig: { title: 'GoBP Admin', subtitle: 'Go Echo Boilerplate' }, // New
 
+        // Audit Trail
+        auditEntries: [],
+        auditMeta: { page: 1, total_pages: 1, total: 0 },
+        auditFilter: { actor: '', action: '', outcome: '', q: '' },
+        auditError: '',
+
+        // Log Search
+        logResults: [],
+        logMore: false,
+        logStats: null,
+        logFilter: { level: '', from: '1h', to: '', q: '', regex: '', field: '' },
+        logError: '',
+
+        // Log Levels
+        logLevels: [],
+        logDebugOverride: { enabled: false, header: '', max_ttl: '' },
+        logLevelForm: { module: '', level: 'debug', revert_after: '15m' },
+        debugTokenTTL: '15m',
+        debugToken: null,
+
+        // Profiling
+        profiling: { enabled: false, captures: [], kinds: [], auto: null },
+        profileForm: { kind: 'cpu', seconds: 10 },
+        profilingPoll: null,
+        goroutines: null,
+        expandedGoroutine: null,
+
+        // IP Allow/Deny Lists
+        ipFilter: { enabled: false, rules: [], trusted_proxies: [], client_ip: '' },
+        ipRuleForm: { scope: 'api', group: '', action: 'deny', cidr: '', note: '' },
+        blockedAttempts: [],
+        blockedTotal: 0,
+
+        // Scanner Bans
+        bans: { enabled: false, bans: [], ban_duration: '' },
+        banForm: { ip: '', reason: '', duration: '' },
+
+        // API Keys
+        apiKeys: { enabled: false, keys: [], auth_type: '', header: '' },
+        apiKeyForm: { name: '', scopes: ['read'], expires_in: '' },
+        newAPIKey: '',
+
+        // Metric History
+        historyEnabled: true,
+        historyNames: [],
+        historyMetric: 'system.cpu.percent',
+        historyRange: '1h',
+        historySeries: null,
+        historyLoading: false,
+
+        // Alerting
+        alerts: { enabled: false, alerts: [], history: [], rules: [], silences: [], maintenance: [], notifiers: [] },
+        silenceForm: { rule: '', duration: '2h', comment: '' },
+        alertTestRunning: false,
+
+        // Public Status Page
+        statusPage: { enabled: false, page: { components: [] }, components: [], incidents: [] },
+        incidentForm: { title: '', impact: 'minor', status: 'investigating', message: '', components: [] },
+        incidentUpdate: {},
+
+        // Error Tracking
+        errorTracking: { enabled: true, counts: {}, groups: [] },
+        errorFilter: 'open',
+        errorDetail: null,
+
+        // Request Captures
+        captures: [],
+        capturesEnabled: true,
+        captureTokens: { enabled: false, max_ttl: '' },
+        captureTokenTTL: '15m',
+        captureToken: null,
+        captureMeta: { page: 1, total_pages: 1, total: 0 },
+        captureFilter: { method: '', status: '', q: '' },
+        selectedCapture: null,
+        captureModalOpen: false,
+
         // User Settings
-        userSettings: { username: '', photoPath: '' },
+        userSettings: { username: '', photoPath: '', role: 'admin', method: 'password' },
         passwordForm: { current: '', new: '', confirm: '' },
 
         // System Data
         infraStats: { total: 0, active: 0, items: [] },
+        statusSources: {}, // refresh state of each /api/status source
         infraStatus: {}, // New
         pgInfo: {},
         pgQueries: [],
@@ -231,7 +347,15 @@ function app() {
         // New Infrastructure
         storage: {},
         system: { cpu: {}, memory: {}, disk: {} },
+        resources: { process: {}, runtime: { heap: {}, gc: {} }, cgroup: null, filesystems: [] },
         external: [],
+        externalDetail: null,
+
+        // Synthetic Checks
+        synthetics: [],
+        syntheticDetail: null,
+        syntheticPoll: null,
+        expandedRun: null,
 
         // System Graphs History
         sysHistory: {
@@ -375,6 +499,7 @@ function app() {
 
                     // Data Load
                     if (val === 'endpoints') this.fetchEndpoints();
+                    if (val === 'system') this.fetchResources();
                     if (val === 'redis') this.fetchRedisKeys();
                     if (val === 'postgres') {
                         this.fetchPgQueries();
@@ -388,6 +513,22 @@ function app() {
                     }
                     if (val === 'kafka') this.fetchKafka();
                     if (val === 'cron') this.fetchCronJobs();
+                    if (val === 'audit') this.fetchAudit(1);
+                    if (val === 'logs') this.searchLogs();
+                    if (val === 'errors') this.fetchErrors();
+                    if (val === 'synthetics') this.fetchSynthetics();
+                    if (val === 'statuspage') this.fetchStatusPage();
+                    if (val === 'loglevels') this.fetchLogLevels();
+                    if (val === 'profiling') this.fetchProfiling();
+                    if (val === 'captures') this.fetchCaptures(1);
+                    if (val === 'history') this.fetchHistory();
+                    if (val === 'alerts') this.fetchAlerts();
+                    if (val === 'apikeys') this.fetchAPIKeys();
+                    if (val === 'ipfilter') {
+                        this.fetchIPFilter();
+                        this.fetchBlockedAttempts();
+                        this.fetchBans();
+                    }
                     if (val === 'config') this.fetchConfig();
                     if (val === 'banner') this.fetchBanner();
                     if (val === 'settings') this.fetchUserSettings();
@@ -410,17 +551,21 @@ function app() {
         },
 
         async logout() {
+            let redirect = '/';
             try {
                 // POST to logout endpoint to clear session
-                await fetch('/logout', {
+                const res = await fetch('/logout', {
                     method: 'POST',
                     headers: this.getHeaders()
                 });
+                // Single sign-on sessions also end the identity provider session
+                const response = await res.json();
+                if (response.data?.redirect) redirect = response.data.redirect;
             } catch (error) {
                 // Silently handle logout error
             } finally {
                 // Always redirect to login page (replace history to prevent back button)
-                window.location.replace('/');
+                window.location.replace(redirect);
             }
         },
 
@@ -538,7 +683,8 @@ function app() {
         },
 
         connectLogs() {
-            const es = new EventSource('/api/logs');
+            // The browser resumes with Last-Event-ID after a reconnect
+            const es = new EventSource('/api/logs?backfill=100');
             this.logBuffer = [];
 
             es.onmessage = (e) => {
@@ -555,7 +701,7 @@ function app() {
                 const data = JSON.parse(logLine);
                 const time = data.time ? new Date(data.time).toLocaleTimeString() : '';
                 const level = (data.level || 'UNKNOWN').toUpperCase();
-                const msg = data.message || JSON.stringify(data);
+                const msg = (data.logger ? '[' + data.logger + '] ' : '') + (data.message || JSON.stringify(data));
 
                 let badgeClass = 'bg-gray-100 text-gray-700 dark:bg-gray-800 dark:text-gray-300';
                 if (level === 'INFO' || level === 'INF') badgeClass = 'bg-blue-100 text-blue-700 dark:bg-blue-900/30 dark:text-blue-300';
@@ -625,6 +771,7 @@ function app() {
                 // Services
                 const servicesData = data.services;
                 this.services = Array.isArray(servicesData) ? servicesData : [];
+                this.statusSources = data.sources || {};
                 this.serviceCount = this.services.filter(s => s.active).length;
 
                 // Infrastructure
@@ -635,15 +782,9 @@ function app() {
                     postgres: data.postgres && data.postgres.connected,
                     kafka: data.kafka && data.kafka.connected,
                     minio: data.storage && data.storage.connected,
-                    external: data.external && data.external.length > 0
+                    external: data.external && data.external.length > 0 && data.external.every(s => s.status !== 'down')
                 };
 
-                // data.external is likely a map or list? 
-                // HttpManager.GetStatus() returns map[string]interface{ "services": []... }?
-                // No, HttpManager GetStatus returns map with "services" list.
-                // Let's assume connected if at least one check ran? 
-                // Actually External tab shows individual status.
-
                 this.infraStatus = infra;
 
                 // Count active infrastructure
@@ -662,6 +803,7 @@ function app() {
                 this.kafka = data.kafka || {};
                 this.storage = data.storage || {};
                 this.external = data.external || [];
+                if (this.externalDetail && this.activeTab === 'external') this.fetchExternalDetail(this.externalDetail.name);
 
                 // System Graphs Data
                 if (data.system) {
@@ -679,6 +821,7 @@ function app() {
                         this.lastGraphUpdate = now;
                     }
                 }
+                if (this.activeTab === 'system') this.fetchResources();
 
                 // System Info (Host/IP) - Mapped from system_info (added in backend)
                 if (data.system_info) {
@@ -692,6 +835,21 @@ function app() {
             } catch (e) { console.error("Fetch status error", e); }
         },
 
+        async fetchResources() {
+            try {
+                const res = await fetch('/api/system/resources', { headers: this.getHeaders() });
+                const response = await res.json();
+                if (response.success) this.resources = response.data;
+            } catch (e) { console.error("Fetch resources error", e); }
+        },
+
+        // Sources whose last background refresh failed or that have gone stale
+        failingSources() {
+            return Object.entries(this.statusSources)
+                .filter(([, s]) => s.error || (s.stale && s.checked_at))
+                .map(([name, s]) => ({ name, error: s.error || 'stale', updated_at: s.updated_at }));
+        },
+
         async fetchDummyStatus() {
             try {
                 const res = await fetch('/api/logs/dummy/status', { headers: this.getHeaders() });
@@ -749,6 +907,809 @@ function app() {
             } catch (e) { this.cronJobs = []; }
         },
 
+        async fetchAudit(page = 1) {
+            const params = new URLSearchParams({ page, per_page: 25 });
+            for (const [k, v] of Object.entries(this.auditFilter)) {
+                if (v) params.set(k, v);
+            }
+            try {
+                const res = await fetch('/api/audit?' + params.toString(), { headers: this.getHeaders() });
+                const response = await res.json();
+                if (!response.success) {
+                    this.auditEntries = [];
+                    this.auditError = response.error?.message || 'Failed to load audit trail';
+                    return;
+                }
+                this.auditError = '';
+                this.auditEntries = response.data || [];
+                this.auditMeta = response.meta || { page: 1, total_pages: 1, total: 0 };
+            } catch (e) {
+                this.auditEntries = [];
+                this.auditError = 'Failed to load audit trail';
+            }
+        },
+
+        logQueryParams() {
+            const params = new URLSearchParams();
+            for (const [k, v] of Object.entries(this.logFilter)) {
+                if (k === 'field') continue;
+                if (v) params.set(k, v);
+            }
+            // "request_id:abc, user:bob" filters on several fields
+            for (const f of this.logFilter.field.split(',')) {
+                if (f.trim()) params.append('field', f.trim());
+            }
+            return params;
+        },
+
+        async searchLogs(older = false) {
+            const params = this.logQueryParams();
+            if (older && this.logResults.length) params.set('before', this.logResults[0].id);
+            params.set('limit', 200);
+            try {
+                const res = await fetch('/api/logs/query?' + params.toString(), { headers: this.getHeaders() });
+                const response = await res.json();
+                if (!response.success) {
+                    if (!older) this.logResults = [];
+                    this.logError = response.error?.message || 'Failed to search logs';
+                    return;
+                }
+                this.logError = '';
+                const entries = response.data.entries || [];
+                this.logResults = older ? entries.concat(this.logResults) : entries;
+                this.logMore = response.data.more;
+                this.logStats = response.data.stats;
+            } catch (e) {
+                this.logError = 'Failed to search logs';
+            }
+        },
+
+        logExportUrl(format) {
+            const params = this.logQueryParams();
+            params.set('format', format);
+            return '/api/logs/export?' + params.toString();
+        },
+
+        logLevelClass(level) {
+            if (level === 'error' || level === 'fatal' || level === 'panic') return 'bg-red-100 text-red-700 dark:bg-red-900/30 dark:text-red-300';
+            if (level === 'warn') return 'bg-yellow-100 text-yellow-700 dark:bg-yellow-900/30 dark:text-yellow-300';
+            if (level === 'debug' || level === 'trace') return 'bg-purple-100 text-purple-700 dark:bg-purple-900/30 dark:text-purple-300';
+            return 'bg-blue-100 text-blue-700 dark:bg-blue-900/30 dark:text-blue-300';
+        },
+
+        async fetchLogLevels() {
+            try {
+                const res = await fetch('/api/logging/levels', { headers: this.getHeaders() });
+                const response = await res.json();
+                if (!response.success) return;
+                this.logLevels = response.data.levels || [];
+                this.logDebugOverride = response.data.debug_override || { enabled: false };
+            } catch (e) { this.logLevels = []; }
+        },
+
+        async setLogLevel(module, level, revertAfter) {
+            try {
+                const res = await fetch('/api/logging/levels', {
+                    method: 'POST',
+                    headers: this.getHeaders(),
+                    body: JSON.stringify({ module, level, revert_after: revertAfter })
+                });
+                const response = await res.json();
+                if (!response.success) {
+                    this.showToast(response.error?.message || 'Failed to change log level', 'error');
+                    return;
+                }
+                this.showToast('Log level of ' + (module || 'root') + ' set to ' + response.data.level, 'success');
+                this.fetchLogLevels();
+            } catch (e) { this.showToast('Failed to change log level', 'error'); }
+        },
+
+        async resetLogLevel(module) {
+            try {
+                const res = await fetch('/api/logging/levels?module=' + encodeURIComponent(module), {
+                    method: 'DELETE',
+                    headers: this.getHeaders()
+                });
+                const response = await res.json();
+                if (!response.success) {
+                    this.showToast(response.error?.message || 'Failed to reset log level', 'error');
+                    return;
+                }
+                this.showToast('Log level of ' + (module || 'root') + ' reset', 'success');
+                this.fetchLogLevels();
+            } catch (e) { this.showToast('Failed to reset log level', 'error'); }
+        },
+
+        async createDebugToken() {
+            try {
+                const res = await fetch('/api/logging/debug-token', {
+                    method: 'POST',
+                    headers: this.getHeaders(),
+                    body: JSON.stringify({ ttl: this.debugTokenTTL })
+                });
+                const response = await res.json();
+                if (!response.success) {
+                    this.showToast(response.error?.message || 'Failed to create debug token', 'error');
+                    return;
+                }
+                this.debugToken = response.data;
+            } catch (e) { this.showToast('Failed to create debug token', 'error'); }
+        },
+
+        async createCaptureToken() {
+            try {
+                const res = await fetch('/api/captures/token', {
+                    method: 'POST',
+                    headers: this.getHeaders(),
+                    body: JSON.stringify({ ttl: this.captureTokenTTL })
+                });
+                const response = await res.json();
+                if (!response.success) {
+                    this.showToast(response.error?.message || 'Failed to create capture token', 'error');
+                    return;
+                }
+                this.captureToken = response.data;
+            } catch (e) { this.showToast('Failed to create capture token', 'error'); }
+        },
+
+        async fetchProfiling() {
+            clearTimeout(this.profilingPoll);
+            try {
+                const res = await fetch('/api/profiling', { headers: this.getHeaders() });
+                const response = await res.json();
+                if (!response.success) return;
+                this.profiling = response.data;
+                // Follow running captures until they finish
+                if (this.activeTab === 'profiling' && (this.profiling.captures || []).some(c => c.status === 'running')) {
+                    this.profilingPoll = setTimeout(() => this.fetchProfiling(), 2000);
+                }
+            } catch (e) { this.profiling = { enabled: false, captures: [], kinds: [], auto: null }; }
+        },
+
+        async captureProfile() {
+            try {
+                const res = await fetch('/api/profiling/captures', {
+                    method: 'POST',
+                    headers: this.getHeaders(),
+                    body: JSON.stringify({ kind: this.profileForm.kind, seconds: parseInt(this.profileForm.seconds) || 0 })
+                });
+                const response = await res.json();
+                if (!response.success) {
+                    this.showToast(response.error?.message || 'Failed to start capture', 'error');
+                    return;
+                }
+                this.showToast('Capturing ' + response.data.kind + ' profile', 'success');
+                this.fetchProfiling();
+            } catch (e) { this.showToast('Failed to start capture', 'error'); }
+        },
+
+        profileTimed(kind) {
+            return kind === 'cpu' || kind === 'mutex' || kind === 'block';
+        },
+
+        async fetchGoroutines() {
+            try {
+                const res = await fetch('/api/profiling/goroutines', { headers: this.getHeaders() });
+                const response = await res.json();
+                if (!response.success) {
+                    this.showToast(response.error?.message || 'Failed to load goroutines', 'error');
+                    return;
+                }
+                this.goroutines = response.data;
+                this.expandedGoroutine = null;
+            } catch (e) { this.showToast('Failed to load goroutines', 'error'); }
+        },
+
+        goroutineStates(states) {
+            return Object.entries(states || {}).map(([s, n]) => n + ' ' + s).join(', ');
+        },
+
+        async fetchExternalDetail(name) {
+            try {
+                const res = await fetch('/api/external/' + encodeURIComponent(name), { headers: this.getHeaders() });
+                const response = await res.json();
+                if (!response.success) {
+                    this.externalDetail = null;
+                    return;
+                }
+                this.externalDetail = response.data;
+            } catch (e) { this.externalDetail = null; }
+        },
+
+        async fetchSynthetics() {
+            clearTimeout(this.syntheticPoll);
+            try {
+                const res = await fetch('/api/synthetics', { headers: this.getHeaders() });
+                const response = await res.json();
+                this.synthetics = response.data || [];
+                if (this.syntheticDetail) this.fetchSyntheticRuns(this.syntheticDetail.check.name);
+                // Follow running checks until they finish
+                if (this.activeTab === 'synthetics' && this.synthetics.some(s => s.running)) {
+                    this.syntheticPoll = setTimeout(() => this.fetchSynthetics(), 2000);
+                }
+            } catch (e) { this.synthetics = []; }
+        },
+
+        async fetchSyntheticRuns(name) {
+            try {
+                const res = await fetch('/api/synthetics/' + encodeURIComponent(name), { headers: this.getHeaders() });
+                const response = await res.json();
+                if (!response.success) {
+                    this.syntheticDetail = null;
+                    return;
+                }
+                if (this.syntheticDetail?.check.name !== name) this.expandedRun = null;
+                this.syntheticDetail = response.data;
+            } catch (e) { this.syntheticDetail = null; }
+        },
+
+        async runSynthetic(name) {
+            try {
+                const res = await fetch('/api/synthetics/' + encodeURIComponent(name) + '/run', {
+                    method: 'POST',
+                    headers: this.getHeaders()
+                });
+                const response = await res.json();
+                if (!response.success) {
+                    this.showToast(response.error?.message || 'Failed to start check', 'error');
+                    return;
+                }
+                this.showToast('Running ' + name, 'success');
+                this.fetchSynthetics();
+            } catch (e) { this.showToast('Failed to start check', 'error'); }
+        },
+
+        externalStatusClass(status) {
+            if (status === 'up') return 'border-transparent bg-green-500 text-white shadow hover:bg-green-600';
+            if (status === 'degraded') return 'border-transparent bg-yellow-500 text-white shadow hover:bg-yellow-600';
+            if (status === 'unknown') return 'border-transparent bg-muted text-muted-foreground';
+            return 'border-transparent bg-destructive text-destructive-foreground shadow hover:bg-destructive/80';
+        },
+
+        formatUptime(v) {
+            return v === undefined || v < 0 ? '-' : v.toFixed(2) + '%';
+        },
+
+        // Latency of the recent probes, scaled to a 100x100 viewBox
+        externalLatencyPath() {
+            const pts = this.externalDetail?.history || [];
+            if (pts.length < 2) return '';
+            const top = Math.max(...pts.map(p => p.latency_ms), 1);
+            return 'M ' + pts.map((p, i) => `${(i / (pts.length - 1)) * 100},${100 - (p.latency_ms / top) * 100}`).join(' L ');
+        },
+
+        bucketClass(b) {
+            const pct = b.checks ? b.up / b.checks : 1;
+            if (pct >= 0.999) return 'bg-green-500';
+            if (pct >= 0.95) return 'bg-yellow-500';
+            return 'bg-red-500';
+        },
+
+        async fetchIPFilter() {
+            try {
+                const res = await fetch('/api/ipfilter', { headers: this.getHeaders() });
+                const response = await res.json();
+                this.ipFilter = response.data || { enabled: false, rules: [] };
+            } catch (e) { this.ipFilter = { enabled: false, rules: [] }; }
+        },
+
+        async fetchBlockedAttempts() {
+            try {
+                const res = await fetch('/api/ipfilter/blocked?limit=100', { headers: this.getHeaders() });
+                const response = await res.json();
+                this.blockedAttempts = response.data || [];
+                this.blockedTotal = response.meta?.total || 0;
+            } catch (e) { this.blockedAttempts = []; }
+        },
+
+        async addIPRule() {
+            if (!this.ipRuleForm.cidr) {
+                this.showToast('Enter an IP address or CIDR', 'error');
+                return;
+            }
+            try {
+                const res = await fetch('/api/ipfilter/rules', {
+                    method: 'POST',
+                    headers: this.getHeaders(),
+                    body: JSON.stringify(this.ipRuleForm)
+                });
+                const response = await res.json();
+                if (!response.success) {
+                    this.showToast(response.error?.message || 'Failed to add rule', 'error');
+                    return;
+                }
+                this.showToast('Rule added', 'success');
+                this.ipRuleForm.cidr = '';
+                this.ipRuleForm.note = '';
+                this.fetchIPFilter();
+            } catch (e) { this.showToast('Failed to add rule', 'error'); }
+        },
+
+        async removeIPRule(rule) {
+            if (!confirm(`Remove ${rule.action} rule for ${rule.cidr}?`)) return;
+            try {
+                const res = await fetch('/api/ipfilter/rules/' + encodeURIComponent(rule.id), {
+                    method: 'DELETE',
+                    headers: this.getHeaders()
+                });
+                const response = await res.json();
+                if (!response.success) {
+                    this.showToast(response.error?.message || 'Failed to remove rule', 'error');
+                    return;
+                }
+                this.showToast('Rule removed', 'success');
+                this.fetchIPFilter();
+            } catch (e) { this.showToast('Failed to remove rule', 'error'); }
+        },
+
+        async fetchBans() {
+            try {
+                const res = await fetch('/api/bans', { headers: this.getHeaders() });
+                const response = await res.json();
+                this.bans = response.data || { enabled: false, bans: [] };
+            } catch (e) { this.bans = { enabled: false, bans: [] }; }
+        },
+
+        async banIP() {
+            if (!this.banForm.ip) {
+                this.showToast('Enter an IP address', 'error');
+                return;
+            }
+            try {
+                const res = await fetch('/api/bans', {
+                    method: 'POST',
+                    headers: this.getHeaders(),
+                    body: JSON.stringify(this.banForm)
+                });
+                const response = await res.json();
+                if (!response.success) {
+                    this.showToast(response.error?.message || 'Failed to ban IP', 'error');
+                    return;
+                }
+                this.showToast('IP banned', 'success');
+                this.banForm = { ip: '', reason: '', duration: '' };
+                this.fetchBans();
+            } catch (e) { this.showToast('Failed to ban IP', 'error'); }
+        },
+
+        async unbanIP(ban) {
+            if (!confirm(`Lift the ban on ${ban.ip}?`)) return;
+            try {
+                const res = await fetch('/api/bans/' + encodeURIComponent(ban.ip), {
+                    method: 'DELETE',
+                    headers: this.getHeaders()
+                });
+                const response = await res.json();
+                if (!response.success) {
+                    this.showToast(response.error?.message || 'Failed to unban IP', 'error');
+                    return;
+                }
+                this.showToast('IP unbanned', 'success');
+                this.fetchBans();
+            } catch (e) { this.showToast('Failed to unban IP', 'error'); }
+        },
+
+        async fetchAPIKeys() {
+            try {
+                const res = await fetch('/api/apikeys', { headers: this.getHeaders() });
+                const response = await res.json();
+                this.apiKeys = response.data || { enabled: false, keys: [] };
+            } catch (e) { this.apiKeys = { enabled: false, keys: [] }; }
+        },
+
+        apiKeyStatus(key) {
+            if (key.revoked_at) return 'revoked';
+            if (key.expires_at && new Date(key.expires_at) <= new Date()) return 'expired';
+            return 'active';
+        },
+
+        async createAPIKey() {
+            if (!this.apiKeyForm.name) {
+                this.showToast('Enter a name for the key', 'error');
+                return;
+            }
+            try {
+                const res = await fetch('/api/apikeys', {
+                    method: 'POST',
+                    headers: this.getHeaders(),
+                    body: JSON.stringify(this.apiKeyForm)
+                });
+                const response = await res.json();
+                if (!response.success) {
+                    this.showToast(response.error?.message || 'Failed to create key', 'error');
+                    return;
+                }
+                this.newAPIKey = response.data.key;
+                this.apiKeyForm = { name: '', scopes: ['read'], expires_in: '' };
+                this.fetchAPIKeys();
+            } catch (e) { this.showToast('Failed to create key', 'error'); }
+        },
+
+        async copyAPIKey() {
+            try {
+                await navigator.clipboard.writeText(this.newAPIKey);
+                this.showToast('Key copied', 'success');
+            } catch (e) { this.showToast('Copy failed, select the key manually', 'error'); }
+        },
+
+        async revokeAPIKey(key) {
+            if (!confirm(`Revoke key "${key.name}"? Clients using it will be rejected.`)) return;
+            try {
+                const res = await fetch('/api/apikeys/' + encodeURIComponent(key.id), {
+                    method: 'DELETE',
+                    headers: this.getHeaders()
+                });
+                const response = await res.json();
+                if (!response.success) {
+                    this.showToast(response.error?.message || 'Failed to revoke key', 'error');
+                    return;
+                }
+                this.showToast('Key revoked', 'success');
+                this.fetchAPIKeys();
+            } catch (e) { this.showToast('Failed to revoke key', 'error'); }
+        },
+
+        async fetchAlerts() {
+            try {
+                const res = await fetch('/api/alerts', { headers: this.getHeaders() });
+                const response = await res.json();
+                this.alerts = Object.assign({ alerts: [], history: [], rules: [], silences: [], maintenance: [], notifiers: [] }, response.data || { enabled: false });
+            } catch (e) { this.alerts = { enabled: false, alerts: [], history: [], rules: [], silences: [], maintenance: [], notifiers: [] }; }
+        },
+
+        alertSeverityClass(severity) {
+            if (severity === 'critical') return 'bg-red-100 text-red-800 dark:bg-red-900/30 dark:text-red-400';
+            if (severity === 'warning') return 'bg-yellow-100 text-yellow-800 dark:bg-yellow-900/30 dark:text-yellow-400';
+            return 'bg-blue-100 text-blue-800 dark:bg-blue-900/30 dark:text-blue-400';
+        },
+
+        async addSilence() {
+            if (!this.silenceForm.duration) {
+                this.showToast('Enter a duration such as 2h', 'error');
+                return;
+            }
+            try {
+                const res = await fetch('/api/alerts/silences', {
+                    method: 'POST',
+                    headers: this.getHeaders(),
+                    body: JSON.stringify(this.silenceForm)
+                });
+                const response = await res.json();
+                if (!response.success) {
+                    this.showToast(response.error?.message || 'Failed to create silence', 'error');
+                    return;
+                }
+                this.showToast('Silence created', 'success');
+                this.silenceForm.comment = '';
+                this.fetchAlerts();
+            } catch (e) { this.showToast('Failed to create silence', 'error'); }
+        },
+
+        async removeSilence(silence) {
+            if (!confirm(`End the silence on ${silence.rule || 'all rules'} now?`)) return;
+            try {
+                const res = await fetch('/api/alerts/silences/' + encodeURIComponent(silence.id), {
+                    method: 'DELETE',
+                    headers: this.getHeaders()
+                });
+                const response = await res.json();
+                if (!response.success) {
+                    this.showToast(response.error?.message || 'Failed to remove silence', 'error');
+                    return;
+                }
+                this.showToast('Silence removed', 'success');
+                this.fetchAlerts();
+            } catch (e) { this.showToast('Failed to remove silence', 'error'); }
+        },
+
+        async fetchStatusPage() {
+            try {
+                const res = await fetch('/api/status-page', { headers: this.getHeaders() });
+                const response = await res.json();
+                this.statusPage = Object.assign({ page: { components: [] }, components: [], incidents: [] }, response.data || { enabled: false });
+                this.incidentUpdate = Object.fromEntries(this.statusPage.incidents.map(inc => [inc.id, { status: '', message: '' }]));
+            } catch (e) { this.statusPage = { enabled: false, page: { components: [] }, components: [], incidents: [] }; }
+        },
+
+        async createIncident() {
+            if (!this.incidentForm.title || !this.incidentForm.message) {
+                this.showToast('Enter a title and a message', 'error');
+                return;
+            }
+            try {
+                const res = await fetch('/api/status-page/incidents', {
+                    method: 'POST',
+                    headers: this.getHeaders(),
+                    body: JSON.stringify(this.incidentForm)
+                });
+                const response = await res.json();
+                if (!response.success) {
+                    this.showToast(response.error?.message || 'Failed to post incident', 'error');
+                    return;
+                }
+                this.showToast('Incident posted', 'success');
+                this.incidentForm = { title: '', impact: 'minor', status: 'investigating', message: '', components: [] };
+                this.fetchStatusPage();
+            } catch (e) { this.showToast('Failed to post incident', 'error'); }
+        },
+
+        async addIncidentUpdate(inc) {
+            const form = this.incidentUpdate[inc.id];
+            if (!form.message) {
+                this.showToast('Enter a message', 'error');
+                return;
+            }
+            try {
+                const res = await fetch('/api/status-page/incidents/' + encodeURIComponent(inc.id) + '/updates', {
+                    method: 'POST',
+                    headers: this.getHeaders(),
+                    body: JSON.stringify({ status: form.status || inc.status, message: form.message })
+                });
+                const response = await res.json();
+                if (!response.success) {
+                    this.showToast(response.error?.message || 'Failed to update incident', 'error');
+                    return;
+                }
+                this.showToast('Incident updated', 'success');
+                this.fetchStatusPage();
+            } catch (e) { this.showToast('Failed to update incident', 'error'); }
+        },
+
+        async deleteIncident(inc) {
+            if (!confirm(`Delete the incident "${inc.title}"? It disappears from the public page.`)) return;
+            try {
+                const res = await fetch('/api/status-page/incidents/' + encodeURIComponent(inc.id), {
+                    method: 'DELETE',
+                    headers: this.getHeaders()
+                });
+                const response = await res.json();
+                if (!response.success) {
+                    this.showToast(response.error?.message || 'Failed to delete incident', 'error');
+                    return;
+                }
+                this.showToast('Incident deleted', 'success');
+                this.fetchStatusPage();
+            } catch (e) { this.showToast('Failed to delete incident', 'error'); }
+        },
+
+        statusPageClass(status) {
+            if (status === 'operational') return 'bg-green-500 text-white';
+            if (status === 'degraded') return 'bg-yellow-500 text-white';
+            if (status === 'outage') return 'bg-destructive text-destructive-foreground';
+            return 'bg-muted text-muted-foreground';
+        },
+
+        async testAlertNotifiers() {
+            this.alertTestRunning = true;
+            try {
+                const res = await fetch('/api/alerts/test', { method: 'POST', headers: this.getHeaders() });
+                const response = await res.json();
+                if (!response.success) {
+                    this.showToast(response.error?.message || 'Failed to send test notification', 'error');
+                    return;
+                }
+                const failed = Object.keys(response.data.failed || {});
+                if (failed.length) {
+                    this.showToast('Test failed for: ' + failed.join(', '), 'error');
+                } else {
+                    this.showToast('Test notification sent', 'success');
+                }
+                this.alerts.notifiers = response.data.notifiers || [];
+            } catch (e) {
+                this.showToast('Failed to send test notification', 'error');
+            } finally {
+                this.alertTestRunning = false;
+            }
+        },
+
+        captureQuery(extra = {}) {
+            const params = new URLSearchParams(extra);
+            for (const [k, v] of Object.entries(this.captureFilter)) {
+                if (v) params.set(k, v);
+            }
+            return params.toString();
+        },
+
+        async fetchHistory() {
+            try {
+                if (this.historyNames.length === 0) {
+                    const res = await fetch('/api/metrics/names', { headers: this.getHeaders() });
+                    const response = await res.json();
+                    this.historyEnabled = response.data?.enabled !== false;
+                    this.historyNames = response.data?.names || [];
+                    if (this.historyNames.length && !this.historyNames.includes(this.historyMetric)) {
+                        this.historyMetric = this.historyNames[0];
+                    }
+                }
+                if (!this.historyEnabled) return;
+
+                this.historyLoading = true;
+                const params = new URLSearchParams({ name: this.historyMetric, from: this.historyRange, to: 'now' });
+                const res = await fetch('/api/metrics/query?' + params.toString(), { headers: this.getHeaders() });
+                const response = await res.json();
+                this.historySeries = response.success ? response.data : null;
+            } catch (e) {
+                this.historySeries = null;
+            } finally {
+                this.historyLoading = false;
+            }
+        },
+
+        // Chart geometry in a 100x100 viewBox; y is scaled to the highest max in range
+        historyScale() {
+            const s = this.historySeries;
+            if (!s || !s.points.length) return null;
+            const top = Math.max(...s.points.map(p => p.max)) || 1;
+            const x = t => ((t - s.from) / Math.max(s.to - s.from, 1)) * 100;
+            const y = v => 100 - (v / top) * 100;
+            return { top, x, y, points: s.points };
+        },
+
+        historyLine() {
+            const sc = this.historyScale();
+            if (!sc) return '';
+            return 'M ' + sc.points.map(p => `${sc.x(p.t)},${sc.y(p.avg)}`).join(' L ');
+        },
+
+        historyBand() {
+            const sc = this.historyScale();
+            if (!sc) return '';
+            const upper = sc.points.map(p => `${sc.x(p.t)},${sc.y(p.max)}`);
+            const lower = sc.points.slice().reverse().map(p => `${sc.x(p.t)},${sc.y(p.min)}`);
+            return 'M ' + upper.concat(lower).join(' L ') + ' Z';
+        },
+
+        historyStats() {
+            const pts = this.historySeries?.points || [];
+            if (!pts.length) return { min: 0, avg: 0, max: 0, top: 0 };
+            return {
+                min: Math.min(...pts.map(p => p.min)),
+                avg: pts.reduce((sum, p) => sum + p.avg, 0) / pts.length,
+                max: Math.max(...pts.map(p => p.max)),
+                top: this.historyScale().top
+            };
+        },
+
+        formatMetric(name, v) {
+            if (v === undefined || v === null || isNaN(v)) return '-';
+            if (name.endsWith('_bytes')) {
+                const units = ['B', 'KB', 'MB', 'GB', 'TB'];
+                let i = 0;
+                while (v >= 1024 && i < units.length - 1) { v /= 1024; i++; }
+                return v.toFixed(1) + ' ' + units[i];
+            }
+            if (name.endsWith('percent')) return v.toFixed(1) + '%';
+            if (name.endsWith('_ms')) return v.toFixed(1) + ' ms';
+            if (name.endsWith('per_sec')) return v.toFixed(2) + '/s';
+            return Number.isInteger(v) ? String(v) : v.toFixed(1);
+        },
+
+        async fetchErrors() {
+            try {
+                const res = await fetch('/api/errors?status=' + this.errorFilter, { headers: this.getHeaders() });
+                const response = await res.json();
+                if (response.success) this.errorTracking = response.data;
+            } catch (e) { this.errorTracking = { enabled: false, counts: {}, groups: [] }; }
+        },
+
+        async viewErrorGroup(id) {
+            if (this.errorDetail?.id === id) {
+                this.errorDetail = null;
+                return;
+            }
+            try {
+                const res = await fetch('/api/errors/' + id, { headers: this.getHeaders() });
+                const response = await res.json();
+                if (!response.success) {
+                    this.showToast(response.error?.message || 'Error group not found', 'error');
+                    return;
+                }
+                this.errorDetail = response.data;
+            } catch (e) { this.showToast('Failed to load error group', 'error'); }
+        },
+
+        async setErrorStatus(id, status) {
+            try {
+                const res = await fetch(`/api/errors/${id}/status`, {
+                    method: 'POST',
+                    headers: this.getHeaders(),
+                    body: JSON.stringify({ status })
+                });
+                const response = await res.json();
+                if (!response.success) {
+                    this.showToast(response.error?.message || 'Failed to update error', 'error');
+                    return;
+                }
+                this.showToast(response.message || 'Error updated', 'success');
+                if (this.errorDetail?.id === id) this.errorDetail.status = status;
+                this.fetchErrors();
+            } catch (e) { this.showToast('Failed to update error', 'error'); }
+        },
+
+        async deleteErrorGroup(id) {
+            if (!confirm('Delete this error group and its history?')) return;
+            try {
+                await fetch('/api/errors/' + id, { method: 'DELETE', headers: this.getHeaders() });
+                this.showToast('Error group deleted', 'success');
+                if (this.errorDetail?.id === id) this.errorDetail = null;
+                this.fetchErrors();
+            } catch (e) { this.showToast('Failed to delete error group', 'error'); }
+        },
+
+        errorStatusClass(status) {
+            if (status === 'open') return 'bg-red-100 text-red-800 dark:bg-red-900 dark:text-red-300';
+            if (status === 'resolved') return 'bg-green-100 text-green-800 dark:bg-green-900 dark:text-green-300';
+            return 'bg-secondary text-secondary-foreground';
+        },
+
+        // Shows the capture of a request, if it was captured
+        findCapture(requestId) {
+            this.captureFilter.q = requestId;
+            this.activeTab = 'captures';
+        },
+
+        async fetchCaptures(page = 1) {
+            try {
+                const res = await fetch('/api/captures?' + this.captureQuery({ page, per_page: 25 }), { headers: this.getHeaders() });
+                const response = await res.json();
+                this.captures = response.data || [];
+                this.captureMeta = response.meta || { page: 1, total_pages: 1, total: 0 };
+                this.capturesEnabled = response.meta?.extra?.enabled !== false;
+                this.captureTokens = { enabled: !!response.meta?.extra?.tokens, max_ttl: response.meta?.extra?.max_ttl || '' };
+            } catch (e) { this.captures = []; }
+        },
+
+        async viewCapture(id) {
+            try {
+                const res = await fetch('/api/captures/' + id, { headers: this.getHeaders() });
+                const response = await res.json();
+                if (!response.success) {
+                    this.showToast(response.error?.message || 'Capture not found', 'error');
+                    return;
+                }
+                this.selectedCapture = response.data;
+                this.captureModalOpen = true;
+            } catch (e) { this.showToast('Failed to load capture', 'error'); }
+        },
+
+        formatCaptureBody(body) {
+            if (!body || !body.text) return '(empty)';
+            if (body.encoding === 'base64') return `(binary, ${body.size} bytes, base64 in HAR export)`;
+            let text = body.text;
+            try { text = JSON.stringify(JSON.parse(text), null, 2); } catch (e) { }
+            return body.truncated ? text + `\n… truncated (${body.size} bytes total)` : text;
+        },
+
+        formatHeaders(headers) {
+            return Object.entries(headers || {}).map(([k, v]) => `${k}: ${v.join(', ')}`).join('\n');
+        },
+
+        async exportCapturesHAR(ids = []) {
+            const extra = ids.length ? { ids: ids.join(',') } : {};
+            try {
+                const res = await fetch('/api/captures/har?' + this.captureQuery(extra), { headers: this.getHeaders() });
+                if (!res.ok) {
+                    this.showToast('Failed to export HAR', 'error');
+                    return;
+                }
+                const blob = new Blob([await res.text()], { type: 'application/json' });
+                const link = document.createElement('a');
+                link.href = URL.createObjectURL(blob);
+                link.download = `captures-${new Date().toISOString().replace(/[:.]/g, '-')}.har`;
+                link.click();
+                URL.revokeObjectURL(link.href);
+            } catch (e) { this.showToast('Failed to export HAR', 'error'); }
+        },
+
+        async clearCaptures() {
+            if (!confirm('Delete all captured requests?')) return;
+            try {
+                await fetch('/api/captures', { method: 'DELETE', headers: this.getHeaders() });
+                this.showToast('Captures cleared', 'success');
+                this.fetchCaptures(1);
+            } catch (e) { this.showToast('Failed to clear captures', 'error'); }
+        },
+
         async fetchConfig() {
             try {
                 // Fetch raw for editor
@@ -897,6 +1858,8 @@ function app() {
                 const data = response.data || {};
                 this.userSettings.username = data.username || 'Admin';
                 this.userSettings.photoPath = data.photo_path || '';
+                this.userSettings.role = data.role || 'admin';
+                this.userSettings.method = data.method || 'password';
             } catch (e) { }
         },
 